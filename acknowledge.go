@@ -0,0 +1,46 @@
+// acknowledge.go - Marking flushed entries in one pass
+
+package zerocopyskiplist
+
+// AcknowledgeFlushed gives every entry whose key is in keys the context
+// context, typically a clean state once a flush of those entries is durable,
+// and returns the number of entries updated. It is UpdateContext for a whole
+// batch under a single write lock: keys in ascending order, as a flush
+// produces them, are found by carrying the search path forward from one key
+// to the next, so a batch of k keys costs O(k + log n) steps rather than k
+// separate descents. Keys out of order are still found, from the head, and
+// missing keys are skipped. Entries refused by a context quota keep their
+// context, and a frozen list updates nothing.
+func (sl *ZeroCopySkiplist[T, K, C]) AcknowledgeFlushed(keys []K, context C) int {
+	sl.lock()
+	defer sl.unlock()
+	if sl.frozen {
+		return 0
+	}
+
+	updated := 0
+	update := make([]*ItemPtr[T, K, C], sl.maxLevel+1)
+	restart := true
+	var prev K
+	for _, key := range keys {
+		key = sl.normalize(key)
+		// Restart from the head unless the path is known to precede key
+		if restart || sl.cmpKey(prev, key) >= 0 {
+			for i := range update {
+				update[i] = sl.header
+			}
+		}
+		prev = key
+
+		current := sl.descendFrom(key, update)
+		// Quota eviction restructures the list
+		restart = len(sl.quotas) > 0
+		if current != nil && sl.cmpKey(current.key, key) == 0 && sl.setContext(current, context) {
+			updated++
+		}
+	}
+	if updated > 0 {
+		sl.enforceWeightLimit()
+	}
+	return updated
+}
@@ -0,0 +1,42 @@
+package zerocopyskiplist
+
+import "testing"
+
+func TestAcknowledgeFlushed(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt, WithContextStats())
+	dirty, clean := TestContext{AccessCount: 1}, TestContext{}
+	for _, item := range createTestItems(1000) {
+		skiplist.Insert(item, dirty)
+	}
+
+	var flushed []int
+	for key := 2; key <= 1000; key += 2 {
+		flushed = append(flushed, key)
+	}
+	// Missing and out of order keys
+	flushed = append(flushed, 5000, 7, 3)
+	generation := skiplist.Generation()
+	if n := skiplist.AcknowledgeFlushed(flushed, clean); n != 502 {
+		t.Errorf("AcknowledgeFlushed updated %d entries, want 502", n)
+	}
+	for entry := range skiplist.Entries() {
+		want := dirty
+		if entry.Key%2 == 0 || entry.Key == 3 || entry.Key == 7 {
+			want = clean
+		}
+		if entry.Context != want {
+			t.Fatalf("Key %d has context %+v, want %+v", entry.Key, entry.Context, want)
+		}
+	}
+	if stats, _ := skiplist.ContextStats(clean); stats.Entries != 502 {
+		t.Errorf("Context stats count %d clean entries", stats.Entries)
+	}
+	if skiplist.Generation() == generation {
+		t.Error("Acknowledging should advance the generation")
+	}
+
+	skiplist.Freeze()
+	if n := skiplist.AcknowledgeFlushed([]int{1}, clean); n != 0 {
+		t.Errorf("A frozen list acknowledged %d entries", n)
+	}
+}
@@ -0,0 +1,296 @@
+// adaptive.go - Lists that shard themselves under write contention
+
+package zerocopyskiplist
+
+import (
+	"iter"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// AdaptivePolicy decides when an AdaptiveSkiplist splits a shard
+type AdaptivePolicy struct {
+	WaitFraction float64 // split a shard once this fraction of its write locks waited
+	MinWrites    uint64  // write locks a shard must take between checks to be judged
+	MaxShards    int     // never split beyond this many shards
+	CheckEvery   uint64  // writes between checks, 0 to check only on Rebalance
+}
+
+// DefaultAdaptivePolicy splits a shard when a fifth of at least 1000 write
+// locks waited, checking every 4096 writes, up to 64 shards
+func DefaultAdaptivePolicy() AdaptivePolicy {
+	return AdaptivePolicy{WaitFraction: 0.2, MinWrites: 1000, MaxShards: 64, CheckEvery: 4096}
+}
+
+// AdaptiveSkiplist is a list that starts as one ZeroCopySkiplist and, when
+// writers contend for its lock, migrates to several lists each holding a key
+// range, so operators need not predict write concurrency when it is built.
+// Shards are split in two at their median key, found with SplitRange, and
+// the split is transparent: it holds out every operation while entries move,
+// and afterwards each key is routed to the shard holding its range.
+//
+// It offers the list's core API: inserts, lookups, deletes, context updates
+// and ordered iteration, which walks the shards in key order. Shards are
+// built by newList and tracked WithContentionTracking; configuration such as
+// quotas and weight limits applies to each shard on its own. Items and
+// contexts move between shards as they are, but per-entry state that the
+// core API cannot create (pins, leases and soft-deleted entries) does not
+// exist in an AdaptiveSkiplist.
+type AdaptiveSkiplist[T any, K comparable, C comparable] struct {
+	newList  func() *ZeroCopySkiplist[T, K, C]
+	policy   AdaptivePolicy
+	mu       sync.RWMutex // held for reading by operations, for writing by splits
+	shards   []*adaptiveShard[T, K, C]
+	writes   atomic.Uint64
+	checking atomic.Bool
+}
+
+// adaptiveShard is one key range of an AdaptiveSkiplist
+type adaptiveShard[T any, K comparable, C comparable] struct {
+	list *ZeroCopySkiplist[T, K, C]
+	min  K // smallest key routed here, unused for the first shard
+	seen contentionCounters
+}
+
+// NewAdaptiveSkiplist returns an empty list of one shard whose shards are
+// built by newList, e.g. a closure calling MakeZeroCopySkiplist with the
+// list's options, and split according to policy
+func NewAdaptiveSkiplist[T any, K comparable, C comparable](newList func() *ZeroCopySkiplist[T, K, C], policy AdaptivePolicy) *AdaptiveSkiplist[T, K, C] {
+	a := &AdaptiveSkiplist[T, K, C]{newList: newList, policy: policy}
+	a.shards = []*adaptiveShard[T, K, C]{{list: a.build()}}
+	return a
+}
+
+// Insert adds item to the shard holding its key, as ZeroCopySkiplist.Insert
+func (a *AdaptiveSkiplist[T, K, C]) Insert(item *T, context C) bool {
+	inserted, _ := a.InsertChecked(item, context)
+	return inserted
+}
+
+// InsertChecked adds item to the shard holding its key, as
+// ZeroCopySkiplist.InsertChecked
+func (a *AdaptiveSkiplist[T, K, C]) InsertChecked(item *T, context C) (bool, error) {
+	a.mu.RLock()
+	list := a.shards[0].list
+	if item != nil {
+		list = a.shard(list.getKeyFromItem(item))
+	}
+	inserted, err := list.InsertChecked(item, context)
+	a.mu.RUnlock()
+	a.wrote()
+	return inserted, err
+}
+
+// Find returns the entry for key, as ZeroCopySkiplist.Find
+func (a *AdaptiveSkiplist[T, K, C]) Find(key K) (*ItemPtr[T, K, C], C) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.shard(key).Find(key)
+}
+
+// FindItem returns the ItemPtr for key, as ZeroCopySkiplist.FindItem
+func (a *AdaptiveSkiplist[T, K, C]) FindItem(key K) *ItemPtr[T, K, C] {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.shard(key).FindItem(key)
+}
+
+// Get returns the entry for key and whether it was found
+func (a *AdaptiveSkiplist[T, K, C]) Get(key K) (Entry[T, K, C], bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.shard(key).Get(key)
+}
+
+// Delete removes the entry for key, as ZeroCopySkiplist.Delete
+func (a *AdaptiveSkiplist[T, K, C]) Delete(key K) bool {
+	a.mu.RLock()
+	deleted := a.shard(key).Delete(key)
+	a.mu.RUnlock()
+	a.wrote()
+	return deleted
+}
+
+// UpdateContext sets the context of key, as ZeroCopySkiplist.UpdateContext
+func (a *AdaptiveSkiplist[T, K, C]) UpdateContext(key K, context C) bool {
+	a.mu.RLock()
+	updated := a.shard(key).UpdateContext(key, context)
+	a.mu.RUnlock()
+	a.wrote()
+	return updated
+}
+
+// Length returns the number of entries across the shards
+func (a *AdaptiveSkiplist[T, K, C]) Length() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	n := 0
+	for _, s := range a.shards {
+		n += s.list.Length()
+	}
+	return n
+}
+
+// ForEach calls callback for every item in ascending key order, stopping
+// early when callback returns false. Shards are walked in turn as for
+// ZeroCopySkiplist.ForEach, with no lock held, so callback may mutate the
+// list; shards split during the walk are finished as they were at the split.
+func (a *AdaptiveSkiplist[T, K, C]) ForEach(callback func(*ItemPtr[T, K, C]) bool) {
+	for _, list := range a.lists() {
+		more := true
+		list.ForEach(func(ip *ItemPtr[T, K, C]) bool {
+			more = callback(ip)
+			return more
+		})
+		if !more {
+			return
+		}
+	}
+}
+
+// Entries returns an iterator over every entry in ascending key order, with
+// the consistency of ForEach
+func (a *AdaptiveSkiplist[T, K, C]) Entries() iter.Seq[Entry[T, K, C]] {
+	return func(yield func(Entry[T, K, C]) bool) {
+		a.ForEach(func(ip *ItemPtr[T, K, C]) bool {
+			return yield(ip.Entry())
+		})
+	}
+}
+
+// Shards returns the number of shards the list is split into
+func (a *AdaptiveSkiplist[T, K, C]) Shards() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return len(a.shards)
+}
+
+// ShardStats returns the Stats of every shard in key order
+func (a *AdaptiveSkiplist[T, K, C]) ShardStats() []Stats {
+	lists := a.lists()
+	stats := make([]Stats, len(lists))
+	for i, list := range lists {
+		stats[i] = list.Stats()
+	}
+	return stats
+}
+
+// Rebalance checks every shard's write contention since the last check and
+// splits those past the policy, returning the number split. Writes call it
+// every CheckEvery writes; a call while another is checking returns 0.
+func (a *AdaptiveSkiplist[T, K, C]) Rebalance() int {
+	if !a.checking.CompareAndSwap(false, true) {
+		return 0
+	}
+	defer a.checking.Store(false)
+
+	a.mu.RLock()
+	var hot []*adaptiveShard[T, K, C]
+	for _, s := range a.shards {
+		now := s.list.writeContention()
+		locks, waits := now.acquisitions-s.seen.acquisitions, now.waits-s.seen.waits
+		s.seen = now
+		if locks > 0 && locks >= a.policy.MinWrites && float64(waits) >= a.policy.WaitFraction*float64(locks) {
+			hot = append(hot, s)
+		}
+	}
+	a.mu.RUnlock()
+	if len(hot) == 0 {
+		return 0
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	split := 0
+	for _, s := range hot {
+		if len(a.shards) >= a.policy.MaxShards {
+			break
+		}
+		if a.split(s) {
+			split++
+		}
+	}
+	return split
+}
+
+// wrote counts a write and runs Rebalance every CheckEvery writes
+func (a *AdaptiveSkiplist[T, K, C]) wrote() {
+	if every := a.policy.CheckEvery; every > 0 && a.writes.Add(1)%every == 0 {
+		a.Rebalance()
+	}
+}
+
+// build returns a new shard list with contention tracked
+func (a *AdaptiveSkiplist[T, K, C]) build() *ZeroCopySkiplist[T, K, C] {
+	list := a.newList()
+	if list.contention == nil {
+		list.contention = &contentionCounters{}
+	}
+	return list
+}
+
+// shard returns the list holding key; the caller must hold the read lock
+func (a *AdaptiveSkiplist[T, K, C]) shard(key K) *ZeroCopySkiplist[T, K, C] {
+	first := a.shards[0].list
+	key = first.normalize(key)
+	i := sort.Search(len(a.shards)-1, func(i int) bool {
+		return first.cmpKey(a.shards[i+1].min, key) > 0
+	})
+	return a.shards[i].list
+}
+
+// lists returns the shard lists in key order
+func (a *AdaptiveSkiplist[T, K, C]) lists() []*ZeroCopySkiplist[T, K, C] {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	lists := make([]*ZeroCopySkiplist[T, K, C], len(a.shards))
+	for i, s := range a.shards {
+		lists[i] = s.list
+	}
+	return lists
+}
+
+// split replaces s with two shards divided at its median key, returning
+// false if it holds too few entries to divide; the caller must hold the
+// write lock
+func (a *AdaptiveSkiplist[T, K, C]) split(s *adaptiveShard[T, K, C]) bool {
+	first, ok := s.list.FirstEntry()
+	last, _ := s.list.LastEntry()
+	if !ok {
+		return false
+	}
+	ranges := s.list.SplitRange(KeyRange[K]{Min: first.Key, Max: last.Key, MaxInclusive: true}, 2)
+	if len(ranges) < 2 {
+		return false
+	}
+	mid := ranges[1].Min
+
+	// No operation can reach the shard, so its entries are moved as they
+	// stand: appended in order to the new shards and the usage recounted
+	lower := &adaptiveShard[T, K, C]{list: a.build(), min: s.min}
+	upper := &adaptiveShard[T, K, C]{list: a.build(), min: mid}
+	lower.list.lock()
+	upper.list.lock()
+	s.list.rlock()
+	for node := s.list.header.forward[0]; node != nil; node = node.forward[0] {
+		dest := lower.list
+		if s.list.cmpKey(node.key, mid) >= 0 {
+			dest = upper.list
+		}
+		dest.appendTail(node.item, node.key, node.context)
+	}
+	s.list.runlock()
+	for _, l := range []*ZeroCopySkiplist[T, K, C]{lower.list, upper.list} {
+		l.recount()
+		l.unlock()
+	}
+
+	for i, t := range a.shards {
+		if t == s {
+			a.shards = append(a.shards[:i], append([]*adaptiveShard[T, K, C]{lower, upper}, a.shards[i+1:]...)...)
+			break
+		}
+	}
+	return true
+}
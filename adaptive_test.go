@@ -0,0 +1,113 @@
+package zerocopyskiplist
+
+import (
+	"sync"
+	"testing"
+)
+
+func newAdaptiveTestList(policy AdaptivePolicy) *AdaptiveSkiplist[TestItem, int, TestContext] {
+	return NewAdaptiveSkiplist(func() *ZeroCopySkiplist[TestItem, int, TestContext] {
+		return MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	}, policy)
+}
+
+// checkAdaptive verifies that a holds exactly the keys 1..n in order and that
+// every shard is sound
+func checkAdaptive(t *testing.T, a *AdaptiveSkiplist[TestItem, int, TestContext], n int) {
+	t.Helper()
+	if a.Length() != n {
+		t.Errorf("Length = %d, want %d", a.Length(), n)
+	}
+	want := 1
+	for entry := range a.Entries() {
+		if entry.Key != want {
+			t.Fatalf("Entries yielded key %d, want %d", entry.Key, want)
+		}
+		want++
+	}
+	for key := 1; key <= n; key++ {
+		if ptr, ctx := a.Find(key); ptr == nil || ptr.Item().ID != key || ctx.AccessCount != key {
+			t.Fatalf("Key %d not found in its shard", key)
+		}
+	}
+	for _, list := range a.lists() {
+		if err := list.Validate(); err != nil {
+			t.Errorf("Validate shard: %v", err)
+		}
+	}
+}
+
+func TestAdaptiveSkiplistSplits(t *testing.T) {
+	// Every write counts as contended, so each check splits every shard
+	a := newAdaptiveTestList(AdaptivePolicy{WaitFraction: 0, MinWrites: 1, MaxShards: 4})
+	for _, item := range createTestItems(1000) {
+		a.Insert(item, TestContext{AccessCount: item.ID})
+	}
+	if a.Shards() != 1 {
+		t.Fatal("No shard should split before a check")
+	}
+
+	if split := a.Rebalance(); split != 1 || a.Shards() != 2 {
+		t.Fatalf("Rebalance split %d into %d shards, want 1 into 2", split, a.Shards())
+	}
+	checkAdaptive(t, a, 1000)
+	stats := a.ShardStats()
+	if stats[0].Length != 500 || stats[1].Length != 500 {
+		t.Errorf("Split at the median should halve the entries, got %d and %d", stats[0].Length, stats[1].Length)
+	}
+
+	if split := a.Rebalance(); split != 2 || a.Shards() != 4 {
+		t.Fatalf("Rebalance split %d into %d shards, want 2 into 4", split, a.Shards())
+	}
+	if a.Rebalance() != 0 || a.Shards() != 4 {
+		t.Error("Shards should not split beyond MaxShards")
+	}
+	checkAdaptive(t, a, 1000)
+
+	if !a.Delete(600) || a.Delete(600) || !a.UpdateContext(700, TestContext{AccessCount: 1}) {
+		t.Error("Writes should reach the shard holding the key")
+	}
+	a.Insert(&TestItem{ID: 600}, TestContext{AccessCount: 600})
+	a.UpdateContext(700, TestContext{AccessCount: 700})
+	checkAdaptive(t, a, 1000)
+}
+
+func TestAdaptiveSkiplistUncontended(t *testing.T) {
+	a := newAdaptiveTestList(DefaultAdaptivePolicy())
+	for _, item := range createTestItems(10000) {
+		a.Insert(item, TestContext{AccessCount: item.ID})
+	}
+	if a.Shards() != 1 {
+		t.Errorf("A single writer should never wait, but the list split into %d shards", a.Shards())
+	}
+	checkAdaptive(t, a, 10000)
+}
+
+func TestAdaptiveSkiplistConcurrent(t *testing.T) {
+	a := newAdaptiveTestList(AdaptivePolicy{WaitFraction: 0, MinWrites: 1, MaxShards: 16, CheckEvery: 200})
+	items := createTestItems(4000)
+
+	// Writers insert interleaved keys while shards split under them
+	const writers = 8
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(items); i += writers {
+				a.Insert(items[i], TestContext{AccessCount: items[i].ID})
+				if i%3 == 0 {
+					a.Delete(items[i].ID)
+					a.Insert(items[i], TestContext{AccessCount: items[i].ID})
+				}
+				a.Find(items[(i*7)%len(items)].ID)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if a.Shards() < 2 {
+		t.Errorf("Expected the list to split, still %d shard", a.Shards())
+	}
+	checkAdaptive(t, a, len(items))
+}
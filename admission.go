@@ -0,0 +1,177 @@
+// admission.go - Admission filtering for bounded lists
+
+package zerocopyskiplist
+
+import (
+	"errors"
+	"hash/maphash"
+	"sync"
+)
+
+// ErrRefused is wrapped by the errors of loads that inserted their other
+// entries but had some refused by a context quota or the admission filter
+var ErrRefused = errors.New("insert refused")
+
+// sketchDepth is the number of counter rows in a FrequencySketch
+const sketchDepth = 4
+
+// sketchMaxCount is where a FrequencySketch counter saturates, as for the
+// 4-bit counters of TinyLFU
+const sketchMaxCount = 15
+
+// FrequencySketch estimates how often keys have been seen with a count-min
+// sketch of small saturating counters, in the manner of TinyLFU. Its counters
+// are halved once it has recorded ten times its width, so estimates favour
+// recent history. It is safe for concurrent use.
+type FrequencySketch[K comparable] struct {
+	mu        sync.Mutex
+	seed      maphash.Seed
+	rows      [sketchDepth][]uint8
+	mask      uint64
+	additions int
+	sample    int // additions between halvings
+}
+
+// NewFrequencySketch returns a sketch with width counters per row, rounded up
+// to a power of two of at least 16. A width around the number of entries the
+// list holds at capacity works well.
+func NewFrequencySketch[K comparable](width int) *FrequencySketch[K] {
+	n := 16
+	for n < width {
+		n <<= 1
+	}
+	s := &FrequencySketch[K]{seed: maphash.MakeSeed(), mask: uint64(n - 1), sample: 10 * n}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, n)
+	}
+	return s
+}
+
+// index returns the counter in row i of the key hashing to h, remixing h per
+// row so that keys colliding in one row are unlikely to collide in the others
+func (s *FrequencySketch[K]) index(h uint64, i int) uint64 {
+	h += uint64(i+1) * 0x9e3779b97f4a7c15
+	h = (h ^ h>>30) * 0xbf58476d1ce4e5b9
+	h = (h ^ h>>27) * 0x94d049bb133111eb
+	return (h ^ h>>31) & s.mask
+}
+
+// Record counts one occurrence of key
+func (s *FrequencySketch[K]) Record(key K) {
+	h := maphash.Comparable(s.seed, key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.rows {
+		if c := &s.rows[i][s.index(h, i)]; *c < sketchMaxCount {
+			*c++
+		}
+	}
+	s.additions++
+	if s.additions >= s.sample {
+		for i := range s.rows {
+			for j := range s.rows[i] {
+				s.rows[i][j] >>= 1
+			}
+		}
+		s.additions /= 2
+	}
+}
+
+// Estimate returns the approximate number of recent occurrences of key, which
+// never undercounts between halvings
+func (s *FrequencySketch[K]) Estimate(key K) int {
+	h := maphash.Comparable(s.seed, key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := sketchMaxCount
+	for i := range s.rows {
+		count = min(count, int(s.rows[i][s.index(h, i)]))
+	}
+	return count
+}
+
+// Admit returns true if candidate has been seen more often than victim, the
+// TinyLFU admission rule
+func (s *FrequencySketch[K]) Admit(candidate, victim K) bool {
+	return s.Estimate(candidate) > s.Estimate(victim)
+}
+
+// admissionFilter decides whether candidate may displace victim, see
+// SetAdmissionFilter
+type admissionFilter[T any, K comparable, C comparable] func(candidate *T, context C, victim *ItemPtr[T, K, C]) bool
+
+// SetAdmissionFilter registers admit to decide whether a new entry may
+// displace an existing one when the list is at capacity: when an Insert of a
+// new key would take the total weight over the SetWeightLimit limit, admit is
+// called with the candidate and the entry the limit would evict first, the
+// first evictable entry from the head. If it returns false the Insert is
+// refused and returns false, leaving the list unchanged, so a scan of
+// one-off keys cannot flush a hot working set. Replacements of existing keys
+// and inserts while under the limit are always admitted, as are inserts when
+// every entry is pinned or leased.
+//
+// admit runs under the write lock and must not call methods of the list.
+// Passing nil removes the filter, along with any sketch registered by
+// SetFrequencyAdmission. Copies of the list do not inherit the filter.
+func (sl *ZeroCopySkiplist[T, K, C]) SetAdmissionFilter(admit func(candidate *T, context C, victim *ItemPtr[T, K, C]) bool) {
+	sl.lock()
+	defer sl.unlock()
+	sl.admitFilter = admit
+	sl.sketch = nil
+}
+
+// SetFrequencyAdmission installs the TinyLFU admission policy: every Insert,
+// Find, FindItem and Get records its key in sketch, and a newcomer is only
+// admitted at capacity if sketch has seen its key more often than the
+// victim's, as described for SetAdmissionFilter. Passing nil removes it.
+func (sl *ZeroCopySkiplist[T, K, C]) SetFrequencyAdmission(sketch *FrequencySketch[K]) {
+	sl.lock()
+	defer sl.unlock()
+	sl.admitFilter = nil
+	sl.sketch = sketch
+	if sketch != nil {
+		sl.admitFilter = func(candidate *T, _ C, victim *ItemPtr[T, K, C]) bool {
+			return sketch.Admit(sl.getKeyFromItem(candidate), victim.key)
+		}
+	}
+}
+
+// refused returns true if the admission filter turns away item, whose key is
+// key and whose search path ends before current; the caller must hold the
+// write lock
+func (sl *ZeroCopySkiplist[T, K, C]) refused(item *T, key K, context C, current *ItemPtr[T, K, C]) bool {
+	if sl.admitFilter == nil || (current != nil && sl.cmpKey(current.key, key) == 0) {
+		return false
+	}
+	return !sl.admitNew(item, context)
+}
+
+// admitNew applies the admission filter to an insert of a new key, returning
+// true if the item may be inserted; the caller must hold the write lock
+func (sl *ZeroCopySkiplist[T, K, C]) admitNew(item *T, context C) bool {
+	if sl.weightLimit <= 0 || sl.frozen {
+		return true
+	}
+	weight := int64(sl.getItemSize(item))
+	if sl.weigher != nil {
+		weight = sl.weigher(item, context)
+	}
+	if sl.weight()+weight <= sl.weightLimit {
+		return true
+	}
+	for node := sl.header.forward[0]; node != nil; node = node.forward[0] {
+		if sl.evictable(node.key) {
+			return sl.admitFilter(item, context, node)
+		}
+	}
+	return true
+}
+
+// tryInsert performs insert and also reports whether a quota or the admission
+// filter refused the item, which the result of insert alone does not tell
+// apart from a replacement; the caller must hold the write lock
+func (sl *ZeroCopySkiplist[T, K, C]) tryInsert(item *T, context C) (inserted, refused bool) {
+	before := sl.refusals
+	inserted = sl.insert(item, context)
+	return inserted, sl.refusals != before
+}
@@ -0,0 +1,100 @@
+package zerocopyskiplist
+
+import "testing"
+
+func TestFrequencySketch(t *testing.T) {
+	sketch := NewFrequencySketch[int](64)
+	for i := 0; i < 5; i++ {
+		sketch.Record(1)
+	}
+	sketch.Record(2)
+	if sketch.Estimate(1) < 5 || sketch.Estimate(2) < 1 {
+		t.Errorf("Estimates %d, %d undercount 5 and 1", sketch.Estimate(1), sketch.Estimate(2))
+	}
+	if !sketch.Admit(1, 2) || sketch.Admit(2, 1) {
+		t.Error("Admit should prefer the more frequent key")
+	}
+
+	// Counters saturate, and halve once the sample is reached
+	for i := 0; i < 100; i++ {
+		sketch.Record(1)
+	}
+	if sketch.Estimate(1) != sketchMaxCount {
+		t.Errorf("Estimate = %d, want saturation at %d", sketch.Estimate(1), sketchMaxCount)
+	}
+	for i := 0; i < 10*64; i++ {
+		sketch.Record(1000 + i)
+	}
+	if got := sketch.Estimate(1); got >= sketchMaxCount {
+		t.Errorf("Estimate after a sample period = %d, want it halved", got)
+	}
+}
+
+func TestAdmissionFilter(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	skiplist.SetWeigher(func(*TestItem, TestContext) int64 { return 1 })
+	skiplist.SetWeightLimit(3)
+
+	var victims []int
+	skiplist.SetAdmissionFilter(func(candidate *TestItem, _ TestContext, victim *ItemPtr[TestItem, int, TestContext]) bool {
+		victims = append(victims, victim.Key())
+		return candidate.ID%2 == 0
+	})
+	for _, item := range createTestItems(3) {
+		skiplist.Insert(item, TestContext{})
+	}
+	if len(victims) != 0 {
+		t.Errorf("Filter consulted under the limit for %v", victims)
+	}
+
+	skiplist.Pin(1)
+	if skiplist.Insert(&TestItem{ID: 5}, TestContext{}) || contains(skiplist, 5) {
+		t.Error("A refused newcomer should not be inserted")
+	}
+	if !skiplist.Insert(&TestItem{ID: 4}, TestContext{}) || contains(skiplist, 2) || skiplist.Length() != 3 {
+		t.Error("An admitted newcomer should displace the victim")
+	}
+	if len(victims) != 2 || victims[0] != 2 || victims[1] != 2 {
+		t.Errorf("Victims = %v, want the first unpinned entry twice", victims)
+	}
+
+	// Replacing an existing key bypasses the filter
+	skiplist.Insert(&TestItem{ID: 3, Data: []byte("new")}, TestContext{})
+	if len(victims) != 2 {
+		t.Error("Filter consulted for a replacement")
+	}
+}
+
+func TestFrequencyAdmission(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	skiplist.SetWeigher(func(*TestItem, TestContext) int64 { return 1 })
+	skiplist.SetWeightLimit(5)
+	skiplist.SetFrequencyAdmission(NewFrequencySketch[int](1024))
+	for _, item := range createTestItems(5) {
+		skiplist.Insert(item, TestContext{})
+	}
+	for i := 0; i < 8; i++ {
+		for key := 1; key <= 5; key++ {
+			skiplist.Find(key)
+		}
+	}
+
+	// A scan of one-off keys leaves the hot set alone
+	for key := 100; key < 200; key++ {
+		skiplist.Insert(&TestItem{ID: key}, TestContext{})
+	}
+	for key := 1; key <= 5; key++ {
+		if !contains(skiplist, key) {
+			t.Errorf("Hot key %d was flushed by the scan", key)
+		}
+	}
+
+	// A key seen often enough gets in
+	admitted := false
+	for i := 0; i < 20 && !admitted; i++ {
+		admitted = skiplist.Insert(&TestItem{ID: 50}, TestContext{})
+	}
+	if !admitted || skiplist.Length() != 5 {
+		t.Errorf("A repeatedly inserted key should be admitted; admitted %v, length %d", admitted, skiplist.Length())
+	}
+}
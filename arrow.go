@@ -0,0 +1,183 @@
+// arrow.go - Columnar export in the Apache Arrow memory layout
+
+package zerocopyskiplist
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// ArrowType is the Arrow data type of an exported column
+type ArrowType int
+
+// Supported Arrow column types
+const (
+	ArrowInt64   ArrowType = iota // 8 byte little-endian values
+	ArrowUint64                   // 8 byte little-endian values
+	ArrowFloat64                  // 8 byte little-endian IEEE 754 values
+	ArrowBool                     // bit-packed values, least significant bit first
+	ArrowUtf8                     // int32 offsets and UTF-8 data
+	ArrowBinary                   // int32 offsets and raw data
+)
+
+// String returns the Arrow name of the type
+func (t ArrowType) String() string {
+	switch t {
+	case ArrowInt64:
+		return "int64"
+	case ArrowUint64:
+		return "uint64"
+	case ArrowFloat64:
+		return "float64"
+	case ArrowBool:
+		return "bool"
+	case ArrowUtf8:
+		return "utf8"
+	case ArrowBinary:
+		return "binary"
+	}
+	return fmt.Sprintf("ArrowType(%d)", int(t))
+}
+
+// ArrowField describes one exported column and how to read its value from an
+// item and context. Build fields with Int64Field, Uint64Field, Float64Field,
+// BoolField, StringField and BinaryField.
+type ArrowField[T any, C comparable] struct {
+	Name string
+	Type ArrowType
+	add  func(col *ArrowColumn, row int, item *T, context C)
+}
+
+// ArrowSchema lists the names and types of a record batch's columns
+type ArrowSchema struct {
+	Names []string
+	Types []ArrowType
+}
+
+// ArrowColumn holds one column of a record batch as Arrow buffers. Values is
+// the data buffer; variable width columns also have Offsets, with Rows+1
+// entries. Columns never contain nulls, so there is no validity bitmap.
+type ArrowColumn struct {
+	Offsets []int32
+	Values  []byte
+}
+
+// ArrowRecordBatch is a set of equal length columns in schema order
+type ArrowRecordBatch struct {
+	Schema  *ArrowSchema
+	Rows    int
+	Columns []ArrowColumn
+}
+
+// Int64Field returns a field exporting get as an int64 column
+func Int64Field[T any, C comparable](name string, get func(item *T, context C) int64) ArrowField[T, C] {
+	return ArrowField[T, C]{Name: name, Type: ArrowInt64, add: func(col *ArrowColumn, row int, item *T, context C) {
+		col.Values = binary.LittleEndian.AppendUint64(col.Values, uint64(get(item, context)))
+	}}
+}
+
+// Uint64Field returns a field exporting get as a uint64 column
+func Uint64Field[T any, C comparable](name string, get func(item *T, context C) uint64) ArrowField[T, C] {
+	return ArrowField[T, C]{Name: name, Type: ArrowUint64, add: func(col *ArrowColumn, row int, item *T, context C) {
+		col.Values = binary.LittleEndian.AppendUint64(col.Values, get(item, context))
+	}}
+}
+
+// Float64Field returns a field exporting get as a float64 column
+func Float64Field[T any, C comparable](name string, get func(item *T, context C) float64) ArrowField[T, C] {
+	return ArrowField[T, C]{Name: name, Type: ArrowFloat64, add: func(col *ArrowColumn, row int, item *T, context C) {
+		col.Values = binary.LittleEndian.AppendUint64(col.Values, math.Float64bits(get(item, context)))
+	}}
+}
+
+// BoolField returns a field exporting get as a bool column
+func BoolField[T any, C comparable](name string, get func(item *T, context C) bool) ArrowField[T, C] {
+	return ArrowField[T, C]{Name: name, Type: ArrowBool, add: func(col *ArrowColumn, row int, item *T, context C) {
+		if row%8 == 0 {
+			col.Values = append(col.Values, 0)
+		}
+		if get(item, context) {
+			col.Values[row/8] |= 1 << (row % 8)
+		}
+	}}
+}
+
+// StringField returns a field exporting get as a utf8 column
+func StringField[T any, C comparable](name string, get func(item *T, context C) string) ArrowField[T, C] {
+	return ArrowField[T, C]{Name: name, Type: ArrowUtf8, add: func(col *ArrowColumn, row int, item *T, context C) {
+		col.Values = append(col.Values, get(item, context)...)
+		col.Offsets = append(col.Offsets, int32(len(col.Values)))
+	}}
+}
+
+// BinaryField returns a field exporting get as a binary column
+func BinaryField[T any, C comparable](name string, get func(item *T, context C) []byte) ArrowField[T, C] {
+	return ArrowField[T, C]{Name: name, Type: ArrowBinary, add: func(col *ArrowColumn, row int, item *T, context C) {
+		col.Values = append(col.Values, get(item, context)...)
+		col.Offsets = append(col.Offsets, int32(len(col.Values)))
+	}}
+}
+
+// ExportArrow scans the list in key order and calls fn with record batches of
+// up to batchRows rows, one column per field. The buffers follow the Arrow
+// columnar format, so they can be wrapped without copying by an Arrow library
+// (for example array.NewData in arrow-go) or written as IPC messages. Each
+// batch owns its buffers and may be retained by fn. Export stops at the first
+// error returned by fn.
+//
+// Items are snapshotted in batches under the read lock as for
+// CallbackToIovecSlice; no lock is held while fields are read or fn runs.
+func (sl *ZeroCopySkiplist[T, K, C]) ExportArrow(batchRows int, fields []ArrowField[T, C], fn func(*ArrowRecordBatch) error) error {
+	if batchRows <= 0 {
+		return fmt.Errorf("invalid arrow batch size: %d", batchRows)
+	}
+
+	schema := &ArrowSchema{}
+	for _, field := range fields {
+		schema.Names = append(schema.Names, field.Name)
+		schema.Types = append(schema.Types, field.Type)
+	}
+
+	record := newArrowRecordBatch(schema)
+	snapshot := make([]ItemPtr[T, K, C], 0, callbackBatchSize)
+	var lastKey K
+	resume := false
+	for {
+		snapshot = sl.snapshotBatch(snapshot[:0], lastKey, resume)
+		for i := range snapshot {
+			for c, field := range fields {
+				field.add(&record.Columns[c], record.Rows, snapshot[i].item, snapshot[i].context)
+			}
+			record.Rows++
+			if record.Rows == batchRows {
+				if err := fn(record); err != nil {
+					return err
+				}
+				record = newArrowRecordBatch(schema)
+			}
+		}
+		if len(snapshot) < callbackBatchSize {
+			break
+		}
+		lastKey = snapshot[len(snapshot)-1].key
+		resume = true
+	}
+
+	if record.Rows > 0 {
+		return fn(record)
+	}
+	return nil
+}
+
+// newArrowRecordBatch returns an empty batch for schema with variable width
+// columns holding their leading zero offset
+func newArrowRecordBatch(schema *ArrowSchema) *ArrowRecordBatch {
+	record := &ArrowRecordBatch{Schema: schema, Columns: make([]ArrowColumn, len(schema.Types))}
+	for i, t := range schema.Types {
+		if t == ArrowUtf8 || t == ArrowBinary {
+			record.Columns[i].Offsets = []int32{0}
+		}
+	}
+	return record
+}
@@ -0,0 +1,72 @@
+package zerocopyskiplist
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestExportArrow(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		8,
+		getKeyFromTestItem,
+		getTestItemSize,
+		compareInt,
+	)
+	for i, item := range createTestItems(700) {
+		skiplist.Insert(item, TestContext{AccessCount: i, IsCached: i%3 == 0})
+	}
+
+	fields := []ArrowField[TestItem, TestContext]{
+		Int64Field("id", func(item *TestItem, _ TestContext) int64 { return int64(item.ID) }),
+		StringField("value", func(item *TestItem, _ TestContext) string { return item.Value }),
+		BoolField("cached", func(_ *TestItem, ctx TestContext) bool { return ctx.IsCached }),
+		Float64Field("score", func(_ *TestItem, ctx TestContext) float64 { return float64(ctx.AccessCount) / 2 }),
+		BinaryField("data", func(item *TestItem, _ TestContext) []byte { return item.Data }),
+	}
+
+	var batches []*ArrowRecordBatch
+	err := skiplist.ExportArrow(300, fields, func(batch *ArrowRecordBatch) error {
+		batches = append(batches, batch)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExportArrow: %v", err)
+	}
+	if len(batches) != 3 || batches[0].Rows != 300 || batches[2].Rows != 100 {
+		t.Fatalf("Expected batches of 300, 300 and 100 rows, got %d batches", len(batches))
+	}
+	if batches[0].Schema.Names[1] != "value" || batches[0].Schema.Types[2] != ArrowBool {
+		t.Errorf("Unexpected schema %+v", batches[0].Schema)
+	}
+
+	row := 0
+	for _, batch := range batches {
+		ids, values, cached, scores := batch.Columns[0], batch.Columns[1], batch.Columns[2], batch.Columns[3]
+		if len(ids.Values) != 8*batch.Rows || len(values.Offsets) != batch.Rows+1 || len(cached.Values) != (batch.Rows+7)/8 {
+			t.Fatalf("Wrong buffer sizes for a batch of %d rows", batch.Rows)
+		}
+		for r := 0; r < batch.Rows; r++ {
+			id := int64(binary.LittleEndian.Uint64(ids.Values[8*r:]))
+			value := string(values.Values[values.Offsets[r]:values.Offsets[r+1]])
+			isCached := cached.Values[r/8]&(1<<(r%8)) != 0
+			score := math.Float64frombits(binary.LittleEndian.Uint64(scores.Values[8*r:]))
+			if id != int64(row+1) || value != fmt.Sprintf("value_%d", row+1) || isCached != (row%3 == 0) || score != float64(row)/2 {
+				t.Fatalf("Wrong values in row %d: %d %q %v %v", row, id, value, isCached, score)
+			}
+			row++
+		}
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = skiplist.ExportArrow(100, fields, func(*ArrowRecordBatch) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("Expected export to stop after the first error, got %v after %d calls", err, calls)
+	}
+}
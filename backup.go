@@ -0,0 +1,499 @@
+// backup.go - Incremental backups shipped to a blob store
+
+package zerocopyskiplist
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Delta record operations
+const (
+	deltaUpsert = 1
+	deltaDelete = 2
+)
+
+// Backup blob name suffixes
+const (
+	backupFullSuffix  = ".full"
+	backupDeltaSuffix = ".delta"
+)
+
+// BackupReport describes one backup cycle
+type BackupReport struct {
+	Name    string // blob written, empty if the list had not changed
+	Full    bool   // the blob is a full snapshot rather than a delta
+	Upserts int    // entries added or replaced since the previous backup
+	Deletes int    // entries removed since the previous backup
+}
+
+// BackupInfo describes a blob written by a BackupAgent, as parsed from its
+// name by ListBackups
+type BackupInfo struct {
+	Name       string
+	Sequence   int       // position in the backup chain, from 1
+	Full       bool      // a full snapshot rather than a delta
+	Time       time.Time // when the list was copied
+	Generation uint64    // the list's Generation when it was copied
+}
+
+// RestorePoint selects the state RestoreBackupAt rebuilds: the last backup
+// taken at or before AsOf whose generation is at most Generation, a zero
+// field leaving that bound unset
+type RestorePoint struct {
+	AsOf       time.Time
+	Generation uint64
+}
+
+// includes returns true if the state backed up in b is at or before p
+func (p RestorePoint) includes(b BackupInfo) bool {
+	return (p.AsOf.IsZero() || !b.Time.After(p.AsOf)) && (p.Generation == 0 || b.Generation <= p.Generation)
+}
+
+// BackupAgent backs a list up to a BlobStore: a full snapshot first, then on
+// each cycle a delta holding only the entries inserted, replaced or deleted
+// since the previous cycle, and a fresh full snapshot every fullEvery deltas.
+// Blobs are named prefix/<sequence>-<unix nanoseconds>-<generation> with a
+// .full or .delta suffix. Once a full snapshot is stored the blobs before the
+// oldest retained full snapshot are deleted, see SetRetention.
+// RestoreBackup and RestoreBackupAt rebuild a list from them.
+//
+// Each cycle takes a Copy of the list, holding the read lock only while the
+// nodes are copied, and diffs it against the copy kept from the previous
+// cycle. Entries are compared by item pointer and context, so an item
+// modified in place without being replaced is not shipped. A cycle is skipped
+// when the list's Generation has not changed.
+type BackupAgent[T any, K comparable, C comparable] struct {
+	list      *ZeroCopySkiplist[T, K, C]
+	store     BlobStore
+	prefix    string
+	codec     ItemCodec[T, C]
+	keys      KeyCodec[K]
+	fullEvery int
+
+	mu         sync.Mutex
+	retain     int                        // full snapshots kept, with their deltas
+	prev       *ZeroCopySkiplist[T, K, C] // copy taken by the last cycle, nil before the first
+	generation uint64                     // list generation when prev was taken
+	seq        int                        // sequence number of the next blob, 0 until listed
+	deltas     int                        // deltas written since the last full snapshot
+}
+
+// NewBackupAgent returns an agent backing list up to store under prefix,
+// encoding items with codec and deleted keys with keys. fullEvery <= 0 never
+// writes a full snapshot after the first.
+func NewBackupAgent[T any, K comparable, C comparable](list *ZeroCopySkiplist[T, K, C], store BlobStore, prefix string, codec ItemCodec[T, C], keys KeyCodec[K], fullEvery int) *BackupAgent[T, K, C] {
+	return &BackupAgent[T, K, C]{
+		list:      list,
+		store:     store,
+		prefix:    strings.TrimSuffix(prefix, "/"),
+		codec:     codec,
+		keys:      keys,
+		fullEvery: fullEvery,
+		retain:    1,
+	}
+}
+
+// SetRetention keeps the last fulls full snapshots, at least one, and the
+// deltas after each, so RestoreBackupAt can go back that far. By default
+// only the latest full snapshot and its deltas are kept.
+func (a *BackupAgent[T, K, C]) SetRetention(fulls int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.retain = max(fulls, 1)
+}
+
+// Backup runs one backup cycle. A failed cycle ships nothing and leaves the
+// next cycle to diff against the last successful one.
+func (a *BackupAgent[T, K, C]) Backup(ctx context.Context) (BackupReport, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.seq == 0 {
+		seq, err := a.nextSequence(ctx)
+		if err != nil {
+			return BackupReport{}, err
+		}
+		a.seq = seq
+	}
+
+	if a.prev != nil && a.list.Generation() == a.generation {
+		return BackupReport{}, nil
+	}
+	current, generation := a.list.copyWithGeneration()
+	info := BackupInfo{Sequence: a.seq, Time: a.list.Clock().Now(), Generation: generation}
+
+	var report BackupReport
+	var err error
+	if a.prev == nil || (a.fullEvery > 0 && a.deltas >= a.fullEvery) {
+		info.Full = true
+		report, err = a.shipFull(ctx, current, backupBlobName(a.prefix, info))
+	} else {
+		report, err = a.shipDelta(ctx, current, backupBlobName(a.prefix, info))
+	}
+	if err != nil {
+		return report, err
+	}
+
+	a.prev, a.generation = current, generation
+	a.seq++
+	return report, nil
+}
+
+// Run returns a background task for Go that calls Backup every interval until
+// the list is closed, passing failures to onError when it is not nil
+func (a *BackupAgent[T, K, C]) Run(interval time.Duration, onError func(error)) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		ticker := a.list.Clock().NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C():
+				if _, err := a.Backup(ctx); err != nil && onError != nil {
+					onError(err)
+				}
+			}
+		}
+	}
+}
+
+// shipFull stores current as a full snapshot under name and deletes the
+// blobs older than the retained full snapshots
+func (a *BackupAgent[T, K, C]) shipFull(ctx context.Context, current *ZeroCopySkiplist[T, K, C], name string) (BackupReport, error) {
+	report := BackupReport{Name: name, Full: true, Upserts: current.Length()}
+	if err := current.SaveCheckpoint(ctx, a.store, name, a.codec); err != nil {
+		return report, fmt.Errorf("storing %s: %w", name, err)
+	}
+	a.deltas = 0
+
+	// Blobs left behind are harmless, restores start at a full snapshot
+	backups, _ := ListBackups(ctx, a.store, a.prefix)
+	var fulls []int
+	for _, b := range backups {
+		if b.Full {
+			fulls = append(fulls, b.Sequence)
+		}
+	}
+	if len(fulls) > a.retain {
+		oldest := fulls[len(fulls)-a.retain]
+		for _, b := range backups {
+			if b.Sequence < oldest {
+				a.store.Delete(ctx, b.Name)
+			}
+		}
+	}
+	return report, nil
+}
+
+// shipDelta stores the changes from the previous copy to current under name
+func (a *BackupAgent[T, K, C]) shipDelta(ctx context.Context, current *ZeroCopySkiplist[T, K, C], name string) (BackupReport, error) {
+	report := BackupReport{Name: name}
+
+	var records bytes.Buffer
+	var buf []byte
+	var err error
+	diffLists(a.prev, current, func(old, cur *ItemPtr[T, K, C]) bool {
+		if cur != nil {
+			buf, err = appendRecord(append(buf[:0], deltaUpsert), a.codec, cur.item, cur.context)
+			if err != nil {
+				err = fmt.Errorf("encoding key %v: %w", cur.key, err)
+				return false
+			}
+			report.Upserts++
+		} else {
+			buf = a.keys.AppendKey(append(buf[:0], deltaDelete, 0, 0, 0, 0), old.key)
+			binary.LittleEndian.PutUint32(buf[1:], uint32(len(buf)-5))
+			report.Deletes++
+		}
+		records.Write(buf)
+		return true
+	})
+	if err != nil {
+		return report, err
+	}
+
+	header := persistHeader{
+		kind:      persistDelta,
+		schema:    uint32(a.codec.SchemaVersion()),
+		count:     uint64(report.Upserts + report.Deletes),
+		collation: a.list.opts.collation,
+	}
+	blob := header.appendTo(make([]byte, 0, persistHeaderSize+2+len(header.collation)+records.Len()+4))
+	blob = append(blob, records.Bytes()...)
+	blob = binary.LittleEndian.AppendUint32(blob, crc32.Checksum(blob, crcTable))
+	if err := a.store.Put(ctx, name, bytes.NewReader(blob)); err != nil {
+		return report, fmt.Errorf("storing %s: %w", name, err)
+	}
+	a.deltas++
+	return report, nil
+}
+
+// nextSequence returns the sequence number after the last blob under the prefix
+func (a *BackupAgent[T, K, C]) nextSequence(ctx context.Context) (int, error) {
+	backups, err := ListBackups(ctx, a.store, a.prefix)
+	if err != nil {
+		return 0, err
+	}
+	if len(backups) == 0 {
+		return 1, nil
+	}
+	return backups[len(backups)-1].Sequence + 1, nil
+}
+
+// ListBackups returns the blobs a BackupAgent has stored under prefix, in
+// sequence order, ignoring other names
+func ListBackups(ctx context.Context, store BlobStore, prefix string) ([]BackupInfo, error) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	names, err := store.List(ctx, prefix+"/")
+	if err != nil {
+		return nil, fmt.Errorf("listing backups: %w", err)
+	}
+	var backups []BackupInfo
+	for _, name := range names {
+		if b, ok := parseBackupName(prefix, name); ok {
+			backups = append(backups, b)
+		}
+	}
+	slices.SortFunc(backups, func(a, b BackupInfo) int {
+		return cmp.Compare(a.Sequence, b.Sequence)
+	})
+	return backups, nil
+}
+
+// backupBlobName returns the name of the blob described by b under prefix
+func backupBlobName(prefix string, b BackupInfo) string {
+	suffix := backupDeltaSuffix
+	if b.Full {
+		suffix = backupFullSuffix
+	}
+	return fmt.Sprintf("%s/%016d-%d-%d%s", prefix, b.Sequence, b.Time.UnixNano(), b.Generation, suffix)
+}
+
+// parseBackupName parses a blob name written by backupBlobName, false for
+// other names
+func parseBackupName(prefix, name string) (BackupInfo, bool) {
+	base, ok := strings.CutPrefix(name, prefix+"/")
+	if !ok {
+		return BackupInfo{}, false
+	}
+	b := BackupInfo{Name: name}
+	if base, b.Full = strings.CutSuffix(base, backupFullSuffix); !b.Full {
+		if base, ok = strings.CutSuffix(base, backupDeltaSuffix); !ok {
+			return BackupInfo{}, false
+		}
+	}
+
+	fields := strings.Split(base, "-")
+	if len(fields) != 3 {
+		return BackupInfo{}, false
+	}
+	seq, err1 := strconv.Atoi(fields[0])
+	nanos, err2 := strconv.ParseInt(fields[1], 10, 64)
+	generation, err3 := strconv.ParseUint(fields[2], 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return BackupInfo{}, false
+	}
+	b.Sequence, b.Time, b.Generation = seq, time.Unix(0, nanos), generation
+	return b, true
+}
+
+// diffLists calls fn, in key order, for every entry of cur that is not in old
+// or differs in item or context, with that entry as cur, and for every entry
+// of old missing from cur with a nil cur, until fn returns false. Neither
+// list may be written while it runs.
+func diffLists[T any, K comparable, C comparable](old, cur *ZeroCopySkiplist[T, K, C], fn func(old, cur *ItemPtr[T, K, C]) bool) {
+	o, c := old.header.forward[0], cur.header.forward[0]
+	for o != nil || c != nil {
+		order := 0
+		switch {
+		case o == nil:
+			order = 1
+		case c == nil:
+			order = -1
+		default:
+			order = cur.cmpKey(o.key, c.key)
+		}
+
+		switch {
+		case order < 0:
+			if !fn(o, nil) {
+				return
+			}
+			o = o.forward[0]
+		case order > 0:
+			if !fn(nil, c) {
+				return
+			}
+			c = c.forward[0]
+		default:
+			if (o.item != c.item || o.context != c.context) && !fn(o, c) {
+				return
+			}
+			o, c = o.forward[0], c.forward[0]
+		}
+	}
+}
+
+// RestoreBackup loads the latest full snapshot stored under prefix by a
+// BackupAgent into the list, then applies the deltas written after it in
+// order; it is RestoreBackupAt with no restore point.
+func (sl *ZeroCopySkiplist[T, K, C]) RestoreBackup(ctx context.Context, store BlobStore, prefix string, codec ItemCodec[T, C], keys KeyCodec[K]) error {
+	_, err := sl.RestoreBackupAt(ctx, store, prefix, codec, keys, RestorePoint{})
+	return err
+}
+
+// RestoreBackupAt rebuilds the list as backed up under prefix at point: it
+// loads the last full snapshot at or before point and applies the deltas
+// after it up to the last one at or before point, returning the backup
+// restored to. The chain must be complete, so a missing delta fails the
+// restore rather than silently skipping changes. Each blob's checksum is
+// verified before it is applied, so a corrupt delta stops the restore with
+// the list at the state of the blob before it. Restore into an empty list:
+// entries already present and absent from the backup are kept. Records
+// refused by a context quota or the admission filter are skipped without
+// stopping the restore, which then returns an error wrapping ErrRefused.
+func (sl *ZeroCopySkiplist[T, K, C]) RestoreBackupAt(ctx context.Context, store BlobStore, prefix string, codec ItemCodec[T, C], keys KeyCodec[K], point RestorePoint) (BackupInfo, error) {
+	backups, err := ListBackups(ctx, store, prefix)
+	if err != nil {
+		return BackupInfo{}, err
+	}
+
+	// Backups are taken in sequence, so those at or before point are a prefix
+	end := 0
+	for end < len(backups) && point.includes(backups[end]) {
+		end++
+	}
+	start := -1
+	for i, b := range backups[:end] {
+		if b.Full {
+			start = i
+		}
+	}
+	if start < 0 {
+		return BackupInfo{}, fmt.Errorf("%w: no full backup under %s at or before the restore point", ErrBlobNotFound, prefix)
+	}
+	for i := start + 1; i < end; i++ {
+		if backups[i].Sequence != backups[i-1].Sequence+1 {
+			return BackupInfo{}, fmt.Errorf("%w: backup %d missing from the chain under %s", ErrBlobNotFound, backups[i-1].Sequence+1, prefix)
+		}
+	}
+
+	var refusals []error
+	if err := sl.LoadCheckpoint(ctx, store, backups[start].Name, codec); errors.Is(err, ErrRefused) {
+		refusals = append(refusals, fmt.Errorf("restoring %s: %w", backups[start].Name, err))
+	} else if err != nil {
+		return BackupInfo{}, fmt.Errorf("restoring %s: %w", backups[start].Name, err)
+	}
+	for i, b := range backups[start+1 : end] {
+		if err := sl.applyDelta(ctx, store, b.Name, codec, keys); errors.Is(err, ErrRefused) {
+			refusals = append(refusals, fmt.Errorf("restoring %s: %w", b.Name, err))
+		} else if err != nil {
+			return backups[start+i], fmt.Errorf("restoring %s: %w", b.Name, err)
+		}
+	}
+	return backups[end-1], errors.Join(refusals...)
+}
+
+// applyDelta reads, verifies and applies the delta stored under name
+func (sl *ZeroCopySkiplist[T, K, C]) applyDelta(ctx context.Context, store BlobStore, name string, codec ItemCodec[T, C], keys KeyCodec[K]) error {
+	rc, err := store.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	blob, err := io.ReadAll(bufio.NewReader(rc))
+	if err != nil {
+		return err
+	}
+
+	if len(blob) < persistHeaderSize+4 {
+		return fmt.Errorf("%w: truncated delta", ErrSnapshotCorrupt)
+	}
+	body, sum := blob[:len(blob)-4], binary.LittleEndian.Uint32(blob[len(blob)-4:])
+	if crc32.Checksum(body, crcTable) != sum {
+		return fmt.Errorf("%w: checksum mismatch", ErrSnapshotCorrupt)
+	}
+	header, err := parsePersistHeader(body, persistDelta)
+	if err != nil {
+		return err
+	}
+	if err := sl.checkCollation(header.collation); err != nil {
+		return err
+	}
+
+	type change struct {
+		key     K
+		item    *T
+		context C
+	}
+	changes := make([]change, 0, header.count)
+	rest := body[header.size():]
+	for i := uint64(0); i < header.count; i++ {
+		if len(rest) < 5 || uint64(len(rest)-5) < uint64(binary.LittleEndian.Uint32(rest[1:])) {
+			return fmt.Errorf("%w: truncated record %d", ErrSnapshotCorrupt, i)
+		}
+		op, raw := rest[0], rest[5:5+binary.LittleEndian.Uint32(rest[1:])]
+		rest = rest[5+len(raw):]
+
+		switch op {
+		case deltaUpsert:
+			item, context, err := DecodeVersion(codec, int(header.schema), raw)
+			if err != nil {
+				return fmt.Errorf("decoding record %d: %w", i, err)
+			}
+			changes = append(changes, change{item: item, context: context})
+		case deltaDelete:
+			key, err := keys.DecodeKey(raw)
+			if err != nil {
+				return fmt.Errorf("decoding key of record %d: %w", i, err)
+			}
+			changes = append(changes, change{key: key})
+		default:
+			return fmt.Errorf("%w: unknown operation %d in record %d", ErrSnapshotCorrupt, op, i)
+		}
+	}
+
+	var frozen bool
+	refused := 0
+	hook, totalBytes, length := func() (func(int64, int), int64, int) {
+		sl.lock()
+		defer sl.unlock()
+		if sl.frozen {
+			frozen = true
+			return nil, 0, 0
+		}
+		for _, c := range changes {
+			if c.item == nil {
+				sl.delete(c.key)
+			} else if _, r := sl.tryInsert(c.item, c.context); r {
+				refused++
+			}
+		}
+		return sl.checkPressure()
+	}()
+
+	if hook != nil {
+		hook(totalBytes, length)
+	}
+	if frozen {
+		return ErrFrozen
+	}
+	if refused > 0 {
+		return fmt.Errorf("%w: %d of %d records", ErrRefused, refused, len(changes))
+	}
+	return nil
+}
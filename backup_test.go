@@ -0,0 +1,153 @@
+package zerocopyskiplist
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// backupContents returns the keys, values and contexts of a list
+func backupContents(sl *ZeroCopySkiplist[TestItem, int, TestContext]) []Entry[TestItem, int, TestContext] {
+	var entries []Entry[TestItem, int, TestContext]
+	sl.ForEach(func(ip *ItemPtr[TestItem, int, TestContext]) bool {
+		entry := ip.Entry()
+		entry.Item = &TestItem{ID: entry.Item.ID, Value: entry.Item.Value}
+		entries = append(entries, entry)
+		return true
+	})
+	return entries
+}
+
+func TestBackupAgent(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirStore: %v", err)
+	}
+	skiplist := newSnapshotTestList()
+	for i, item := range createTestItems(100) {
+		item.Value = "v1"
+		skiplist.Insert(item, TestContext{AccessCount: i})
+	}
+
+	agent := NewBackupAgent(skiplist, store, "backups/main", testItemCodecV2{}, intKeys{}, 2)
+	report, err := agent.Backup(ctx)
+	if err != nil || !report.Full || report.Upserts != 100 {
+		t.Fatalf("First backup = %+v, %v; want a full backup of 100 entries", report, err)
+	}
+	if report, err := agent.Backup(ctx); err != nil || report.Name != "" {
+		t.Errorf("Backup of an unchanged list = %+v, %v; want nothing shipped", report, err)
+	}
+
+	skiplist.Insert(&TestItem{ID: 5, Value: "v2"}, TestContext{AccessCount: 5})
+	skiplist.Insert(&TestItem{ID: 200, Value: "new"}, TestContext{})
+	skiplist.UpdateContext(7, TestContext{AccessCount: 70})
+	skiplist.Delete(9)
+	skiplist.Delete(10)
+	report, err = agent.Backup(ctx)
+	if err != nil || report.Full || report.Upserts != 3 || report.Deletes != 2 {
+		t.Fatalf("Delta backup = %+v, %v; want 3 upserts and 2 deletes", report, err)
+	}
+
+	skiplist.Delete(1)
+	if _, err := agent.Backup(ctx); err != nil {
+		t.Fatalf("Second delta: %v", err)
+	}
+	restored := newSnapshotTestList()
+	if err := restored.RestoreBackup(ctx, store, "backups/main", testItemCodecV2{}, intKeys{}); err != nil {
+		t.Fatalf("RestoreBackup: %v", err)
+	}
+	if want, got := backupContents(skiplist), backupContents(restored); !reflect.DeepEqual(want, got) {
+		t.Errorf("Restored %d entries differ from the %d backed up", len(got), len(want))
+	}
+
+	// The third cycle after a full snapshot writes a new one and prunes the chain
+	skiplist.Delete(2)
+	report, err = agent.Backup(ctx)
+	if err != nil || !report.Full {
+		t.Fatalf("Backup after 2 deltas = %+v, %v; want a full backup", report, err)
+	}
+	names, _ := store.List(ctx, "backups/main/")
+	if len(names) != 1 || names[0] != report.Name {
+		t.Errorf("Blobs after a full backup = %v, want only the new full snapshot", names)
+	}
+}
+
+func TestRestoreBackupMissing(t *testing.T) {
+	store, err := NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirStore: %v", err)
+	}
+	err = newSnapshotTestList().RestoreBackup(context.Background(), store, "none", testItemCodecV2{}, intKeys{})
+	if !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("Restore without a full backup should return ErrBlobNotFound, got %v", err)
+	}
+}
+
+func TestRestoreBackupAt(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirStore: %v", err)
+	}
+	skiplist := newSnapshotTestList()
+	for _, item := range createTestItems(20) {
+		skiplist.Insert(item, TestContext{})
+	}
+
+	// A full snapshot, two deltas, then a full snapshot that keeps the first chain
+	agent := NewBackupAgent(skiplist, store, "pit", testItemCodecV2{}, intKeys{}, 2)
+	agent.SetRetention(2)
+	var states [][]Entry[TestItem, int, TestContext]
+	for i := range 4 {
+		skiplist.Delete(i + 1)
+		if _, err := agent.Backup(ctx); err != nil {
+			t.Fatalf("Backup %d: %v", i, err)
+		}
+		states = append(states, backupContents(skiplist))
+	}
+
+	backups, err := ListBackups(ctx, store, "pit")
+	if err != nil || len(backups) != 4 {
+		t.Fatalf("ListBackups = %d backups, %v; want 4", len(backups), err)
+	}
+	if !backups[0].Full || backups[1].Full || backups[2].Full || !backups[3].Full {
+		t.Errorf("Backup kinds = %+v, want full, delta, delta, full", backups)
+	}
+
+	for i, point := range []RestorePoint{
+		{Generation: backups[0].Generation},
+		{AsOf: backups[1].Time},
+		{Generation: backups[2].Generation, AsOf: backups[3].Time},
+		{},
+	} {
+		restored := newSnapshotTestList()
+		info, err := restored.RestoreBackupAt(ctx, store, "pit", testItemCodecV2{}, intKeys{}, point)
+		if err != nil || info.Name != backups[i].Name {
+			t.Fatalf("RestoreBackupAt(%+v) = %+v, %v; want %s", point, info, err, backups[i].Name)
+		}
+		if got := backupContents(restored); !reflect.DeepEqual(states[i], got) {
+			t.Errorf("RestoreBackupAt(%+v) restored %d entries, want %d", point, len(got), len(states[i]))
+		}
+	}
+
+	point := RestorePoint{AsOf: backups[0].Time.Add(-time.Second)}
+	_, err = newSnapshotTestList().RestoreBackupAt(ctx, store, "pit", testItemCodecV2{}, intKeys{}, point)
+	if !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("Restore before the first backup should return ErrBlobNotFound, got %v", err)
+	}
+
+	// A chain with a missing delta is refused
+	skiplist.Delete(10)
+	agent.Backup(ctx)
+	skiplist.Delete(11)
+	agent.Backup(ctx)
+	backups, _ = ListBackups(ctx, store, "pit")
+	store.Delete(ctx, backups[len(backups)-2].Name)
+	_, err = newSnapshotTestList().RestoreBackupAt(ctx, store, "pit", testItemCodecV2{}, intKeys{}, RestorePoint{})
+	if !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("Restore over a missing delta should return ErrBlobNotFound, got %v", err)
+	}
+}
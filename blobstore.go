@@ -0,0 +1,185 @@
+// blobstore.go - Checkpoint storage behind a blob store interface
+
+package zerocopyskiplist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrBlobNotFound is returned by BlobStore.Get for a name that does not exist
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore stores named blobs, such as checkpoints, on a local disk or in an
+// object store. Names are slash separated paths. Put must make the blob
+// visible atomically: a concurrent Get or List sees either the complete blob
+// or none of it.
+//
+// An object store only needs its client's put, get, list and delete calls;
+// with the AWS SDK for Go v2, for example:
+//
+//	func (s *S3Store) Put(ctx context.Context, name string, r io.Reader) error {
+//		_, err := s.uploader.Upload(ctx, &s3.PutObjectInput{Bucket: &s.bucket, Key: &name, Body: r})
+//		return err
+//	}
+//
+//	func (s *S3Store) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+//		out, err := s.client.GetObject(ctx, &s3.GetObjectInput{Bucket: &s.bucket, Key: &name})
+//		var noKey *types.NoSuchKey
+//		if errors.As(err, &noKey) {
+//			return nil, zerocopyskiplist.ErrBlobNotFound
+//		}
+//		if err != nil {
+//			return nil, err
+//		}
+//		return out.Body, nil
+//	}
+//
+// with List paging through ListObjectsV2 and Delete calling DeleteObject. The
+// upload manager streams the body, so checkpoints never need to fit in memory.
+type BlobStore interface {
+	// Put stores the contents of r under name, replacing any existing blob
+	Put(ctx context.Context, name string, r io.Reader) error
+	// Get opens the blob stored under name, or returns ErrBlobNotFound
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+	// List returns the names of the blobs starting with prefix, in order
+	List(ctx context.Context, prefix string) ([]string, error)
+	// Delete removes the blob stored under name; deleting a missing blob is not an error
+	Delete(ctx context.Context, name string) error
+}
+
+// DirStore is a BlobStore keeping blobs as files under a directory
+type DirStore struct {
+	dir string
+}
+
+// NewDirStore returns a DirStore rooted at dir, creating it if necessary
+func NewDirStore(dir string) (*DirStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DirStore{dir: dir}, nil
+}
+
+// path maps a blob name to a file path, rejecting names that escape the directory
+func (s *DirStore) path(name string) (string, error) {
+	if !fs.ValidPath(name) || name == "." {
+		return "", fmt.Errorf("invalid blob name %q", name)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(name)), nil
+}
+
+// Put implements BlobStore, writing to a temporary file renamed into place
+func (s *DirStore) Put(ctx context.Context, name string, r io.Reader) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".put-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get implements BlobStore
+func (s *DirStore) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrBlobNotFound, name)
+	}
+	return f, err
+}
+
+// List implements BlobStore
+func (s *DirStore) List(ctx context.Context, prefix string) ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(s.dir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if strings.HasPrefix(name, prefix) && !strings.HasPrefix(d.Name(), ".put-") {
+			names = append(names, name)
+		}
+		return nil
+	})
+	sort.Strings(names)
+	return names, err
+}
+
+// Delete implements BlobStore
+func (s *DirStore) Delete(ctx context.Context, name string) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// SaveCheckpoint streams a snapshot of the list, encoded with codec, into
+// store under name. The snapshot is encoded as it is uploaded, so remote
+// tiers go through the portable codec path rather than the raw iovec layout
+// used for local disks. As with SaveSnapshot the read lock is held until the
+// store has consumed the snapshot; to keep writers running during a slow
+// upload, checkpoint a Copy() of the list instead.
+func (sl *ZeroCopySkiplist[T, K, C]) SaveCheckpoint(ctx context.Context, store BlobStore, name string, codec ItemCodec[T, C]) error {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(sl.SaveSnapshot(pw, codec))
+	}()
+
+	err := store.Put(ctx, name, pr)
+	// Unblock the snapshot writer if Put returned without reading everything
+	pr.CloseWithError(errors.New("checkpoint upload ended"))
+	return err
+}
+
+// LoadCheckpoint loads the snapshot stored in store under name with
+// LoadSnapshot, leaving the list unchanged if the checkpoint is unreadable
+func (sl *ZeroCopySkiplist[T, K, C]) LoadCheckpoint(ctx context.Context, store BlobStore, name string, codec ItemCodec[T, C]) error {
+	rc, err := store.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return sl.LoadSnapshot(rc, codec)
+}
@@ -0,0 +1,94 @@
+package zerocopyskiplist
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// failingStore reads part of each upload and then fails
+type failingStore struct {
+	*DirStore
+}
+
+func (s failingStore) Put(ctx context.Context, name string, r io.Reader) error {
+	io.CopyN(io.Discard, r, 10)
+	return errors.New("upload failed")
+}
+
+func TestDirStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirStore: %v", err)
+	}
+
+	for _, name := range []string{"ckpt/002", "ckpt/001", "other"} {
+		if err := store.Put(ctx, name, strings.NewReader("blob "+name)); err != nil {
+			t.Fatalf("Put %s: %v", name, err)
+		}
+	}
+	names, err := store.List(ctx, "ckpt/")
+	if err != nil || !reflect.DeepEqual(names, []string{"ckpt/001", "ckpt/002"}) {
+		t.Errorf("List returned %v, %v", names, err)
+	}
+
+	rc, err := store.Get(ctx, "ckpt/001")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "blob ckpt/001" {
+		t.Errorf("Get returned %q", data)
+	}
+
+	if err := store.Delete(ctx, "ckpt/001"); err != nil {
+		t.Errorf("Delete: %v", err)
+	}
+	if err := store.Delete(ctx, "ckpt/001"); err != nil {
+		t.Errorf("Deleting a missing blob should succeed: %v", err)
+	}
+	if _, err := store.Get(ctx, "ckpt/001"); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("Expected ErrBlobNotFound, got %v", err)
+	}
+	if err := store.Put(ctx, "../escape", strings.NewReader("x")); err == nil {
+		t.Error("Names outside the store should be rejected")
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirStore: %v", err)
+	}
+
+	skiplist := newSnapshotTestList()
+	for i, item := range createTestItems(2000) {
+		skiplist.Insert(item, TestContext{AccessCount: i})
+	}
+	if err := skiplist.SaveCheckpoint(ctx, store, "ckpt/0001", testItemCodecV2{}); err != nil {
+		t.Fatalf("SaveCheckpoint: %v", err)
+	}
+
+	loaded := newSnapshotTestList()
+	if err := loaded.LoadCheckpoint(ctx, store, "ckpt/0001", testItemCodecV2{}); err != nil {
+		t.Fatalf("LoadCheckpoint: %v", err)
+	}
+	if loaded.Length() != 2000 {
+		t.Errorf("Expected 2000 items, got %d", loaded.Length())
+	}
+	if err := loaded.LoadCheckpoint(ctx, store, "ckpt/0002", testItemCodecV2{}); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("Expected ErrBlobNotFound, got %v", err)
+	}
+
+	// A failed upload must not leave the snapshot writer blocked holding the read lock
+	if err := skiplist.SaveCheckpoint(ctx, failingStore{store}, "ckpt/0003", testItemCodecV2{}); err == nil {
+		t.Error("Expected the upload error")
+	}
+	skiplist.Insert(&TestItem{ID: 5000}, TestContext{})
+}
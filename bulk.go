@@ -0,0 +1,115 @@
+// bulk.go - Bulk loading of sorted items
+
+package zerocopyskiplist
+
+import "fmt"
+
+// BulkLoad inserts items, with the matching entries of contexts or zero
+// contexts when contexts is nil, and returns the number of new keys.
+//
+// Items whose keys ascend beyond the current last key are appended at the
+// level tails without a search, so loading sorted input into an empty list or
+// onto the end of one is O(1) per item. Other items are inserted normally,
+// replacing any existing item with the same key. The write lock is held for
+// the whole load. The items are checked for misuse before any is loaded, see
+// WithMisusePolicy.
+func (sl *ZeroCopySkiplist[T, K, C]) BulkLoad(items []*T, contexts []C) (int, error) {
+	if contexts != nil && len(contexts) != len(items) {
+		return 0, fmt.Errorf("bulk load of %d items with %d contexts", len(items), len(contexts))
+	}
+
+	for i, item := range items {
+		if err := sl.checkItem("BulkLoad", item); err != nil {
+			return 0, fmt.Errorf("item %d: %w", i, err)
+		}
+	}
+
+	var frozen bool
+	added := 0
+	hook, totalBytes, length := func() (func(int64, int), int64, int) {
+		sl.lock()
+		defer sl.unlock()
+		if sl.frozen {
+			frozen = true
+			return nil, 0, 0
+		}
+		for i, item := range items {
+			var context C
+			if contexts != nil {
+				context = contexts[i]
+			}
+
+			key := sl.getKeyFromItem(item)
+			if last := sl.tails[0]; len(sl.quotas) == 0 && (last == nil || sl.cmpKey(last.key, key) < 0) {
+				sl.appendTail(item, key, context)
+				added++
+			} else if sl.insert(item, context) {
+				added++
+			}
+		}
+		return sl.checkPressure()
+	}()
+
+	if hook != nil {
+		hook(totalBytes, length)
+	}
+	if frozen {
+		return 0, ErrFrozen
+	}
+	return added, nil
+}
+
+// appendTail adds item after the last entry, whose key must sort before key;
+// the caller must hold the write lock
+func (sl *ZeroCopySkiplist[T, K, C]) appendTail(item *T, key K, context C) {
+	if sl.flat {
+		if len(sl.small) < sl.opts.smallThreshold {
+			sl.addSmall(len(sl.small), item, key, context)
+			return
+		}
+		sl.materialize()
+	}
+	sl.appendTailAt(item, key, context, sl.randomLevel(true))
+}
+
+// appendTailAt is appendTail with the new node's level given, for a list
+// that is not in small list storage
+func (sl *ZeroCopySkiplist[T, K, C]) appendTailAt(item *T, key K, context C, newLevel int) {
+	newNode := sl.linkTail(item, key, context, newLevel)
+
+	sl.length++
+	sl.totalBytes += int64(sl.getItemSize(item))
+	sl.chargeContext(context, int64(sl.getItemSize(item)), 1, true)
+	sl.chargeWeight(item, context, 1)
+	sl.recordVersion(newNode, false)
+	sl.countChange(sl.getItemSize(item))
+
+	if sl.sampleParanoid() {
+		sl.checkAround("insert", newNode)
+	}
+}
+
+// linkTail links a new node of level newLevel after the last node and
+// returns it, leaving the bookkeeping to the caller
+func (sl *ZeroCopySkiplist[T, K, C]) linkTail(item *T, key K, context C, newLevel int) *ItemPtr[T, K, C] {
+	if newLevel > sl.level {
+		sl.level = newLevel
+	}
+
+	newNode := sl.allocNode(newLevel)
+	newNode.item = item
+	newNode.key = key
+	newNode.context = context
+
+	for i := 0; i <= newLevel; i++ {
+		prev := sl.tails[i]
+		if prev == nil {
+			prev = sl.header
+		}
+		sl.link(prev, i, newNode)
+		newNode.forward[i] = nil
+		newNode.setPrevAt(i, sl.nodeOrNil(prev))
+		sl.tails[i] = newNode
+	}
+	return newNode
+}
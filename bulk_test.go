@@ -0,0 +1,78 @@
+package zerocopyskiplist
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestBulkLoad(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithLevelBacklinks(), WithInlineKeys()}} {
+		skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+			8,
+			getKeyFromTestItem,
+			getTestItemSize,
+			compareInt,
+			opts...,
+		)
+
+		// Sorted input appends, the shuffled tail is inserted normally
+		items := createTestItems(3000)
+		contexts := createTestContexts(3000)
+		added, err := skiplist.BulkLoad(items[1000:2000], contexts[1000:2000])
+		if err != nil || added != 1000 {
+			t.Fatalf("BulkLoad of sorted items added %d: %v", added, err)
+		}
+		rest := append(append([]*TestItem(nil), items[:1000]...), items[2000:]...)
+		rand.New(rand.NewSource(3)).Shuffle(len(rest), func(i, j int) { rest[i], rest[j] = rest[j], rest[i] })
+		if added, err := skiplist.BulkLoad(rest, nil); err != nil || added != 2000 {
+			t.Fatalf("BulkLoad of shuffled items added %d: %v", added, err)
+		}
+		if added, _ := skiplist.BulkLoad(items[:10], nil); added != 0 {
+			t.Errorf("Reloading existing keys should add nothing, added %d", added)
+		}
+
+		if skiplist.Length() != 3000 {
+			t.Errorf("Expected 3000 items, got %d", skiplist.Length())
+		}
+		if err := skiplist.Validate(); err != nil {
+			t.Errorf("Validate after bulk load: %v", err)
+		}
+		if _, ctx := skiplist.Find(1500); ctx != contexts[1499] {
+			t.Error("Bulk loaded context was not stored")
+		}
+		if skiplist.Last().Item() != items[2999] {
+			t.Error("Last should return the largest key after bulk load")
+		}
+	}
+
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	if _, err := skiplist.BulkLoad(createTestItems(2), createTestContexts(1)); err == nil {
+		t.Error("Expected an error for mismatched contexts")
+	}
+}
+
+func TestForEach(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	skiplist.BulkLoad(createTestItems(1000), nil)
+
+	next := 1
+	skiplist.ForEach(func(ip *ItemPtr[TestItem, int, TestContext]) bool {
+		if ip.Key() != next {
+			t.Fatalf("Expected key %d, got %d", next, ip.Key())
+		}
+		next++
+		return next <= 600
+	})
+	if next != 601 {
+		t.Errorf("ForEach should stop when the callback returns false, stopped at %d", next)
+	}
+}
+
+func BenchmarkBulkLoadSorted(b *testing.B) {
+	items := createTestItems(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt)
+		skiplist.BulkLoad(items, nil)
+	}
+}
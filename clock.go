@@ -0,0 +1,140 @@
+// clock.go - Pluggable time source for time-based features
+
+package zerocopyskiplist
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of time for the list's time-based features:
+// soft-delete grace periods, context stats flush times, hotspot decay and
+// backup timestamps and intervals. Tests can substitute a FakeClock and
+// embedded systems a coarse clock with WithClock.
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks from a Clock, as time.Ticker does
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// SystemClock is the Clock backed by the time package, used by lists built
+// without WithClock
+var SystemClock Clock = systemClock{}
+
+// systemClock implements Clock with the time package
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{ticker: time.NewTicker(d)}
+}
+
+// systemTicker adapts a time.Ticker to Ticker
+type systemTicker struct {
+	ticker *time.Ticker
+}
+
+func (t systemTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t systemTicker) Stop() {
+	t.ticker.Stop()
+}
+
+// WithClock uses clock instead of SystemClock for the features described for
+// Clock. Latency histograms, lock contention, the slow lock watchdog and
+// flush reports keep measuring real elapsed time with the time package.
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// Clock returns the list's time source, SystemClock unless WithClock was given
+func (sl *ZeroCopySkiplist[T, K, C]) Clock() Clock {
+	if sl.opts.clock == nil {
+		return SystemClock
+	}
+	return sl.opts.clock
+}
+
+// FakeClock is a Clock whose time only moves when Advance is called, for
+// tests of time-based behaviour. Its tickers fire during Advance.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers map[*fakeTicker]struct{}
+}
+
+// fakeTicker is a Ticker driven by a FakeClock
+type fakeTicker struct {
+	clock  *FakeClock
+	period time.Duration
+	next   time.Time
+	ch     chan time.Time
+}
+
+// NewFakeClock returns a FakeClock reading start
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start, tickers: make(map[*fakeTicker]struct{})}
+}
+
+// Now returns the clock's current time
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTicker returns a ticker firing every d of fake time; it panics if d is
+// not positive, as time.NewTicker does
+func (c *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for FakeClock.NewTicker")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTicker{clock: c, period: d, next: c.now.Add(d), ch: make(chan time.Time, 1)}
+	c.tickers[t] = struct{}{}
+	return t
+}
+
+// Advance moves the clock forward by d and fires every ticker that falls due.
+// Like time.Ticker, a ticker whose last tick has not been received drops the
+// ticks it misses.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for t := range c.tickers {
+		if t.next.After(c.now) {
+			continue
+		}
+		select {
+		case t.ch <- c.now:
+		default:
+		}
+		for !t.next.After(c.now) {
+			t.next = t.next.Add(t.period)
+		}
+	}
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	delete(t.clock.tickers, t)
+}
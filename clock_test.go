@@ -0,0 +1,84 @@
+package zerocopyskiplist
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	ticker := clock.NewTicker(time.Second)
+
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case <-ticker.C():
+		t.Fatal("Ticker fired before its period")
+	default:
+	}
+
+	// Three periods pass but only one tick is buffered, as with time.Ticker
+	clock.Advance(3 * time.Second)
+	if got := <-ticker.C(); !got.Equal(start.Add(3500 * time.Millisecond)) {
+		t.Errorf("Tick at %v, want the clock's time", got)
+	}
+	select {
+	case <-ticker.C():
+		t.Error("Missed ticks should be dropped")
+	default:
+	}
+
+	ticker.Stop()
+	clock.Advance(time.Hour)
+	select {
+	case <-ticker.C():
+		t.Error("Stopped ticker fired")
+	default:
+	}
+	if !clock.Now().Equal(start.Add(time.Hour + 3500*time.Millisecond)) {
+		t.Errorf("Now = %v after advancing", clock.Now())
+	}
+}
+
+func TestWithClock(t *testing.T) {
+	clock := NewFakeClock(time.Unix(1000, 0))
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt, WithClock(clock))
+	if skiplist.Clock() != clock {
+		t.Fatal("Clock should return the clock given to WithClock")
+	}
+	if MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt).Clock() != SystemClock {
+		t.Error("Lists should default to SystemClock")
+	}
+	for _, item := range createTestItems(3) {
+		skiplist.Insert(item, TestContext{})
+	}
+
+	// Grace periods follow the fake clock, not the wall clock
+	skiplist.SoftDelete(1, time.Minute)
+	skiplist.SoftDelete(2, time.Minute)
+	clock.Advance(30 * time.Second)
+	if !skiplist.Restore(1) {
+		t.Error("Restore within the fake grace period should succeed")
+	}
+	clock.Advance(time.Minute)
+	if skiplist.Restore(2) {
+		t.Error("Restore after the fake grace period should fail")
+	}
+
+	// The sweeper ticks on the fake clock
+	skiplist.SoftDelete(3, time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		skiplist.SoftDeleteSweeper(time.Minute)(ctx)
+		close(done)
+	}()
+	for skiplist.Stats().SoftDeleted != 0 {
+		clock.Advance(time.Minute)
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+}
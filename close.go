@@ -0,0 +1,129 @@
+// close.go - Background tasks and the teardown path
+
+package zerocopyskiplist
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+)
+
+// ErrClosed is returned when starting work on, or closing, a closed list
+var ErrClosed = errors.New("skiplist is closed")
+
+// lifecycle tracks the background tasks and teardown steps of a list. It has
+// its own mutex so Close never holds the list lock while waiting on tasks.
+type lifecycle struct {
+	mu      sync.Mutex
+	ctx     context.Context // cancelled by Close; created by the first Go
+	cancel  context.CancelFunc
+	tasks   sync.WaitGroup
+	closers []func(context.Context) error
+	closed  bool
+
+	health      []healthCheck
+	tasksExited int // tasks that returned before Close cancelled them
+}
+
+// Go runs fn in a new goroutine as a background task of the list, such as a
+// sweeper, flusher or compactor. fn must return promptly once ctx is
+// cancelled, which Close does before waiting for it; returning earlier makes
+// Healthy report the list unhealthy.
+func (sl *ZeroCopySkiplist[T, K, C]) Go(fn func(ctx context.Context)) error {
+	return sl.life.start(fn)
+}
+
+// start performs Go
+func (lc *lifecycle) start(fn func(ctx context.Context)) error {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	if lc.closed {
+		return ErrClosed
+	}
+	if lc.ctx == nil {
+		lc.ctx, lc.cancel = context.WithCancel(context.Background())
+	}
+
+	ctx := lc.ctx
+	lc.tasks.Add(1)
+	go func() {
+		defer lc.tasks.Done()
+		fn(ctx)
+		if ctx.Err() == nil {
+			lc.mu.Lock()
+			lc.tasksExited++
+			lc.mu.Unlock()
+		}
+	}()
+	return nil
+}
+
+// OnClose registers fn as a teardown step, run by Close once background tasks
+// have stopped: draining flush queues, writing a final checkpoint, releasing
+// an OffHeap allocator or a SharedReader's mapping. Steps run in reverse order
+// of registration, as deferred calls do, so a resource registered first is
+// released after the steps that may still use it.
+func (sl *ZeroCopySkiplist[T, K, C]) OnClose(fn func(ctx context.Context) error) error {
+	return sl.life.onClose(fn)
+}
+
+// onClose performs OnClose
+func (lc *lifecycle) onClose(fn func(ctx context.Context) error) error {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	if lc.closed {
+		return ErrClosed
+	}
+	lc.closers = append(lc.closers, fn)
+	return nil
+}
+
+// Close tears the list down: it cancels the background tasks started by Go
+// and waits for them to return, freezes the list so the final state is stable,
+// then runs the OnClose steps. Reads keep working afterwards.
+//
+// If ctx ends before the tasks have returned, Close stops waiting, still
+// freezes the list and runs the steps (with the expired ctx, so steps that
+// honour it give up quickly) and includes ctx's error in the result. Errors
+// from every step are joined. Closing a closed list returns ErrClosed.
+func (sl *ZeroCopySkiplist[T, K, C]) Close(ctx context.Context) error {
+	return sl.life.close(ctx, sl.Freeze)
+}
+
+// close performs Close, calling freeze between stopping the tasks and running
+// the teardown steps
+func (lc *lifecycle) close(ctx context.Context, freeze func()) error {
+	lc.mu.Lock()
+	if lc.closed {
+		lc.mu.Unlock()
+		return ErrClosed
+	}
+	lc.closed = true
+	cancel, closers := lc.cancel, lc.closers
+	lc.closers = nil
+	lc.mu.Unlock()
+
+	var errs []error
+	if cancel != nil {
+		cancel()
+		stopped := make(chan struct{})
+		go func() {
+			lc.tasks.Wait()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			errs = append(errs, ctx.Err())
+		}
+	}
+
+	freeze()
+	for _, fn := range slices.Backward(closers) {
+		if err := fn(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
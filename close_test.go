@@ -0,0 +1,86 @@
+package zerocopyskiplist
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestClose(t *testing.T) {
+	sl := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	items := createTestItems(10)
+	sl.Insert(items[0], TestContext{})
+
+	var order []string
+	stopped := make(chan struct{})
+	if err := sl.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		// Background tasks may still write until they return
+		sl.Insert(items[1], TestContext{})
+		close(stopped)
+	}); err != nil {
+		t.Fatalf("Go: %v", err)
+	}
+	sl.OnClose(func(context.Context) error {
+		order = append(order, "release")
+		return nil
+	})
+	sl.OnClose(func(context.Context) error {
+		select {
+		case <-stopped:
+		default:
+			t.Error("Teardown steps should run after background tasks stop")
+		}
+		if !sl.Frozen() {
+			t.Error("The list should be frozen before teardown steps run")
+		}
+		order = append(order, "checkpoint")
+		return errors.New("checkpoint failed")
+	})
+
+	err := sl.Close(context.Background())
+	if err == nil || err.Error() != "checkpoint failed" {
+		t.Errorf("Close = %v, want the checkpoint error", err)
+	}
+	if !slices.Equal(order, []string{"checkpoint", "release"}) {
+		t.Errorf("Teardown order %v, want reverse registration order", order)
+	}
+	if sl.Length() != 2 || sl.Insert(items[2], TestContext{}) {
+		t.Error("A closed list should keep its data and reject writes")
+	}
+
+	if err := sl.Close(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Errorf("Second Close = %v, want ErrClosed", err)
+	}
+	if err := sl.Go(func(context.Context) {}); !errors.Is(err, ErrClosed) {
+		t.Errorf("Go after Close = %v, want ErrClosed", err)
+	}
+	if err := sl.OnClose(func(context.Context) error { return nil }); !errors.Is(err, ErrClosed) {
+		t.Errorf("OnClose after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestCloseDeadline(t *testing.T) {
+	sl := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	release := make(chan struct{})
+	defer close(release)
+	sl.Go(func(context.Context) {
+		<-release // ignores cancellation
+	})
+	ran := false
+	sl.OnClose(func(context.Context) error {
+		ran = true
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := sl.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Close = %v, want context.DeadlineExceeded", err)
+	}
+	if !ran || !sl.Frozen() {
+		t.Error("Close should still freeze and tear down when the deadline passes")
+	}
+}
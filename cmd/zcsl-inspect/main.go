@@ -0,0 +1,157 @@
+// main.go - Command line inspector for persisted skiplist files
+
+// Command zcsl-inspect prints the header, checksum, key range and records of
+// snapshots, backup deltas, indexes and shared segments written by the
+// zerocopyskiplist package, so files on disk can be triaged without writing
+// Go. Keys and values are shown encoded: quoted when they are printable text,
+// in hex otherwise. With -verify only a verdict is printed per file. It exits
+// non-zero if any file cannot be read or has problems.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mattkeenan/zerocopyskiplist"
+)
+
+func main() {
+	verify := flag.Bool("verify", false, "only check each file and print a verdict")
+	contextSize := flag.Int("context-size", -1, "size in bytes of the fixed size context of index files")
+	limit := flag.Int("limit", 0, "records listed per file, 0 lists all")
+	preview := flag.Int("preview", 32, "bytes of each key and value shown")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: zcsl-inspect [flags] file...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	p := printer{out: os.Stdout, limit: *limit, preview: *preview, verify: *verify}
+	failed := false
+	for _, path := range flag.Args() {
+		if !p.inspect(path, *contextSize) {
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// printer writes the report for each file
+type printer struct {
+	out     io.Writer
+	limit   int
+	preview int
+	verify  bool
+}
+
+// inspect reports on the file at path and returns false if it is unreadable
+// or has problems
+func (p printer) inspect(path string, contextSize int) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(p.out, "%s: %v\n", path, err)
+		return false
+	}
+	defer f.Close()
+
+	if !p.verify {
+		fmt.Fprintf(p.out, "%s:\n", path)
+	}
+	listed := 0
+	file, err := zerocopyskiplist.InspectPersisted(f, contextSize, func(rec zerocopyskiplist.PersistedRecord) error {
+		if !p.verify && (p.limit == 0 || listed < p.limit) {
+			p.record(rec)
+			listed++
+		}
+		return nil
+	})
+	if p.verify {
+		switch {
+		case err != nil:
+			fmt.Fprintf(p.out, "%s: %v\n", path, err)
+		case len(file.Problems) > 0:
+			fmt.Fprintf(p.out, "%s: %d problems\n", path, len(file.Problems))
+		default:
+			fmt.Fprintf(p.out, "%s: ok, %s of %d records\n", path, file.Kind, file.Records)
+		}
+	} else {
+		if listed < int(file.Records) {
+			fmt.Fprintf(p.out, "  ... %d more records\n", int(file.Records)-listed)
+		}
+		p.summary(file)
+		if err != nil {
+			fmt.Fprintf(p.out, "  error: %v\n", err)
+		}
+	}
+	for _, problem := range file.Problems {
+		fmt.Fprintf(p.out, "  problem: %s\n", problem)
+	}
+	return err == nil && len(file.Problems) == 0
+}
+
+// summary prints the header fields, checksum and key range of file
+func (p printer) summary(file zerocopyskiplist.PersistedFile) {
+	fmt.Fprintf(p.out, "  kind %s, %d bytes, %d of %d records read\n", file.Kind, file.Size, file.Records, file.Count)
+	if file.Kind == zerocopyskiplist.PersistedShared {
+		fmt.Fprintf(p.out, "  level %d of max %d\n", file.Level, file.MaxLevel)
+	} else {
+		fmt.Fprintf(p.out, "  format %d, schema %d, collation %q\n", file.Format, file.Schema, file.Collation)
+	}
+	if file.HasChecksum {
+		verdict := "ok"
+		if file.Checksum != file.Computed {
+			verdict = fmt.Sprintf("MISMATCH, contents give %08x", file.Computed)
+		}
+		fmt.Fprintf(p.out, "  checksum %08x %s\n", file.Checksum, verdict)
+	}
+	if file.FirstKey != nil {
+		fmt.Fprintf(p.out, "  keys %s .. %s\n", p.bytes(file.FirstKey), p.bytes(file.LastKey))
+	}
+}
+
+// record prints one record on a line
+func (p printer) record(rec zerocopyskiplist.PersistedRecord) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "  #%d @%d", rec.Index, rec.Offset)
+	if rec.Op != "" {
+		fmt.Fprintf(&b, " %s", rec.Op)
+	}
+	if rec.Level >= 0 {
+		fmt.Fprintf(&b, " level %d", rec.Level)
+	}
+	if rec.Key != nil {
+		fmt.Fprintf(&b, " key %s", p.bytes(rec.Key))
+	}
+	if rec.Context != nil {
+		fmt.Fprintf(&b, " item @%d context %x", rec.ItemOffset, rec.Context)
+	}
+	if rec.Value != nil {
+		fmt.Fprintf(&b, " value %d bytes %s", len(rec.Value), p.bytes(rec.Value))
+	}
+	fmt.Fprintln(p.out, b.String())
+}
+
+// bytes formats encoded data quoted if it is printable text and in hex
+// otherwise, cut to the preview length
+func (p printer) bytes(data []byte) string {
+	cut := ""
+	if p.preview > 0 && len(data) > p.preview {
+		data, cut = data[:p.preview], "..."
+	}
+	if utf8.Valid(data) && !strings.ContainsFunc(string(data), func(r rune) bool { return !unicode.IsPrint(r) }) {
+		return strconv.Quote(string(data)) + cut
+	}
+	return fmt.Sprintf("%x", data) + cut
+}
@@ -0,0 +1,95 @@
+// main.go - Command line RESP server backed by a zero copy skiplist
+
+// Command zcsl-server serves GET, SET, DEL, SCAN, RANGE and related commands
+// over the Redis protocol from a single skiplist, so redis-cli and
+// redis-benchmark can be pointed at it. Expired keys are swept periodically
+// and, with -snapshot, the data set is loaded at start and saved by SAVE and
+// on interrupt.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/mattkeenan/zerocopyskiplist"
+	"github.com/mattkeenan/zerocopyskiplist/zcslserver"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:6380", "listen address")
+	snapshot := flag.String("snapshot", "", "snapshot file loaded at start and written by SAVE")
+	sweepEvery := flag.Duration("sweep-every", time.Second, "expired key sweep interval, 0 disables")
+	nodePool := flag.Bool("node-pool", false, "build the list WithNodePool")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 10*time.Second, "time allowed for the sweeper to stop and the final save")
+	flag.Parse()
+
+	var opts []zerocopyskiplist.Option
+	if *nodePool {
+		opts = append(opts, zerocopyskiplist.WithNodePool())
+	}
+
+	server, err := zcslserver.New(*snapshot, opts...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "zcsl-server: %v\n", err)
+		os.Exit(1)
+	}
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "zcsl-server: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	list := server.List()
+	if *snapshot != "" {
+		list.OnClose(func(context.Context) error {
+			if err := server.Save(); err != nil {
+				return fmt.Errorf("saving snapshot: %w", err)
+			}
+			return nil
+		})
+	}
+	if *sweepEvery > 0 {
+		list.Go(func(ctx context.Context) {
+			sweep(ctx, server, *sweepEvery)
+		})
+	}
+
+	fmt.Printf("serving %d keys on %s\n", server.List().Length(), l.Addr())
+	if err := server.Serve(l); err != nil && ctx.Err() == nil {
+		fmt.Fprintf(os.Stderr, "zcsl-server: %v\n", err)
+		os.Exit(1)
+	}
+
+	shutdown, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if err := list.Close(shutdown); err != nil {
+		fmt.Fprintf(os.Stderr, "zcsl-server: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// sweep removes expired keys every interval until ctx is done
+func sweep(ctx context.Context, server *zcslserver.Server, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			server.SweepExpired()
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// main.go - Command line driver for the zcslbench stress harness
+
+// Command zcslbench soaks a zerocopyskiplist with a concurrent workload and
+// prints per-operation latency percentiles. It exits non-zero if structural
+// validation fails during the run.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattkeenan/zerocopyskiplist"
+	"github.com/mattkeenan/zerocopyskiplist/zcslbench"
+)
+
+func main() {
+	var cfg zcslbench.Config
+	profile := flag.String("profile", "balanced", "named workload mix: "+profileNames())
+	flag.IntVar(&cfg.Profile.Read, "read", -1, "read weight, overrides the profile")
+	flag.IntVar(&cfg.Profile.Insert, "insert", -1, "insert weight, overrides the profile")
+	flag.IntVar(&cfg.Profile.Delete, "delete", -1, "delete weight, overrides the profile")
+	flag.IntVar(&cfg.Profile.Scan, "scan", -1, "scan weight, overrides the profile")
+	flag.IntVar(&cfg.Profile.Flush, "flush", -1, "flush weight, overrides the profile")
+	flag.IntVar(&cfg.Goroutines, "goroutines", 8, "concurrent workers")
+	flag.DurationVar(&cfg.Duration, "duration", 10*time.Second, "run length")
+	flag.IntVar(&cfg.KeySpace, "keys", 1<<20, "size of the key space")
+	flag.IntVar(&cfg.Prefill, "prefill", 0, "keys inserted before the run")
+	flag.IntVar(&cfg.ScanLength, "scan-length", 100, "items visited per scan")
+	flag.IntVar(&cfg.MaxLevel, "max-level", 20, "skiplist max level")
+	flag.DurationVar(&cfg.ValidateEvery, "validate-every", time.Second, "validation interval, 0 disables")
+	flag.Int64Var(&cfg.Seed, "seed", time.Now().UnixNano(), "random seed")
+	devNull := flag.Bool("writev", false, "writev flushes to /dev/null instead of only building iovecs")
+	nodePool := flag.Bool("node-pool", false, "build the list WithNodePool")
+	inlineKeys := flag.Bool("inline-keys", false, "build the list WithInlineKeys")
+	flag.Parse()
+
+	base, ok := zcslbench.Profiles[*profile]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "zcslbench: unknown profile %q, want one of %s\n", *profile, profileNames())
+		os.Exit(2)
+	}
+	override(&cfg.Profile.Read, base.Read)
+	override(&cfg.Profile.Insert, base.Insert)
+	override(&cfg.Profile.Delete, base.Delete)
+	override(&cfg.Profile.Scan, base.Scan)
+	override(&cfg.Profile.Flush, base.Flush)
+
+	if *nodePool {
+		cfg.Options = append(cfg.Options, zerocopyskiplist.WithNodePool())
+	}
+	if *inlineKeys {
+		cfg.Options = append(cfg.Options, zerocopyskiplist.WithInlineKeys())
+	}
+	if *devNull {
+		f, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "zcslbench: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		cfg.FlushTarget = f
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("profile %+v, %d goroutines for %v, seed %d\n", cfg.Profile, cfg.Goroutines, cfg.Duration, cfg.Seed)
+	report, err := zcslbench.Run(ctx, cfg)
+	if report != nil {
+		report.Format(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "zcslbench: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// override replaces a weight left at its -1 flag default with the profile value
+func override(weight *int, profile int) {
+	if *weight < 0 {
+		*weight = profile
+	}
+}
+
+// profileNames lists the named profiles for usage messages
+func profileNames() string {
+	names := make([]string, 0, len(zcslbench.Profiles))
+	for name := range zcslbench.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
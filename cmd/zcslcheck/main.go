@@ -0,0 +1,83 @@
+// main.go - Command line driver for the zcslcheck misuse checks
+
+// Command zcslcheck reports misuse of the zerocopyskiplist API in the
+// packages in the given directories, in the manner of go vet. A directory
+// ending in /... includes every package below it, skipping testdata, vendor
+// and hidden directories. Files are chosen for the target platform, so
+// GOOS=darwin zcslcheck ./... also reports iovec use that needs chunking
+// there. It exits non-zero if anything is reported or a package fails to
+// load.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/build"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattkeenan/zerocopyskiplist/zcslcheck"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: zcslcheck dir...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	failed := false
+	for _, arg := range flag.Args() {
+		dirs, err := expand(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "zcslcheck: %v\n", err)
+			failed = true
+			continue
+		}
+		for _, dir := range dirs {
+			fset, diags, err := zcslcheck.CheckDir(&build.Default, dir)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "zcslcheck: %v\n", err)
+				failed = true
+				continue
+			}
+			for _, d := range diags {
+				fmt.Printf("%s: %s (%s)\n", fset.Position(d.Pos), d.Message, d.Check)
+				failed = true
+			}
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// expand returns the directories named by arg, every directory holding Go
+// files below it if it ends in /...
+func expand(arg string) ([]string, error) {
+	root, ok := strings.CutSuffix(arg, "/...")
+	if !ok {
+		return []string{arg}, nil
+	}
+
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		if name := d.Name(); path != root && (name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+			return filepath.SkipDir
+		}
+		if matches, _ := filepath.Glob(filepath.Join(path, "*.go")); len(matches) > 0 {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	return dirs, err
+}
@@ -0,0 +1,133 @@
+// codec.go - Item encoding for persisted and transmitted data
+
+package zerocopyskiplist
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// ItemCodec converts items and their contexts to and from a byte encoding
+// whose layout is owned by the application. SchemaVersion identifies that
+// layout; it is written into every persisted header so data written by an
+// older release can be recognised and upgraded on load.
+type ItemCodec[T any, C comparable] interface {
+	// SchemaVersion returns the version of the encoding produced by Encode
+	SchemaVersion() int
+	// Encode appends the encoding of item and context to dst
+	Encode(dst []byte, item *T, context C) ([]byte, error)
+	// Decode decodes an item and context encoded at the current schema
+	// version. raw is only valid for the duration of the call.
+	Decode(raw []byte) (*T, C, error)
+}
+
+// Migrator is implemented by codecs that can decode data written under a
+// different schema version. Load calls Migrate instead of Decode whenever the
+// persisted version differs from the codec's SchemaVersion.
+type Migrator[T any, C comparable] interface {
+	// Migrate decodes raw, encoded at schema version fromVersion, into an
+	// item and context of the current version. raw is only valid for the
+	// duration of the call.
+	Migrate(fromVersion int, raw []byte) (*T, C, error)
+}
+
+// KeyCodec converts keys to and from bytes, for persisted and replicated
+// operations that name a key without an item, such as deletes
+type KeyCodec[K comparable] interface {
+	// AppendKey appends the encoding of key to dst
+	AppendKey(dst []byte, key K) []byte
+	// DecodeKey decodes a key encoded by AppendKey
+	DecodeKey(raw []byte) (K, error)
+}
+
+// DecodeVersion decodes raw written under schema version, migrating it if it
+// differs from the codec's version
+func DecodeVersion[T any, C comparable](codec ItemCodec[T, C], version int, raw []byte) (*T, C, error) {
+	if version == codec.SchemaVersion() {
+		return codec.Decode(raw)
+	}
+	if migrator, ok := codec.(Migrator[T, C]); ok {
+		return migrator.Migrate(version, raw)
+	}
+	var zeroContext C
+	return nil, zeroContext, &SchemaVersionError{Persisted: version, Current: codec.SchemaVersion()}
+}
+
+// SchemaVersionError reports data written under a schema version the codec
+// cannot read because it does not implement Migrator
+type SchemaVersionError struct {
+	Persisted int
+	Current   int
+}
+
+// Error implements the error interface
+func (e *SchemaVersionError) Error() string {
+	return fmt.Sprintf("schema version %d cannot be read by codec version %d without a Migrator", e.Persisted, e.Current)
+}
+
+// appendRecord appends the encoding of item and context to dst framed by its
+// length as a little-endian uint32
+func appendRecord[T any, C comparable](dst []byte, codec ItemCodec[T, C], item *T, context C) ([]byte, error) {
+	start := len(dst)
+	out, err := codec.Encode(append(dst, 0, 0, 0, 0), item, context)
+	if err != nil {
+		return dst, err
+	}
+	binary.LittleEndian.PutUint32(out[start:], uint32(len(out)-start-4))
+	return out, nil
+}
+
+// LittleEndianCodec encodes items, and contexts, whose fields are all fixed
+// size (sized integers, floats, bools and arrays or structs of them) in their
+// field order, little-endian and without padding, using encoding/binary. The
+// layout is independent of the architecture, unlike the raw iovec path which
+// writes the in-memory struct with its padding and native byte order.
+type LittleEndianCodec[T any, C comparable] struct {
+	version  int
+	itemSize int
+}
+
+// NewLittleEndianCodec returns a LittleEndianCodec reporting schema version
+// version, or an error if T or C is not fixed size. A context type with no
+// fields, such as struct{}, encodes to nothing.
+func NewLittleEndianCodec[T any, C comparable](version int) (*LittleEndianCodec[T, C], error) {
+	var item T
+	itemSize := binary.Size(&item)
+	if itemSize < 0 {
+		return nil, fmt.Errorf("item type %T is not fixed size", item)
+	}
+	if _, err := fixedContextSize[C](); err != nil {
+		return nil, err
+	}
+	return &LittleEndianCodec[T, C]{version: version, itemSize: itemSize}, nil
+}
+
+// SchemaVersion implements ItemCodec
+func (c *LittleEndianCodec[T, C]) SchemaVersion() int {
+	return c.version
+}
+
+// Encode implements ItemCodec
+func (c *LittleEndianCodec[T, C]) Encode(dst []byte, item *T, context C) ([]byte, error) {
+	dst, err := binary.Append(dst, binary.LittleEndian, item)
+	if err != nil {
+		return dst, err
+	}
+	return binary.Append(dst, binary.LittleEndian, &context)
+}
+
+// Decode implements ItemCodec
+func (c *LittleEndianCodec[T, C]) Decode(raw []byte) (*T, C, error) {
+	item := new(T)
+	var context C
+	if len(raw) < c.itemSize {
+		return nil, context, fmt.Errorf("record of %d bytes is shorter than the %d byte item", len(raw), c.itemSize)
+	}
+	if _, err := binary.Decode(raw[:c.itemSize], binary.LittleEndian, item); err != nil {
+		return nil, context, err
+	}
+	if _, err := binary.Decode(raw[c.itemSize:], binary.LittleEndian, &context); err != nil {
+		return nil, context, err
+	}
+	return item, context, nil
+}
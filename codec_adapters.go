@@ -0,0 +1,183 @@
+// codec_adapters.go - ItemCodec adapters for schema-compiled message formats
+
+package zerocopyskiplist
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// MessageCodec adapts a message format with marshal and unmarshal functions,
+// such as Protocol Buffers, to ItemCodec. The package does not import any
+// message library; the functions are supplied by the caller, e.g. for a
+// generated protobuf type pb.Entry:
+//
+//	codec, err := NewMessageCodec[pb.Entry, struct{}](1,
+//		func(dst []byte, m *pb.Entry) ([]byte, error) {
+//			return proto.MarshalOptions{}.MarshalAppend(dst, m)
+//		},
+//		func(raw []byte, m *pb.Entry) error {
+//			return proto.Unmarshal(raw, m)
+//		})
+//
+// Contexts are written before the message with encoding/binary, so C must be
+// fixed size; a context type with no fields, such as struct{}, costs nothing.
+type MessageCodec[T any, C comparable] struct {
+	version     int
+	contextSize int
+	marshal     func(dst []byte, item *T) ([]byte, error)
+	unmarshal   func(raw []byte, item *T) error
+}
+
+// NewMessageCodec returns a MessageCodec reporting schema version version, or
+// an error if C is not fixed size. marshal appends the encoding of item to dst
+// and unmarshal decodes raw into an empty item; unmarshal must copy anything it
+// keeps from raw.
+func NewMessageCodec[T any, C comparable](
+	version int,
+	marshal func(dst []byte, item *T) ([]byte, error),
+	unmarshal func(raw []byte, item *T) error,
+) (*MessageCodec[T, C], error) {
+	contextSize, err := fixedContextSize[C]()
+	if err != nil {
+		return nil, err
+	}
+	return &MessageCodec[T, C]{
+		version:     version,
+		contextSize: contextSize,
+		marshal:     marshal,
+		unmarshal:   unmarshal,
+	}, nil
+}
+
+// SchemaVersion implements ItemCodec
+func (c *MessageCodec[T, C]) SchemaVersion() int {
+	return c.version
+}
+
+// Encode implements ItemCodec
+func (c *MessageCodec[T, C]) Encode(dst []byte, item *T, context C) ([]byte, error) {
+	dst, err := binary.Append(dst, binary.LittleEndian, &context)
+	if err != nil {
+		return dst, err
+	}
+	return c.marshal(dst, item)
+}
+
+// Decode implements ItemCodec
+func (c *MessageCodec[T, C]) Decode(raw []byte) (*T, C, error) {
+	context, raw, err := splitContext[C](raw, c.contextSize)
+	if err != nil {
+		return nil, context, err
+	}
+	item := new(T)
+	if err := c.unmarshal(raw, item); err != nil {
+		return nil, context, err
+	}
+	return item, context, nil
+}
+
+// TableCodec adapts FlatBuffers-style tables, which are read in place from
+// their serialized bytes, to ItemCodec. Decoding does not parse or unpack the
+// record: Decode copies the record bytes once, since the reader reuses its
+// buffer, and roots the table on the copy, so field accessors read directly
+// from it. DecodeInPlace skips the copy for callers that own the buffer. For
+// a generated FlatBuffers type fb.Entry:
+//
+//	codec, err := NewTableCodec[fb.Entry, struct{}](1,
+//		func(e *fb.Entry) []byte { return e.Table().Bytes },
+//		func(buf []byte) *fb.Entry { return fb.GetRootAsEntry(buf, 0) })
+//
+// where each item's table is the root of its own finished buffer. Contexts
+// are written before the table as for MessageCodec.
+type TableCodec[T any, C comparable] struct {
+	version     int
+	contextSize int
+	tableBytes  func(item *T) []byte
+	root        func(buf []byte) *T
+}
+
+// NewTableCodec returns a TableCodec reporting schema version version, or an
+// error if C is not fixed size. tableBytes returns the finished buffer an item
+// is rooted in and root returns the table rooted at the start of buf.
+func NewTableCodec[T any, C comparable](
+	version int,
+	tableBytes func(item *T) []byte,
+	root func(buf []byte) *T,
+) (*TableCodec[T, C], error) {
+	contextSize, err := fixedContextSize[C]()
+	if err != nil {
+		return nil, err
+	}
+	return &TableCodec[T, C]{
+		version:     version,
+		contextSize: contextSize,
+		tableBytes:  tableBytes,
+		root:        root,
+	}, nil
+}
+
+// SchemaVersion implements ItemCodec
+func (c *TableCodec[T, C]) SchemaVersion() int {
+	return c.version
+}
+
+// Encode implements ItemCodec
+func (c *TableCodec[T, C]) Encode(dst []byte, item *T, context C) ([]byte, error) {
+	dst, err := binary.Append(dst, binary.LittleEndian, &context)
+	if err != nil {
+		return dst, err
+	}
+	return append(dst, c.tableBytes(item)...), nil
+}
+
+// Decode implements ItemCodec. raw belongs to the reader, so the table is
+// rooted on its own copy of the record.
+func (c *TableCodec[T, C]) Decode(raw []byte) (*T, C, error) {
+	return c.decode(raw, true)
+}
+
+// DecodeInPlace is Decode rooting the table on raw itself, with no copy, for
+// records in a buffer the caller owns, such as a mapped segment file or a
+// whole file read into memory. The table reads raw for as long as it is in
+// use, so raw must stay valid and unmodified until the item is dropped from
+// every list and no longer referenced.
+func (c *TableCodec[T, C]) DecodeInPlace(raw []byte) (*T, C, error) {
+	return c.decode(raw, false)
+}
+
+// decode performs Decode, copying the record if clone
+func (c *TableCodec[T, C]) decode(raw []byte, clone bool) (*T, C, error) {
+	context, raw, err := splitContext[C](raw, c.contextSize)
+	if err != nil {
+		return nil, context, err
+	}
+	if clone {
+		raw = bytes.Clone(raw)
+	}
+	return c.root(raw), context, nil
+}
+
+// fixedContextSize returns the encoded size of C, or an error if it is not fixed size
+func fixedContextSize[C comparable]() (int, error) {
+	var context C
+	size := binary.Size(&context)
+	if size < 0 {
+		return 0, fmt.Errorf("context type %T is not fixed size", context)
+	}
+	return size, nil
+}
+
+// splitContext decodes the leading fixed size context from raw and returns it
+// with the remaining bytes
+func splitContext[C comparable](raw []byte, size int) (C, []byte, error) {
+	var context C
+	if len(raw) < size {
+		return context, nil, fmt.Errorf("record of %d bytes is shorter than the %d byte context", len(raw), size)
+	}
+	if _, err := binary.Decode(raw[:size], binary.LittleEndian, &context); err != nil {
+		return context, nil, err
+	}
+	return context, raw[size:], nil
+}
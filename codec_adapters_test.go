@@ -0,0 +1,115 @@
+package zerocopyskiplist
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testMessage stands in for a generated protobuf message
+type testMessage struct {
+	ID   uint64
+	Name string
+}
+
+func marshalTestMessage(dst []byte, m *testMessage) ([]byte, error) {
+	dst = binary.AppendUvarint(dst, m.ID)
+	return append(dst, m.Name...), nil
+}
+
+func unmarshalTestMessage(raw []byte, m *testMessage) error {
+	id, n := binary.Uvarint(raw)
+	if n <= 0 {
+		return errors.New("bad varint")
+	}
+	m.ID, m.Name = id, string(raw[n:])
+	return nil
+}
+
+// testTable stands in for a generated FlatBuffers table read in place from buf
+type testTable struct {
+	buf []byte
+}
+
+func newTestTable(id uint64, name string) *testTable {
+	return &testTable{buf: append(binary.LittleEndian.AppendUint64(nil, id), name...)}
+}
+
+func (t *testTable) ID() uint64   { return binary.LittleEndian.Uint64(t.buf) }
+func (t *testTable) Name() string { return string(t.buf[8:]) }
+
+func TestMessageCodecSnapshot(t *testing.T) {
+	codec, err := NewMessageCodec[testMessage, PaddedContext](1, marshalTestMessage, unmarshalTestMessage)
+	if err != nil {
+		t.Fatalf("NewMessageCodec: %v", err)
+	}
+
+	skiplist := MakeOrderedZeroCopySkiplist[testMessage, uint64, PaddedContext](
+		8,
+		func(m *testMessage) uint64 { return m.ID },
+		func(m *testMessage) int { return 16 + len(m.Name) },
+	)
+	for i := uint64(1); i <= 300; i++ {
+		skiplist.Insert(&testMessage{ID: i * 1000, Name: strings.Repeat("n", int(i%5))}, PaddedContext{Generation: uint32(i)})
+	}
+
+	var buf bytes.Buffer
+	if err := skiplist.SaveSnapshot(&buf, codec); err != nil {
+		t.Fatalf("SaveSnapshot: %v", err)
+	}
+	loaded := MakeOrderedZeroCopySkiplist[testMessage, uint64, PaddedContext](
+		8,
+		func(m *testMessage) uint64 { return m.ID },
+		func(m *testMessage) int { return 16 + len(m.Name) },
+	)
+	if err := loaded.LoadSnapshot(&buf, codec); err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	for i := uint64(1); i <= 300; i++ {
+		found, ctx := loaded.Find(i * 1000)
+		if found == nil || found.Item().Name != strings.Repeat("n", int(i%5)) || ctx.Generation != uint32(i) {
+			t.Errorf("Wrong entry for key %d after load", i*1000)
+		}
+	}
+
+	if _, err := NewMessageCodec[testMessage, TestContext](1, marshalTestMessage, unmarshalTestMessage); err == nil {
+		t.Error("Expected an error for a variable size context type")
+	}
+}
+
+func TestTableCodecReadsInPlace(t *testing.T) {
+	codec, err := NewTableCodec[testTable, struct{}](1,
+		func(t *testTable) []byte { return t.buf },
+		func(buf []byte) *testTable { return &testTable{buf: buf} })
+	if err != nil {
+		t.Fatalf("NewTableCodec: %v", err)
+	}
+
+	encoded, err := codec.Encode(nil, newTestTable(42, "answer"), struct{}{})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if !bytes.Equal(encoded, newTestTable(42, "answer").buf) {
+		t.Error("A context with no fields should add no bytes")
+	}
+
+	table, _, err := codec.Decode(encoded)
+	if err != nil || table.ID() != 42 || table.Name() != "answer" {
+		t.Fatalf("Decode returned %v, %v", table, err)
+	}
+
+	// The table owns its bytes, so reuse of the reader's buffer cannot change it
+	clear(encoded)
+	if table.ID() != 42 || table.Name() != "answer" {
+		t.Error("Decoded table should not alias the record buffer")
+	}
+
+	// DecodeInPlace roots the table on the caller's buffer itself
+	encoded, _ = codec.Encode(nil, newTestTable(7, "seven"), struct{}{})
+	table, _, err = codec.DecodeInPlace(encoded)
+	if err != nil || table.ID() != 7 || &table.buf[0] != &encoded[0] {
+		t.Fatalf("DecodeInPlace returned %v, %v", table, err)
+	}
+}
@@ -0,0 +1,43 @@
+// collation.go - Recording the key order persisted data was written in
+
+package zerocopyskiplist
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrCollationMismatch is returned, wrapped with both names, when persisted
+// data written under one collation is loaded into a list using another
+var ErrCollationMismatch = errors.New("collation mismatch")
+
+// WithCollation names the key order the list's comparator implements, such
+// as a locale-aware comparator for string keys built with
+// golang.org/x/text/collate: MakeZeroCopySkiplist(maxLevel, getKey, size,
+// collate.New(language.German).CompareString, WithCollation("de")). The name
+// is recorded in snapshots, checkpoints and backup deltas, and loading them
+// fails with ErrCollationMismatch unless the list's name is the same, so data
+// sorted under one collation is never silently loaded under another. Use a
+// short name that changes whenever the order does, for example a BCP 47 tag
+// with the collation's version. Lists without a collation, including
+// those built by MakeOrderedZeroCopySkiplist, only load data written without
+// one.
+func WithCollation(name string) Option {
+	return func(o *options) {
+		o.collation = name
+	}
+}
+
+// Collation returns the name given to WithCollation, empty for none
+func (sl *ZeroCopySkiplist[T, K, C]) Collation() string {
+	return sl.opts.collation
+}
+
+// checkCollation returns an error if data written under collation cannot be
+// loaded into the list
+func (sl *ZeroCopySkiplist[T, K, C]) checkCollation(collation string) error {
+	if collation != sl.opts.collation {
+		return fmt.Errorf("%w: data was written under %q, the list uses %q", ErrCollationMismatch, collation, sl.opts.collation)
+	}
+	return nil
+}
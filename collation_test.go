@@ -0,0 +1,53 @@
+package zerocopyskiplist
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"testing"
+)
+
+func TestCollation(t *testing.T) {
+	// Keys in reverse order stand in for a locale-aware comparator
+	reverse := func(a, b int) int { return compareInt(b, a) }
+	collated := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, reverse, WithCollation("reverse-v1"))
+	for _, item := range createTestItems(20) {
+		collated.Insert(item, TestContext{})
+	}
+	if collated.Collation() != "reverse-v1" {
+		t.Errorf("Collation = %q", collated.Collation())
+	}
+	var snapshot bytes.Buffer
+	if err := collated.SaveSnapshot(&snapshot, testItemCodecV2{}); err != nil {
+		t.Fatalf("SaveSnapshot: %v", err)
+	}
+
+	same := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, reverse, WithCollation("reverse-v1"))
+	if err := same.LoadSnapshot(bytes.NewReader(snapshot.Bytes()), testItemCodecV2{}); err != nil || same.Length() != 20 {
+		t.Errorf("Load under the same collation = %v, %d entries", err, same.Length())
+	}
+	plain := newSnapshotTestList()
+	if err := plain.LoadSnapshot(bytes.NewReader(snapshot.Bytes()), testItemCodecV2{}); !errors.Is(err, ErrCollationMismatch) || plain.Length() != 0 {
+		t.Errorf("Load under another collation should return ErrCollationMismatch, got %v", err)
+	}
+
+	// A format 1 snapshot has no collation and loads into plain lists only
+	for _, item := range createTestItems(5) {
+		plain.Insert(item, TestContext{})
+	}
+	var v2 bytes.Buffer
+	if err := plain.SaveSnapshot(&v2, testItemCodecV2{}); err != nil {
+		t.Fatalf("SaveSnapshot: %v", err)
+	}
+	v1 := append([]byte(nil), v2.Bytes()[:persistHeaderSize]...)
+	binary.LittleEndian.PutUint16(v1[4:], 1)
+	v1 = append(v1, v2.Bytes()[persistHeaderSize+2:v2.Len()-4]...)
+	v1 = binary.LittleEndian.AppendUint32(v1, crc32.Checksum(v1, crcTable))
+	if loaded := newSnapshotTestList(); loaded.LoadSnapshot(bytes.NewReader(v1), testItemCodecV2{}) != nil || loaded.Length() != 5 {
+		t.Errorf("Loading a format 1 snapshot gave %d entries", loaded.Length())
+	}
+	if err := same.LoadSnapshot(bytes.NewReader(v1), testItemCodecV2{}); !errors.Is(err, ErrCollationMismatch) {
+		t.Errorf("A format 1 snapshot should not load under a collation, got %v", err)
+	}
+}
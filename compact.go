@@ -0,0 +1,110 @@
+// compact.go - Relocating off-heap items out of fragmented slabs
+
+package zerocopyskiplist
+
+import (
+	"sort"
+	"unsafe"
+)
+
+// ArenaCompaction reports what CompactArena did
+type ArenaCompaction struct {
+	Moved         int   // items copied into fresh slabs
+	Skipped       int   // items left in sparse slabs because they were pinned, leased or awaiting a flush
+	ReleasedBytes int64 // bytes of mapped memory returned to the OS
+}
+
+// CompactArena relocates the list's items out of the sparsely used slabs of
+// arena, so that slabs kept mapped by a few survivors of heavy churn can be
+// returned to the OS. A slab is sparse when less than maxUtilization of the
+// bytes carved out of it are still live; zero means 0.5. Every item in a
+// sparse slab, soft-deleted ones included, is copied into a fresh allocation,
+// its node repointed under the write lock and the old copy freed. Pinned and
+// leased items, and items awaiting FlushExactlyOnce, are skipped since iovecs
+// may still reference them.
+//
+// The items must have been allocated from arena with OffHeapNew. Relocation
+// invalidates every pointer to a moved item held outside the list: ItemPtrs,
+// entries, iovecs, copies and sealed lists must not be kept across the call.
+// Generation advances when anything moves. It returns ErrFrozen for a frozen
+// list and stops at the first failed allocation, reporting what it moved.
+func (sl *ZeroCopySkiplist[T, K, C]) CompactArena(arena *OffHeap, maxUtilization float64) (result ArenaCompaction, err error) {
+	if maxUtilization <= 0 {
+		maxUtilization = 0.5
+	}
+
+	sl.flushMu.Lock()
+	defer sl.flushMu.Unlock()
+	sl.lock()
+	defer sl.unlock()
+
+	if sl.frozen {
+		return result, ErrFrozen
+	}
+
+	mapped := arena.MappedBytes()
+	defer func() { result.ReleasedBytes = mapped - arena.MappedBytes() }()
+
+	spans := arena.sparseSlabs(maxUtilization)
+	if len(spans) == 0 {
+		return result, nil
+	}
+	sparse := func(item *T) bool {
+		addr := uintptr(unsafe.Pointer(item))
+		i := sort.Search(len(spans), func(i int) bool { return spans[i].end > addr })
+		return i < len(spans) && addr >= spans[i].base
+	}
+
+	unflushed := make(map[*T]struct{}, len(sl.flushLog))
+	for _, version := range sl.flushLog {
+		unflushed[version.Item] = struct{}{}
+	}
+	size := int(unsafe.Sizeof(*new(T)))
+	relocate := func(item *T) (*T, error) {
+		b, err := arena.Alloc(size)
+		if err != nil {
+			return nil, err
+		}
+		moved := (*T)(unsafe.Pointer(unsafe.SliceData(b)))
+		*moved = *item
+		OffHeapFree(arena, item)
+		result.Moved++
+		return moved, nil
+	}
+	defer func() {
+		if result.Moved > 0 {
+			sl.version++
+		}
+	}()
+
+	for current := sl.header.forward[0]; current != nil; current = current.forward[0] {
+		if !sparse(current.item) {
+			continue
+		}
+		if _, ok := unflushed[current.item]; ok || !sl.evictable(current.key) {
+			result.Skipped++
+			continue
+		}
+		moved, err := relocate(current.item)
+		if err != nil {
+			return result, err
+		}
+		current.item = moved
+	}
+	for key, stone := range sl.graveyard {
+		if !sparse(stone.item) {
+			continue
+		}
+		if _, ok := unflushed[stone.item]; ok {
+			result.Skipped++
+			continue
+		}
+		moved, err := relocate(stone.item)
+		if err != nil {
+			return result, err
+		}
+		stone.item = moved
+		sl.graveyard[key] = stone
+	}
+	return result, nil
+}
@@ -0,0 +1,95 @@
+package zerocopyskiplist
+
+import (
+	"cmp"
+	"errors"
+	"testing"
+	"unsafe"
+)
+
+func TestCompactArena(t *testing.T) {
+	arena, err := NewOffHeap(4096)
+	if err != nil {
+		t.Fatalf("NewOffHeap: %v", err)
+	}
+	defer arena.Close()
+
+	skiplist := MakeZeroCopySkiplist[OffHeapRecord, int64, string](
+		16,
+		func(r *OffHeapRecord) int64 { return r.ID },
+		func(r *OffHeapRecord) int { return int(unsafe.Sizeof(*r)) },
+		cmp.Compare[int64],
+	)
+	const numItems = 640
+	for i := int64(0); i < numItems; i++ {
+		record, err := OffHeapNew[OffHeapRecord](arena)
+		if err != nil {
+			t.Fatalf("OffHeapNew: %v", err)
+		}
+		record.ID = i
+		record.Payload[0] = byte(i)
+		skiplist.Insert(record, "ctx")
+	}
+
+	// Churn leaves every slab an eighth full
+	for i := int64(0); i < numItems; i++ {
+		if i%8 != 0 {
+			ip, _ := skiplist.Find(i)
+			record := ip.Item()
+			skiplist.Delete(i)
+			OffHeapFree(arena, record)
+		}
+	}
+	fragmented := arena.MappedBytes()
+	pinned, _ := skiplist.Find(8)
+	pinnedRecord := pinned.Item()
+	skiplist.Pin(8)
+	generation := skiplist.Generation()
+
+	result, err := skiplist.CompactArena(arena, 0)
+	if err != nil {
+		t.Fatalf("CompactArena: %v", err)
+	}
+	if result.Moved != numItems/8-1 || result.Skipped != 1 {
+		t.Errorf("Moved %d and skipped %d items, want %d and 1", result.Moved, result.Skipped, numItems/8-1)
+	}
+	if result.ReleasedBytes <= 0 || arena.MappedBytes() != fragmented-result.ReleasedBytes {
+		t.Errorf("Released %d bytes, mapped %d of %d", result.ReleasedBytes, arena.MappedBytes(), fragmented)
+	}
+	if arena.MappedBytes() > fragmented/2 {
+		t.Errorf("Compaction should release most slabs, still mapped %d of %d bytes", arena.MappedBytes(), fragmented)
+	}
+	if arena.LiveBytes() != numItems/8*int64(unsafe.Sizeof(OffHeapRecord{})) {
+		t.Errorf("Live bytes %d after compaction", arena.LiveBytes())
+	}
+	if skiplist.Generation() == generation {
+		t.Error("Relocating items should advance the generation")
+	}
+	if ip, _ := skiplist.Find(8); ip.Item() != pinnedRecord {
+		t.Error("Pinned item should stay in place")
+	}
+
+	// Every item survives the move intact and in the arena
+	if err := skiplist.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	for i := int64(0); i < numItems; i += 8 {
+		ip, context := skiplist.Find(i)
+		if ip == nil || context != "ctx" {
+			t.Fatalf("Item %d lost by compaction", i)
+		}
+		if ip.Item().ID != i || ip.Item().Payload[0] != byte(i) || !arena.Contains(unsafe.Pointer(ip.Item())) {
+			t.Fatalf("Item %d corrupted by compaction", i)
+		}
+	}
+
+	// A dense arena is left alone
+	if result, _ := skiplist.CompactArena(arena, 0); result.Moved != 0 || result.ReleasedBytes != 0 {
+		t.Errorf("Second compaction = %+v, want nothing to do", result)
+	}
+
+	skiplist.Freeze()
+	if _, err := skiplist.CompactArena(arena, 1); !errors.Is(err, ErrFrozen) {
+		t.Errorf("Compacting a frozen list should return ErrFrozen, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+// comparatorcheck.go - Sampled consistency checks of the key comparator
+
+package zerocopyskiplist
+
+import "fmt"
+
+// comparatorWindow is the number of neighbours either side of a sampled key
+// that WithComparatorCheck compares it and each other with
+const comparatorWindow = 2
+
+// WithComparatorCheck checks the comparator more thoroughly on a sampled
+// fraction rate (0 < rate <= 1) of inserts, in place of the default check of
+// one insert in 64 against its immediate neighbours. The new key and up to
+// comparatorWindow neighbours either side are compared pairwise in both
+// argument orders, so a comparator that is not antisymmetric, not transitive
+// across the window, or that reports keys equal that == does not (NaN floats,
+// collations without a matching key normalizer) is caught while the damage is
+// still local. Violations are reported through the policy set by
+// WithMisusePolicy. Each check costs about 2*comparatorWindow+1 squared
+// comparisons.
+func WithComparatorCheck(rate float64) Option {
+	return func(o *options) {
+		o.comparatorRate = rate
+	}
+}
+
+// windowViolation compares the keys of node and its comparatorWindow
+// neighbours either side pairwise, returning a description of the first pair
+// that is out of order in either argument order, or an empty string; the
+// caller must hold the lock
+func (sl *ZeroCopySkiplist[T, K, C]) windowViolation(node *ItemPtr[T, K, C]) string {
+	start := node
+	for i := 0; i < comparatorWindow && start.backward != nil; i++ {
+		start = start.backward
+	}
+	keys := make([]K, 0, 2*comparatorWindow+1)
+	for current := start; current != nil && len(keys) < cap(keys); current = current.forward[0] {
+		keys = append(keys, current.key)
+	}
+
+	// The keys are in list order, so every earlier key must sort before every
+	// later one; a pair that does not, when its neighbours in between do,
+	// breaks transitivity
+	for i, a := range keys {
+		for j := i + 1; j < len(keys); j++ {
+			b := keys[j]
+			ab, ba := sl.cmpKey(a, b), sl.cmpKey(b, a)
+			switch {
+			case a == b:
+				return fmt.Sprintf("%v is held by two nodes", a)
+			case ab == 0 || ba == 0:
+				return fmt.Sprintf("%v and %v compare equal but are not ==", a, b)
+			case (ab < 0) != (ba > 0):
+				return fmt.Sprintf("%v and %v do not compare in opposite orders (%d and %d)", a, b, ab, ba)
+			case ab > 0 && j == i+1:
+				return fmt.Sprintf("%v and its successor %v compare out of order", a, b)
+			case ab > 0:
+				return fmt.Sprintf("%v sorts after %v, %d keys later, although the keys between are in order", a, b, j-i)
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+package zerocopyskiplist
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+func TestComparatorCheck(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt,
+		WithComparatorCheck(1), WithMisusePolicy(MisuseReturnErrors))
+	items := createTestItems(2000)
+	rand.New(rand.NewSource(1)).Shuffle(len(items), func(i, j int) { items[i], items[j] = items[j], items[i] })
+	for _, item := range items {
+		if _, err := skiplist.InsertChecked(item, TestContext{}); err != nil {
+			t.Fatalf("A consistent comparator was reported: %v", err)
+		}
+	}
+	if skiplist.Stats().Misuses != 0 {
+		t.Errorf("Counted %d misuses of a consistent comparator", skiplist.Stats().Misuses)
+	}
+}
+
+func TestComparatorCheckEquality(t *testing.T) {
+	// Keys a thousand apart compare equal but are not ==, so the second
+	// replaces the first without changing its key
+	modulo := func(a, b int) int { return compareInt(a%1000, b%1000) }
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, modulo,
+		WithComparatorCheck(1), WithMisusePolicy(MisuseReturnErrors))
+	skiplist.Insert(&TestItem{ID: 5}, TestContext{})
+
+	_, err := skiplist.InsertChecked(&TestItem{ID: 1005}, TestContext{})
+	var misuse *MisuseError
+	if !errors.As(err, &misuse) || misuse.Kind != MisuseInconsistentComparator {
+		t.Errorf("Replacing an unequal key returned %v", err)
+	}
+}
+
+func TestComparatorCheckTransitivity(t *testing.T) {
+	// Each key sorts before the four that follow it modulo ten and after the
+	// four before it, which is antisymmetric but cyclic
+	cyclic := func(a, b int) int {
+		switch d := ((b-a)%10 + 10) % 10; {
+		case d == 0 || d == 5:
+			return compareInt(a, b)
+		case d < 5:
+			return -1
+		default:
+			return 1
+		}
+	}
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, cyclic,
+		WithComparatorCheck(1), WithMisusePolicy(MisuseReturnErrors))
+
+	// Keys three apart, so every window of five spans a full cycle
+	var err error
+	for i := 1; i <= 100; i++ {
+		if _, err = skiplist.InsertChecked(&TestItem{ID: 3 * i}, TestContext{}); err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, ErrMisuse) {
+		t.Errorf("A cyclic comparator was not reported, got %v", err)
+	}
+}
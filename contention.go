@@ -0,0 +1,78 @@
+// contention.go - Measuring how long writers wait for the lock
+
+package zerocopyskiplist
+
+import "time"
+
+// contentionCounters accumulate write lock statistics, only
+// WithContentionTracking; they are updated with the write lock held
+type contentionCounters struct {
+	acquisitions uint64
+	waits        uint64
+	waitTime     time.Duration
+}
+
+// lock takes the write lock as lockFlat does and gives a list in small list
+// storage its nodes, so the caller can work on the nodes alone
+func (sl *ZeroCopySkiplist[T, K, C]) lock() {
+	sl.lockFlat()
+	if sl.flat {
+		sl.materialize()
+	}
+}
+
+// lockFlat takes the write lock, timing the wait when it is already held and
+// contention is tracked, and stamps the acquisition for the watchdog. In
+// zcslparanoid builds it panics if the calling goroutine holds the lock. The
+// list may be in small list storage, see WithSmallListThreshold.
+func (sl *ZeroCopySkiplist[T, K, C]) lockFlat() {
+	var g uint64
+	if paranoidBuild {
+		g = goroutineID()
+		sl.holders.check(g, "write lock")
+	}
+
+	switch {
+	case sl.contention == nil:
+		sl.rw.Lock()
+	case sl.rw.TryLock():
+		sl.contention.acquisitions++
+	default:
+		start := time.Now()
+		sl.rw.Lock()
+		sl.contention.acquisitions++
+		sl.contention.waits++
+		sl.contention.waitTime += time.Since(start)
+	}
+	if sl.watchdog != nil {
+		sl.watchdog.acquired()
+	}
+	if paranoidBuild {
+		sl.holders.acquired(g, true)
+	}
+}
+
+// writeContention returns the write lock counters so far, zero without
+// WithContentionTracking
+func (sl *ZeroCopySkiplist[T, K, C]) writeContention() contentionCounters {
+	sl.rlock()
+	defer sl.runlock()
+	if sl.contention == nil {
+		return contentionCounters{}
+	}
+	return *sl.contention
+}
+
+// WriteContention returns the fraction of write lock acquisitions that had to
+// wait, and the mean wait of those that did; zeros without
+// WithContentionTracking or before the first write
+func (s Stats) WriteContention() (waited float64, meanWait time.Duration) {
+	if s.WriteLocks == 0 {
+		return 0, 0
+	}
+	waited = float64(s.WriteLockWaits) / float64(s.WriteLocks)
+	if s.WriteLockWaits > 0 {
+		meanWait = s.WriteLockWaitTime / time.Duration(s.WriteLockWaits)
+	}
+	return waited, meanWait
+}
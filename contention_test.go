@@ -0,0 +1,40 @@
+package zerocopyskiplist
+
+import (
+	"testing"
+	"time"
+)
+
+func TestContentionTracking(t *testing.T) {
+	sl := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt, WithContentionTracking())
+	items := createTestItems(10)
+	for _, item := range items[:9] {
+		sl.Insert(item, TestContext{})
+	}
+	if stats := sl.Stats(); stats.WriteLocks != 9 || stats.WriteLockWaits != 0 {
+		t.Errorf("Expected 9 uncontended write locks, got %d with %d waits", stats.WriteLocks, stats.WriteLockWaits)
+	}
+
+	// A writer arriving while the lock is held waits and is timed
+	sl.rw.Lock()
+	done := make(chan struct{})
+	go func() {
+		sl.Insert(items[9], TestContext{})
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	sl.rw.Unlock()
+	<-done
+
+	stats := sl.Stats()
+	if stats.WriteLocks != 10 || stats.WriteLockWaits != 1 || stats.WriteLockWaitTime < 10*time.Millisecond {
+		t.Errorf("Expected one timed wait in 10 write locks, got %d waits of %v in %d", stats.WriteLockWaits, stats.WriteLockWaitTime, stats.WriteLocks)
+	}
+	if waited, mean := stats.WriteContention(); waited != 0.1 || mean != stats.WriteLockWaitTime {
+		t.Errorf("WriteContention() = %v, %v", waited, mean)
+	}
+
+	if waited, mean := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt).Stats().WriteContention(); waited != 0 || mean != 0 {
+		t.Errorf("Untracked lists should report no contention, got %v, %v", waited, mean)
+	}
+}
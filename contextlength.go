@@ -0,0 +1,85 @@
+// contextlength.go - Constant-time entry counts per context
+
+package zerocopyskiplist
+
+// contextLengths counts entries per context for up to limit contexts. A
+// context is only tracked from a point where it is known to have no entries,
+// so every tracked count is exact; once a context has been turned away for
+// lack of room, untracked contexts must be counted by a scan.
+type contextLengths[C comparable] struct {
+	counts   map[C]int
+	limit    int
+	overflow bool // a context with entries went untracked
+}
+
+// WithContextLengths keeps the number of entries of up to maxContexts
+// distinct contexts, so LengthByContext answers without scanning the list.
+// A context's slot is freed when its last entry goes; contexts first written
+// while every slot is taken are counted by a scan until Clear. Unlike WithContextStats the memory used stays bounded, so it
+// suits lists whose contexts come and go.
+func WithContextLengths(maxContexts int) Option {
+	return func(o *options) {
+		o.contextLengths = max(maxContexts, 1)
+	}
+}
+
+// LengthByContext returns the number of entries carrying context. It takes
+// constant time for contexts counted WithContextStats or WithContextLengths
+// and scans the list otherwise. As for ContextStats, contexts changed with
+// ItemPtr.SetContext are not seen by the counts.
+func (sl *ZeroCopySkiplist[T, K, C]) LengthByContext(context C) int {
+	sl.rlock()
+	defer sl.runlock()
+
+	if sl.contextStats != nil {
+		if cs, ok := sl.contextStats[context]; ok {
+			return cs.Entries
+		}
+		return 0
+	}
+	if sl.lengths != nil {
+		if n, ok := sl.lengths.counts[context]; ok || !sl.lengths.overflow {
+			return n
+		}
+	}
+
+	n := 0
+	for current := sl.header.forward[0]; current != nil; current = current.forward[0] {
+		if current.context == context {
+			n++
+		}
+	}
+	return n
+}
+
+// countContext adds sign to the entries of context; the caller must hold the
+// write lock
+func (sl *ZeroCopySkiplist[T, K, C]) countContext(context C, sign int) {
+	l := sl.lengths
+	if l == nil {
+		return
+	}
+	n, ok := l.counts[context]
+	if !ok {
+		if l.overflow || sign < 0 {
+			return
+		}
+		if len(l.counts) >= l.limit {
+			l.overflow = true
+			return
+		}
+	}
+	// Once a context has been turned away an emptied slot cannot be handed
+	// on, so keep it for the context to come back to
+	if n += sign; n == 0 && !l.overflow {
+		delete(l.counts, context)
+		return
+	}
+	l.counts[context] = n
+}
+
+// reset forgets every count, for a list emptied or about to be recounted
+func (l *contextLengths[C]) reset() {
+	clear(l.counts)
+	l.overflow = false
+}
@@ -0,0 +1,68 @@
+package zerocopyskiplist
+
+import "testing"
+
+func TestLengthByContext(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt, WithContextLengths(2))
+	items := createTestItems(30)
+	check := func(step string) {
+		t.Helper()
+		for c := 0; c < 4; c++ {
+			want := 0
+			for entry := range skiplist.Entries() {
+				if entry.Context.AccessCount == c {
+					want++
+				}
+			}
+			if got := skiplist.LengthByContext(TestContext{AccessCount: c}); got != want {
+				t.Errorf("%s: LengthByContext(%d) = %d, want %d", step, c, got, want)
+			}
+		}
+	}
+
+	// Two contexts fit and are counted without a scan
+	for _, item := range items[:20] {
+		skiplist.Insert(item, TestContext{AccessCount: item.ID % 2})
+	}
+	check("inserts")
+	if len(skiplist.lengths.counts) != 2 || skiplist.lengths.overflow {
+		t.Fatalf("Both contexts should be tracked, got %v", skiplist.lengths.counts)
+	}
+
+	// Replacements and context updates move entries between counts
+	skiplist.Insert(items[0], TestContext{AccessCount: 1})
+	skiplist.UpdateContext(2, TestContext{AccessCount: 1})
+	skiplist.Delete(4)
+	check("updates")
+
+	// A third context overflows and falls back to scanning; a context
+	// emptied after that keeps its slot
+	for _, item := range items[20:] {
+		skiplist.Insert(item, TestContext{AccessCount: 2})
+	}
+	for _, item := range items[:20] {
+		if item.ID%2 == 0 {
+			skiplist.Delete(item.ID)
+		}
+	}
+	skiplist.Insert(items[5], TestContext{AccessCount: 3})
+	check("overflow")
+	if !skiplist.lengths.overflow || len(skiplist.lengths.counts) != 2 {
+		t.Errorf("Overflowed counts = %v", skiplist.lengths.counts)
+	}
+
+	// Clear starts afresh
+	skiplist.Clear()
+	skiplist.Insert(items[0], TestContext{AccessCount: 3})
+	check("clear")
+	if skiplist.lengths.overflow || skiplist.lengths.counts[TestContext{AccessCount: 3}] != 1 {
+		t.Errorf("Counts after Clear = %v", skiplist.lengths.counts)
+	}
+
+	// Without counts the length is scanned
+	plain := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	plain.Insert(items[0], TestContext{AccessCount: 7})
+	if plain.LengthByContext(TestContext{AccessCount: 7}) != 1 || plain.LengthByContext(TestContext{}) != 0 {
+		t.Error("LengthByContext should scan lists without counts")
+	}
+}
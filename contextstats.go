@@ -0,0 +1,97 @@
+// contextstats.go - Per-context usage and activity counters
+
+package zerocopyskiplist
+
+import (
+	"iter"
+	"slices"
+	"time"
+)
+
+// ContextStats is the usage and activity of one context, maintained
+// WithContextStats so per-context decisions need no scan of the list
+type ContextStats struct {
+	Entries   int
+	Bytes     int64     // item bytes of the context's entries
+	Inserts   uint64    // items stored with the context, including replacements
+	Evictions uint64    // entries removed by ShedOldest or quota eviction
+	LastFlush time.Time // last encoded flush or MarkFlushed including the context, zero if none
+}
+
+// WithContextStats maintains a ContextStats for every context written to the
+// list, read with ContextStats. Each write updates a map entry for its
+// context under the write lock; contexts stay in the map once seen, so lists
+// with unbounded sets of contexts should not enable it.
+func WithContextStats() Option {
+	return func(o *options) {
+		o.contextStats = true
+	}
+}
+
+// ContextStats returns the counters of context, false if the list was not
+// built WithContextStats or has never held the context. Entries moved in with
+// ItemPtr.SetContext are not seen.
+func (sl *ZeroCopySkiplist[T, K, C]) ContextStats(context C) (ContextStats, bool) {
+	sl.rlock()
+	defer sl.runlock()
+
+	cs, ok := sl.contextStats[context]
+	if !ok {
+		return ContextStats{}, false
+	}
+	return *cs, true
+}
+
+// MarkFlushed records now as the last flush time of contexts, for callers
+// flushing through iovecs; encoded flushes record it themselves
+func (sl *ZeroCopySkiplist[T, K, C]) MarkFlushed(contexts ...C) {
+	sl.markFlushed(slices.Values(contexts))
+}
+
+// markFlushed records now as the last flush time of contexts
+func (sl *ZeroCopySkiplist[T, K, C]) markFlushed(contexts iter.Seq[C]) {
+	if sl.contextStats == nil {
+		return
+	}
+	now := sl.Clock().Now()
+	sl.lock()
+	defer sl.unlock()
+	for context := range contexts {
+		sl.contextStat(context).LastFlush = now
+	}
+}
+
+// contextStat returns the counters of context, creating them; the caller must
+// hold the write lock and check that the list keeps context stats
+func (sl *ZeroCopySkiplist[T, K, C]) contextStat(context C) *ContextStats {
+	cs := sl.contextStats[context]
+	if cs == nil {
+		cs = &ContextStats{}
+		sl.contextStats[context] = cs
+	}
+	return cs
+}
+
+// chargeContext adds an entry of size bytes to the usage of context, or
+// removes one when sign is -1, counting an insert for additions when insert
+// is true; the caller must hold the write lock
+func (sl *ZeroCopySkiplist[T, K, C]) chargeContext(context C, size int64, sign int, insert bool) {
+	sl.countContext(context, sign)
+	if sl.contextStats == nil {
+		return
+	}
+	cs := sl.contextStat(context)
+	cs.Entries += sign
+	cs.Bytes += int64(sign) * size
+	if insert {
+		cs.Inserts++
+	}
+}
+
+// countEviction counts an entry of context removed by eviction; the caller
+// must hold the write lock
+func (sl *ZeroCopySkiplist[T, K, C]) countEviction(context C) {
+	if sl.contextStats != nil {
+		sl.contextStat(context).Evictions++
+	}
+}
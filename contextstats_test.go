@@ -0,0 +1,77 @@
+package zerocopyskiplist
+
+import (
+	"io"
+	"testing"
+	"unsafe"
+)
+
+func TestContextStats(t *testing.T) {
+	sl := MakeOrderedZeroCopySkiplist[PaddedRecord, int64, PaddedContext](
+		8,
+		func(r *PaddedRecord) int64 { return r.ID },
+		func(r *PaddedRecord) int { return int(unsafe.Sizeof(*r)) },
+		WithContextStats(),
+	)
+	size := int64(unsafe.Sizeof(PaddedRecord{}))
+	hot, cold := PaddedContext{Generation: 1}, PaddedContext{Generation: 2}
+
+	for i := int64(1); i <= 10; i++ {
+		context := hot
+		if i > 6 {
+			context = cold
+		}
+		sl.Insert(&PaddedRecord{ID: i}, context)
+	}
+	sl.Insert(&PaddedRecord{ID: 1, Kind: 1}, hot)
+	sl.UpdateContext(2, cold)
+	sl.Delete(3)
+	sl.SetContextQuota(cold, 4, 0, QuotaEvictOldest)
+	sl.Insert(&PaddedRecord{ID: 11}, cold)
+
+	stats, ok := sl.ContextStats(hot)
+	if !ok || stats.Entries != 4 || stats.Bytes != 4*size || stats.Inserts != 7 || stats.Evictions != 0 {
+		t.Errorf("Hot stats = %+v, want 4 entries of %d bytes after 7 inserts", stats, 4*size)
+	}
+	stats, _ = sl.ContextStats(cold)
+	if stats.Entries != 4 || stats.Inserts != 5 || stats.Evictions != 2 || !stats.LastFlush.IsZero() {
+		t.Errorf("Cold stats = %+v, want 4 entries, 5 inserts and 2 evictions", stats)
+	}
+	if _, ok := sl.ContextStats(PaddedContext{Generation: 3}); ok {
+		t.Error("An unseen context should have no stats")
+	}
+
+	codec, err := NewLittleEndianCodec[PaddedRecord, PaddedContext](1)
+	if err != nil {
+		t.Fatalf("NewLittleEndianCodec: %v", err)
+	}
+	if _, err := sl.CallbackToEncoded(io.Discard, codec, func(ip *ItemPtr[PaddedRecord, int64, PaddedContext]) bool {
+		return ip.Context() == cold
+	}); err != nil {
+		t.Fatalf("CallbackToEncoded: %v", err)
+	}
+	if stats, _ := sl.ContextStats(cold); stats.LastFlush.IsZero() {
+		t.Error("An encoded flush should record the last flush time")
+	}
+	if stats, _ := sl.ContextStats(hot); !stats.LastFlush.IsZero() {
+		t.Error("A context left out of the flush should keep a zero flush time")
+	}
+	sl.MarkFlushed(hot)
+	if stats, _ := sl.ContextStats(hot); stats.LastFlush.IsZero() {
+		t.Error("MarkFlushed should record the last flush time")
+	}
+
+	sl.Clear()
+	if stats, _ := sl.ContextStats(hot); stats.Entries != 0 || stats.Bytes != 0 || stats.Inserts != 7 {
+		t.Errorf("Stats after Clear = %+v, want usage reset and counters kept", stats)
+	}
+}
+
+func TestContextStatsDisabled(t *testing.T) {
+	sl := newPaddedList()
+	sl.Insert(&PaddedRecord{ID: 1}, PaddedContext{})
+	sl.MarkFlushed(PaddedContext{})
+	if _, ok := sl.ContextStats(PaddedContext{}); ok {
+		t.Error("Context stats should only be kept WithContextStats")
+	}
+}
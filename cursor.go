@@ -0,0 +1,162 @@
+// cursor.go - Seek-then-scan cursors for the zero copy skiplist
+
+package zerocopyskiplist
+
+// Cursor is a position in a skiplist that can be moved forwards and backwards.
+//
+// A cursor caches the key, item and context of its current position, taken
+// under the read lock when the cursor last moved, so reading them is always
+// safe. Each Next or Prev takes the read lock briefly; no lock is held between
+// calls, so the list may be mutated while a cursor is in use. If the current
+// item is deleted, the next move continues from its key as if it were still
+// present, even once WithNodePool has reused its node for another key.
+type Cursor[T any, K comparable, C comparable] struct {
+	sl      *ZeroCopySkiplist[T, K, C]
+	node    *ItemPtr[T, K, C]
+	key     K
+	item    *T
+	context C
+}
+
+// SeekGE returns a cursor positioned at the first item with a key >= key.
+// The cursor is not Valid if there is no such item.
+func (sl *ZeroCopySkiplist[T, K, C]) SeekGE(key K) *Cursor[T, K, C] {
+	key = sl.normalize(key)
+	sl.rlock()
+	defer sl.runlock()
+
+	c := &Cursor[T, K, C]{sl: sl}
+	if sl.leaks != nil {
+		trackCursor(sl.leaks, c)
+	}
+	c.moveTo(sl.seek(key, true))
+	return c
+}
+
+// SeekLE returns a cursor positioned at the last item with a key <= key.
+// The cursor is not Valid if there is no such item.
+func (sl *ZeroCopySkiplist[T, K, C]) SeekLE(key K) *Cursor[T, K, C] {
+	key = sl.normalize(key)
+	sl.rlock()
+	defer sl.runlock()
+
+	c := &Cursor[T, K, C]{sl: sl}
+	if sl.leaks != nil {
+		trackCursor(sl.leaks, c)
+	}
+	c.moveTo(sl.seekLast(key, true))
+	return c
+}
+
+// Valid returns true if the cursor is positioned at an item
+func (c *Cursor[T, K, C]) Valid() bool {
+	return c.node != nil
+}
+
+// Key returns the key at the cursor position
+func (c *Cursor[T, K, C]) Key() K {
+	return c.key
+}
+
+// Item returns the item at the cursor position, nil if the cursor is not Valid
+func (c *Cursor[T, K, C]) Item() *T {
+	return c.item
+}
+
+// Context returns the context at the cursor position as of the last move
+func (c *Cursor[T, K, C]) Context() C {
+	return c.context
+}
+
+// Next moves the cursor to the next item in key order and returns Valid()
+func (c *Cursor[T, K, C]) Next() bool {
+	if c.node == nil {
+		return false
+	}
+
+	c.sl.rlock()
+	defer c.sl.runlock()
+
+	if c.moved() {
+		c.moveTo(c.sl.seek(c.key, false))
+	} else {
+		c.moveTo(c.node.forward[0])
+	}
+	return c.node != nil
+}
+
+// Prev moves the cursor to the previous item in key order and returns Valid()
+func (c *Cursor[T, K, C]) Prev() bool {
+	if c.node == nil {
+		return false
+	}
+
+	c.sl.rlock()
+	defer c.sl.runlock()
+
+	if c.moved() {
+		c.moveTo(c.sl.seekLast(c.key, false))
+	} else {
+		c.moveTo(c.node.backward)
+	}
+	return c.node != nil
+}
+
+// moved reports whether the cursor's node no longer holds its position: it
+// was unlinked, or recycled by the node pool for another key. The caller must
+// hold the lock.
+func (c *Cursor[T, K, C]) moved() bool {
+	return c.node.level == unlinkedLevel || c.node.key != c.key
+}
+
+// moveTo positions the cursor at node, caching its fields; the caller must hold the lock
+func (c *Cursor[T, K, C]) moveTo(node *ItemPtr[T, K, C]) {
+	c.node = node
+	if node == nil {
+		var zeroItem *T
+		var zeroContext C
+		c.item, c.context = zeroItem, zeroContext
+		return
+	}
+	c.key, c.item, c.context = node.key, node.item, node.context
+}
+
+// DeleteCurrent deletes the item at the cursor and moves the cursor to the
+// next item in key order, both under one write lock, so no other writer can
+// slip in between and the cursor never rests on an unlinked node. It returns
+// true if the item was deleted. If another writer deleted it first the
+// cursor still moves on and false is returned; a cursor that is not Valid,
+// or a frozen list, is left alone. To delete while scanning, call
+// DeleteCurrent instead of Next for the items to remove:
+//
+//	for c := sl.SeekGE(from); c.Valid(); {
+//		if expired(c.Context()) {
+//			c.DeleteCurrent()
+//			continue
+//		}
+//		c.Next()
+//	}
+func (c *Cursor[T, K, C]) DeleteCurrent() bool {
+	if c.node == nil {
+		return false
+	}
+	defer c.sl.endOp(OperationDelete, c.sl.startOp())
+
+	sl := c.sl
+	var deleted bool
+	hook, totalBytes, length := func() (func(int64, int), int64, int) {
+		sl.lock()
+		defer sl.unlock()
+		if sl.frozen {
+			return nil, 0, 0
+		}
+		deleted = sl.seek(c.key, true) == c.node && sl.delete(c.key)
+		c.moveTo(sl.seek(c.key, false))
+		return sl.checkPressure()
+	}()
+
+	if hook != nil {
+		hook(totalBytes, length)
+	}
+	return deleted
+}
@@ -0,0 +1,189 @@
+package zerocopyskiplist
+
+import "testing"
+
+func TestCursorSeekAndScan(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		16,
+		getKeyFromTestItem,
+		getTestItemSize,
+		compareInt,
+	)
+
+	// Even keys 2..20
+	for i := 1; i <= 10; i++ {
+		skiplist.Insert(&TestItem{ID: i * 2}, TestContext{AccessCount: i})
+	}
+
+	// Seek to an absent key lands on the next larger key
+	c := skiplist.SeekGE(7)
+	if !c.Valid() || c.Key() != 8 {
+		t.Fatalf("SeekGE(7) should land on key 8, got valid=%v key=%d", c.Valid(), c.Key())
+	}
+	if c.Context().AccessCount != 4 || c.Item().ID != 8 {
+		t.Error("Cursor should expose the item and context at its position")
+	}
+
+	// Scan forward to the end
+	var keys []int
+	for ; c.Valid(); c.Next() {
+		keys = append(keys, c.Key())
+	}
+	expected := []int{8, 10, 12, 14, 16, 18, 20}
+	if len(keys) != len(expected) {
+		t.Fatalf("Expected keys %v, got %v", expected, keys)
+	}
+	for i := range expected {
+		if keys[i] != expected[i] {
+			t.Fatalf("Expected keys %v, got %v", expected, keys)
+		}
+	}
+	if c.Next() || c.Prev() {
+		t.Error("An exhausted cursor should stay invalid")
+	}
+
+	// Seek to an exact key, then walk backwards
+	c = skiplist.SeekLE(6)
+	if !c.Valid() || c.Key() != 6 {
+		t.Fatalf("SeekLE(6) should land on key 6, got valid=%v key=%d", c.Valid(), c.Key())
+	}
+	if !c.Prev() || c.Key() != 4 || !c.Prev() || c.Key() != 2 {
+		t.Error("Prev() should walk back through keys 4 and 2")
+	}
+	if c.Prev() || c.Valid() || c.Item() != nil {
+		t.Error("Prev() before the first item should invalidate the cursor")
+	}
+
+	// Seeks past either end
+	if skiplist.SeekGE(21).Valid() {
+		t.Error("SeekGE past the last key should not be valid")
+	}
+	if skiplist.SeekLE(1).Valid() {
+		t.Error("SeekLE before the first key should not be valid")
+	}
+}
+
+func TestCursorSurvivesDelete(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		16,
+		getKeyFromTestItem,
+		getTestItemSize,
+		compareInt,
+	)
+
+	for _, item := range createTestItems(10) {
+		skiplist.Insert(item, TestContext{})
+	}
+
+	// Delete the current and next items out from under the cursor
+	c := skiplist.SeekGE(5)
+	skiplist.Delete(5)
+	skiplist.Delete(6)
+	if !c.Next() || c.Key() != 7 {
+		t.Errorf("Next() after deleting the current item should land on key 7, got %d", c.Key())
+	}
+
+	c = skiplist.SeekGE(4)
+	skiplist.Delete(4)
+	skiplist.Delete(3)
+	if !c.Prev() || c.Key() != 2 {
+		t.Errorf("Prev() after deleting the current item should land on key 2, got %d", c.Key())
+	}
+
+	// Scanning with mutations in the loop body is safe
+	for c = skiplist.SeekGE(0); c.Valid(); c.Next() {
+		skiplist.Delete(c.Key())
+	}
+	if !skiplist.IsEmpty() {
+		t.Errorf("Deleting during a cursor scan should empty the list, %d left", skiplist.Length())
+	}
+}
+
+func TestCursorSurvivesNodeReuse(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		16,
+		getKeyFromTestItem,
+		getTestItemSize,
+		compareInt,
+		WithNodePool(),
+	)
+	for _, item := range createTestItems(100) {
+		skiplist.Insert(item, TestContext{})
+	}
+
+	// Deleting the current item and inserting a new key hands its node to
+	// the new key; the cursor must not follow the node's new links
+	c := skiplist.SeekGE(50)
+	skiplist.Delete(50)
+	skiplist.Insert(&TestItem{ID: 150}, TestContext{})
+	if !c.Next() || c.Key() != 51 {
+		t.Errorf("Next() after the current node was reused should land on key 51, got %d", c.Key())
+	}
+
+	c = skiplist.SeekGE(40)
+	skiplist.Delete(40)
+	skiplist.Insert(&TestItem{ID: 160}, TestContext{})
+	if !c.Prev() || c.Key() != 39 {
+		t.Errorf("Prev() after the current node was reused should land on key 39, got %d", c.Key())
+	}
+}
+
+func TestCursorDeleteCurrent(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		16,
+		getKeyFromTestItem,
+		getTestItemSize,
+		compareInt,
+		WithLevelBacklinks(),
+		WithNodePool(),
+	)
+	items := createTestItems(1000)
+	for _, item := range items {
+		skiplist.Insert(item, TestContext{})
+	}
+
+	// Delete every multiple of 3 in one pass
+	visited := 0
+	for c := skiplist.SeekGE(0); c.Valid(); {
+		visited++
+		if c.Key()%3 == 0 {
+			key := c.Key()
+			if !c.DeleteCurrent() {
+				t.Fatalf("DeleteCurrent of key %d failed", key)
+			}
+			if c.Valid() && c.Key() != key+1 {
+				t.Fatalf("DeleteCurrent of key %d should move to %d, got %d", key, key+1, c.Key())
+			}
+			continue
+		}
+		c.Next()
+	}
+	if visited != 1000 || skiplist.Length() != 667 {
+		t.Errorf("Expected 1000 visits leaving 667 items, got %d and %d", visited, skiplist.Length())
+	}
+	if err := skiplist.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	// An item deleted, even replaced, behind the cursor's back is left alone
+	c := skiplist.SeekGE(10)
+	skiplist.Delete(10)
+	skiplist.Insert(items[9], TestContext{})
+	if c.DeleteCurrent() || c.Key() != 11 {
+		t.Errorf("DeleteCurrent of a replaced item should only move on, got key %d", c.Key())
+	}
+	if node, _ := skiplist.Find(10); node == nil {
+		t.Error("The replacement for key 10 should not be deleted")
+	}
+
+	// Frozen lists and exhausted cursors do nothing
+	skiplist.Freeze()
+	if c.DeleteCurrent() || c.Key() != 11 {
+		t.Error("DeleteCurrent on a frozen list should do nothing")
+	}
+	skiplist.Thaw()
+	c = skiplist.SeekGE(1000)
+	if !c.DeleteCurrent() || c.Valid() || c.DeleteCurrent() {
+		t.Error("Deleting the last item should leave the cursor invalid")
+	}
+}
@@ -0,0 +1,170 @@
+// digest.go - Range digests for anti-entropy between replicas
+
+package zerocopyskiplist
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+// KeyRange is the range of keys from Min up to Max, including Max only when
+// MaxInclusive is set
+type KeyRange[K comparable] struct {
+	Min          K
+	Max          K
+	MaxInclusive bool
+}
+
+// Digest summarises the entries in a key range. Two lists hold the same
+// entries in a range exactly when their digests of it are equal (up to the
+// collision resistance of SHA-256).
+type Digest struct {
+	Count int
+	Sum   [sha256.Size]byte
+}
+
+// pastRange returns true if key lies beyond the end of r
+func (sl *ZeroCopySkiplist[T, K, C]) pastRange(r KeyRange[K], key K) bool {
+	c := sl.cmpKey(key, r.Max)
+	return c > 0 || (c == 0 && !r.MaxInclusive)
+}
+
+// walkRange calls fn for every entry in r in key order, snapshotting in
+// batches as for CallbackToIovecSlice, until fn returns false
+func (sl *ZeroCopySkiplist[T, K, C]) walkRange(r KeyRange[K], fn func(*ItemPtr[T, K, C]) bool) {
+	batch := make([]ItemPtr[T, K, C], 0, callbackBatchSize)
+	from, inclusive := r.Min, true
+	for {
+		batch = sl.snapshotFrom(batch[:0], from, inclusive)
+		for i := range batch {
+			if sl.pastRange(r, batch[i].key) || !fn(&batch[i]) {
+				return
+			}
+		}
+		if len(batch) < callbackBatchSize {
+			return
+		}
+		from, inclusive = batch[len(batch)-1].key, false
+	}
+}
+
+// snapshotFrom appends copies of up to cap(dst) nodes to dst, starting at the
+// first node with a key >= from (> from when not inclusive)
+func (sl *ZeroCopySkiplist[T, K, C]) snapshotFrom(dst []ItemPtr[T, K, C], from K, inclusive bool) []ItemPtr[T, K, C] {
+	sl.rlock()
+	defer sl.runlock()
+
+	touched := 0
+	for current := sl.seek(from, inclusive); current != nil && len(dst) < cap(dst); current = current.forward[0] {
+		touched += prefetch(current, 1)
+		dst = append(dst, current.detach())
+	}
+	prefetchSink.Add(int64(touched))
+	return dst
+}
+
+// RangeDigest returns the digest of the entries in r: their count and a
+// SHA-256 over their codec encodings in key order. Both sides of a comparison
+// must use the same codec schema version.
+func (sl *ZeroCopySkiplist[T, K, C]) RangeDigest(r KeyRange[K], codec ItemCodec[T, C]) (Digest, error) {
+	r.Min, r.Max = sl.normalize(r.Min), sl.normalize(r.Max)
+	h := sha256.New()
+	var digest Digest
+	var buf []byte
+	var err error
+	sl.walkRange(r, func(ip *ItemPtr[T, K, C]) bool {
+		// Length framing keeps the boundaries between entries unambiguous
+		if buf, err = appendRecord(buf[:0], codec, ip.item, ip.context); err != nil {
+			err = fmt.Errorf("encoding key %v: %w", ip.key, err)
+			return false
+		}
+		h.Write(buf)
+		digest.Count++
+		return true
+	})
+	if err != nil {
+		return Digest{}, err
+	}
+	var count [8]byte
+	binary.LittleEndian.PutUint64(count[:], uint64(digest.Count))
+	h.Write(count[:])
+	h.Sum(digest.Sum[:0])
+	return digest, nil
+}
+
+// SplitRange divides r into up to parts consecutive ranges holding roughly
+// equal numbers of local entries. The split keys come from this list, so a
+// peer digesting the same sub-ranges compares like with like.
+func (sl *ZeroCopySkiplist[T, K, C]) SplitRange(r KeyRange[K], parts int) []KeyRange[K] {
+	r.Min, r.Max = sl.normalize(r.Min), sl.normalize(r.Max)
+	count := 0
+	sl.walkRange(r, func(*ItemPtr[T, K, C]) bool {
+		count++
+		return true
+	})
+	if parts < 2 || count < 2 {
+		return []KeyRange[K]{r}
+	}
+	parts = min(parts, count)
+
+	var splits []K
+	i := 0
+	sl.walkRange(r, func(ip *ItemPtr[T, K, C]) bool {
+		if i > 0 && i*parts/count != (i-1)*parts/count {
+			splits = append(splits, ip.key)
+		}
+		i++
+		return true
+	})
+
+	ranges := make([]KeyRange[K], 0, len(splits)+1)
+	from := r.Min
+	for _, split := range splits {
+		ranges = append(ranges, KeyRange[K]{Min: from, Max: split})
+		from = split
+	}
+	return append(ranges, KeyRange[K]{Min: from, Max: r.Max, MaxInclusive: r.MaxInclusive})
+}
+
+// DivergentRanges compares this list with a peer over r and returns the
+// smallest ranges whose entries differ, each holding at most leafSize local
+// entries or else no longer divisible. remote returns the peer's digest of a
+// range, typically over the network. Differing ranges are split fanout ways
+// and compared again, so only the digests along divergent paths are
+// exchanged; the entries in the returned ranges are then reconciled directly.
+func (sl *ZeroCopySkiplist[T, K, C]) DivergentRanges(
+	r KeyRange[K],
+	codec ItemCodec[T, C],
+	leafSize, fanout int,
+	remote func(KeyRange[K]) (Digest, error),
+) ([]KeyRange[K], error) {
+	local, err := sl.RangeDigest(r, codec)
+	if err != nil {
+		return nil, err
+	}
+	peer, err := remote(r)
+	if err != nil {
+		return nil, err
+	}
+	if local == peer {
+		return nil, nil
+	}
+	if local.Count <= leafSize {
+		return []KeyRange[K]{r}, nil
+	}
+
+	parts := sl.SplitRange(r, fanout)
+	if len(parts) == 1 {
+		return parts, nil
+	}
+	var divergent []KeyRange[K]
+	for _, part := range parts {
+		ranges, err := sl.DivergentRanges(part, codec, leafSize, fanout, remote)
+		if err != nil {
+			return nil, err
+		}
+		divergent = append(divergent, ranges...)
+	}
+	return divergent, nil
+}
@@ -0,0 +1,101 @@
+package zerocopyskiplist
+
+import "testing"
+
+func TestRangeDigest(t *testing.T) {
+	a, b := newSnapshotTestList(), newSnapshotTestList()
+	for _, item := range createTestItems(100) {
+		a.Insert(item, TestContext{})
+		b.Insert(&TestItem{ID: item.ID, Value: item.Value}, TestContext{})
+	}
+
+	all := KeyRange[int]{Min: 1, Max: 100, MaxInclusive: true}
+	da, _ := a.RangeDigest(all, testItemCodecV2{})
+	db, _ := b.RangeDigest(all, testItemCodecV2{})
+	if da != db || da.Count != 100 {
+		t.Fatalf("Equal lists should have equal digests: %+v %+v", da, db)
+	}
+
+	b.UpdateContext(50, TestContext{AccessCount: 1})
+	db, _ = b.RangeDigest(all, testItemCodecV2{})
+	if da == db {
+		t.Error("A changed context should change the digest")
+	}
+	lower := KeyRange[int]{Min: 1, Max: 50}
+	da, _ = a.RangeDigest(lower, testItemCodecV2{})
+	db, _ = b.RangeDigest(lower, testItemCodecV2{})
+	if da != db || da.Count != 49 {
+		t.Errorf("Ranges excluding the change should match: %+v %+v", da, db)
+	}
+}
+
+func TestSplitRange(t *testing.T) {
+	skiplist := newSnapshotTestList()
+	for _, item := range createTestItems(1000) {
+		skiplist.Insert(item, TestContext{})
+	}
+
+	r := KeyRange[int]{Min: 101, Max: 900, MaxInclusive: true}
+	parts := skiplist.SplitRange(r, 4)
+	if len(parts) != 4 || parts[0].Min != 101 || parts[3].Max != 900 || !parts[3].MaxInclusive {
+		t.Fatalf("Unexpected split %+v", parts)
+	}
+	total := 0
+	for i, part := range parts {
+		d, _ := skiplist.RangeDigest(part, testItemCodecV2{})
+		if d.Count != 200 {
+			t.Errorf("Part %d holds %d entries, expected 200", i, d.Count)
+		}
+		if i > 0 && part.Min != parts[i-1].Max {
+			t.Errorf("Part %d does not start where part %d ends", i, i-1)
+		}
+		total += d.Count
+	}
+	if total != 800 {
+		t.Errorf("Parts hold %d entries, expected 800", total)
+	}
+}
+
+func TestDivergentRanges(t *testing.T) {
+	local, peer := newSnapshotTestList(), newSnapshotTestList()
+	for _, item := range createTestItems(10000) {
+		local.Insert(item, TestContext{})
+		peer.Insert(item, TestContext{})
+	}
+	peer.Delete(1234)
+	peer.UpdateContext(5000, TestContext{AccessCount: 9})
+	peer.Insert(&TestItem{ID: 20000}, TestContext{})
+
+	exchanged := 0
+	ranges, err := local.DivergentRanges(KeyRange[int]{Min: 0, Max: 1 << 30}, testItemCodecV2{}, 16, 8,
+		func(r KeyRange[int]) (Digest, error) {
+			exchanged++
+			return peer.RangeDigest(r, testItemCodecV2{})
+		})
+	if err != nil {
+		t.Fatalf("DivergentRanges: %v", err)
+	}
+
+	covered := func(key int) bool {
+		for _, r := range ranges {
+			if key >= r.Min && (key < r.Max || (r.MaxInclusive && key == r.Max)) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, key := range []int{1234, 5000, 20000} {
+		if !covered(key) {
+			t.Errorf("Divergent key %d is not covered by %+v", key, ranges)
+		}
+	}
+
+	entries := 0
+	for _, r := range ranges {
+		d, _ := local.RangeDigest(r, testItemCodecV2{})
+		entries += d.Count
+	}
+	if entries > 100 || exchanged > 100 {
+		t.Errorf("Expected a narrow result, got %d entries in %d ranges after %d digests", entries, len(ranges), exchanged)
+	}
+}
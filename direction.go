@@ -0,0 +1,70 @@
+// direction.go - Flushing in either key order
+
+package zerocopyskiplist
+
+import "syscall"
+
+// Direction is the order in which a flush walks the list
+type Direction int
+
+const (
+	// Ascending walks the list from its first entry to its last, the order of
+	// every other scan
+	Ascending Direction = iota
+	// Descending walks the list from its last entry to its first along the
+	// backward links, for formats that want the newest records first. On a
+	// WithDescending list it yields ascending keys.
+	Descending
+)
+
+// String returns the direction's name
+func (d Direction) String() string {
+	if d == Descending {
+		return "descending"
+	}
+	return "ascending"
+}
+
+// AppendIovecsIn is AppendIovecs walking the list in dir. A Descending walk
+// follows the backward links in batches exactly as an Ascending one follows
+// the forward links, so the iovecs come out in the order they are to be
+// written and no multi-million entry slice has to be reversed afterwards.
+func (sl *ZeroCopySkiplist[T, K, C]) AppendIovecsIn(dst []syscall.Iovec, dir Direction, filter func(*ItemPtr[T, K, C]) bool) []syscall.Iovec {
+	defer sl.endOp(OperationScan, sl.startOp())
+	iovecs := sl.appendIovecs(dst, dir, filter)
+	if sl.leaks != nil && cap(iovecs) > 0 && (cap(dst) == 0 || &iovecs[:1][0] != &dst[:1][0]) {
+		sl.leaks.trackIovecs(iovecs)
+	}
+	return iovecs
+}
+
+// ToIovecSliceIn generates an iovec for every item, walking the list in dir
+func (sl *ZeroCopySkiplist[T, K, C]) ToIovecSliceIn(dir Direction) []syscall.Iovec {
+	return sl.AppendIovecsIn(make([]syscall.Iovec, 0, sl.Length()), dir, func(*ItemPtr[T, K, C]) bool {
+		return true
+	})
+}
+
+// snapshotIn appends copies of up to cap(dst) nodes to dst walking in dir,
+// starting at the end of the list or, when resume is true, just past after
+func (sl *ZeroCopySkiplist[T, K, C]) snapshotIn(dir Direction, dst []ItemPtr[T, K, C], after K, resume bool) []ItemPtr[T, K, C] {
+	if dir != Descending {
+		return sl.snapshotBatch(dst, after, resume)
+	}
+
+	sl.rlock()
+	defer sl.runlock()
+
+	current := sl.tails[0]
+	if resume {
+		current = sl.seekLast(after, false)
+	}
+	touched := 0
+	for current != nil && len(dst) < cap(dst) {
+		touched += prefetch(current, -1)
+		dst = append(dst, current.detach())
+		current = current.backward
+	}
+	prefetchSink.Add(int64(touched))
+	return dst
+}
@@ -0,0 +1,71 @@
+package zerocopyskiplist
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+	"unsafe"
+)
+
+func TestAppendIovecsDescending(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithLevelBacklinks()}} {
+		skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt, opts...)
+		for _, item := range createTestItems(1000) {
+			skiplist.Insert(item, TestContext{AccessCount: item.ID % 3})
+		}
+
+		ascending := skiplist.ToIovecSliceIn(Ascending)
+		descending := skiplist.ToIovecSliceIn(Descending)
+		slices.Reverse(descending)
+		if !slices.Equal(ascending, descending) || len(ascending) != 1000 {
+			t.Fatalf("Descending iovecs should be the ascending ones reversed")
+		}
+
+		// Filters see the entries newest first across batch boundaries
+		last := 1001
+		filtered := skiplist.AppendIovecsIn(nil, Descending, func(ip *ItemPtr[TestItem, int, TestContext]) bool {
+			if ip.Key() >= last {
+				t.Fatalf("Key %d after %d in a descending walk", ip.Key(), last)
+			}
+			last = ip.Key()
+			return ip.Context().AccessCount == 0
+		})
+		if len(filtered) != 333 || (*TestItem)(unsafe.Pointer(filtered[0].Base)).ID != 999 {
+			t.Errorf("Filtered descending walk returned %d iovecs", len(filtered))
+		}
+	}
+
+	if got := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt).ToIovecSliceIn(Descending); len(got) != 0 {
+		t.Errorf("Empty list produced %d iovecs", len(got))
+	}
+}
+
+func TestCallbackToEncodedDescending(t *testing.T) {
+	codec, err := NewLittleEndianCodec[PaddedRecord, PaddedContext](1)
+	if err != nil {
+		t.Fatalf("NewLittleEndianCodec: %v", err)
+	}
+	skiplist := newPaddedList()
+	for i := int64(1); i <= 1000; i++ {
+		skiplist.Insert(&PaddedRecord{ID: i}, PaddedContext{Generation: uint32(i)})
+	}
+
+	var buf bytes.Buffer
+	report, err := skiplist.CallbackToEncodedIn(&buf, codec, Descending, func(ip *ItemPtr[PaddedRecord, int64, PaddedContext]) bool {
+		return ip.Key()%2 == 0
+	})
+	if err != nil || report.Entries != 500 {
+		t.Fatalf("CallbackToEncodedIn = %+v, %v", report, err)
+	}
+	next := int64(1000)
+	err = DecodeEncoded(bytes.NewReader(buf.Bytes()), codec, func(item *PaddedRecord, ctx PaddedContext) error {
+		if item.ID != next || ctx.Generation != uint32(next) {
+			t.Errorf("Expected record %d, got %+v", next, item)
+		}
+		next -= 2
+		return nil
+	})
+	if err != nil || next != 0 {
+		t.Errorf("DecodeEncoded stopped at %d: %v", next, err)
+	}
+}
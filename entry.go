@@ -0,0 +1,87 @@
+// entry.go - Plain value views of skiplist entries
+
+package zerocopyskiplist
+
+import "iter"
+
+// Entry is a copy of one entry's key, item pointer and context, taken under
+// the read lock. Unlike an ItemPtr it has no links into the list, so it stays
+// safe to read however the list changes, and it does not depend on the node
+// layout. Use an ItemPtr or a Cursor only when navigating from an entry.
+type Entry[T any, K comparable, C comparable] struct {
+	Key     K
+	Item    *T
+	Context C
+}
+
+// Entry returns the entry ip currently holds
+func (ip *ItemPtr[T, K, C]) Entry() Entry[T, K, C] {
+	return Entry[T, K, C]{Key: ip.key, Item: ip.item, Context: ip.context}
+}
+
+// Entry returns the entry at the cursor position as of the last move; the
+// zero Entry if the cursor is not Valid
+func (c *Cursor[T, K, C]) Entry() Entry[T, K, C] {
+	return Entry[T, K, C]{Key: c.key, Item: c.item, Context: c.context}
+}
+
+// Get returns the entry for key and whether it was found
+func (sl *ZeroCopySkiplist[T, K, C]) Get(key K) (Entry[T, K, C], bool) {
+	key = sl.normalize(key)
+	defer sl.endOp(OperationFind, sl.startOp())
+	sl.rlockFlat()
+	defer sl.runlock()
+	if sl.hot != nil {
+		sl.touch(key)
+	}
+	if sl.sketch != nil {
+		sl.sketch.Record(key)
+	}
+
+	if sl.flat {
+		i, found := sl.smallIndex(key)
+		if !found {
+			return Entry[T, K, C]{}, false
+		}
+		e := &sl.small[i]
+		return Entry[T, K, C]{Key: e.key, Item: e.item, Context: e.context}, true
+	}
+	current := sl.seek(key, true)
+	if current == nil || sl.cmpKey(current.key, key) != 0 {
+		return Entry[T, K, C]{}, false
+	}
+	return current.Entry(), true
+}
+
+// FirstEntry returns the entry with the smallest key, false if the list is empty
+func (sl *ZeroCopySkiplist[T, K, C]) FirstEntry() (Entry[T, K, C], bool) {
+	sl.rlock()
+	defer sl.runlock()
+
+	if first := sl.header.forward[0]; first != nil {
+		return first.Entry(), true
+	}
+	return Entry[T, K, C]{}, false
+}
+
+// LastEntry returns the entry with the largest key, false if the list is empty
+func (sl *ZeroCopySkiplist[T, K, C]) LastEntry() (Entry[T, K, C], bool) {
+	sl.rlock()
+	defer sl.runlock()
+
+	if last := sl.tails[0]; last != nil {
+		return last.Entry(), true
+	}
+	return Entry[T, K, C]{}, false
+}
+
+// Entries returns an iterator over every entry in ascending key order. It
+// snapshots entries in batches as ForEach does, so the loop body runs with no
+// lock held and may mutate the list.
+func (sl *ZeroCopySkiplist[T, K, C]) Entries() iter.Seq[Entry[T, K, C]] {
+	return func(yield func(Entry[T, K, C]) bool) {
+		sl.ForEach(func(ip *ItemPtr[T, K, C]) bool {
+			return yield(ip.Entry())
+		})
+	}
+}
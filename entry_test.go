@@ -0,0 +1,50 @@
+package zerocopyskiplist
+
+import "testing"
+
+func TestEntry(t *testing.T) {
+	sl := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	if _, ok := sl.FirstEntry(); ok {
+		t.Error("FirstEntry of an empty list should report false")
+	}
+	if _, ok := sl.LastEntry(); ok {
+		t.Error("LastEntry of an empty list should report false")
+	}
+
+	items := createTestItems(20)
+	for _, item := range items {
+		sl.Insert(item, TestContext{AccessCount: item.ID})
+	}
+
+	entry, ok := sl.Get(7)
+	if !ok || entry.Key != 7 || entry.Item != items[6] || entry.Context.AccessCount != 7 {
+		t.Errorf("Get(7) = %+v, %v", entry, ok)
+	}
+	if _, ok := sl.Get(21); ok {
+		t.Error("Get of a missing key should report false")
+	}
+	if first, _ := sl.FirstEntry(); first.Key != 1 {
+		t.Errorf("FirstEntry key = %d, want 1", first.Key)
+	}
+	if last, _ := sl.LastEntry(); last.Key != 20 {
+		t.Errorf("LastEntry key = %d, want 20", last.Key)
+	}
+	if c := sl.SeekGE(15); c.Entry() != (Entry[TestItem, int, TestContext]{Key: 15, Item: items[14], Context: TestContext{AccessCount: 15}}) {
+		t.Errorf("Cursor entry = %+v", c.Entry())
+	}
+
+	// The loop body may delete entries while iterating
+	want := 1
+	for e := range sl.Entries() {
+		if e.Key != want {
+			t.Fatalf("Entries yielded key %d, want %d", e.Key, want)
+		}
+		sl.Delete(e.Key)
+		if want++; want > 10 {
+			break
+		}
+	}
+	if sl.Length() != 10 {
+		t.Errorf("Length after deleting in the loop = %d, want 10", sl.Length())
+	}
+}
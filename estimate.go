@@ -0,0 +1,96 @@
+// estimate.go - Cheap range size estimates from the upper levels
+
+package zerocopyskiplist
+
+// estimateSamples is how many nodes in range EstimateRangeSize counts on a
+// level before it scales that count up instead of descending further
+const estimateSamples = 32
+
+// EstimateRangeSize estimates the number of items with min <= key <= max
+// without walking level 0, so callers can choose between point lookups and a
+// range scan or size result buffers before scanning. It searches down from
+// the top level, counting the range's nodes on each level until one holds at
+// least estimateSamples of them, and scales that count by the share of nodes
+// reaching the level. The cost is a search plus O(estimateSamples) steps, and
+// the error shrinks as the range grows; a range small enough to reach level 0
+// is counted exactly at little cost. The estimate is most accurate after
+// Rebalance.
+func (sl *ZeroCopySkiplist[T, K, C]) EstimateRangeSize(min, max K) int {
+	min, max = sl.normalize(min), sl.normalize(max)
+	sl.rlock()
+	defer sl.runlock()
+
+	if sl.cmpKey(min, max) > 0 {
+		return 0
+	}
+
+	current := sl.header
+	atOrAbove := 0
+	for i := sl.level; i >= 0; i-- {
+		atOrAbove += sl.levelCounts[i]
+		for current.forward[i] != nil && sl.cmpKey(current.forward[i].key, min) < 0 {
+			current = current.forward[i]
+		}
+
+		count := 0
+		for next := current.forward[i]; next != nil && sl.cmpKey(next.key, max) <= 0; next = next.forward[i] {
+			count++
+		}
+		if i == 0 {
+			return count
+		}
+		if count >= estimateSamples {
+			return count * sl.length / atOrAbove
+		}
+	}
+	return 0
+}
+
+// overlapSamples is how many keys EstimateOverlap samples from the list
+const overlapSamples = 256
+
+// EstimateOverlap estimates the Jaccard similarity of the key sets of sl and
+// other, the share of their combined keys that both hold: 0 for disjoint
+// lists, 1 for lists with the same keys. Use it to pick a merge strategy
+// before committing to one, such as streaming other into sl when they barely
+// overlap or rebuilding when they are nearly the same.
+//
+// It samples at least overlapSamples keys of sl from the lowest level holding
+// that many, whose nodes are a random sample of the list, and looks each up
+// in other, so it costs O(overlapSamples log n) rather than a walk of either
+// list; lists of fewer keys are compared exactly. The lists are read one
+// after the other, not as a single snapshot. Both lists must order keys the
+// same way.
+func (sl *ZeroCopySkiplist[T, K, C]) EstimateOverlap(other *ZeroCopySkiplist[T, K, C]) float64 {
+	sl.rlock()
+	n := sl.length
+	level := 0
+	for i, atOrAbove := sl.level, 0; i > 0; i-- {
+		atOrAbove += sl.levelCounts[i]
+		if atOrAbove >= overlapSamples {
+			level = i
+			break
+		}
+	}
+	var keys []K
+	for node := sl.header.forward[level]; node != nil; node = node.forward[level] {
+		keys = append(keys, node.key)
+	}
+	sl.runlock()
+
+	other.rlock()
+	m := other.length
+	found := 0
+	for _, key := range keys {
+		if node := other.seek(key, true); node != nil && other.cmpKey(node.key, key) == 0 {
+			found++
+		}
+	}
+	other.runlock()
+
+	if len(keys) == 0 || m == 0 {
+		return 0
+	}
+	common := min(float64(found)/float64(len(keys))*float64(n), float64(m))
+	return common / (float64(n+m) - common)
+}
@@ -0,0 +1,85 @@
+package zerocopyskiplist
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestEstimateRangeSize(t *testing.T) {
+	// Seeded geometric levels keep the estimates reproducible
+	rng := rand.New(rand.NewSource(5))
+	items := createTestItems(10000)
+	levels := make([]int, len(items))
+	for i := range levels {
+		for rng.Intn(2) == 0 {
+			levels[i]++
+		}
+	}
+	sl := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt, WithLevelSequence(levels))
+	for _, i := range rng.Perm(len(items)) {
+		sl.Insert(items[i], TestContext{})
+	}
+
+	// Small ranges are counted exactly on level 0
+	if got := sl.EstimateRangeSize(100, 109); got != 10 {
+		t.Errorf("EstimateRangeSize(100, 109) = %d, want 10", got)
+	}
+	if got := sl.EstimateRangeSize(20000, 30000); got != 0 {
+		t.Errorf("Range past the last key should be empty, got %d", got)
+	}
+	if got := sl.EstimateRangeSize(500, 100); got != 0 {
+		t.Errorf("Inverted range should be empty, got %d", got)
+	}
+
+	check := func(name string, tolerance float64) {
+		for _, r := range [][2]int{{1, 10000}, {1000, 5999}, {2500, 3499}, {7000, 7299}} {
+			want := r[1] - r[0] + 1
+			got := sl.EstimateRangeSize(r[0], r[1])
+			if diff := float64(got - want); diff > tolerance*float64(want) || -diff > tolerance*float64(want) {
+				t.Errorf("%s: EstimateRangeSize(%d, %d) = %d, want %d within %.0f%%", name, r[0], r[1], got, want, tolerance*100)
+			}
+		}
+	}
+	check("random levels", 0.5)
+	sl.Rebalance()
+	check("rebalanced", 0.1)
+}
+
+func TestEstimateOverlap(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	build := func(from, to int) *ZeroCopySkiplist[TestItem, int, TestContext] {
+		levels := make([]int, to-from)
+		for i := range levels {
+			for rng.Intn(2) == 0 {
+				levels[i]++
+			}
+		}
+		sl := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt, WithLevelSequence(levels))
+		for key := from; key < to; key++ {
+			sl.Insert(&TestItem{ID: key}, TestContext{})
+		}
+		return sl
+	}
+	a := build(0, 10000)
+
+	if got := a.EstimateOverlap(a.Copy()); got != 1 {
+		t.Errorf("Overlap with a copy = %v, want 1", got)
+	}
+	if got := a.EstimateOverlap(build(20000, 30000)); got != 0 {
+		t.Errorf("Overlap of disjoint lists = %v, want 0", got)
+	}
+	// Half of each list is shared: 5000 common of 15000 keys
+	if got := a.EstimateOverlap(build(5000, 15000)); got < 0.25 || got > 0.42 {
+		t.Errorf("Overlap of half shared lists = %v, want about 1/3", got)
+	}
+
+	// Small lists are compared exactly
+	small := build(0, 10)
+	if got := small.EstimateOverlap(build(5, 20)); got != 5.0/20 {
+		t.Errorf("Overlap of small lists = %v, want 0.25", got)
+	}
+	empty := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt)
+	if empty.EstimateOverlap(small) != 0 || small.EstimateOverlap(empty) != 0 {
+		t.Error("Overlap with an empty list should be 0")
+	}
+}
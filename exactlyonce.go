@@ -0,0 +1,113 @@
+// exactlyonce.go - Flushing every entry version exactly once
+
+package zerocopyskiplist
+
+import (
+	"syscall"
+	"time"
+)
+
+// FlushVersion is one version of an entry awaiting an exactly-once flush:
+// the entry as a write left it, stamped with the list's Generation just after
+// the write. A delete is recorded as a version with Deleted set, carrying the
+// item and context the entry had when it went.
+type FlushVersion[T any, K comparable, C comparable] struct {
+	Entry[T, K, C]
+	Generation uint64
+	Deleted    bool
+}
+
+// WithFlushExactlyOnce records every version of every entry, so that
+// FlushExactlyOnce can hand each one to the storage layer exactly once across
+// repeated flush cycles, however writes interleave with them. Each Insert,
+// replacement, UpdateContext and Delete through the list, including
+// evictions, moves out, BulkLoad and Clear, appends a version to a pending
+// log; ItemPtr.SetContext bypasses it. The log holds the item pointer of every
+// version, so superseded items stay alive until they have been flushed. Copies
+// of the list start with every entry pending, and SwapContents refuses lists
+// with this option.
+func WithFlushExactlyOnce() Option {
+	return func(o *options) {
+		o.flushExactlyOnce = true
+	}
+}
+
+// FlushExactlyOnce runs one flush cycle of a list built WithFlushExactlyOnce.
+// Under the write lock it takes every version recorded since the last
+// successful cycle, a copy-on-write snapshot that later writes cannot touch,
+// then calls flush with the versions in the order they were written and an
+// iovec per version over the version's own item, zero length for a delete,
+// without holding any lock. Writes made meanwhile go to the next cycle.
+//
+// If flush returns nil the versions are done and are never offered again. If
+// it returns an error the versions are put back ahead of any recorded since,
+// the error is returned, and the next cycle offers them again together with
+// the newer ones; flush must therefore discard whatever it wrote of a batch it
+// fails, for instance by writing at the same offset next time, for the
+// storage layer to see each version exactly once. Cycles are serialised, so
+// no version is in two flushes at once. The items must not be modified while
+// flush runs, which the package already requires of indexed items.
+//
+// The report counts the versions as Entries and the item bytes their iovecs
+// cover as Bytes, in one chunk, and its Generation is the list's at the
+// snapshot: every version stamped at or below it has now been flushed. A
+// failed cycle reports only its Duration.
+func (sl *ZeroCopySkiplist[T, K, C]) FlushExactlyOnce(flush func(versions []FlushVersion[T, K, C], iovecs []syscall.Iovec) error) (FlushReport[K], error) {
+	defer sl.endOp(OperationScan, sl.startOp())
+	sl.flushMu.Lock()
+	defer sl.flushMu.Unlock()
+
+	start := time.Now()
+	sl.lock()
+	versions := sl.flushLog
+	sl.flushLog = nil
+	generation := sl.version
+	changed := sl.startFlush()
+	sl.unlock()
+
+	report := FlushReport[K]{Entries: len(versions), Generation: generation}
+	iovecs := make([]syscall.Iovec, len(versions))
+	for i, v := range versions {
+		if v.Deleted {
+			continue
+		}
+		iovecs[i] = sl.iovec(v.Item)
+		report.Bytes += int64(iovecs[i].Len)
+	}
+	if len(versions) > 0 {
+		report.Chunks = 1
+	}
+
+	if err := flush(versions, iovecs); err != nil {
+		sl.lock()
+		sl.flushLog = append(versions, sl.flushLog...)
+		sl.amp.unflushed.Add(changed)
+		sl.unlock()
+		failed := FlushReport[K]{}
+		failed.finish(start)
+		return failed, err
+	}
+	sl.amp.flushed.Add(uint64(report.Bytes))
+	report.finish(start)
+	return report, nil
+}
+
+// PendingFlush returns the number of versions waiting for FlushExactlyOnce
+func (sl *ZeroCopySkiplist[T, K, C]) PendingFlush() int {
+	sl.rlock()
+	defer sl.runlock()
+	return len(sl.flushLog)
+}
+
+// recordVersion appends the current version of node to the pending flush log
+// if the list was built WithFlushExactlyOnce; the caller must hold the write
+// lock and have changed the generation for the write
+func (sl *ZeroCopySkiplist[T, K, C]) recordVersion(node *ItemPtr[T, K, C], deleted bool) {
+	if sl.opts.flushExactlyOnce {
+		sl.flushLog = append(sl.flushLog, FlushVersion[T, K, C]{
+			Entry:      Entry[T, K, C]{Key: node.key, Item: node.item, Context: node.context},
+			Generation: sl.version,
+			Deleted:    deleted,
+		})
+	}
+}
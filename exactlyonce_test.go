@@ -0,0 +1,147 @@
+package zerocopyskiplist
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+func TestFlushExactlyOnce(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt, WithFlushExactlyOnce())
+	items := createTestItems(3)
+	for _, item := range items {
+		skiplist.Insert(item, TestContext{})
+	}
+	skiplist.UpdateContext(2, TestContext{AccessCount: 7})
+	skiplist.Delete(3)
+
+	var got []FlushVersion[TestItem, int, TestContext]
+	cycle, err := skiplist.FlushExactlyOnce(func(versions []FlushVersion[TestItem, int, TestContext], iovecs []syscall.Iovec) error {
+		got = versions
+		if iovecs[0].Base != (*byte)(unsafe.Pointer(items[0])) || iovecs[4].Len != 0 {
+			t.Error("Iovecs should cover each version's item, empty for a delete")
+		}
+		return nil
+	})
+	if err != nil || cycle.Entries != 5 || cycle.Chunks != 1 || cycle.Bytes != 4*int64(getTestItemSize(items[0])) || cycle.Generation != skiplist.Generation() {
+		t.Fatalf("FlushExactlyOnce = %+v, %v", cycle, err)
+	}
+	if got[3].Key != 2 || got[3].Context.AccessCount != 7 || !got[4].Deleted || got[4].Key != 3 {
+		t.Errorf("Versions = %+v", got)
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i].Generation <= got[i-1].Generation {
+			t.Error("Versions should be stamped with increasing generations")
+		}
+	}
+
+	// A failed cycle offers its versions again, ahead of newer ones
+	skiplist.Insert(&TestItem{ID: 4}, TestContext{})
+	failure := errors.New("write failed")
+	if _, err := skiplist.FlushExactlyOnce(func([]FlushVersion[TestItem, int, TestContext], []syscall.Iovec) error { return failure }); !errors.Is(err, failure) {
+		t.Fatalf("Failed cycle returned %v", err)
+	}
+	skiplist.Delete(4)
+	skiplist.Clear()
+	cycle, _ = skiplist.FlushExactlyOnce(func(versions []FlushVersion[TestItem, int, TestContext], _ []syscall.Iovec) error {
+		got = versions
+		return nil
+	})
+	if cycle.Entries != 4 || got[0].Key != 4 || got[0].Deleted || !got[1].Deleted || !got[2].Deleted || !got[3].Deleted {
+		t.Errorf("Retried cycle = %+v", got)
+	}
+	if skiplist.PendingFlush() != 0 {
+		t.Error("Nothing should be pending after a successful cycle")
+	}
+}
+
+func TestFlushExactlyOnceConcurrent(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt, WithFlushExactlyOnce())
+
+	// Writers count the versions they create; every Insert makes one and
+	// every successful Delete one more
+	var writes atomic.Int64
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(seed))
+			for i := 0; i < 2000; i++ {
+				key := rng.Intn(50)
+				switch rng.Intn(3) {
+				case 0, 1:
+					skiplist.Insert(&TestItem{ID: key, Value: fmt.Sprint(rng.Intn(1000))}, TestContext{AccessCount: i})
+					writes.Add(1)
+				case 2:
+					if skiplist.Delete(key) {
+						writes.Add(1)
+					}
+				}
+			}
+		}(int64(w))
+	}
+
+	// The storage layer replays each version it accepts into its own image,
+	// failing a third of the cycles
+	seen := make(map[uint64]bool)
+	image := make(map[int]TestItem)
+	rng := rand.New(rand.NewSource(99))
+	flush := func(versions []FlushVersion[TestItem, int, TestContext], iovecs []syscall.Iovec) error {
+		if rng.Intn(3) == 0 {
+			return errors.New("injected failure")
+		}
+		for i, v := range versions {
+			if seen[v.Generation] {
+				t.Errorf("Version %d of key %d flushed twice", v.Generation, v.Key)
+			}
+			seen[v.Generation] = true
+			if v.Deleted {
+				delete(image, v.Key)
+				continue
+			}
+			if iovecs[i].Len != uint64(getTestItemSize(v.Item)) {
+				t.Errorf("Iovec %d has length %d", i, iovecs[i].Len)
+			}
+			image[v.Key] = *v.Item
+		}
+		return nil
+	}
+	var flusher sync.WaitGroup
+	flusher.Add(1)
+	go func() {
+		defer flusher.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				skiplist.FlushExactlyOnce(flush)
+			}
+		}
+	}()
+	wg.Wait()
+	close(stop)
+	flusher.Wait()
+	for skiplist.PendingFlush() > 0 {
+		skiplist.FlushExactlyOnce(flush)
+	}
+
+	if int64(len(seen)) != writes.Load() {
+		t.Errorf("Flushed %d versions, want the %d written", len(seen), writes.Load())
+	}
+	if len(image) != skiplist.Length() {
+		t.Errorf("Replayed image has %d entries, list has %d", len(image), skiplist.Length())
+	}
+	for entry := range skiplist.Entries() {
+		if image[entry.Key].Value != entry.Item.Value {
+			t.Errorf("Key %d replays as %+v, list holds %+v", entry.Key, image[entry.Key], *entry.Item)
+		}
+	}
+}
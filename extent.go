@@ -0,0 +1,76 @@
+// extent.go - Indexing items stored in caller-owned memory
+
+package zerocopyskiplist
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// Extent describes an item stored in memory the caller owns and manages,
+// such as a ring buffer, an mmap'd file or an OffHeap slab: Len bytes at Ptr.
+// A list of Extents, built with MakeExtentSkiplist, indexes that memory
+// without copying it, and its iovecs point straight at it rather than at the
+// Extent. The memory must stay valid and unchanged while its Extent is in the
+// list or referenced by an iovec; the list never frees it.
+type Extent struct {
+	Ptr unsafe.Pointer
+	Len int
+}
+
+// ExtentOf returns an Extent over b; b must not be resliced or reused while
+// the Extent is in use
+func ExtentOf(b []byte) *Extent {
+	return &Extent{Ptr: unsafe.Pointer(unsafe.SliceData(b)), Len: len(b)}
+}
+
+// ExtentFor returns an Extent over the memory of v
+func ExtentFor[V any](v *V) *Extent {
+	return &Extent{Ptr: unsafe.Pointer(v), Len: int(unsafe.Sizeof(*v))}
+}
+
+// Bytes returns the memory the Extent describes, without copying it
+func (e *Extent) Bytes() []byte {
+	return unsafe.Slice((*byte)(e.Ptr), e.Len)
+}
+
+// ExtentAs returns the memory e describes as a *V, for reading fixed layout
+// records in place. It panics if e is shorter than a V or not aligned for it.
+func ExtentAs[V any](e *Extent) *V {
+	var v V
+	if uintptr(e.Len) < unsafe.Sizeof(v) {
+		panic(fmt.Sprintf("extent of %d bytes is too short for %T", e.Len, v))
+	}
+	if uintptr(e.Ptr)%unsafe.Alignof(v) != 0 {
+		panic(fmt.Sprintf("extent at %p is misaligned for %T", e.Ptr, v))
+	}
+	return (*V)(e.Ptr)
+}
+
+// MakeExtentSkiplist creates a skiplist of Extents keyed by getKey, which
+// typically decodes the key from the Extent's bytes. Items are sized by
+// their Len, and every iovec the list produces, through CallbackToIovecSlice,
+// leases, grouping, sealing or FlushExactlyOnce, covers the external memory
+// itself, so a flush writes the caller's buffers with no copy. The Extent
+// descriptors are small Go allocations; the data they describe is never
+// scanned by the garbage collector unless it lives on the Go heap.
+func MakeExtentSkiplist[K comparable, C comparable](
+	maxLevel int,
+	getKey func(*Extent) K,
+	cmpKey func(K, K) int,
+	opts ...Option,
+) *ZeroCopySkiplist[Extent, K, C] {
+	sl := MakeZeroCopySkiplist[Extent, K, C](maxLevel, getKey, extentLen, cmpKey, opts...)
+	sl.itemBase = extentBase
+	return sl
+}
+
+// extentLen is the item size of an Extent
+func extentLen(e *Extent) int {
+	return e.Len
+}
+
+// extentBase is the item memory of an Extent
+func extentBase(e *Extent) unsafe.Pointer {
+	return e.Ptr
+}
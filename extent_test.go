@@ -0,0 +1,94 @@
+package zerocopyskiplist
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"unsafe"
+)
+
+func TestExtentSkiplist(t *testing.T) {
+	// Records of varying length in one caller-owned buffer, each starting
+	// with its little-endian key
+	ring := make([]byte, 4096)
+	skiplist := MakeExtentSkiplist[uint64, TestContext](8,
+		func(e *Extent) uint64 { return binary.LittleEndian.Uint64(e.Bytes()) },
+		func(a, b uint64) int { return compareInt(int(a), int(b)) })
+	offset := 0
+	for _, key := range []uint64{30, 10, 20} {
+		record := ring[offset : offset+8+int(key)]
+		binary.LittleEndian.PutUint64(record, key)
+		for i := 8; i < len(record); i++ {
+			record[i] = byte(key)
+		}
+		skiplist.Insert(ExtentOf(record), TestContext{})
+		offset += len(record)
+	}
+	if skiplist.TotalItemBytes() != int64(offset) {
+		t.Errorf("TotalItemBytes = %d, want the %d bytes described", skiplist.TotalItemBytes(), offset)
+	}
+
+	iovecs := skiplist.CallbackToIovecSlice(func(*ItemPtr[Extent, uint64, TestContext]) bool { return true })
+	if len(iovecs) != 3 || iovecs[0].Base != &ring[38] || iovecs[0].Len != 18 {
+		t.Fatalf("Iovecs should point into the caller's buffer in key order, got %+v", iovecs)
+	}
+	if sealed := skiplist.Seal().ToIovecSlice(); sealed[2].Base != &ring[0] {
+		t.Error("Sealed iovecs should point into the caller's buffer")
+	}
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "extents"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := skiplist.NewIovecWriter(f.Fd(), iovecs, nil).Flush(); err != nil {
+		t.Fatal(err)
+	}
+	written, _ := os.ReadFile(f.Name())
+	want := append(append(append([]byte(nil), ring[38:56]...), ring[56:84]...), ring[:38]...)
+	if !bytes.Equal(written, want) {
+		t.Error("Flushed bytes differ from the buffer's records in key order")
+	}
+
+	// The copy keeps pointing at the external memory
+	if skiplist.Copy().CallbackToIovecSlice(func(*ItemPtr[Extent, uint64, TestContext]) bool { return true })[1].Base != &ring[56] {
+		t.Error("Copies should produce iovecs over the external memory")
+	}
+}
+
+func TestExtentAccessors(t *testing.T) {
+	arena, err := NewOffHeap(4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer arena.Close()
+	record, err := OffHeapNew[OffHeapRecord](arena)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record.ID = 42
+
+	extent := ExtentFor(record)
+	if extent.Len != int(unsafe.Sizeof(*record)) || ExtentAs[OffHeapRecord](extent) != record {
+		t.Error("ExtentFor and ExtentAs should round trip the record in place")
+	}
+	if ExtentAs[OffHeapRecord](extent).ID != 42 {
+		t.Error("ExtentAs should read the record's fields")
+	}
+
+	for name, bad := range map[string]*Extent{
+		"short":      {Ptr: extent.Ptr, Len: 8},
+		"misaligned": {Ptr: unsafe.Add(extent.Ptr, 1), Len: extent.Len},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("ExtentAs of a %s extent should panic", name)
+				}
+			}()
+			ExtentAs[OffHeapRecord](bad)
+		}()
+	}
+}
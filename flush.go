@@ -0,0 +1,264 @@
+// flush.go - Codec-based flushing of items in a portable layout
+
+package zerocopyskiplist
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"syscall"
+	"time"
+)
+
+// ErrSkipItem may be returned, possibly wrapped, by a codec's Encode to leave
+// an item out of a flush without failing it; the item's key is reported in
+// FlushReport.Skipped
+var ErrSkipItem = errors.New("skip item")
+
+// FlushReport summarises a completed or failed flush for logging and metrics
+type FlushReport[K comparable] struct {
+	Entries    int           // records written, versions for exactly-once flushes
+	Bytes      int64         // bytes written, including record framing
+	Chunks     int           // writes issued to the destination
+	Duration   time.Duration // wall time of the whole flush
+	Throughput float64       // Bytes per second over Duration
+	Skipped    []K           // keys whose Encode returned ErrSkipItem
+	Generation uint64        // exactly-once flushes: every version stamped at or below it is flushed
+}
+
+// finish stamps the duration and throughput of a flush that began at start
+func (r *FlushReport[K]) finish(start time.Time) {
+	r.Duration = time.Since(start)
+	r.rate()
+}
+
+// rate sets Throughput from Bytes and Duration
+func (r *FlushReport[K]) rate() {
+	if r.Duration > 0 {
+		r.Throughput = float64(r.Bytes) / r.Duration.Seconds()
+	}
+}
+
+// CallbackToEncoded writes the items that match the callback filter to w in key
+// order, each encoded by codec and framed by its length as a little-endian
+// uint32, and reports what was written. The report is filled in as far as the
+// flush got when an error is returned.
+//
+// This is the portable counterpart of CallbackToIovecSlice: the output layout
+// is defined by the codec rather than by Go's in-memory struct layout, so it
+// can be read on another architecture or by another release. The iovec path
+// remains the fast option when the reader shares the writer's architecture.
+//
+// Items are snapshotted in batches exactly as for CallbackToIovecSlice, and
+// no lock is held while the callback runs or while w is written.
+func (sl *ZeroCopySkiplist[T, K, C]) CallbackToEncoded(w io.Writer, codec ItemCodec[T, C], callback func(*ItemPtr[T, K, C]) bool) (FlushReport[K], error) {
+	return sl.CallbackToEncodedIn(w, codec, Ascending, callback)
+}
+
+// CallbackToEncodedIn is CallbackToEncoded walking the list in dir, so a
+// Descending flush writes the records from the last key to the first without
+// gathering and reversing them first
+func (sl *ZeroCopySkiplist[T, K, C]) CallbackToEncodedIn(w io.Writer, codec ItemCodec[T, C], dir Direction, callback func(*ItemPtr[T, K, C]) bool) (FlushReport[K], error) {
+	var report FlushReport[K]
+	start := time.Now()
+	sl.startFlush()
+
+	// With context stats, remember the contexts of the records written
+	var flushed, chunk map[C]struct{}
+	if sl.contextStats != nil {
+		flushed, chunk = make(map[C]struct{}), make(map[C]struct{})
+		defer func() { sl.markFlushed(maps.Keys(flushed)) }()
+	}
+
+	var buf []byte
+	batch := make([]ItemPtr[T, K, C], 0, callbackBatchSize)
+	var lastKey K
+	resume := false
+	for {
+		batch = sl.snapshotIn(dir, batch[:0], lastKey, resume)
+		buf = buf[:0]
+		entries := 0
+		clear(chunk)
+		for i := range batch {
+			if !callback(&batch[i]) {
+				continue
+			}
+			encoded, err := appendRecord(buf, codec, batch[i].item, batch[i].context)
+			if errors.Is(err, ErrSkipItem) {
+				report.Skipped = append(report.Skipped, batch[i].key)
+				continue
+			}
+			if err != nil {
+				report.finish(start)
+				return report, fmt.Errorf("encoding key %v: %w", batch[i].key, err)
+			}
+			buf = encoded
+			entries++
+			if chunk != nil {
+				chunk[batch[i].context] = struct{}{}
+			}
+		}
+
+		if len(buf) > 0 {
+			n, err := w.Write(buf)
+			report.Bytes += int64(n)
+			sl.amp.flushed.Add(uint64(n))
+			report.Chunks++
+			if err != nil {
+				report.finish(start)
+				return report, err
+			}
+			report.Entries += entries
+			maps.Copy(flushed, chunk)
+		}
+
+		if len(batch) < callbackBatchSize {
+			report.finish(start)
+			return report, nil
+		}
+		lastKey = batch[len(batch)-1].key
+		resume = true
+	}
+}
+
+// ToEncoded writes every item to w as CallbackToEncoded does
+func (sl *ZeroCopySkiplist[T, K, C]) ToEncoded(w io.Writer, codec ItemCodec[T, C]) (FlushReport[K], error) {
+	return sl.CallbackToEncoded(w, codec, func(*ItemPtr[T, K, C]) bool {
+		return true
+	})
+}
+
+// DecodeEncoded reads records written by CallbackToEncoded from r, decoding
+// each with codec and passing it to fn, until r is exhausted or fn returns an
+// error. A record truncated by the end of r is reported as io.ErrUnexpectedEOF,
+// and a tombstone record as an error: read streams that may hold them with
+// DecodeEncodedTombstones.
+func DecodeEncoded[T any, C comparable](r io.Reader, codec ItemCodec[T, C], fn func(item *T, context C) error) error {
+	return decodeEncoded(r, codec, fn, nil)
+}
+
+// DecodeEncodedTombstones reads records written by CallbackToEncoded or
+// FlushEncodedExactlyOnce from r in order, passing data records to fn as
+// DecodeEncoded does and the keys of tombstone records, decoded with keys,
+// to deleted
+func DecodeEncodedTombstones[T any, K comparable, C comparable](r io.Reader, codec ItemCodec[T, C], keys KeyCodec[K], fn func(item *T, context C) error, deleted func(key K) error) error {
+	return decodeEncoded(r, codec, fn, func(raw []byte) error {
+		key, err := keys.DecodeKey(raw)
+		if err != nil {
+			return err
+		}
+		return deleted(key)
+	})
+}
+
+// decodeEncoded performs DecodeEncoded, passing the bodies of tombstone
+// records to tombstone, or failing on them if it is nil
+func decodeEncoded[T any, C comparable](r io.Reader, codec ItemCodec[T, C], fn func(item *T, context C) error, tombstone func(raw []byte) error) error {
+	in := bufio.NewReader(r)
+	var buf []byte
+	for {
+		var prefix [4]byte
+		if _, err := io.ReadFull(in, prefix[:]); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		size := binary.LittleEndian.Uint32(prefix[:])
+		deletion := size&encodedTombstone != 0
+		if deletion && tombstone == nil {
+			return errors.New("tombstone record in a stream without tombstone support")
+		}
+		size &^= encodedTombstone
+		if uint64(cap(buf)) < uint64(size) {
+			buf = make([]byte, size)
+		}
+		buf = buf[:size]
+		if _, err := io.ReadFull(in, buf); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+
+		if deletion {
+			if err := tombstone(buf); err != nil {
+				return err
+			}
+			continue
+		}
+		item, context, err := codec.Decode(buf)
+		if err != nil {
+			return err
+		}
+		if err := fn(item, context); err != nil {
+			return err
+		}
+	}
+}
+
+// encodedTombstone is set in the length prefix of a tombstone record in an
+// encoded stream, above any data record's length
+const encodedTombstone = 1 << 31
+
+// appendTombstone appends a tombstone record for key, encoded with keys, to dst
+func appendTombstone[K comparable](dst []byte, keys KeyCodec[K], key K) []byte {
+	start := len(dst)
+	dst = keys.AppendKey(append(dst, 0, 0, 0, 0), key)
+	binary.LittleEndian.PutUint32(dst[start:], uint32(len(dst)-start-4)|encodedTombstone)
+	return dst
+}
+
+// FlushEncodedExactlyOnce runs a FlushExactlyOnce cycle of a list built
+// WithFlushExactlyOnce that writes the pending versions to w as encoded
+// records, in the order they were written. Inserts, replacements and context
+// updates are written as CallbackToEncoded writes items; deletes, which an
+// item flush cannot express, become tombstone records holding the key
+// encoded with keys, so a consumer applying the stream in order with
+// DecodeEncodedTombstones ends up with the list's entries. Versions whose
+// Encode returns ErrSkipItem are left out.
+//
+// The cycle's records go to w in one Write. If it fails, or a version cannot
+// be encoded, the versions are put back for the next cycle as FlushExactlyOnce
+// describes, so w must discard a partial write. The report counts the
+// encoded bytes and records written and the keys of skipped versions.
+func (sl *ZeroCopySkiplist[T, K, C]) FlushEncodedExactlyOnce(w io.Writer, codec ItemCodec[T, C], keys KeyCodec[K]) (FlushReport[K], error) {
+	var written FlushReport[K]
+	report, err := sl.FlushExactlyOnce(func(versions []FlushVersion[T, K, C], _ []syscall.Iovec) error {
+		written = FlushReport[K]{}
+		var buf []byte
+		for _, v := range versions {
+			if v.Deleted {
+				buf = appendTombstone(buf, keys, v.Key)
+				written.Entries++
+				continue
+			}
+			encoded, err := appendRecord(buf, codec, v.Item, v.Context)
+			if errors.Is(err, ErrSkipItem) {
+				written.Skipped = append(written.Skipped, v.Key)
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("encoding key %v: %w", v.Key, err)
+			}
+			buf = encoded
+			written.Entries++
+		}
+		if len(buf) == 0 {
+			return nil
+		}
+		n, err := w.Write(buf)
+		written.Bytes = int64(n)
+		written.Chunks = 1
+		return err
+	})
+	if err != nil {
+		return report, err
+	}
+	report.Entries, report.Bytes, report.Chunks, report.Skipped = written.Entries, written.Bytes, written.Chunks, written.Skipped
+	report.rate()
+	return report, nil
+}
@@ -0,0 +1,241 @@
+package zerocopyskiplist
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"testing"
+	"unsafe"
+)
+
+// PaddedRecord has padding between its fields in memory
+type PaddedRecord struct {
+	Flag bool
+	ID   int64
+	Kind uint16
+}
+
+// PaddedContext is a fixed size context
+type PaddedContext struct {
+	Generation uint32
+}
+
+func newPaddedList() *ZeroCopySkiplist[PaddedRecord, int64, PaddedContext] {
+	return MakeOrderedZeroCopySkiplist[PaddedRecord, int64, PaddedContext](
+		8,
+		func(r *PaddedRecord) int64 { return r.ID },
+		func(r *PaddedRecord) int { return int(unsafe.Sizeof(*r)) },
+	)
+}
+
+func TestLittleEndianCodecLayout(t *testing.T) {
+	codec, err := NewLittleEndianCodec[PaddedRecord, PaddedContext](3)
+	if err != nil {
+		t.Fatalf("NewLittleEndianCodec: %v", err)
+	}
+
+	encoded, err := codec.Encode(nil, &PaddedRecord{Flag: true, ID: 0x0102030405060708, Kind: 0x0a0b}, PaddedContext{Generation: 0x11223344})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	want := []byte{1, 8, 7, 6, 5, 4, 3, 2, 1, 0x0b, 0x0a, 0x44, 0x33, 0x22, 0x11}
+	if !bytes.Equal(encoded, want) {
+		t.Errorf("Expected packed little-endian layout %x, got %x", want, encoded)
+	}
+
+	item, ctx, err := codec.Decode(encoded)
+	if err != nil || item.ID != 0x0102030405060708 || !item.Flag || item.Kind != 0x0a0b || ctx.Generation != 0x11223344 {
+		t.Errorf("Decode returned %+v %+v %v", item, ctx, err)
+	}
+
+	if _, err := NewLittleEndianCodec[TestItem, PaddedContext](1); err == nil {
+		t.Error("Expected an error for a variable size item type")
+	}
+	if _, err := NewLittleEndianCodec[PaddedRecord, TestContext](1); err == nil {
+		t.Error("Expected an error for a variable size context type")
+	}
+}
+
+func TestCallbackToEncoded(t *testing.T) {
+	codec, err := NewLittleEndianCodec[PaddedRecord, PaddedContext](1)
+	if err != nil {
+		t.Fatalf("NewLittleEndianCodec: %v", err)
+	}
+
+	// Enough items to span several snapshot batches
+	skiplist := newPaddedList()
+	for i := int64(1); i <= 1000; i++ {
+		skiplist.Insert(&PaddedRecord{ID: i, Kind: uint16(i % 7)}, PaddedContext{Generation: uint32(i)})
+	}
+
+	var buf bytes.Buffer
+	report, err := skiplist.CallbackToEncoded(&buf, codec, func(ip *ItemPtr[PaddedRecord, int64, PaddedContext]) bool {
+		return ip.Key()%2 == 0
+	})
+	if err != nil {
+		t.Fatalf("CallbackToEncoded: %v", err)
+	}
+	if n := report.Bytes; n != 500*(4+15) || int64(buf.Len()) != n {
+		t.Errorf("Expected %d bytes written, got %d (buffer %d)", 500*(4+15), n, buf.Len())
+	}
+	if report.Entries != 500 || report.Chunks != 4 || len(report.Skipped) != 0 {
+		t.Errorf("Expected 500 entries in 4 chunks, got %+v", report)
+	}
+	if report.Duration <= 0 || report.Throughput <= 0 {
+		t.Errorf("Expected a positive duration and throughput, got %v and %v", report.Duration, report.Throughput)
+	}
+	if size := binary.LittleEndian.Uint32(buf.Bytes()); size != 15 {
+		t.Errorf("Expected a 15 byte first record, got %d", size)
+	}
+
+	var next int64 = 2
+	err = DecodeEncoded(bytes.NewReader(buf.Bytes()), codec, func(item *PaddedRecord, ctx PaddedContext) error {
+		if item.ID != next || item.Kind != uint16(next%7) || ctx.Generation != uint32(next) {
+			t.Errorf("Expected record %d, got %+v %+v", next, item, ctx)
+		}
+		next += 2
+		return nil
+	})
+	if err != nil || next != 1002 {
+		t.Errorf("DecodeEncoded stopped at %d: %v", next, err)
+	}
+
+	truncated := buf.Bytes()[:buf.Len()-1]
+	err = DecodeEncoded(bytes.NewReader(truncated), codec, func(*PaddedRecord, PaddedContext) error { return nil })
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected io.ErrUnexpectedEOF for a truncated stream, got %v", err)
+	}
+}
+
+// skipOddCodec leaves records with odd IDs out of a flush
+type skipOddCodec struct {
+	*LittleEndianCodec[PaddedRecord, PaddedContext]
+}
+
+func (c skipOddCodec) Encode(dst []byte, item *PaddedRecord, context PaddedContext) ([]byte, error) {
+	if item.ID%2 == 1 {
+		return nil, fmt.Errorf("record %d: %w", item.ID, ErrSkipItem)
+	}
+	return c.LittleEndianCodec.Encode(dst, item, context)
+}
+
+func TestToEncodedSkipped(t *testing.T) {
+	base, err := NewLittleEndianCodec[PaddedRecord, PaddedContext](1)
+	if err != nil {
+		t.Fatalf("NewLittleEndianCodec: %v", err)
+	}
+	skiplist := newPaddedList()
+	for i := int64(1); i <= 10; i++ {
+		skiplist.Insert(&PaddedRecord{ID: i}, PaddedContext{})
+	}
+
+	var buf bytes.Buffer
+	report, err := skiplist.ToEncoded(&buf, skipOddCodec{base})
+	if err != nil {
+		t.Fatalf("ToEncoded: %v", err)
+	}
+	if report.Entries != 5 || report.Bytes != int64(buf.Len()) || !slices.Equal(report.Skipped, []int64{1, 3, 5, 7, 9}) {
+		t.Errorf("Unexpected report %+v", report)
+	}
+
+	var ids []int64
+	DecodeEncoded(bytes.NewReader(buf.Bytes()), base, func(item *PaddedRecord, _ PaddedContext) error {
+		ids = append(ids, item.ID)
+		return nil
+	})
+	if !slices.Equal(ids, []int64{2, 4, 6, 8, 10}) {
+		t.Errorf("Decoded IDs %v, want the even ones", ids)
+	}
+}
+
+func TestFlushEncodedExactlyOnce(t *testing.T) {
+	codec, err := NewLittleEndianCodec[PaddedRecord, PaddedContext](1)
+	if err != nil {
+		t.Fatalf("NewLittleEndianCodec: %v", err)
+	}
+	skiplist := MakeZeroCopySkiplist[PaddedRecord, int, PaddedContext](
+		8,
+		func(r *PaddedRecord) int { return int(r.ID) },
+		func(r *PaddedRecord) int { return int(unsafe.Sizeof(*r)) },
+		compareInt,
+		WithFlushExactlyOnce(),
+	)
+
+	// A consumer applies each cycle's stream to its own copy
+	applied := make(map[int]PaddedRecord)
+	apply := func(stream []byte) {
+		t.Helper()
+		err := DecodeEncodedTombstones(bytes.NewReader(stream), codec, intKeys{},
+			func(item *PaddedRecord, _ PaddedContext) error {
+				applied[int(item.ID)] = *item
+				return nil
+			},
+			func(key int) error {
+				if _, ok := applied[key]; !ok {
+					t.Errorf("Tombstone for %d, which the consumer never saw", key)
+				}
+				delete(applied, key)
+				return nil
+			})
+		if err != nil {
+			t.Fatalf("DecodeEncodedTombstones: %v", err)
+		}
+		if len(applied) != skiplist.Length() {
+			t.Fatalf("Consumer holds %d entries, the list %d", len(applied), skiplist.Length())
+		}
+		for entry := range skiplist.Entries() {
+			if applied[entry.Key] != *entry.Item {
+				t.Errorf("Consumer has %+v for %d, the list %+v", applied[entry.Key], entry.Key, *entry.Item)
+			}
+		}
+	}
+
+	for i := int64(1); i <= 10; i++ {
+		skiplist.Insert(&PaddedRecord{ID: i}, PaddedContext{})
+	}
+	skiplist.Delete(3)
+	skiplist.Insert(&PaddedRecord{ID: 5, Kind: 9}, PaddedContext{})
+	var stream bytes.Buffer
+	cycle, err := skiplist.FlushEncodedExactlyOnce(&stream, codec, intKeys{})
+	if err != nil || cycle.Entries != 12 || cycle.Bytes != int64(stream.Len()) || cycle.Chunks != 1 {
+		t.Fatalf("FlushEncodedExactlyOnce = %+v, %v", cycle, err)
+	}
+	apply(stream.Bytes())
+
+	// Item-only readers refuse streams with tombstones
+	if err := DecodeEncoded(bytes.NewReader(stream.Bytes()), codec, func(*PaddedRecord, PaddedContext) error { return nil }); err == nil {
+		t.Error("DecodeEncoded should fail on a tombstone record")
+	}
+
+	// Later cycles carry only the new versions
+	skiplist.Delete(7)
+	skiplist.Delete(8)
+	skiplist.Insert(&PaddedRecord{ID: 8, Kind: 1}, PaddedContext{})
+	stream.Reset()
+	if cycle, err := skiplist.FlushEncodedExactlyOnce(&stream, codec, intKeys{}); err != nil || cycle.Entries != 3 {
+		t.Fatalf("Second cycle = %+v, %v", cycle, err)
+	}
+	apply(stream.Bytes())
+
+	// A failed write leaves the versions pending
+	skiplist.Delete(1)
+	failure := errors.New("disk full")
+	if _, err := skiplist.FlushEncodedExactlyOnce(failingWriter{failure}, codec, intKeys{}); !errors.Is(err, failure) || skiplist.PendingFlush() != 1 {
+		t.Errorf("Failed cycle = %v with %d pending", err, skiplist.PendingFlush())
+	}
+	stream.Reset()
+	skiplist.FlushEncodedExactlyOnce(&stream, codec, intKeys{})
+	apply(stream.Bytes())
+}
+
+// failingWriter fails every write
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write([]byte) (int, error) {
+	return 0, w.err
+}
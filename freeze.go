@@ -0,0 +1,37 @@
+// freeze.go - Rejecting mutations while the structure must stay stable
+
+package zerocopyskiplist
+
+import "errors"
+
+// ErrFrozen is returned by mutations of a frozen list that report errors
+var ErrFrozen = errors.New("skiplist is frozen")
+
+// Freeze makes the list reject every mutation until Thaw is called, while
+// reads, scans and flushes carry on. It takes the write lock, so it waits for
+// mutations already in progress and none can slip in after it returns: the
+// structure is stable for a final flush and checksum.
+//
+// BulkLoad, LoadSnapshot and Merge return ErrFrozen. Insert, Delete,
+// UpdateContext and MoveTo return false, ShedOldest frees nothing and Clear
+// does nothing. ItemPtr.SetContext on a live node bypasses the list and is not
+// prevented.
+func (sl *ZeroCopySkiplist[T, K, C]) Freeze() {
+	sl.lock()
+	defer sl.unlock()
+	sl.frozen = true
+}
+
+// Thaw lets a frozen list accept mutations again
+func (sl *ZeroCopySkiplist[T, K, C]) Thaw() {
+	sl.lock()
+	defer sl.unlock()
+	sl.frozen = false
+}
+
+// Frozen returns true between Freeze and Thaw
+func (sl *ZeroCopySkiplist[T, K, C]) Frozen() bool {
+	sl.rlockFlat()
+	defer sl.runlock()
+	return sl.frozen
+}
@@ -0,0 +1,94 @@
+package zerocopyskiplist
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestFreeze(t *testing.T) {
+	sl := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	other := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	items := createTestItems(20)
+	for _, item := range items[:10] {
+		sl.Insert(item, TestContext{})
+	}
+	other.Insert(items[10], TestContext{})
+
+	var snapshot bytes.Buffer
+	if err := sl.SaveSnapshot(&snapshot, testItemCodecV1{}); err != nil {
+		t.Fatalf("SaveSnapshot: %v", err)
+	}
+
+	sl.Freeze()
+	if !sl.Frozen() {
+		t.Fatal("Frozen should report true after Freeze")
+	}
+	if sl.Insert(items[15], TestContext{}) || sl.Delete(1) || sl.UpdateContext(2, TestContext{IsCached: true}) {
+		t.Error("Mutations of a frozen list should report false")
+	}
+	if sl.MoveTo(other, 3) || other.MoveTo(sl, 11) {
+		t.Error("MoveTo should not move entries into or out of a frozen list")
+	}
+	if _, err := sl.BulkLoad(items[15:], nil); !errors.Is(err, ErrFrozen) {
+		t.Errorf("BulkLoad = %v, want ErrFrozen", err)
+	}
+	if err := sl.Merge(other, MergeTheirs); !errors.Is(err, ErrFrozen) {
+		t.Errorf("Merge = %v, want ErrFrozen", err)
+	}
+	if err := sl.LoadSnapshot(bytes.NewReader(snapshot.Bytes()), testItemCodecV1{}); !errors.Is(err, ErrFrozen) {
+		t.Errorf("LoadSnapshot = %v, want ErrFrozen", err)
+	}
+	if freed, n := sl.ShedOldest(1 << 20); freed != 0 || n != 0 {
+		t.Error("ShedOldest should free nothing from a frozen list")
+	}
+	sl.Clear()
+
+	// Reads carry on
+	if sl.Length() != 10 || other.Length() != 1 || sl.FindItem(1) == nil {
+		t.Fatalf("Frozen list changed: length %d", sl.Length())
+	}
+	if _, ctx := sl.Find(2); ctx.IsCached {
+		t.Error("UpdateContext changed a frozen list")
+	}
+	if err := sl.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+
+	sl.Thaw()
+	if sl.Frozen() || !sl.Insert(items[15], TestContext{}) || !sl.Delete(1) {
+		t.Error("A thawed list should accept mutations again")
+	}
+}
+
+func TestFreezeWaitsForWriters(t *testing.T) {
+	sl := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	items := createTestItems(2000)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	accepted := 0
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := g; i < len(items); i += 4 {
+				if sl.Insert(items[i], TestContext{}) {
+					mu.Lock()
+					accepted++
+					mu.Unlock()
+				}
+			}
+		}(g)
+	}
+
+	sl.Freeze()
+	frozenLength := sl.Length()
+	wg.Wait()
+
+	// Every insert that reported success landed before the freeze
+	if sl.Length() != frozenLength || accepted != frozenLength {
+		t.Errorf("Length %d after writers finished, %d at freeze, %d inserts accepted", sl.Length(), frozenLength, accepted)
+	}
+}
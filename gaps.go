@@ -0,0 +1,141 @@
+// gaps.go - Distribution of the distances between adjacent numeric keys
+
+package zerocopyskiplist
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"reflect"
+	"unsafe"
+)
+
+// ErrKeyNotNumeric is returned by GapStats for lists whose keys are not
+// integers or floats
+var ErrKeyNotNumeric = errors.New("keys are not numeric")
+
+// gapBuckets is the number of buckets in GapStats.Buckets
+const gapBuckets = 65
+
+// GapStats is the distribution of the distances between adjacent keys, for
+// choosing shard boundaries and spotting dense key ranges
+type GapStats struct {
+	Count int     // gaps measured, one fewer than the keys
+	Min   float64 // smallest gap
+	Max   float64 // largest gap
+	Mean  float64
+	// Buckets is an exponential histogram: Buckets[0] counts gaps below 1
+	// and Buckets[i] gaps in [2^(i-1), 2^i), the last bucket also holding
+	// gaps of 2^64 and above
+	Buckets [gapBuckets]int
+}
+
+// GapStats measures the distance between every pair of adjacent keys,
+// returning ErrKeyNotNumeric unless K's underlying type is an integer or
+// float. Integer gaps are exact up to 2^53. The list is scanned in batches as
+// for ForEach, so the result is not an atomic snapshot of a list being
+// written concurrently.
+func (sl *ZeroCopySkiplist[T, K, C]) GapStats() (GapStats, error) {
+	gap := keyGap[K]()
+	if gap == nil {
+		return GapStats{}, ErrKeyNotNumeric
+	}
+
+	var stats GapStats
+	var prev K
+	first := true
+	sum := 0.0
+	sl.ForEach(func(ip *ItemPtr[T, K, C]) bool {
+		if first {
+			prev, first = ip.key, false
+			return true
+		}
+		g := gap(prev, ip.key)
+		prev = ip.key
+
+		if stats.Count == 0 || g < stats.Min {
+			stats.Min = g
+		}
+		stats.Max = max(stats.Max, g)
+		sum += g
+		stats.Count++
+		stats.Buckets[gapBucket(g)]++
+		return true
+	})
+	if stats.Count > 0 {
+		stats.Mean = sum / float64(stats.Count)
+	}
+	return stats, nil
+}
+
+// gapBucket returns the GapStats bucket of gap g
+func gapBucket(g float64) int {
+	switch {
+	case g < 1:
+		return 0
+	case g >= math.Exp2(64):
+		return gapBuckets - 1
+	}
+	return bits.Len64(uint64(g))
+}
+
+// keyGap returns a function measuring the absolute distance between two keys
+// of type K, or nil if K is not numeric. Integers are subtracted in 64 bits so
+// the gap between the extremes of a signed type does not overflow.
+func keyGap[K any]() func(a, b K) float64 {
+	t := reflect.TypeFor[K]()
+	size := t.Size()
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(a, b K) float64 {
+			x, y := signedKey(unsafe.Pointer(&a), size), signedKey(unsafe.Pointer(&b), size)
+			if x > y {
+				x, y = y, x
+			}
+			return float64(uint64(y) - uint64(x))
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(a, b K) float64 {
+			x, y := unsignedKey(unsafe.Pointer(&a), size), unsignedKey(unsafe.Pointer(&b), size)
+			if x > y {
+				x, y = y, x
+			}
+			return float64(y - x)
+		}
+	case reflect.Float32:
+		return func(a, b K) float64 {
+			return math.Abs(float64(*(*float32)(unsafe.Pointer(&b)) - *(*float32)(unsafe.Pointer(&a))))
+		}
+	case reflect.Float64:
+		return func(a, b K) float64 {
+			return math.Abs(*(*float64)(unsafe.Pointer(&b)) - *(*float64)(unsafe.Pointer(&a)))
+		}
+	}
+	return nil
+}
+
+// signedKey reads a signed integer of size bytes at p
+func signedKey(p unsafe.Pointer, size uintptr) int64 {
+	switch size {
+	case 1:
+		return int64(*(*int8)(p))
+	case 2:
+		return int64(*(*int16)(p))
+	case 4:
+		return int64(*(*int32)(p))
+	}
+	return *(*int64)(p)
+}
+
+// unsignedKey reads an unsigned integer of size bytes at p
+func unsignedKey(p unsafe.Pointer, size uintptr) uint64 {
+	switch size {
+	case 1:
+		return uint64(*(*uint8)(p))
+	case 2:
+		return uint64(*(*uint16)(p))
+	case 4:
+		return uint64(*(*uint32)(p))
+	}
+	return *(*uint64)(p)
+}
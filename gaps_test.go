@@ -0,0 +1,55 @@
+package zerocopyskiplist
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGapStats(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt)
+	if stats, err := skiplist.GapStats(); err != nil || stats.Count != 0 {
+		t.Errorf("Empty list GapStats = %+v, %v", stats, err)
+	}
+
+	// Gaps of 1, 1, 2, 4 and 100
+	for _, id := range []int{-4, -3, -2, 0, 4, 104} {
+		skiplist.Insert(&TestItem{ID: id}, TestContext{})
+	}
+	stats, err := skiplist.GapStats()
+	if err != nil {
+		t.Fatalf("GapStats: %v", err)
+	}
+	if stats.Count != 5 || stats.Min != 1 || stats.Max != 100 || stats.Mean != 108.0/5 {
+		t.Errorf("GapStats = %+v, want 5 gaps from 1 to 100 averaging 21.6", stats)
+	}
+	want := map[int]int{1: 2, 2: 1, 3: 1, 7: 1}
+	for i, n := range stats.Buckets {
+		if n != want[i] {
+			t.Errorf("Buckets[%d] = %d, want %d", i, n, want[i])
+		}
+	}
+}
+
+func TestGapStatsKeyTypes(t *testing.T) {
+	signed := MakeOrderedZeroCopySkiplist[int8, int8, struct{}](8, func(k *int8) int8 { return *k }, func(*int8) int { return 1 })
+	for _, k := range []int8{math.MinInt8, math.MaxInt8} {
+		signed.Insert(&k, struct{}{})
+	}
+	if stats, err := signed.GapStats(); err != nil || stats.Max != 255 {
+		t.Errorf("int8 extremes GapStats = %+v, %v; want a gap of 255", stats, err)
+	}
+
+	floats := MakeOrderedZeroCopySkiplist[float64, float64, struct{}](8, func(k *float64) float64 { return *k }, func(*float64) int { return 8 },
+		WithDescending())
+	for _, k := range []float64{0.25, 0.5, 1e30} {
+		floats.Insert(&k, struct{}{})
+	}
+	if stats, err := floats.GapStats(); err != nil || stats.Min != 0.25 || stats.Buckets[0] != 1 || stats.Buckets[gapBuckets-1] != 1 {
+		t.Errorf("Descending float GapStats = %+v, %v", stats, err)
+	}
+
+	names := MakeOrderedZeroCopySkiplist[string, string, struct{}](8, func(k *string) string { return *k }, func(k *string) int { return len(*k) })
+	if _, err := names.GapStats(); err != ErrKeyNotNumeric {
+		t.Errorf("String keys should return ErrKeyNotNumeric, got %v", err)
+	}
+}
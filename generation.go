@@ -0,0 +1,39 @@
+// generation.go - Detecting mutations between building iovecs and writing them
+
+package zerocopyskiplist
+
+import "syscall"
+
+// IovecBatch is a set of iovecs stamped with the generation of the list they
+// were built from, so a delayed flush can check with IsBatchStale that the
+// list has not changed before writing them
+type IovecBatch struct {
+	Iovecs     []syscall.Iovec
+	Generation uint64
+}
+
+// Generation returns the list's generation, which changes whenever an entry is
+// inserted, deleted, given a new item or given a new context through the list.
+// ItemPtr.SetContext on a node does not change it.
+func (sl *ZeroCopySkiplist[T, K, C]) Generation() uint64 {
+	sl.rlockFlat()
+	defer sl.runlock()
+	return sl.version
+}
+
+// CallbackToIovecBatch is CallbackToIovecSlice with the result stamped with
+// the generation at which the scan started. A mutation while the scan is
+// running leaves the batch already stale.
+func (sl *ZeroCopySkiplist[T, K, C]) CallbackToIovecBatch(callback func(*ItemPtr[T, K, C]) bool) IovecBatch {
+	generation := sl.Generation()
+	return IovecBatch{Iovecs: sl.CallbackToIovecSlice(callback), Generation: generation}
+}
+
+// IsBatchStale returns true if the list has been mutated since batch was
+// built, meaning its iovecs may point at items no longer in the list or miss
+// items added since; callers that need an exact flush regenerate the batch.
+// Leases (see CallbackToLeasedIovecSlice) keep a batch's memory valid without
+// regenerating it.
+func (sl *ZeroCopySkiplist[T, K, C]) IsBatchStale(batch IovecBatch) bool {
+	return sl.Generation() != batch.Generation
+}
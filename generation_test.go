@@ -0,0 +1,38 @@
+package zerocopyskiplist
+
+import "testing"
+
+func TestIovecBatchStale(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt)
+	for _, item := range createTestItems(10) {
+		skiplist.Insert(item, TestContext{})
+	}
+
+	all := func(*ItemPtr[TestItem, int, TestContext]) bool { return true }
+	batch := skiplist.CallbackToIovecBatch(all)
+	if len(batch.Iovecs) != 10 || skiplist.IsBatchStale(batch) {
+		t.Fatalf("A fresh batch of %d iovecs should not be stale", len(batch.Iovecs))
+	}
+
+	// Reads and failed writes leave the batch fresh
+	skiplist.FindItem(3)
+	skiplist.Delete(42)
+	skiplist.UpdateContext(42, TestContext{AccessCount: 1})
+	if skiplist.IsBatchStale(batch) {
+		t.Error("Reads and no-op writes should not make a batch stale")
+	}
+
+	mutations := []func(){
+		func() { skiplist.Insert(&TestItem{ID: 11}, TestContext{}) },
+		func() { skiplist.Insert(&TestItem{ID: 5}, TestContext{}) },
+		func() { skiplist.UpdateContext(6, TestContext{AccessCount: 1}) },
+		func() { skiplist.Delete(7) },
+	}
+	for i, mutate := range mutations {
+		batch = skiplist.CallbackToIovecBatch(all)
+		mutate()
+		if !skiplist.IsBatchStale(batch) {
+			t.Errorf("Mutation %d should make the batch stale", i)
+		}
+	}
+}
@@ -2,6 +2,14 @@ module github.com/mattkeenan/zerocopyskiplist
 
 go 1.24.3
 
-require golang.org/x/sys v0.33.0
+require (
+	golang.org/x/sys v0.41.0
+	golang.org/x/tools v0.42.0
+)
 
-require github.com/google/vectorio v0.0.0-20160107201919-f555dd215279 // indirect
+require github.com/google/vectorio v0.0.0-20160107201919-f555dd215279
+
+require (
+	golang.org/x/mod v0.33.0 // indirect
+	golang.org/x/sync v0.19.0 // indirect
+)
@@ -0,0 +1,116 @@
+// grouped.go - Flushing items clustered by context instead of key order
+
+package zerocopyskiplist
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// CallbackToGroupedIovecSlice is CallbackToIovecSlice with the items clustered
+// by context: every item with one context, in key order, then every item with
+// the next. Contexts are ordered by cmpContext, or by their first appearance in
+// key order when cmpContext is nil. The grouping costs one pass that copies
+// the accepted entries, so readers that want records clustered by context do
+// not have to sort them again.
+func (sl *ZeroCopySkiplist[T, K, C]) CallbackToGroupedIovecSlice(callback func(*ItemPtr[T, K, C]) bool, cmpContext func(a, b C) int) []syscall.Iovec {
+	entries := sl.groupByContext(callback, cmpContext)
+	iovecs := make([]syscall.Iovec, len(entries))
+	for i, entry := range entries {
+		iovecs[i] = sl.iovec(entry.Item)
+	}
+	if sl.leaks != nil {
+		sl.leaks.trackIovecs(iovecs)
+	}
+	return iovecs
+}
+
+// CallbackToGroupedEncoded is CallbackToEncoded with the items clustered by
+// context as for CallbackToGroupedIovecSlice. Records are written in chunks of
+// up to callbackBatchSize entries. The bytes written count towards
+// WriteAmplification as for CallbackToEncoded; if the flush fails, the
+// changes it was to write stay unflushed.
+func (sl *ZeroCopySkiplist[T, K, C]) CallbackToGroupedEncoded(w io.Writer, codec ItemCodec[T, C], callback func(*ItemPtr[T, K, C]) bool, cmpContext func(a, b C) int) (FlushReport[K], error) {
+	var report FlushReport[K]
+	start := time.Now()
+	changed := sl.startFlush()
+
+	// With context stats, remember the contexts of the records written
+	var flushed, written map[C]struct{}
+	if sl.contextStats != nil {
+		flushed, written = make(map[C]struct{}), make(map[C]struct{})
+		defer func() { sl.markFlushed(maps.Keys(flushed)) }()
+	}
+
+	var buf []byte
+	for chunk := range slices.Chunk(sl.groupByContext(callback, cmpContext), callbackBatchSize) {
+		buf = buf[:0]
+		entries := 0
+		clear(written)
+		for _, entry := range chunk {
+			encoded, err := appendRecord(buf, codec, entry.Item, entry.Context)
+			if errors.Is(err, ErrSkipItem) {
+				report.Skipped = append(report.Skipped, entry.Key)
+				continue
+			}
+			if err != nil {
+				sl.amp.unflushed.Add(changed)
+				report.finish(start)
+				return report, fmt.Errorf("encoding key %v: %w", entry.Key, err)
+			}
+			buf = encoded
+			entries++
+			if written != nil {
+				written[entry.Context] = struct{}{}
+			}
+		}
+
+		if len(buf) > 0 {
+			n, err := w.Write(buf)
+			report.Bytes += int64(n)
+			sl.amp.flushed.Add(uint64(n))
+			report.Chunks++
+			if err != nil {
+				sl.amp.unflushed.Add(changed)
+				report.finish(start)
+				return report, err
+			}
+			report.Entries += entries
+			maps.Copy(flushed, written)
+		}
+	}
+	report.finish(start)
+	return report, nil
+}
+
+// groupByContext returns the entries the callback accepts, grouped by context
+// in the order described for CallbackToGroupedIovecSlice
+func (sl *ZeroCopySkiplist[T, K, C]) groupByContext(callback func(*ItemPtr[T, K, C]) bool, cmpContext func(a, b C) int) []Entry[T, K, C] {
+	var groups [][]Entry[T, K, C]
+	index := make(map[C]int)
+	sl.ForEach(func(ip *ItemPtr[T, K, C]) bool {
+		if !callback(ip) {
+			return true
+		}
+		i, ok := index[ip.context]
+		if !ok {
+			i = len(groups)
+			index[ip.context] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], ip.Entry())
+		return true
+	})
+
+	if cmpContext != nil {
+		slices.SortStableFunc(groups, func(a, b []Entry[T, K, C]) int {
+			return cmpContext(a[0].Context, b[0].Context)
+		})
+	}
+	return slices.Concat(groups...)
+}
@@ -0,0 +1,90 @@
+package zerocopyskiplist
+
+import (
+	"bytes"
+	"cmp"
+	"testing"
+	"unsafe"
+)
+
+func TestCallbackToGroupedIovecSlice(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt)
+	items := createTestItems(1000)
+	for _, item := range items {
+		skiplist.Insert(item, TestContext{AccessCount: 3 - item.ID%3})
+	}
+	all := func(*ItemPtr[TestItem, int, TestContext]) bool { return true }
+
+	grouped := func(cmpContext func(a, b TestContext) int) []int {
+		var keys []int
+		for _, iovec := range skiplist.CallbackToGroupedIovecSlice(all, cmpContext) {
+			item := (*TestItem)(unsafe.Pointer(iovec.Base))
+			if iovec.Len != uint64(getTestItemSize(item)) {
+				t.Fatalf("Iovec for key %d has length %d", item.ID, iovec.Len)
+			}
+			keys = append(keys, item.ID)
+		}
+		return keys
+	}
+	verify := func(name string, keys []int, contextOrder []int) {
+		if len(keys) != 1000 {
+			t.Fatalf("%s: expected 1000 items, got %d", name, len(keys))
+		}
+		group := 0
+		for i, key := range keys {
+			for 3-key%3 != contextOrder[group] {
+				group++
+				if group == len(contextOrder) {
+					t.Fatalf("%s: key %d at %d is out of its context group", name, key, i)
+				}
+			}
+			if i > 0 && 3-keys[i-1]%3 == 3-key%3 && keys[i-1] >= key {
+				t.Fatalf("%s: keys %d and %d are out of order within a group", name, keys[i-1], key)
+			}
+		}
+	}
+	// Without a context order groups follow first appearance: key 1 has
+	// context 2, key 2 context 1 and key 3 context 3
+	verify("first appearance", grouped(nil), []int{2, 1, 3})
+	verify("by context", grouped(func(a, b TestContext) int { return cmp.Compare(a.AccessCount, b.AccessCount) }), []int{1, 2, 3})
+}
+
+func TestCallbackToGroupedEncoded(t *testing.T) {
+	codec, err := NewLittleEndianCodec[PaddedRecord, PaddedContext](1)
+	if err != nil {
+		t.Fatalf("NewLittleEndianCodec: %v", err)
+	}
+	skiplist := newPaddedList()
+	for i := int64(1); i <= 600; i++ {
+		skiplist.Insert(&PaddedRecord{ID: i}, PaddedContext{Generation: uint32(i % 2)})
+	}
+
+	var buf bytes.Buffer
+	report, err := skiplist.CallbackToGroupedEncoded(&buf, codec, func(*ItemPtr[PaddedRecord, int64, PaddedContext]) bool {
+		return true
+	}, func(a, b PaddedContext) int {
+		return cmp.Compare(a.Generation, b.Generation)
+	})
+	if err != nil {
+		t.Fatalf("CallbackToGroupedEncoded: %v", err)
+	}
+	if report.Entries != 600 || report.Chunks != 3 || report.Bytes != int64(buf.Len()) {
+		t.Errorf("Expected 600 entries in 3 chunks, got %+v", report)
+	}
+
+	// Even keys (generation 0) come first, then odd keys
+	next := int64(2)
+	err = DecodeEncoded(bytes.NewReader(buf.Bytes()), codec, func(item *PaddedRecord, ctx PaddedContext) error {
+		if item.ID != next || ctx.Generation != uint32(next%2) {
+			t.Fatalf("Expected record %d, got %+v %+v", next, item, ctx)
+		}
+		next += 2
+		if next == 602 {
+			next = 1
+		}
+		return nil
+	})
+	if err != nil || next != 601 {
+		t.Errorf("DecodeEncoded stopped at %d: %v", next, err)
+	}
+}
@@ -0,0 +1,84 @@
+// health.go - Health checks over the list's background machinery
+
+package zerocopyskiplist
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// healthCheck is one named check registered with AddHealthCheck or Heartbeat
+type healthCheck struct {
+	name  string
+	check func() error
+}
+
+// AddHealthCheck registers check to be run by Healthy under name, e.g. a WAL
+// probing that its file is still writable. check must be cheap and safe to
+// call from any goroutine.
+func (sl *ZeroCopySkiplist[T, K, C]) AddHealthCheck(name string, check func() error) {
+	sl.life.addHealthCheck(name, check)
+}
+
+// addHealthCheck performs AddHealthCheck
+func (lc *lifecycle) addHealthCheck(name string, check func() error) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.health = append(lc.health, healthCheck{name, check})
+}
+
+// Heartbeat registers a liveness check under name and returns the function
+// that feeds it: Healthy reports name as stalled once maxInterval passes
+// without a call. A flusher beats after every pass, a checkpointer after every
+// successful SaveCheckpoint, so a task that hangs or dies is noticed even
+// though nothing fails. The interval starts when Heartbeat is called.
+func (sl *ZeroCopySkiplist[T, K, C]) Heartbeat(name string, maxInterval time.Duration) func() {
+	return sl.life.heartbeat(name, maxInterval)
+}
+
+// heartbeat performs Heartbeat
+func (lc *lifecycle) heartbeat(name string, maxInterval time.Duration) func() {
+	var last atomic.Int64
+	last.Store(time.Now().UnixNano())
+	lc.addHealthCheck(name, func() error {
+		if since := time.Since(time.Unix(0, last.Load())); since > maxInterval {
+			return fmt.Errorf("stalled: no heartbeat for %v", since.Round(time.Millisecond))
+		}
+		return nil
+	})
+	return func() {
+		last.Store(time.Now().UnixNano())
+	}
+}
+
+// Healthy returns nil if the list is fit to serve, otherwise an error joining
+// every problem found: the list has been closed, a background task started
+// with Go has returned before Close, or a registered check or heartbeat
+// failed. It is meant to back a service's health or readiness endpoint.
+func (sl *ZeroCopySkiplist[T, K, C]) Healthy() error {
+	return sl.life.healthy()
+}
+
+// healthy performs Healthy
+func (lc *lifecycle) healthy() error {
+	lc.mu.Lock()
+	closed, exited := lc.closed, lc.tasksExited
+	checks := lc.health
+	lc.mu.Unlock()
+
+	var errs []error
+	if closed {
+		errs = append(errs, ErrClosed)
+	}
+	if exited > 0 {
+		errs = append(errs, fmt.Errorf("%d background tasks exited before Close", exited))
+	}
+	for _, hc := range checks {
+		if err := hc.check(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", hc.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,59 @@
+package zerocopyskiplist
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHealthy(t *testing.T) {
+	sl := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	if err := sl.Healthy(); err != nil {
+		t.Fatalf("A new list should be healthy, got %v", err)
+	}
+
+	walErr := errors.New("read-only file system")
+	var walBroken bool
+	sl.AddHealthCheck("wal", func() error {
+		if walBroken {
+			return walErr
+		}
+		return nil
+	})
+	beat := sl.Heartbeat("flusher", 30*time.Millisecond)
+	if err := sl.Healthy(); err != nil {
+		t.Errorf("Healthy with passing checks = %v", err)
+	}
+
+	walBroken = true
+	time.Sleep(50 * time.Millisecond)
+	err := sl.Healthy()
+	if !errors.Is(err, walErr) || !strings.Contains(err.Error(), "wal: ") || !strings.Contains(err.Error(), "flusher: stalled") {
+		t.Errorf("Healthy should report the failed check and the stalled heartbeat, got %v", err)
+	}
+
+	walBroken = false
+	beat()
+	if err := sl.Healthy(); err != nil {
+		t.Errorf("Healthy after recovery = %v", err)
+	}
+
+	// A background task that dies is reported, one stopped by Close is not
+	sl.Go(func(context.Context) {})
+	deadline := time.Now().Add(time.Second)
+	for sl.Healthy() == nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if err := sl.Healthy(); err == nil || !strings.Contains(err.Error(), "1 background tasks exited") {
+		t.Errorf("Healthy after a task died = %v", err)
+	}
+
+	other := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	other.Go(func(ctx context.Context) { <-ctx.Done() })
+	other.Close(context.Background())
+	if err := other.Healthy(); !errors.Is(err, ErrClosed) || strings.Contains(err.Error(), "exited") {
+		t.Errorf("Healthy of a closed list = %v, want only ErrClosed", err)
+	}
+}
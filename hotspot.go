@@ -0,0 +1,157 @@
+// hotspot.go - Decaying access counts per key range
+
+package zerocopyskiplist
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// hotCounter is an access count that halves every half-life
+type hotCounter struct {
+	value float64
+	at    time.Time // when value was last decayed
+}
+
+// add decays the counter to now and adds one access
+func (c *hotCounter) add(now time.Time, halfLife time.Duration) {
+	c.value = c.score(now, halfLife) + 1
+	c.at = now
+}
+
+// score returns the counter decayed to now
+func (c *hotCounter) score(now time.Time, halfLife time.Duration) float64 {
+	return c.value * math.Exp2(-float64(now.Sub(c.at))/float64(halfLife))
+}
+
+// hotspotTracker counts accesses per key range. A range starts at a node of
+// at least the tracker's level and runs to the next such node; accesses
+// before the first one count against head. Counters are keyed by the range's
+// first key, so a deleted boundary leaves a stale counter that HotRanges drops.
+type hotspotTracker[K comparable] struct {
+	level    int
+	halfLife time.Duration
+	mu       sync.Mutex // counters are updated under the list's read lock
+	head     hotCounter
+	ranges   map[K]*hotCounter
+}
+
+// HotRange is a key range and its decayed access count. The range covers
+// From <= key < To; FromStart and ToEnd mark a range that runs from the
+// start or to the end of the list, leaving From or To unset.
+type HotRange[K comparable] struct {
+	From, To  K
+	FromStart bool
+	ToEnd     bool
+	Score     float64 // accesses, each weighted by 2^(-age/halfLife)
+}
+
+// WithHotspotTracking counts Insert, Delete, Find, FindItem and Get calls per
+// key range, with counts that halve every halfLife, and reports the busiest
+// ranges with HotRanges. Ranges are bounded by the nodes of at least level,
+// so with the default geometric levels each spans about 2^level keys and
+// moves with the data as nodes come and go. Every tracked call pays a second
+// descent, stopping at level, and a mutex shared by readers.
+func WithHotspotTracking(level int, halfLife time.Duration) Option {
+	return func(o *options) {
+		o.hotspotLevel = level
+		o.hotspotHalfLife = halfLife
+	}
+}
+
+// newHotspotTracker returns a tracker for the options, nil if none is wanted
+func newHotspotTracker[K comparable](opts options, maxLevel int) *hotspotTracker[K] {
+	if opts.hotspotHalfLife <= 0 {
+		return nil
+	}
+	return &hotspotTracker[K]{
+		level:    min(max(opts.hotspotLevel, 0), maxLevel),
+		halfLife: opts.hotspotHalfLife,
+		ranges:   make(map[K]*hotCounter),
+	}
+}
+
+// touch counts an access to key against its range; the caller must hold the lock
+func (sl *ZeroCopySkiplist[T, K, C]) touch(key K) {
+	h := sl.hot
+	start := sl.rangeStart(key, h.level)
+	now := sl.Clock().Now()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if start == sl.header {
+		h.head.add(now, h.halfLife)
+		return
+	}
+	c := h.ranges[start.key]
+	if c == nil {
+		c = &hotCounter{}
+		h.ranges[start.key] = c
+	}
+	c.add(now, h.halfLife)
+}
+
+// rangeStart returns the last node of at least level whose key is <= key, or
+// the header if there is none; the caller must hold the lock
+func (sl *ZeroCopySkiplist[T, K, C]) rangeStart(key K, level int) *ItemPtr[T, K, C] {
+	current := sl.header
+	for i := sl.level; i >= level; i-- {
+		for next := current.forward[i]; next != nil && sl.cmpKey(next.key, key) <= 0; next = current.forward[i] {
+			current = next
+		}
+	}
+	return current
+}
+
+// HotRanges returns up to n key ranges with the highest decayed access
+// counts, busiest first, or nil if the list was not built
+// WithHotspotTracking. Counters of ranges whose first node has been deleted,
+// and of ranges idle long enough to have decayed below one hundredth of an
+// access, are dropped.
+func (sl *ZeroCopySkiplist[T, K, C]) HotRanges(n int) []HotRange[K] {
+	h := sl.hot
+	if h == nil {
+		return nil
+	}
+	sl.rlock()
+	defer sl.runlock()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := sl.Clock().Now()
+	var ranges []HotRange[K]
+	if score := h.head.score(now, h.halfLife); score >= 0.01 {
+		r := HotRange[K]{FromStart: true, Score: score}
+		sl.endRange(&r, sl.header, h.level)
+		ranges = append(ranges, r)
+	}
+	for key, c := range h.ranges {
+		node := sl.seek(key, true)
+		score := c.score(now, h.halfLife)
+		if node == nil || sl.cmpKey(node.key, key) != 0 || node.level < h.level || score < 0.01 {
+			delete(h.ranges, key)
+			continue
+		}
+		r := HotRange[K]{From: key, Score: score}
+		sl.endRange(&r, node, h.level)
+		ranges = append(ranges, r)
+	}
+
+	slices.SortFunc(ranges, func(a, b HotRange[K]) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	return ranges[:min(max(n, 0), len(ranges))]
+}
+
+// endRange sets the end of r, the range starting at node; the caller must
+// hold the lock
+func (sl *ZeroCopySkiplist[T, K, C]) endRange(r *HotRange[K], node *ItemPtr[T, K, C], level int) {
+	if next := node.forward[level]; next != nil {
+		r.To = next.key
+	} else {
+		r.ToEnd = true
+	}
+}
@@ -0,0 +1,69 @@
+package zerocopyskiplist
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHotRanges(t *testing.T) {
+	// Keys 0..99 with every tenth key at level 2, so level 2 ranges are [0, 10), [10, 20), ...
+	levels := make([]int, 100)
+	for i := 0; i < 100; i += 10 {
+		levels[i] = 2
+	}
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt,
+		WithLevelSequence(levels), WithHotspotTracking(2, time.Hour))
+	for i := range 100 {
+		skiplist.Insert(&TestItem{ID: i}, TestContext{})
+	}
+
+	for range 50 {
+		skiplist.FindItem(42)
+		skiplist.Get(47)
+	}
+	for range 20 {
+		skiplist.Find(95)
+	}
+	skiplist.Delete(3)
+
+	ranges := skiplist.HotRanges(2)
+	if len(ranges) != 2 {
+		t.Fatalf("HotRanges(2) returned %d ranges", len(ranges))
+	}
+	if r := ranges[0]; r.From != 40 || r.To != 50 || r.FromStart || r.ToEnd || r.Score < 99 || r.Score > 111 {
+		t.Errorf("Hottest range = %+v, want [40, 50) with about 110 accesses", r)
+	}
+	if r := ranges[1]; r.From != 90 || !r.ToEnd || r.Score < 19 {
+		t.Errorf("Second range = %+v, want [90, end) with about 20 accesses", r)
+	}
+
+	// Deleting a boundary drops its counter
+	skiplist.Delete(40)
+	for _, r := range skiplist.HotRanges(10) {
+		if r.From == 40 && !r.FromStart {
+			t.Errorf("The range of a deleted boundary should be dropped, got %+v", r)
+		}
+	}
+}
+
+func TestHotRangesDecay(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt,
+		WithLevelSequence([]int{0, 0}), WithHotspotTracking(3, time.Millisecond))
+	skiplist.Insert(&TestItem{ID: 1}, TestContext{})
+	skiplist.Insert(&TestItem{ID: 2}, TestContext{})
+
+	ranges := skiplist.HotRanges(5)
+	if len(ranges) != 1 || !ranges[0].FromStart || !ranges[0].ToEnd {
+		t.Fatalf("A list without level 3 nodes should have one range, got %+v", ranges)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if ranges := skiplist.HotRanges(5); len(ranges) != 0 {
+		t.Errorf("Counts should decay away after many half-lives, got %+v", ranges)
+	}
+
+	plain := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt)
+	plain.Insert(&TestItem{ID: 1}, TestContext{})
+	if plain.HotRanges(5) != nil {
+		t.Error("HotRanges should be nil without WithHotspotTracking")
+	}
+}
@@ -0,0 +1,59 @@
+// import.go - Bulk loading from other ordered containers
+
+package zerocopyskiplist
+
+import (
+	"fmt"
+	"iter"
+)
+
+// FromSortedMapIter loads the items yielded by seq with zero contexts, as
+// BulkLoad does, and returns the number of new keys. seq yields each item
+// with its key, as a sorted map's ascending iterator does, so migrating from
+// another ordered container needs no intermediate slice of its contents.
+// Items are gathered in batches of up to callbackBatchSize and each batch is
+// loaded under the write lock, so seq runs with no lock held and readers see
+// the import progress; ascending input is appended without a search. An
+// item whose own key differs from the key it was yielded with stops the
+// import with an error after the items before it are loaded.
+func (sl *ZeroCopySkiplist[T, K, C]) FromSortedMapIter(seq iter.Seq2[K, *T]) (int, error) {
+	batch := make([]*T, 0, callbackBatchSize)
+	added := 0
+	var mismatch error
+	for key, item := range seq {
+		if got := sl.getKeyFromItem(item); sl.cmpKey(got, sl.normalize(key)) != 0 {
+			mismatch = fmt.Errorf("item yielded for key %v has key %v", key, got)
+			break
+		}
+		batch = append(batch, item)
+		if len(batch) == cap(batch) {
+			n, err := sl.BulkLoad(batch, nil)
+			added += n
+			if err != nil {
+				return added, err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	n, err := sl.BulkLoad(batch, nil)
+	added += n
+	if err != nil {
+		return added, err
+	}
+	return added, mismatch
+}
+
+// FromBTree loads the items yielded by ascend with zero contexts, as
+// FromSortedMapIter does, for containers such as B-trees whose ascending
+// iterators yield items alone; wrap the container's ascend method in an
+// iter.Seq. It returns the number of new keys.
+func (sl *ZeroCopySkiplist[T, K, C]) FromBTree(ascend iter.Seq[*T]) (int, error) {
+	return sl.FromSortedMapIter(func(yield func(K, *T) bool) {
+		for item := range ascend {
+			if !yield(sl.getKeyFromItem(item), item) {
+				return
+			}
+		}
+	})
+}
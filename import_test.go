@@ -0,0 +1,64 @@
+package zerocopyskiplist
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestFromSortedMapIter(t *testing.T) {
+	source := make(map[int]*TestItem)
+	for _, item := range createTestItems(1000) {
+		source[item.ID] = item
+	}
+	sortedMap := func(yield func(int, *TestItem) bool) {
+		for _, key := range slices.Sorted(maps.Keys(source)) {
+			if !yield(key, source[key]) {
+				return
+			}
+		}
+	}
+
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt)
+	added, err := skiplist.FromSortedMapIter(sortedMap)
+	if err != nil || added != 1000 || skiplist.Length() != 1000 {
+		t.Fatalf("FromSortedMapIter = %d, %v; Length %d", added, err, skiplist.Length())
+	}
+	if ptr, _ := skiplist.Find(500); ptr == nil || ptr.Item() != source[500] {
+		t.Error("Imported list does not share the source's items")
+	}
+	if err := skiplist.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+
+	// A key that does not match its item stops the import after the items before it
+	mismatched := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt)
+	added, err = mismatched.FromSortedMapIter(func(yield func(int, *TestItem) bool) {
+		_ = yield(1, source[1]) && yield(2, source[2]) && yield(3, source[4]) && yield(5, source[5])
+	})
+	if err == nil || added != 2 || mismatched.Length() != 2 {
+		t.Errorf("Import with a mismatched key = %d, %v; want 2 items and an error", added, err)
+	}
+
+	frozen := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt)
+	frozen.Freeze()
+	if _, err := frozen.FromSortedMapIter(sortedMap); !errors.Is(err, ErrFrozen) {
+		t.Errorf("Import into a frozen list should return ErrFrozen, got %v", err)
+	}
+}
+
+func TestFromBTree(t *testing.T) {
+	items := createTestItems(300)
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt)
+	skiplist.Insert(&TestItem{ID: 1000}, TestContext{})
+
+	// Input that does not ascend past the last key is still inserted in order
+	added, err := skiplist.FromBTree(slices.Values(items))
+	if err != nil || added != 300 || skiplist.Length() != 301 {
+		t.Fatalf("FromBTree = %d, %v; Length %d", added, err, skiplist.Length())
+	}
+	if first := skiplist.First(); first == nil || first.Item() != items[0] {
+		t.Error("First entry should be the first imported item")
+	}
+}
@@ -0,0 +1,166 @@
+// index.go - Persisting the list's topology without its items
+
+package zerocopyskiplist
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// SaveIndex writes the list's topology to w in key order: each entry's key,
+// context, level and the offset of its item as given by offset, but none of
+// the item bytes. It is meant for lists whose items live in an mmap'd file or
+// segment that outlives the process, so LoadIndex can rebuild the list over
+// the same items without reading them, sorting or drawing new levels.
+//
+// Keys are encoded with keys and contexts in their fixed size little-endian
+// layout, so C must be a fixed size type. Each record is a uint8 level, a
+// uint64 offset, the context, a uint32 key length and the key, behind the
+// usual persisted header and followed by a CRC-32C of everything before it.
+// The read lock is held for the whole save, so w should be buffered.
+func (sl *ZeroCopySkiplist[T, K, C]) SaveIndex(w io.Writer, keys KeyCodec[K], offset func(*T) uint64) error {
+	if _, err := fixedContextSize[C](); err != nil {
+		return err
+	}
+
+	sl.rlock()
+	defer sl.runlock()
+
+	crc := crc32.New(crcTable)
+	out := io.MultiWriter(w, crc)
+
+	header := persistHeader{
+		kind:      persistIndex,
+		count:     uint64(sl.length),
+		collation: sl.opts.collation,
+	}
+	buf := header.appendTo(make([]byte, 0, 256))
+	if _, err := out.Write(buf); err != nil {
+		return err
+	}
+
+	for current := sl.header.forward[0]; current != nil; current = current.forward[0] {
+		buf = append(buf[:0], byte(current.level))
+		buf = binary.LittleEndian.AppendUint64(buf, offset(current.item))
+		buf, _ = binary.Append(buf, binary.LittleEndian, &current.context)
+		start := len(buf)
+		buf = keys.AppendKey(append(buf, 0, 0, 0, 0), current.key)
+		binary.LittleEndian.PutUint32(buf[start:], uint32(len(buf)-start-4))
+		if _, err := out.Write(buf); err != nil {
+			return err
+		}
+	}
+
+	_, err := w.Write(binary.LittleEndian.AppendUint32(buf[:0], crc.Sum32()))
+	return err
+}
+
+// LoadIndex rebuilds an empty list from an index written by SaveIndex,
+// calling resolve for the item at each saved offset. Entries are appended in
+// their saved order at their saved levels, so loading costs no searches and
+// the list has the shape it was saved with; levels above the list's maxLevel
+// are lowered to it. Items are not read, so resolve must return the item each
+// key was saved for: Validate checks the keys if in doubt.
+//
+// As for LoadSnapshot the whole index is decoded and its checksum verified
+// before the list is modified. It fails if the list is not empty, and
+// returns ErrFrozen for a frozen list. With context quotas or an admission
+// filter entries are inserted one by one instead, and those refused are
+// skipped with an error wrapping ErrRefused.
+func (sl *ZeroCopySkiplist[T, K, C]) LoadIndex(r io.Reader, keys KeyCodec[K], resolve func(offset uint64) (*T, error)) error {
+	contextSize, err := fixedContextSize[C]()
+	if err != nil {
+		return err
+	}
+
+	crc := crc32.New(crcTable)
+	in := io.TeeReader(bufio.NewReader(r), crc)
+
+	header, err := readPersistHeader(in, persistIndex)
+	if err != nil {
+		return err
+	}
+	if err := sl.checkCollation(header.collation); err != nil {
+		return err
+	}
+
+	type entry struct {
+		item    *T
+		key     K
+		context C
+		level   int
+	}
+	entries := make([]entry, 0, min(header.count, 1<<20))
+	fixed := 1 + 8 + contextSize
+	buf := make([]byte, max(256, fixed+4))
+	for i := uint64(0); i < header.count; i++ {
+		if _, err := io.ReadFull(in, buf[:fixed+4]); err != nil {
+			return fmt.Errorf("%w: reading index entry %d: %v", ErrSnapshotCorrupt, i, err)
+		}
+		e := entry{level: int(buf[0])}
+		if e.item, err = resolve(binary.LittleEndian.Uint64(buf[1:])); err != nil {
+			return fmt.Errorf("resolving index entry %d: %w", i, err)
+		}
+		if e.context, _, err = splitContext[C](buf[9:fixed], contextSize); err != nil {
+			return fmt.Errorf("decoding index entry %d: %w", i, err)
+		}
+
+		if buf, err = readSized(in, buf, binary.LittleEndian.Uint32(buf[fixed:])); err != nil {
+			return fmt.Errorf("%w: reading index entry %d: %v", ErrSnapshotCorrupt, i, err)
+		}
+		if e.key, err = keys.DecodeKey(buf); err != nil {
+			return fmt.Errorf("decoding index entry %d: %w", i, err)
+		}
+		if len(entries) > 0 && sl.cmpKey(entries[len(entries)-1].key, e.key) >= 0 {
+			return fmt.Errorf("%w: index entry %d is out of order", ErrSnapshotCorrupt, i)
+		}
+		entries = append(entries, e)
+		buf = buf[:cap(buf)]
+	}
+
+	sum := crc.Sum32()
+	if _, err := io.ReadFull(in, buf[:4]); err != nil {
+		return fmt.Errorf("%w: reading checksum: %v", ErrSnapshotCorrupt, err)
+	}
+	if binary.LittleEndian.Uint32(buf) != sum {
+		return fmt.Errorf("%w: checksum mismatch", ErrSnapshotCorrupt)
+	}
+
+	refused := 0
+	hook, totalBytes, length := func() (func(int64, int), int64, int) {
+		sl.lock()
+		defer sl.unlock()
+		if sl.frozen {
+			err = ErrFrozen
+			return nil, 0, 0
+		}
+		if sl.length > 0 {
+			err = fmt.Errorf("loading an index into a list of %d entries", sl.length)
+			return nil, 0, 0
+		}
+		for _, e := range entries {
+			if len(sl.quotas) > 0 || sl.admitFilter != nil {
+				if _, r := sl.tryInsert(e.item, e.context); r {
+					refused++
+				}
+				continue
+			}
+			sl.appendTailAt(e.item, e.key, e.context, min(e.level, sl.maxLevel))
+		}
+		return sl.checkPressure()
+	}()
+
+	if hook != nil {
+		hook(totalBytes, length)
+	}
+	if err != nil {
+		return err
+	}
+	if refused > 0 {
+		return fmt.Errorf("%w: %d of %d entries", ErrRefused, refused, len(entries))
+	}
+	return nil
+}
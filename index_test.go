@@ -0,0 +1,93 @@
+package zerocopyskiplist
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"unsafe"
+)
+
+func TestSaveLoadIndex(t *testing.T) {
+	// Items live in a segment that outlives the list, addressed by offset
+	segment := make([]PaddedRecord, 1000)
+	size := unsafe.Sizeof(PaddedRecord{})
+	offset := func(r *PaddedRecord) uint64 {
+		return uint64(uintptr(unsafe.Pointer(r)) - uintptr(unsafe.Pointer(&segment[0])))
+	}
+	resolve := func(off uint64) (*PaddedRecord, error) {
+		if off%uint64(size) != 0 || off/uint64(size) >= uint64(len(segment)) {
+			return nil, fmt.Errorf("bad offset %d", off)
+		}
+		return &segment[off/uint64(size)], nil
+	}
+	newList := func() *ZeroCopySkiplist[PaddedRecord, int, PaddedContext] {
+		return MakeZeroCopySkiplist[PaddedRecord, int, PaddedContext](
+			16,
+			func(r *PaddedRecord) int { return int(r.ID) },
+			func(r *PaddedRecord) int { return int(unsafe.Sizeof(*r)) },
+			compareInt,
+		)
+	}
+
+	original := newList()
+	for i := range segment {
+		// Segment order differs from key order
+		segment[i].ID = int64((i * 7919) % len(segment))
+		original.Insert(&segment[i], PaddedContext{Generation: uint32(i)})
+	}
+
+	var buf bytes.Buffer
+	if err := original.SaveIndex(&buf, intKeys{}, offset); err != nil {
+		t.Fatalf("SaveIndex: %v", err)
+	}
+	if buf.Len() >= len(segment)*int(size)+len(segment)*25 {
+		t.Errorf("Index of %d bytes should hold no item bytes", buf.Len())
+	}
+
+	loaded := newList()
+	if err := loaded.LoadIndex(bytes.NewReader(buf.Bytes()), intKeys{}, resolve); err != nil {
+		t.Fatalf("LoadIndex: %v", err)
+	}
+	if err := loaded.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	if loaded.Length() != original.Length() || loaded.Stats().TotalItemBytes != original.Stats().TotalItemBytes {
+		t.Fatalf("Loaded %d entries, want %d", loaded.Length(), original.Length())
+	}
+
+	// Same items, contexts and levels, node for node
+	a, b := original.header.forward[0], loaded.header.forward[0]
+	for ; a != nil && b != nil; a, b = a.forward[0], b.forward[0] {
+		if a.item != b.item || a.key != b.key || a.context != b.context || a.level != b.level {
+			t.Fatalf("Loaded node %d/%+v at level %d, want %d/%+v at level %d", b.key, b.context, b.level, a.key, a.context, a.level)
+		}
+	}
+	if a != nil || b != nil {
+		t.Fatal("Loaded list has a different length")
+	}
+
+	// Loading requires an empty list and an intact index
+	if err := loaded.LoadIndex(bytes.NewReader(buf.Bytes()), intKeys{}, resolve); err == nil {
+		t.Error("LoadIndex into a populated list should fail")
+	}
+	corrupt := bytes.Clone(buf.Bytes())
+	corrupt[len(corrupt)/2] ^= 0xff
+	fresh := newList()
+	if err := fresh.LoadIndex(bytes.NewReader(corrupt), intKeys{}, func(off uint64) (*PaddedRecord, error) {
+		return &segment[0], nil
+	}); !errors.Is(err, ErrSnapshotCorrupt) || fresh.Length() != 0 {
+		t.Errorf("Corrupt index = %v, want ErrSnapshotCorrupt and an empty list", err)
+	}
+	failure := errors.New("segment gone")
+	if err := fresh.LoadIndex(bytes.NewReader(buf.Bytes()), intKeys{}, func(uint64) (*PaddedRecord, error) {
+		return nil, failure
+	}); !errors.Is(err, failure) {
+		t.Errorf("Failed resolve = %v, want it wrapped", err)
+	}
+
+	// Contexts must be fixed size
+	if err := newSnapshotTestList().SaveIndex(&buf, intKeys{}, func(*TestItem) uint64 { return 0 }); err == nil {
+		t.Error("SaveIndex with a variable size context should fail")
+	}
+}
@@ -0,0 +1,106 @@
+package zerocopyskiplist
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestInlineKeys(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		12,
+		getKeyFromTestItem,
+		getTestItemSize,
+		compareInt,
+		WithInlineKeys(),
+		WithNodePool(),
+	)
+	if !skiplist.inlineKeys {
+		t.Fatal("int keys should use the inline key layout")
+	}
+
+	items := createTestItems(1000)
+	rng := rand.New(rand.NewSource(3))
+	for _, i := range rng.Perm(len(items)) {
+		skiplist.Insert(items[i], TestContext{})
+	}
+	for _, i := range rng.Perm(len(items))[:500] {
+		skiplist.Delete(items[i].ID)
+	}
+	if err := skiplist.Validate(); err != nil {
+		t.Fatalf("Validate with inline keys: %v", err)
+	}
+
+	for _, item := range items {
+		found := skiplist.FindItem(item.ID)
+		if found != nil && found.Item() != item {
+			t.Errorf("Found wrong item for key %d", item.ID)
+		}
+	}
+	if c := skiplist.SeekGE(0); !c.Valid() || c.Key() != skiplist.First().Key() {
+		t.Error("SeekGE should work with inline keys")
+	}
+}
+
+func TestInlineKeysFallback(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, string, int](
+		12,
+		func(item *TestItem) string { return item.Value },
+		getTestItemSize,
+		func(a, b string) int {
+			if a < b {
+				return -1
+			}
+			if a > b {
+				return 1
+			}
+			return 0
+		},
+		WithInlineKeys(),
+	)
+	if skiplist.inlineKeys {
+		t.Fatal("string keys should fall back to the default layout")
+	}
+
+	for _, item := range createTestItems(100) {
+		skiplist.Insert(item, 0)
+	}
+	if err := skiplist.Validate(); err != nil {
+		t.Fatalf("Validate after fallback: %v", err)
+	}
+	if skiplist.FindItem("value_42") == nil {
+		t.Error("Should find item by string key after fallback")
+	}
+}
+
+func BenchmarkFindLarge(b *testing.B) {
+	for _, inline := range []bool{false, true} {
+		name := "Default"
+		var opts []Option
+		if inline {
+			name = "InlineKeys"
+			opts = append(opts, WithInlineKeys())
+		}
+		b.Run(name, func(b *testing.B) {
+			skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+				20,
+				getKeyFromTestItem,
+				getTestItemSize,
+				compareInt,
+				opts...,
+			)
+
+			const numItems = 1 << 20
+			items := make([]TestItem, numItems)
+			rng := rand.New(rand.NewSource(1))
+			for _, i := range rng.Perm(numItems) {
+				items[i].ID = i
+				skiplist.Insert(&items[i], TestContext{})
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = skiplist.FindItem(rng.Intn(numItems))
+			}
+		})
+	}
+}
@@ -0,0 +1,326 @@
+// inspect.go - Reading persisted files without their item and key types
+
+package zerocopyskiplist
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// maxInspectedRecord bounds the record sizes InspectPersisted will read, so a
+// corrupt length is reported instead of allocated
+const maxInspectedRecord = 1 << 30
+
+// PersistedKind identifies a file read by InspectPersisted
+type PersistedKind int
+
+const (
+	PersistedSnapshot PersistedKind = persistSnapshot // written by SaveSnapshot
+	PersistedDelta    PersistedKind = persistDelta    // written by BackupAgent
+	PersistedIndex    PersistedKind = persistIndex    // written by SaveIndex
+	PersistedShared   PersistedKind = 16              // written by PublishShared
+)
+
+// String returns the kind's name
+func (k PersistedKind) String() string {
+	switch k {
+	case PersistedSnapshot:
+		return "snapshot"
+	case PersistedDelta:
+		return "delta"
+	case PersistedIndex:
+		return "index"
+	case PersistedShared:
+		return "shared segment"
+	default:
+		return fmt.Sprintf("PersistedKind(%d)", int(k))
+	}
+}
+
+// PersistedFile describes a file read by InspectPersisted
+type PersistedFile struct {
+	Kind      PersistedKind
+	Format    uint16 // container format, 0 for shared segments
+	Schema    uint32 // codec schema version of snapshots and deltas
+	Count     uint64 // records declared by the header
+	Records   uint64 // records read
+	Collation string
+	Size      int64 // bytes read
+
+	// Shared segments record the list's level and maxLevel
+	Level    int
+	MaxLevel int
+
+	// Snapshots, deltas and indexes end with a CRC-32C of the bytes before it
+	HasChecksum bool
+	Checksum    uint32 // as stored
+	Computed    uint32 // as read
+
+	// Encoded keys of the first and last records that carry one: the key
+	// range of indexes and shared segments, the first and last deletes of a
+	// delta in write order. Snapshot records carry no separate key.
+	FirstKey []byte
+	LastKey  []byte
+
+	// Inconsistencies found in a file whose records could all be read, such
+	// as a checksum mismatch or broken links; a file that cannot be read to
+	// the end fails with an error instead
+	Problems []string
+}
+
+// PersistedRecord is one record of a file read by InspectPersisted. Its
+// slices are only valid until the callback returns.
+type PersistedRecord struct {
+	Index      uint64
+	Offset     int64  // position of the record in the file
+	Op         string // "upsert" or "delete" for delta records, empty otherwise
+	Level      int    // node level of index and shared records, -1 otherwise
+	Key        []byte // encoded key, nil for snapshot records and delta upserts
+	Value      []byte // encoded item and context, nil for index records
+	Context    []byte // fixed size context of index records
+	ItemOffset uint64 // item offset of index records
+}
+
+// InspectPersisted reads a snapshot, backup delta, index or shared segment
+// from r, telling them apart by their headers, and calls fn, if not nil, for
+// every record in file order. Items, contexts and keys are left encoded, so
+// no codec or type parameters are needed: it is meant for tools that triage
+// files on disk, see cmd/zcsl-inspect. Index records hold their contexts in
+// the fixed size layout of C, whose size must be given as contextSize; it is
+// ignored for the other kinds.
+//
+// The whole file is read and checked: checksums are compared, the links of
+// shared segments are followed and trailing bytes are reported, all as
+// Problems. A file that is truncated or whose framing is broken fails with
+// an error wrapping ErrSnapshotCorrupt or ErrSharedCorrupt, returned with
+// what was read before it; an error from fn stops the read and is returned.
+func InspectPersisted(r io.Reader, contextSize int, fn func(PersistedRecord) error) (PersistedFile, error) {
+	in := &inspectReader{r: bufio.NewReaderSize(r, 1<<16), crc: crc32.New(crcTable)}
+	magic, err := in.r.Peek(8)
+	if err != nil {
+		return PersistedFile{}, fmt.Errorf("%w: reading header: %v", ErrSnapshotCorrupt, err)
+	}
+	if [8]byte(magic) == sharedMagic {
+		return in.shared(fn)
+	}
+
+	header, err := readPersistHeader(in, binary.LittleEndian.Uint16(magic[6:]))
+	if err != nil {
+		return PersistedFile{}, err
+	}
+	file := PersistedFile{
+		Kind:      PersistedKind(header.kind),
+		Format:    header.format,
+		Schema:    header.schema,
+		Count:     header.count,
+		Collation: header.collation,
+	}
+	switch file.Kind {
+	case PersistedSnapshot, PersistedDelta, PersistedIndex:
+	default:
+		return file, fmt.Errorf("%w: unknown kind %d", ErrSnapshotCorrupt, header.kind)
+	}
+	if file.Kind == PersistedIndex && contextSize < 0 {
+		return file, fmt.Errorf("inspecting an index needs its context size")
+	}
+
+	var fixed, key, value []byte
+	for i := uint64(0); i < header.count; i++ {
+		rec := PersistedRecord{Index: i, Offset: in.n, Level: -1}
+		switch file.Kind {
+		case PersistedSnapshot:
+			value, err = in.block(value)
+			rec.Value = value
+		case PersistedDelta:
+			if fixed, err = in.full(fixed, 1); err != nil {
+				break
+			}
+			if value, err = in.block(value); err != nil {
+				break
+			}
+			switch fixed[0] {
+			case deltaUpsert:
+				rec.Op, rec.Value = "upsert", value
+			case deltaDelete:
+				rec.Op, rec.Key = "delete", value
+			default:
+				rec.Op = fmt.Sprintf("op %d", fixed[0])
+				file.Problems = append(file.Problems, fmt.Sprintf("record %d has unknown operation %d", i, fixed[0]))
+			}
+		case PersistedIndex:
+			if fixed, err = in.full(fixed, 1+8+contextSize); err != nil {
+				break
+			}
+			rec.Level = int(fixed[0])
+			rec.ItemOffset = binary.LittleEndian.Uint64(fixed[1:])
+			rec.Context = fixed[9:]
+			key, err = in.block(key)
+			rec.Key = key
+		}
+		if err != nil {
+			file.Size = in.n
+			return file, fmt.Errorf("%w: reading record %d: %v", ErrSnapshotCorrupt, i, err)
+		}
+		if err := file.record(rec, fn); err != nil {
+			return file, err
+		}
+	}
+
+	file.Computed = in.crc.Sum32()
+	if fixed, err = in.full(fixed, 4); err != nil {
+		file.Size = in.n
+		return file, fmt.Errorf("%w: reading checksum: %v", ErrSnapshotCorrupt, err)
+	}
+	file.HasChecksum = true
+	file.Checksum = binary.LittleEndian.Uint32(fixed)
+	if file.Checksum != file.Computed {
+		file.Problems = append(file.Problems, fmt.Sprintf("checksum %08x does not match the contents, %08x", file.Checksum, file.Computed))
+	}
+	return file, in.finish(&file)
+}
+
+// record counts rec, notes its key and passes it to fn
+func (f *PersistedFile) record(rec PersistedRecord, fn func(PersistedRecord) error) error {
+	f.Records++
+	if rec.Key != nil {
+		if f.FirstKey == nil {
+			f.FirstKey = bytes.Clone(rec.Key)
+		}
+		f.LastKey = append(f.LastKey[:0], rec.Key...)
+	}
+	if fn == nil {
+		return nil
+	}
+	return fn(rec)
+}
+
+// inspectReader reads a persisted file, counting its bytes and checksumming
+// them as it goes
+type inspectReader struct {
+	r   *bufio.Reader
+	crc hash.Hash32
+	n   int64
+}
+
+// Read implements io.Reader
+func (in *inspectReader) Read(p []byte) (int, error) {
+	n, err := in.r.Read(p)
+	in.crc.Write(p[:n])
+	in.n += int64(n)
+	return n, err
+}
+
+// full reads exactly size bytes into buf, reallocating it if it is too small
+func (in *inspectReader) full(buf []byte, size int) ([]byte, error) {
+	if cap(buf) < size {
+		buf = make([]byte, size)
+	}
+	buf = buf[:size]
+	_, err := io.ReadFull(in, buf)
+	return buf, err
+}
+
+// block reads a uint32 length and that many bytes into buf
+func (in *inspectReader) block(buf []byte) ([]byte, error) {
+	buf, err := in.full(buf, 4)
+	if err != nil {
+		return buf, err
+	}
+	size := binary.LittleEndian.Uint32(buf)
+	if size > maxInspectedRecord {
+		return buf, fmt.Errorf("record of %d bytes", size)
+	}
+	return in.full(buf, int(size))
+}
+
+// finish records the size of the file and any bytes after its end as a problem
+func (in *inspectReader) finish(file *PersistedFile) error {
+	end := in.n
+	extra, err := io.Copy(io.Discard, in)
+	file.Size = in.n
+	if extra > 0 {
+		file.Problems = append(file.Problems, fmt.Sprintf("%d bytes follow the end at offset %d", extra, end))
+	}
+	return err
+}
+
+// shared reads a segment written by PublishShared. Every node's forward
+// offsets must name the next node on each of its levels, as the segment is
+// written in key order, so the links are checked as the nodes go by.
+func (in *inspectReader) shared(fn func(PersistedRecord) error) (PersistedFile, error) {
+	header, err := in.full(nil, sharedHeaderSize)
+	if err != nil {
+		return PersistedFile{}, fmt.Errorf("%w: reading header: %v", ErrSharedCorrupt, err)
+	}
+	file := PersistedFile{
+		Kind:     PersistedShared,
+		Count:    binary.LittleEndian.Uint64(header[8:]),
+		Level:    int(binary.LittleEndian.Uint32(header[16:])),
+		MaxLevel: int(binary.LittleEndian.Uint32(header[20:])),
+	}
+	if file.Level > file.MaxLevel || file.MaxLevel > 255 {
+		return file, fmt.Errorf("%w: level %d of max %d", ErrSharedCorrupt, file.Level, file.MaxLevel)
+	}
+
+	// pending[i] is the offset the last node on level i links to
+	pending := make([]uint64, file.MaxLevel+1)
+	var fixed, forward, key, value []byte
+	for i := int64(-1); ; i++ {
+		offset := in.n
+		if i >= 0 {
+			if _, err := in.r.Peek(1); err == io.EOF {
+				break
+			}
+		}
+		if fixed, err = in.full(fixed, sharedNodeFixed); err != nil {
+			return file, fmt.Errorf("%w: reading node %d: %v", ErrSharedCorrupt, i, err)
+		}
+		level := int(binary.LittleEndian.Uint32(fixed))
+		keySize, valueSize := binary.LittleEndian.Uint32(fixed[4:]), binary.LittleEndian.Uint32(fixed[8:])
+		if level > file.MaxLevel || (i < 0 && level != file.MaxLevel) || keySize > maxInspectedRecord || valueSize > maxInspectedRecord {
+			return file, fmt.Errorf("%w: node %d at offset %d has level %d and %d+%d bytes", ErrSharedCorrupt, i, offset, level, keySize, valueSize)
+		}
+		if forward, err = in.full(forward, 8*(level+1)); err == nil {
+			if key, err = in.full(key, int(keySize)); err == nil {
+				if value, err = in.full(value, int(valueSize)); err == nil {
+					_, err = in.full(fixed, int(-in.n&7))
+				}
+			}
+		}
+		if err != nil {
+			return file, fmt.Errorf("%w: reading node %d: %v", ErrSharedCorrupt, i, err)
+		}
+
+		for l := 0; l <= level; l++ {
+			if i >= 0 && pending[l] != uint64(offset) {
+				file.Problems = append(file.Problems, fmt.Sprintf("node %d at offset %d is not linked from its predecessor on level %d, which links to %d", i, offset, l, pending[l]))
+			}
+			pending[l] = binary.LittleEndian.Uint64(forward[8*l:])
+		}
+		if i < 0 {
+			continue // the head node
+		}
+		if level > file.Level {
+			file.Problems = append(file.Problems, fmt.Sprintf("node %d has level %d above the list's %d", i, level, file.Level))
+		}
+		rec := PersistedRecord{Index: uint64(i), Offset: offset, Level: level, Key: key, Value: value}
+		if err := file.record(rec, fn); err != nil {
+			return file, err
+		}
+	}
+
+	for l, next := range pending {
+		if next != 0 {
+			file.Problems = append(file.Problems, fmt.Sprintf("level %d links past the last node to offset %d", l, next))
+		}
+	}
+	if file.Records != file.Count {
+		file.Problems = append(file.Problems, fmt.Sprintf("header declares %d nodes, found %d", file.Count, file.Records))
+	}
+	return file, in.finish(&file)
+}
@@ -0,0 +1,138 @@
+package zerocopyskiplist
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"os"
+	"testing"
+)
+
+func TestInspectSnapshot(t *testing.T) {
+	skiplist := newSnapshotTestList()
+	for i, item := range createTestItems(50) {
+		skiplist.Insert(item, TestContext{AccessCount: i})
+	}
+	var buf bytes.Buffer
+	if err := skiplist.SaveSnapshot(&buf, testItemCodecV2{}); err != nil {
+		t.Fatalf("SaveSnapshot: %v", err)
+	}
+	data := buf.Bytes()
+
+	var offsets []int64
+	file, err := InspectPersisted(bytes.NewReader(data), -1, func(rec PersistedRecord) error {
+		offsets = append(offsets, rec.Offset)
+		if rec.Key != nil || len(rec.Value) == 0 || rec.Level != -1 {
+			t.Errorf("Snapshot record %d is %+v", rec.Index, rec)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("InspectPersisted: %v", err)
+	}
+	if file.Kind != PersistedSnapshot || file.Count != 50 || file.Records != 50 || file.Schema != 2 || file.Size != int64(len(data)) {
+		t.Errorf("Inspected %+v", file)
+	}
+	if !file.HasChecksum || file.Checksum != file.Computed || len(file.Problems) != 0 {
+		t.Errorf("Intact snapshot has checksum %08x, computed %08x, problems %v", file.Checksum, file.Computed, file.Problems)
+	}
+	if len(offsets) != 50 || binary.LittleEndian.Uint32(data[offsets[1]:]) != uint32(offsets[2]-offsets[1]-4) {
+		t.Errorf("Record offsets %v do not frame the records", offsets)
+	}
+
+	// A flipped byte inside a record is a problem, trailing bytes another
+	damaged := append(bytes.Clone(data), 0, 0)
+	damaged[offsets[10]+6] ^= 0xff
+	if file, err := InspectPersisted(bytes.NewReader(damaged), -1, nil); err != nil || len(file.Problems) != 2 {
+		t.Errorf("Damaged snapshot gave %v, problems %v", err, file.Problems)
+	}
+
+	// A truncated one cannot be read to the end
+	if file, err := InspectPersisted(bytes.NewReader(data[:offsets[20]+3]), -1, nil); !errors.Is(err, ErrSnapshotCorrupt) || file.Records != 20 {
+		t.Errorf("Truncated snapshot gave %v after %d records", err, file.Records)
+	}
+}
+
+func TestInspectDelta(t *testing.T) {
+	header := persistHeader{kind: persistDelta, schema: 2, count: 2}
+	blob := header.appendTo(nil)
+	blob, _ = appendRecord(append(blob, deltaUpsert), testItemCodecV2{}, createTestItems(1)[0], TestContext{})
+	blob = intKeys{}.AppendKey(binary.LittleEndian.AppendUint32(append(blob, deltaDelete), 8), 7)
+	blob = binary.LittleEndian.AppendUint32(blob, crc32.Checksum(blob, crcTable))
+
+	var ops []string
+	file, err := InspectPersisted(bytes.NewReader(blob), -1, func(rec PersistedRecord) error {
+		ops = append(ops, rec.Op)
+		return nil
+	})
+	if err != nil || len(file.Problems) != 0 || file.Kind != PersistedDelta {
+		t.Fatalf("InspectPersisted: %v, %+v", err, file)
+	}
+	if len(ops) != 2 || ops[0] != "upsert" || ops[1] != "delete" {
+		t.Errorf("Delta operations %v", ops)
+	}
+	if !bytes.Equal(file.FirstKey, intKeys{}.AppendKey(nil, 7)) {
+		t.Errorf("Delta keys start at %x, want the deleted key", file.FirstKey)
+	}
+}
+
+func TestInspectIndex(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[PaddedRecord, int, PaddedContext](16, func(r *PaddedRecord) int { return int(r.ID) },
+		func(*PaddedRecord) int { return 16 }, compareInt)
+	records := make([]PaddedRecord, 100)
+	for i := range records {
+		records[i].ID = int64(i * 3)
+		skiplist.Insert(&records[i], PaddedContext{Generation: uint32(i)})
+	}
+	var buf bytes.Buffer
+	if err := skiplist.SaveIndex(&buf, intKeys{}, func(r *PaddedRecord) uint64 { return uint64(r.ID) * 16 }); err != nil {
+		t.Fatalf("SaveIndex: %v", err)
+	}
+
+	if _, err := InspectPersisted(bytes.NewReader(buf.Bytes()), -1, nil); err == nil {
+		t.Error("An index should need its context size")
+	}
+	file, err := InspectPersisted(bytes.NewReader(buf.Bytes()), 4, func(rec PersistedRecord) error {
+		if key := int(binary.LittleEndian.Uint64(rec.Key)); rec.ItemOffset != uint64(key)*16 || binary.LittleEndian.Uint32(rec.Context) != uint32(key/3) {
+			t.Errorf("Index record %d is %+v", rec.Index, rec)
+		}
+		return nil
+	})
+	if err != nil || len(file.Problems) != 0 || file.Records != 100 {
+		t.Fatalf("InspectPersisted: %v, %+v", err, file)
+	}
+	if binary.LittleEndian.Uint64(file.FirstKey) != 0 || binary.LittleEndian.Uint64(file.LastKey) != 297 {
+		t.Errorf("Index keys %x .. %x", file.FirstKey, file.LastKey)
+	}
+}
+
+func TestInspectShared(t *testing.T) {
+	data, err := os.ReadFile(publishTestSegment(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := InspectPersisted(bytes.NewReader(data), -1, nil)
+	if err != nil || len(file.Problems) != 0 {
+		t.Fatalf("InspectPersisted: %v, problems %v", err, file.Problems)
+	}
+	if file.Kind != PersistedShared || file.Records != 500 || file.Count != 500 || file.HasChecksum {
+		t.Errorf("Inspected %+v", file)
+	}
+	if binary.LittleEndian.Uint64(file.FirstKey) != 1 || binary.LittleEndian.Uint64(file.LastKey) != 999 {
+		t.Errorf("Segment keys %x .. %x", file.FirstKey, file.LastKey)
+	}
+
+	// Redirect the head's level 0 link to a later node
+	var second int64
+	InspectPersisted(bytes.NewReader(data), -1, func(rec PersistedRecord) error {
+		if rec.Index == 1 {
+			second = rec.Offset
+		}
+		return nil
+	})
+	binary.LittleEndian.PutUint64(data[sharedHeaderSize+sharedNodeFixed:], uint64(second))
+	if file, err := InspectPersisted(bytes.NewReader(data), -1, nil); err != nil || len(file.Problems) == 0 {
+		t.Errorf("A broken link gave %v, problems %v", err, file.Problems)
+	}
+}
@@ -0,0 +1,43 @@
+// iovmax.go - Per-platform limit on iovecs per vectored write
+
+package zerocopyskiplist
+
+import (
+	"sync"
+	"syscall"
+)
+
+// detectedIovMax caches the platform limit, which cannot change while the
+// process runs
+var detectedIovMax = sync.OnceValue(platformIovMax)
+
+// IovMax returns the largest number of iovecs the platform accepts in a single
+// writev or pwritev call, as sysconf(_SC_IOV_MAX) would report it.
+func IovMax() int {
+	return detectedIovMax()
+}
+
+// iovMax returns the chunk size for this list: the WithIovMax override if
+// there is one, otherwise the platform limit
+func (sl *ZeroCopySkiplist[T, K, C]) iovMax() int {
+	if sl.opts.iovMax > 0 {
+		return sl.opts.iovMax
+	}
+	return IovMax()
+}
+
+// IovecChunks splits iovecs into consecutive chunks no longer than the list's
+// iovec limit, each small enough to pass to one writev or pwritev call. The
+// chunks share iovecs' backing array.
+func (sl *ZeroCopySkiplist[T, K, C]) IovecChunks(iovecs []syscall.Iovec) [][]syscall.Iovec {
+	limit := sl.iovMax()
+	chunks := make([][]syscall.Iovec, 0, (len(iovecs)+limit-1)/limit)
+	for len(iovecs) > limit {
+		chunks = append(chunks, iovecs[:limit:limit])
+		iovecs = iovecs[limit:]
+	}
+	if len(iovecs) > 0 {
+		chunks = append(chunks, iovecs)
+	}
+	return chunks
+}
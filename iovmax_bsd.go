@@ -0,0 +1,16 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+// iovmax_bsd.go - IOV_MAX on the BSDs, read from the kern.iov_max sysctl
+
+package zerocopyskiplist
+
+import "syscall"
+
+// platformIovMax reads kern.iov_max, which backs sysconf(_SC_IOV_MAX), falling
+// back to 1024 (the value on every BSD release to date) if it is unavailable
+func platformIovMax() int {
+	if n, err := syscall.SysctlUint32("kern.iov_max"); err == nil && n > 0 {
+		return int(n)
+	}
+	return 1024
+}
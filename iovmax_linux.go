@@ -0,0 +1,9 @@
+// iovmax_linux.go - IOV_MAX on Linux
+
+package zerocopyskiplist
+
+// platformIovMax returns UIO_MAXIOV, the kernel's fixed limit, which is what
+// glibc and musl return for sysconf(_SC_IOV_MAX)
+func platformIovMax() int {
+	return 1024
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+// iovmax_other.go - IOV_MAX where the platform limit cannot be discovered
+
+package zerocopyskiplist
+
+// platformIovMax returns _XOPEN_IOV_MAX, the smallest limit POSIX allows
+func platformIovMax() int {
+	return 16
+}
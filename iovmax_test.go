@@ -0,0 +1,39 @@
+package zerocopyskiplist
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestIovecChunks(t *testing.T) {
+	if IovMax() < 16 {
+		t.Errorf("IovMax() = %d, below the POSIX minimum of 16", IovMax())
+	}
+
+	sl := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	if stats := sl.Stats(); stats.IovMax != IovMax() {
+		t.Errorf("Stats().IovMax = %d, want the platform limit %d", stats.IovMax, IovMax())
+	}
+	if chunks := sl.IovecChunks(nil); len(chunks) != 0 {
+		t.Errorf("IovecChunks(nil) returned %d chunks", len(chunks))
+	}
+
+	limited := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt, WithIovMax(4))
+	if stats := limited.Stats(); stats.IovMax != 4 {
+		t.Errorf("Stats().IovMax = %d, want the override 4", stats.IovMax)
+	}
+	iovecs := make([]syscall.Iovec, 10)
+	for i := range iovecs {
+		iovecs[i].SetLen(i)
+	}
+	chunks := limited.IovecChunks(iovecs)
+	if len(chunks) != 3 || len(chunks[0]) != 4 || len(chunks[1]) != 4 || len(chunks[2]) != 2 {
+		t.Fatalf("Chunk lengths wrong: %d chunks", len(chunks))
+	}
+	if chunks[2][1].Len != 9 {
+		t.Error("Chunks should keep the iovecs in order")
+	}
+	if cap(chunks[0]) != 4 {
+		t.Error("Appending to a chunk should not overwrite the next one")
+	}
+}
@@ -0,0 +1,213 @@
+// latency.go - Opt-in per-operation latency histograms
+
+package zerocopyskiplist
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/bits"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Operation identifies a class of list operation for latency histograms
+type Operation int
+
+const (
+	OperationInsert Operation = iota // Insert
+	OperationFind                    // Find, FindItem and Get
+	OperationDelete                  // Delete
+	OperationScan                    // ForEach and the iovec scans
+	numOperations
+)
+
+// String returns the operation's lower case name, as used in metric labels
+func (op Operation) String() string {
+	switch op {
+	case OperationInsert:
+		return "insert"
+	case OperationFind:
+		return "find"
+	case OperationDelete:
+		return "delete"
+	case OperationScan:
+		return "scan"
+	}
+	return "Operation(" + strconv.Itoa(int(op)) + ")"
+}
+
+// latencySubBuckets is the number of linear buckets per power of two, giving
+// about 12% worst-case relative error on reported percentiles
+const latencySubBuckets = 8
+
+// latencyBuckets covers every non-negative time.Duration
+const latencyBuckets = 64 * latencySubBuckets
+
+// latencyHistogram records durations in log-linear buckets, as HDR
+// histograms do, with atomic counters so concurrent operations record
+// without a lock
+type latencyHistogram struct {
+	counts [latencyBuckets]atomic.Uint64
+	total  atomic.Uint64
+	sum    atomic.Int64
+	max    atomic.Int64
+}
+
+// record adds one sample
+func (h *latencyHistogram) record(d time.Duration) {
+	d = max(d, 0)
+	h.counts[latencyBucketOf(uint64(d))].Add(1)
+	h.total.Add(1)
+	h.sum.Add(int64(d))
+	for {
+		m := h.max.Load()
+		if int64(d) <= m || h.max.CompareAndSwap(m, int64(d)) {
+			return
+		}
+	}
+}
+
+// snapshot copies the histogram; samples recorded meanwhile may be partly
+// included
+func (h *latencyHistogram) snapshot() LatencySnapshot {
+	var s LatencySnapshot
+	for i := range h.counts {
+		s.counts[i] = h.counts[i].Load()
+	}
+	s.total = h.total.Load()
+	s.sum = time.Duration(h.sum.Load())
+	s.max = time.Duration(h.max.Load())
+	return s
+}
+
+// LatencySnapshot is a copy of one operation's latency histogram, returned
+// in Stats.Latency. Latencies include the wait for the lock, so convoys of
+// writers show up in the tail percentiles.
+type LatencySnapshot struct {
+	counts [latencyBuckets]uint64
+	total  uint64
+	sum    time.Duration
+	max    time.Duration
+}
+
+// Count returns the number of operations recorded
+func (s *LatencySnapshot) Count() uint64 {
+	return s.total
+}
+
+// Sum returns the total time of the operations recorded
+func (s *LatencySnapshot) Sum() time.Duration {
+	return s.sum
+}
+
+// Max returns the slowest operation recorded
+func (s *LatencySnapshot) Max() time.Duration {
+	return s.max
+}
+
+// Mean returns the average operation time
+func (s *LatencySnapshot) Mean() time.Duration {
+	if s.total == 0 {
+		return 0
+	}
+	return s.sum / time.Duration(s.total)
+}
+
+// Percentile returns the upper bound of the bucket holding the p-th
+// percentile operation (0 < p <= 100), capped at Max
+func (s *LatencySnapshot) Percentile(p float64) time.Duration {
+	if s.total == 0 {
+		return 0
+	}
+
+	rank := max(uint64(p/100*float64(s.total)), 1)
+	var seen uint64
+	for i, count := range s.counts {
+		seen += count
+		if seen >= rank {
+			return min(time.Duration(latencyBucketUpper(i)), s.max)
+		}
+	}
+	return s.max
+}
+
+// below returns the number of operations that took less than d
+func (s *LatencySnapshot) below(d time.Duration) uint64 {
+	var n uint64
+	for _, count := range s.counts[:latencyBucketOf(uint64(d))] {
+		n += count
+	}
+	return n
+}
+
+// latencyBucketOf returns the bucket index for v
+func latencyBucketOf(v uint64) int {
+	if v < latencySubBuckets {
+		return int(v)
+	}
+	exp := bits.Len64(v) - 1 // v is in [2^exp, 2^(exp+1))
+	sub := (v >> (exp - 3)) & (latencySubBuckets - 1)
+	return (exp-2)*latencySubBuckets + int(sub)
+}
+
+// latencyBucketUpper returns the largest value that falls into bucket i
+func latencyBucketUpper(i int) uint64 {
+	if i < latencySubBuckets {
+		return uint64(i)
+	}
+	exp := i/latencySubBuckets + 2
+	sub := uint64(i % latencySubBuckets)
+	lower := uint64(1)<<exp | sub<<(exp-3)
+	return lower + uint64(1)<<(exp-3) - 1
+}
+
+// startOp returns the start time of an operation, the zero time when
+// latencies are not recorded so untracked lists never read the clock
+func (sl *ZeroCopySkiplist[T, K, C]) startOp() time.Time {
+	if sl.latency == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// endOp records an operation that began at start
+func (sl *ZeroCopySkiplist[T, K, C]) endOp(op Operation, start time.Time) {
+	if sl.latency != nil {
+		sl.latency[op].record(time.Since(start))
+	}
+}
+
+// prometheusBounds are the upper bounds, in powers of two nanoseconds from
+// about 1µs to 34s, of the buckets written by WritePrometheusLatency
+const (
+	prometheusMinExp = 10
+	prometheusMaxExp = 35
+)
+
+// WritePrometheusLatency writes the latency histograms in the Prometheus text
+// exposition format as the histogram metric name, labelled by operation, with
+// buckets doubling from about 1µs to 34s. It writes nothing without
+// WithLatencyHistograms.
+func (sl *ZeroCopySkiplist[T, K, C]) WritePrometheusLatency(w io.Writer, name string) error {
+	latency := sl.Stats().Latency
+	if latency == nil {
+		return nil
+	}
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "# HELP %s Skiplist operation latency in seconds, including lock waits.\n", name)
+	fmt.Fprintf(out, "# TYPE %s histogram\n", name)
+	for op := range numOperations {
+		s := latency[op]
+		for exp := prometheusMinExp; exp <= prometheusMaxExp; exp++ {
+			bound := time.Duration(1) << exp
+			fmt.Fprintf(out, "%s_bucket{op=%q,le=%q} %d\n", name, op.String(), strconv.FormatFloat(bound.Seconds(), 'g', -1, 64), s.below(bound))
+		}
+		fmt.Fprintf(out, "%s_bucket{op=%q,le=\"+Inf\"} %d\n", name, op.String(), s.Count())
+		fmt.Fprintf(out, "%s_sum{op=%q} %s\n", name, op.String(), strconv.FormatFloat(s.Sum().Seconds(), 'g', -1, 64))
+		fmt.Fprintf(out, "%s_count{op=%q} %d\n", name, op.String(), s.Count())
+	}
+	return out.Flush()
+}
@@ -0,0 +1,72 @@
+package zerocopyskiplist
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLatencyHistograms(t *testing.T) {
+	sl := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt, WithLatencyHistograms())
+	items := createTestItems(100)
+	for _, item := range items {
+		sl.Insert(item, TestContext{})
+	}
+	for _, item := range items[:50] {
+		sl.Find(item.ID)
+	}
+	sl.Get(1)
+	sl.Delete(1)
+	sl.ForEach(func(*ItemPtr[TestItem, int, TestContext]) bool { return true })
+	sl.ToIovecSlice(TestContext{})
+
+	latency := sl.Stats().Latency
+	for op, want := range map[Operation]uint64{OperationInsert: 100, OperationFind: 51, OperationDelete: 1, OperationScan: 2} {
+		s := &latency[op]
+		if s.Count() != want {
+			t.Errorf("Expected %d %v operations, got %d", want, op, s.Count())
+		}
+		if s.Max() <= 0 || s.Mean() > s.Max() || s.Percentile(50) > s.Percentile(100) || s.Percentile(100) != s.Max() {
+			t.Errorf("%v: inconsistent summary max %v mean %v p50 %v p100 %v", op, s.Max(), s.Mean(), s.Percentile(50), s.Percentile(100))
+		}
+	}
+
+	// A write stalled behind the lock lands in the tail
+	sl.rw.Lock()
+	done := make(chan struct{})
+	go func() {
+		sl.Insert(items[0], TestContext{})
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	sl.rw.Unlock()
+	<-done
+	inserts := sl.Stats().Latency[OperationInsert]
+	if inserts.Max() < 10*time.Millisecond || inserts.Percentile(50) >= 10*time.Millisecond {
+		t.Errorf("Expected one stalled insert in the tail, got max %v p50 %v", inserts.Max(), inserts.Percentile(50))
+	}
+
+	var buf bytes.Buffer
+	if err := sl.WritePrometheusLatency(&buf, "zcsl_op_seconds"); err != nil {
+		t.Fatalf("WritePrometheusLatency: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"# TYPE zcsl_op_seconds histogram\n",
+		`zcsl_op_seconds_bucket{op="insert",le="+Inf"} 101` + "\n",
+		`zcsl_op_seconds_count{op="find"} 51` + "\n",
+		`zcsl_op_seconds_bucket{op="delete",le="1.024e-06"} `,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Prometheus output lacks %q:\n%s", want, out)
+		}
+	}
+
+	untracked := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	untracked.Insert(items[0], TestContext{})
+	buf.Reset()
+	if untracked.Stats().Latency != nil || untracked.WritePrometheusLatency(&buf, "x") != nil || buf.Len() != 0 {
+		t.Error("Lists without WithLatencyHistograms should report no latencies")
+	}
+}
@@ -0,0 +1,130 @@
+// lease.go - Keeping items referenced by in-flight iovecs alive
+
+package zerocopyskiplist
+
+import "syscall"
+
+// Lease holds the entries whose items are referenced by a set of iovecs, so
+// they are not deleted or replaced while a write that points at their memory
+// is in flight. Release it once the write has completed.
+type Lease[T any, K comparable, C comparable] struct {
+	sl   *ZeroCopySkiplist[T, K, C]
+	keys []K
+}
+
+// deferredWrite is a Delete or replacing Insert of a leased entry, applied
+// when its last lease is released
+type deferredWrite[T any, C comparable] struct {
+	item    *T // nil for a delete
+	context C
+}
+
+// CallbackToLeasedIovecSlice is CallbackToIovecSlice that also leases every
+// included entry. While an entry is leased, Delete and an Insert replacing its
+// item are deferred until the last lease on it is released: they return as if
+// they had happened, readers keep seeing the old entry, and the latest deferred
+// write is applied by Release, passing quotas again for a replacement and
+// discarded if the list has been frozen. Leased entries are never evicted.
+// Clear, MoveTo and Close do not wait for leases.
+//
+// Items the callback accepts that are deleted or replaced before the lease is
+// taken are left out of the result.
+func (sl *ZeroCopySkiplist[T, K, C]) CallbackToLeasedIovecSlice(callback func(*ItemPtr[T, K, C]) bool) ([]syscall.Iovec, *Lease[T, K, C]) {
+	defer sl.endOp(OperationScan, sl.startOp())
+	var accepted []Entry[T, K, C]
+	sl.ForEach(func(ip *ItemPtr[T, K, C]) bool {
+		if callback(ip) {
+			accepted = append(accepted, ip.Entry())
+		}
+		return true
+	})
+
+	lease := &Lease[T, K, C]{sl: sl, keys: make([]K, 0, len(accepted))}
+	iovecs := make([]syscall.Iovec, 0, len(accepted))
+
+	func() {
+		sl.lock()
+		defer sl.unlock()
+		for _, entry := range accepted {
+			node := sl.seek(entry.Key, true)
+			if node == nil || sl.cmpKey(node.key, entry.Key) != 0 || node.item != entry.Item {
+				continue
+			}
+			if sl.leases == nil {
+				sl.leases = make(map[K]int)
+			}
+			sl.leases[node.key]++
+			lease.keys = append(lease.keys, node.key)
+			iovecs = append(iovecs, sl.iovec(node.item))
+		}
+	}()
+
+	if sl.leaks != nil {
+		sl.leaks.trackIovecs(iovecs)
+	}
+	return iovecs, lease
+}
+
+// Len returns the number of entries held by the lease
+func (l *Lease[T, K, C]) Len() int {
+	return len(l.keys)
+}
+
+// Release drops the lease and applies the writes deferred on entries it was
+// the last lease on. A deferred replacement refused by a context quota is
+// dropped, leaving the entry as it was, and counted in Stats.Refused.
+// Releasing a lease more than once does nothing.
+func (l *Lease[T, K, C]) Release() {
+	sl := l.sl
+	hook, totalBytes, entries := func() (func(int64, int), int64, int) {
+		sl.lock()
+		defer sl.unlock()
+		for _, key := range l.keys {
+			if sl.leases[key] > 1 {
+				sl.leases[key]--
+				continue
+			}
+			delete(sl.leases, key)
+
+			write, ok := sl.deferred[key]
+			if !ok {
+				continue
+			}
+			delete(sl.deferred, key)
+			if sl.frozen {
+				continue
+			}
+			if write.item == nil {
+				sl.delete(key)
+			} else {
+				sl.insert(write.item, write.context)
+			}
+		}
+		l.keys = nil
+		return sl.checkPressure()
+	}()
+
+	if hook != nil {
+		hook(totalBytes, entries)
+	}
+}
+
+// leased returns true if key is leased; the caller must hold the lock
+func (sl *ZeroCopySkiplist[T, K, C]) leased(key K) bool {
+	return len(sl.leases) > 0 && sl.leases[key] > 0
+}
+
+// deferWrite records a write to a leased entry, replacing any earlier one;
+// the caller must hold the write lock
+func (sl *ZeroCopySkiplist[T, K, C]) deferWrite(key K, item *T, context C) {
+	if sl.deferred == nil {
+		sl.deferred = make(map[K]deferredWrite[T, C])
+	}
+	sl.deferred[key] = deferredWrite[T, C]{item: item, context: context}
+}
+
+// evictable returns true if key is neither pinned nor leased; the caller
+// must hold the lock
+func (sl *ZeroCopySkiplist[T, K, C]) evictable(key K) bool {
+	return !sl.pinned(key) && !sl.leased(key)
+}
@@ -0,0 +1,74 @@
+package zerocopyskiplist
+
+import "testing"
+
+func TestLeaseDefersWrites(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		16,
+		getKeyFromTestItem,
+		func(item *TestItem) int { return len(item.Data) },
+		compareInt,
+	)
+	for i := 1; i <= 10; i++ {
+		skiplist.Insert(&TestItem{ID: i, Data: make([]byte, 8)}, TestContext{})
+	}
+
+	iovecs, lease := skiplist.CallbackToLeasedIovecSlice(func(ip *ItemPtr[TestItem, int, TestContext]) bool {
+		return ip.Key() <= 3
+	})
+	if len(iovecs) != 3 || lease.Len() != 3 {
+		t.Fatalf("Leased %d iovecs for %d entries, want 3", len(iovecs), lease.Len())
+	}
+
+	// Writes to leased entries are deferred, others happen at once
+	original := skiplist.FindItem(2).Item()
+	replacement := &TestItem{ID: 2, Data: make([]byte, 16)}
+	if !skiplist.Delete(1) || skiplist.Insert(replacement, TestContext{AccessCount: 1}) || !skiplist.Delete(5) {
+		t.Error("Writes to leased entries should report success")
+	}
+	if skiplist.FindItem(1) == nil || skiplist.FindItem(2).Item() != original || skiplist.FindItem(5) != nil {
+		t.Error("Leased entries should be unchanged until the lease is released")
+	}
+	if freed, count := skiplist.ShedOldest(8); freed != 8 || count != 1 || skiplist.FindItem(4) != nil {
+		t.Errorf("ShedOldest should skip leased entries, freed %d bytes in %d items", freed, count)
+	}
+	if stats := skiplist.Stats(); stats.LeasedEntries != 3 || stats.DeferredWrites != 2 {
+		t.Errorf("LeasedEntries = %d, DeferredWrites = %d; want 3, 2", stats.LeasedEntries, stats.DeferredWrites)
+	}
+
+	lease.Release()
+	lease.Release()
+	if skiplist.FindItem(1) != nil || skiplist.FindItem(2).Item() != replacement || skiplist.FindItem(3) == nil {
+		t.Error("Release should apply the deferred writes")
+	}
+	if _, context := skiplist.Find(2); context.AccessCount != 1 {
+		t.Error("A deferred replacement should carry its context")
+	}
+	if stats := skiplist.Stats(); stats.LeasedEntries != 0 || stats.DeferredWrites != 0 || stats.Length != 7 {
+		t.Errorf("Stats after Release = %+v", stats)
+	}
+	if err := skiplist.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+func TestLeaseNested(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt)
+	for _, item := range createTestItems(3) {
+		skiplist.Insert(item, TestContext{})
+	}
+
+	all := func(*ItemPtr[TestItem, int, TestContext]) bool { return true }
+	_, first := skiplist.CallbackToLeasedIovecSlice(all)
+	_, second := skiplist.CallbackToLeasedIovecSlice(all)
+	skiplist.Delete(2)
+
+	first.Release()
+	if skiplist.FindItem(2) == nil {
+		t.Error("An entry should stay while any lease holds it")
+	}
+	second.Release()
+	if skiplist.FindItem(2) != nil || skiplist.Length() != 2 {
+		t.Error("The deferred delete should apply when the last lease is released")
+	}
+}
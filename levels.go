@@ -0,0 +1,144 @@
+// levels.go - Level generation policies for new nodes
+
+package zerocopyskiplist
+
+import "math/rand"
+
+// LevelPolicy chooses the level of a new node. seq counts the nodes created
+// before it and appending is true when the node goes after the current last
+// node. Levels outside [0, maxLevel] are clamped.
+type LevelPolicy func(seq uint64, appending bool, maxLevel int) int
+
+// GeometricLevels promotes each node one more level with probability p, the
+// classic skiplist distribution; the default is p = 0.5. A smaller p makes
+// searches a little longer and nodes smaller.
+func GeometricLevels(p float64) LevelPolicy {
+	return func(_ uint64, _ bool, maxLevel int) int {
+		level := 0
+		for level < maxLevel && rand.Float64() < p {
+			level++
+		}
+		return level
+	}
+}
+
+// EveryKthLevels promotes deterministically: every k-th node created reaches
+// level 1, every k²-th level 2 and so on. Loading keys in ascending order then
+// builds a perfectly balanced list, with no variance in search depth, but an
+// adversarial insert order can unbalance it.
+func EveryKthLevels(k int) LevelPolicy {
+	k = max(k, 2)
+	return func(seq uint64, _ bool, maxLevel int) int {
+		return kthLevel(seq, k, maxLevel)
+	}
+}
+
+// AppendBiasedLevels promotes appended nodes as EveryKthLevels(k) does and
+// other nodes as GeometricLevels(p) does, for lists that mostly grow at the
+// end but take occasional out of order inserts: the appended run stays
+// balanced while the rest keeps random promotion's resistance to bad orders.
+func AppendBiasedLevels(k int, p float64) LevelPolicy {
+	k = max(k, 2)
+	geometric := GeometricLevels(p)
+	return func(seq uint64, appending bool, maxLevel int) int {
+		if appending {
+			return kthLevel(seq, k, maxLevel)
+		}
+		return geometric(seq, appending, maxLevel)
+	}
+}
+
+// kthLevel returns how many times k divides seq+1, at most maxLevel
+func kthLevel(seq uint64, k int, maxLevel int) int {
+	level := 0
+	for n := seq + 1; level < maxLevel && n%uint64(k) == 0; n /= uint64(k) {
+		level++
+	}
+	return level
+}
+
+// defaultLevels is the policy used without WithLevelPolicy
+var defaultLevels = GeometricLevels(0.5)
+
+// EstimatedSearchSteps estimates the forward steps of an average search from
+// LevelCounts, assuming the nodes of each level are spread evenly between
+// those of the level above: a search crosses half of the gap between two
+// promoted nodes on every level. Compare it between level policies on the
+// same data; it is not a measurement.
+func (s Stats) EstimatedSearchSteps() float64 {
+	steps := 0.0
+	atOrAbove := 0
+	for level := len(s.LevelCounts) - 1; level >= 0; level-- {
+		above := atOrAbove
+		atOrAbove += s.LevelCounts[level]
+		if atOrAbove > 0 {
+			steps += float64(atOrAbove) / float64(above+1) / 2
+		}
+	}
+	return steps
+}
+
+// LinkBytes returns the estimated link memory of the list: every node's
+// LevelBytes plus HeadBytes
+func (s Stats) LinkBytes() int64 {
+	total := s.HeadBytes
+	for _, bytes := range s.LevelBytes {
+		total += bytes
+	}
+	return total
+}
+
+// UnusedLevelBytes returns the part of HeadBytes spent on levels above Level,
+// which no node has reached: the cost of a MaxLevel larger than the list
+// needs. A list of n entries rarely uses more than log2(n) levels.
+func (s Stats) UnusedLevelBytes() int64 {
+	if s.MaxLevel < 0 {
+		return 0
+	}
+	return s.HeadBytes * int64(s.MaxLevel-s.Level) / int64(s.MaxLevel+1)
+}
+
+// Rebalance reassigns the level of every node so the list is perfectly
+// balanced: every second node reaches level 1, every fourth level 2 and so on
+// up to the maximum level, giving the shortest searches the list can have.
+// Later inserts are promoted by the level policy as usual, so it suits lists
+// that change slowly, for example after BulkLoad or before handing the list
+// to many readers. Keys, items and contexts are unchanged, so it is allowed
+// on a frozen list; the write lock is held for a single pass over the nodes.
+func (sl *ZeroCopySkiplist[T, K, C]) Rebalance() {
+	sl.lock()
+	defer sl.unlock()
+	sl.rebalance()
+}
+
+// rebalance relinks the nodes in order with levels from kthLevel; the caller
+// must hold the write lock
+func (sl *ZeroCopySkiplist[T, K, C]) rebalance() {
+	current := sl.header.forward[0]
+	for i := range sl.header.forward {
+		sl.link(sl.header, i, nil)
+		sl.tails[i] = nil
+	}
+	sl.level = 0
+	clear(sl.levelCounts)
+
+	for seq := uint64(0); current != nil; seq++ {
+		next := current.forward[0]
+		level := kthLevel(seq, 2, sl.maxLevel)
+		sl.sizeLinks(current, level)
+		sl.levelCounts[level]++
+		sl.level = max(sl.level, level)
+
+		for i := 0; i <= level; i++ {
+			prev := sl.tails[i]
+			if prev == nil {
+				prev = sl.header
+			}
+			sl.link(prev, i, current)
+			current.forward[i] = nil
+			current.setPrevAt(i, sl.nodeOrNil(prev))
+			sl.tails[i] = current
+		}
+		current = next
+	}
+}
@@ -0,0 +1,215 @@
+package zerocopyskiplist
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+	"unsafe"
+)
+
+func TestLevelSequenceReplay(t *testing.T) {
+	// Record a list's topology under a random workload
+	recorded := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		10,
+		getKeyFromTestItem,
+		getTestItemSize,
+		compareInt,
+		WithLevelRecording(),
+	)
+
+	items := createTestItems(300)
+	rng := rand.New(rand.NewSource(9))
+	order := rng.Perm(len(items))
+	deletes := rng.Perm(len(items))[:100]
+	for _, i := range order {
+		recorded.Insert(items[i], TestContext{})
+	}
+	for _, i := range deletes {
+		recorded.Delete(items[i].ID)
+	}
+
+	levels := recorded.LevelSequence()
+	if len(levels) != len(items) {
+		t.Fatalf("Expected %d recorded levels, got %d", len(items), len(levels))
+	}
+
+	// Replay the same operations with the recorded levels
+	replayed := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		10,
+		getKeyFromTestItem,
+		getTestItemSize,
+		compareInt,
+		WithLevelSequence(levels),
+	)
+	for _, i := range order {
+		replayed.Insert(items[i], TestContext{})
+	}
+	for _, i := range deletes {
+		replayed.Delete(items[i].ID)
+	}
+
+	if replayed.level != recorded.level {
+		t.Errorf("Replayed list level %d differs from recorded %d", replayed.level, recorded.level)
+	}
+	a, b := recorded.First(), replayed.First()
+	for a != nil && b != nil {
+		if a.Key() != b.Key() || a.level != b.level {
+			t.Fatalf("Key %d has level %d in the recording but key %d has level %d in the replay", a.Key(), a.level, b.Key(), b.level)
+		}
+		a, b = a.Next(), b.Next()
+	}
+	if a != nil || b != nil {
+		t.Error("Recorded and replayed lists should have the same length")
+	}
+}
+
+func TestLevelSequenceClampAndExhaust(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		4,
+		getKeyFromTestItem,
+		getTestItemSize,
+		compareInt,
+		WithLevelSequence([]int{9, -1, 2}),
+		WithLevelRecording(),
+	)
+
+	for _, item := range createTestItems(10) {
+		skiplist.Insert(item, TestContext{})
+	}
+
+	levels := skiplist.LevelSequence()
+	if levels[0] != 4 || levels[1] != 0 || levels[2] != 2 {
+		t.Errorf("Expected clamped levels [4 0 2], got %v", levels[:3])
+	}
+	for _, level := range levels[3:] {
+		if level < 0 || level > 4 {
+			t.Errorf("Random level %d after the sequence is out of range", level)
+		}
+	}
+	if err := skiplist.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+func TestLevelPolicies(t *testing.T) {
+	items := createTestItems(4096)
+	build := func(policy LevelPolicy) *ZeroCopySkiplist[TestItem, int, TestContext] {
+		sl := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt, WithLevelPolicy(policy))
+		for _, item := range items {
+			sl.Insert(item, TestContext{})
+		}
+		if err := sl.Validate(); err != nil {
+			t.Fatalf("Validate: %v", err)
+		}
+		return sl
+	}
+
+	// Every 4th node is promoted, every 16th twice and so on
+	stats := build(EveryKthLevels(4)).Stats()
+	if want := []int{3072, 768, 192, 48, 12, 3, 1}; !slices.Equal(stats.LevelCounts, want) {
+		t.Errorf("EveryKthLevels(4) level counts = %v, want %v", stats.LevelCounts, want)
+	}
+	balanced := stats.EstimatedSearchSteps()
+	if sparse := build(GeometricLevels(0.05)).Stats().EstimatedSearchSteps(); sparse <= balanced {
+		t.Errorf("Sparse promotion should estimate longer searches: %v <= %v", sparse, balanced)
+	}
+
+	// Out of order inserts use the geometric fallback, never promoting with p = 0
+	sl := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt, WithLevelPolicy(AppendBiasedLevels(2, 0)))
+	for i := len(items) - 1; i >= 0; i-- {
+		sl.Insert(items[i], TestContext{})
+	}
+	if counts := sl.Stats().LevelCounts; len(counts) != 1 || counts[0] != 4096 {
+		t.Errorf("Prepends should stay on level 0, got %v", counts)
+	}
+	appended := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt, WithLevelPolicy(AppendBiasedLevels(2, 0)))
+	appended.BulkLoad(items, nil)
+	if counts := appended.Stats().LevelCounts; len(counts) != 13 || counts[12] != 1 {
+		t.Errorf("Appends should be promoted every other node, got %v", counts)
+	}
+
+	// Counts follow deletes
+	appended.Delete(4096)
+	appended.Delete(1)
+	if counts := appended.Stats().LevelCounts; counts[0] != 2047 || len(counts) != 12 {
+		t.Errorf("Level counts after deletes = %v", counts)
+	}
+}
+
+func TestLevelBytes(t *testing.T) {
+	const ptr = int64(unsafe.Sizeof(uintptr(0)))
+	sl := MakeZeroCopySkiplist[TestItem, int, TestContext](32, getKeyFromTestItem, getTestItemSize, compareInt, WithLevelPolicy(EveryKthLevels(4)))
+	for _, item := range createTestItems(64) {
+		sl.Insert(item, TestContext{})
+	}
+
+	// 48 nodes of level 0, 12 of level 1, 3 of level 2 and one of level 3
+	stats := sl.Stats()
+	if want := []int64{48 * ptr, 12 * 2 * ptr, 3 * 3 * ptr, 4 * ptr}; !slices.Equal(stats.LevelBytes, want) {
+		t.Errorf("LevelBytes = %v, want %v", stats.LevelBytes, want)
+	}
+	if stats.HeadBytes != 33*2*ptr {
+		t.Errorf("HeadBytes = %d, want %d", stats.HeadBytes, 33*2*ptr)
+	}
+	if stats.LinkBytes() != stats.HeadBytes+(48+24+9+4)*ptr {
+		t.Errorf("LinkBytes = %d", stats.LinkBytes())
+	}
+	if want := stats.HeadBytes * 29 / 33; stats.UnusedLevelBytes() != want {
+		t.Errorf("UnusedLevelBytes = %d, want the %d bytes of 29 unused levels", stats.UnusedLevelBytes(), want)
+	}
+
+	// Backlinks and inline keys add to every node's links
+	wide := MakeZeroCopySkiplist[TestItem, int, TestContext](32, getKeyFromTestItem, getTestItemSize, compareInt,
+		WithLevelPolicy(EveryKthLevels(4)), WithLevelBacklinks(), WithInlineKeys())
+	for _, item := range createTestItems(64) {
+		wide.Insert(item, TestContext{})
+	}
+	key := int64(unsafe.Sizeof(0))
+	if got, want := wide.Stats().LevelBytes[1], 12*(2*ptr+ptr+2*key); got != want {
+		t.Errorf("Level 1 bytes with backlinks and inline keys = %d, want %d", got, want)
+	}
+}
+
+func TestRebalance(t *testing.T) {
+	items := createTestItems(1000)
+	order := rand.New(rand.NewSource(3)).Perm(len(items))
+	for _, opts := range [][]Option{nil, {WithLevelBacklinks(), WithInlineKeys()}} {
+		sl := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt, opts...)
+		for _, i := range order {
+			sl.Insert(items[i], TestContext{AccessCount: items[i].ID})
+		}
+
+		sl.Rebalance()
+		if err := sl.Validate(); err != nil {
+			t.Fatalf("Validate after Rebalance: %v", err)
+		}
+		stats := sl.Stats()
+		if want := []int{500, 250, 125, 63, 31, 16, 8, 4, 2, 1}; !slices.Equal(stats.LevelCounts, want) {
+			t.Errorf("Level counts after Rebalance = %v, want %v", stats.LevelCounts, want)
+		}
+
+		// Contents and backward traversal are intact
+		want := 1
+		for node := sl.First(); node != nil; node = node.Next() {
+			if node.Key() != want || node.Context().AccessCount != want {
+				t.Fatalf("Expected key %d after Rebalance, got %d", want, node.Key())
+			}
+			want++
+		}
+		if last := sl.Last(); last == nil || last.Key() != 1000 || last.Prev().Key() != 999 {
+			t.Error("Backward links are broken after Rebalance")
+		}
+		for _, key := range []int{1, 500, 1000} {
+			if node, _ := sl.Find(key); node == nil {
+				t.Errorf("Find(%d) failed after Rebalance", key)
+			}
+		}
+
+		// The list keeps working after it is rebalanced
+		sl.Delete(512)
+		sl.Insert(items[511], TestContext{})
+		if err := sl.Validate(); err != nil {
+			t.Errorf("Validate after mutations: %v", err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// lockcheck.go - Catching re-entrant locking in paranoid builds
+
+package zerocopyskiplist
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// ErrLockReentry is the panic value, wrapped with the offending call, raised
+// in zcslparanoid builds when a goroutine that holds a list's lock locks it
+// again. Without the tag such a call deadlocks instead.
+var ErrLockReentry = errors.New("goroutine already holds the list's lock")
+
+// lockHolders records which goroutines hold a list's lock; it is only
+// maintained in zcslparanoid builds
+type lockHolders struct {
+	mu      sync.Mutex
+	writer  uint64         // goroutine holding the write lock, 0 if none
+	readers map[uint64]int // read locks held by each goroutine
+}
+
+// holds reports whether goroutine g holds the lock
+func (h *lockHolders) holds(g uint64) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.writer == g || h.readers[g] > 0
+}
+
+// check panics with ErrLockReentry if goroutine g holds the lock
+func (h *lockHolders) check(g uint64, call string) {
+	if h.holds(g) {
+		panic(fmt.Errorf("zerocopyskiplist: %s: %w; callbacks run under the lock (comparators, key and size functions, level policies) must not call back into the list", call, ErrLockReentry))
+	}
+}
+
+// acquired records that goroutine g took the lock
+func (h *lockHolders) acquired(g uint64, write bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if write {
+		h.writer = g
+		return
+	}
+	if h.readers == nil {
+		h.readers = make(map[uint64]int)
+	}
+	h.readers[g]++
+}
+
+// released records that goroutine g released the lock
+func (h *lockHolders) released(g uint64, write bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if write {
+		h.writer = 0
+		return
+	}
+	if h.readers[g]--; h.readers[g] <= 0 {
+		delete(h.readers, g)
+	}
+}
+
+// goroutineID parses the current goroutine's id from its stack header; it is
+// slow and only used in zcslparanoid builds
+func goroutineID() uint64 {
+	var buf [64]byte
+	header := buf[:runtime.Stack(buf[:], false)]
+	header = bytes.TrimPrefix(header, []byte("goroutine "))
+	id, _ := strconv.ParseUint(string(header[:bytes.IndexByte(header, ' ')]), 10, 64)
+	return id
+}
+
+// rlock takes the read lock as rlockFlat does, first giving a list in small
+// list storage its nodes under the write lock
+func (sl *ZeroCopySkiplist[T, K, C]) rlock() {
+	sl.rlockFlat()
+	for sl.flat {
+		sl.runlock()
+		sl.lock()
+		sl.unlock()
+		sl.rlockFlat()
+	}
+}
+
+// rlockFlat takes the read lock, panicking in zcslparanoid builds if the
+// calling goroutine already holds the lock. The list may be in small list
+// storage, see WithSmallListThreshold.
+func (sl *ZeroCopySkiplist[T, K, C]) rlockFlat() {
+	if !paranoidBuild {
+		sl.rw.RLock()
+		return
+	}
+	g := goroutineID()
+	sl.holders.check(g, "read lock")
+	sl.rw.RLock()
+	sl.holders.acquired(g, false)
+}
+
+// runlock releases the read lock taken by rlock
+func (sl *ZeroCopySkiplist[T, K, C]) runlock() {
+	if paranoidBuild {
+		sl.holders.released(goroutineID(), false)
+	}
+	sl.rw.RUnlock()
+}
+
+// AssertNotLocked panics with ErrLockReentry in zcslparanoid builds if the
+// calling goroutine holds the list's lock, and does nothing otherwise. Call
+// it at the top of code that may run inside a callback, such as a comparator
+// or level policy, before calling into the list: in tests built with the tag
+// a would-be deadlock then fails with a stack instead of hanging.
+func (sl *ZeroCopySkiplist[T, K, C]) AssertNotLocked() {
+	if paranoidBuild {
+		sl.holders.check(goroutineID(), "AssertNotLocked")
+	}
+}
+
+// HoldsLock reports whether the calling goroutine holds the list's lock. It
+// is only tracked in zcslparanoid builds and always returns false otherwise.
+func (sl *ZeroCopySkiplist[T, K, C]) HoldsLock() bool {
+	return paranoidBuild && sl.holders.holds(goroutineID())
+}
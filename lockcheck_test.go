@@ -0,0 +1,51 @@
+package zerocopyskiplist
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLockReentry(t *testing.T) {
+	var sl *ZeroCopySkiplist[TestItem, int, TestContext]
+	var heldInCompare bool
+	reenter := false
+	sl = MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, func(a, b int) int {
+		heldInCompare = sl.HoldsLock()
+		if reenter {
+			sl.AssertNotLocked()
+		}
+		return compareInt(a, b)
+	})
+	items := createTestItems(3)
+	sl.Insert(items[0], TestContext{})
+	sl.Insert(items[1], TestContext{})
+
+	// Outside the lock the assertion always passes
+	sl.AssertNotLocked()
+	if sl.HoldsLock() {
+		t.Error("HoldsLock should be false outside the list")
+	}
+
+	if !paranoidBuild {
+		if heldInCompare {
+			t.Error("HoldsLock is only tracked in zcslparanoid builds")
+		}
+		reenter = true
+		sl.Insert(items[2], TestContext{})
+		return
+	}
+
+	if !heldInCompare {
+		t.Error("HoldsLock should be true inside the comparator")
+	}
+	reenter = true
+	func() {
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.Is(err, ErrLockReentry) {
+				t.Errorf("Expected an ErrLockReentry panic, got %v", err)
+			}
+		}()
+		sl.Insert(items[2], TestContext{})
+	}()
+}
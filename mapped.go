@@ -0,0 +1,452 @@
+// mapped.go - Crash-consistent item storage in a file-backed mmap
+
+package zerocopyskiplist
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"reflect"
+	"sort"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// ErrMappedCorrupt is returned when a mapped file's header is malformed
+var ErrMappedCorrupt = errors.New("mapped file is corrupt")
+
+// ErrMappedClosed is returned when using a closed MappedFile
+var ErrMappedClosed = errors.New("mapped file is closed")
+
+// mappedMagic starts every mapped file
+var mappedMagic = [8]byte{'Z', 'C', 'S', 'L', 'M', 'A', 'P', '1'}
+
+// mappedHeaderSize is the size of the file header: magic, item size and slot
+// size as uint32 and capacity as uint64, padded
+const mappedHeaderSize = 64
+
+// mappedSlotHeader is the size of the header before each item: state and
+// checksum as uint32 and commit sequence as uint64
+const mappedSlotHeader = 16
+
+// Slot states
+const (
+	mappedFree = 0
+	mappedLive = 1
+)
+
+// MappedFile stores fixed layout items in a file mapped MAP_SHARED, so the
+// items are their own persistent form: nothing is serialized, and a list of
+// them writes through to the file as the items are modified in place. It
+// suits telemetry buffers and counters, where encoding each change for a
+// writev costs more than the change.
+//
+// The file is an array of slots, each a small header and one item. An item
+// becomes durable when Msync commits it: its checksum and a commit sequence
+// number are written to its slot and the pages holding it are flushed, so
+// after a crash RecoverMapped finds every item in the state of its last
+// Msync. An item modified after its last Msync may reach the file whole,
+// in part or not at all; its checksum then no longer matches and recovery
+// reports it as torn rather than loading bytes that were never committed.
+// Items allocated but never committed do not survive a crash.
+//
+// T must be free of pointers, as for OffHeapNew. A MappedFile is safe for
+// concurrent use, but an item must not be modified while it is being
+// committed.
+type MappedFile[T any] struct {
+	mu       sync.Mutex
+	file     *os.File
+	data     []byte
+	itemSize int
+	slotSize int
+	used     []bool // slots holding an item, committed or not
+	free     []int  // unused slots, popped from the end
+	live     int
+	seq      uint64 // commit sequence of the next Msync
+	closed   bool
+}
+
+// OpenMappedFile opens the mapped file at path, creating it with room for
+// capacity items if it does not exist. An existing file keeps the capacity
+// it was created with and must have been created for items of T's size.
+// Its committed items are left in place for RecoverMapped, which should be
+// run before new items are allocated.
+func OpenMappedFile[T any](path string, capacity int) (*MappedFile[T], error) {
+	t := reflect.TypeFor[T]()
+	if typeHasPointers(t) {
+		return nil, fmt.Errorf("type %v contains pointers and cannot be stored in a mapped file", t)
+	}
+	if capacity <= 0 {
+		return nil, fmt.Errorf("invalid mapped file capacity: %d", capacity)
+	}
+	itemSize := int(t.Size())
+	slotSize := alignOffHeap(mappedSlotHeader + itemSize)
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	header := make([]byte, mappedHeaderSize)
+	if info.Size() == 0 {
+		copy(header, mappedMagic[:])
+		binary.LittleEndian.PutUint32(header[8:], uint32(itemSize))
+		binary.LittleEndian.PutUint32(header[12:], uint32(slotSize))
+		binary.LittleEndian.PutUint64(header[16:], uint64(capacity))
+		if err := file.Truncate(int64(mappedHeaderSize + capacity*slotSize)); err == nil {
+			_, err = file.WriteAt(header, 0)
+		}
+		if err == nil {
+			err = file.Sync()
+		}
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("creating mapped file %s: %w", path, err)
+		}
+	} else if _, err := file.ReadAt(header, 0); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%w: reading header: %v", ErrMappedCorrupt, err)
+	}
+
+	if [8]byte(header) != mappedMagic {
+		file.Close()
+		return nil, fmt.Errorf("%w: bad magic", ErrMappedCorrupt)
+	}
+	if size := int(binary.LittleEndian.Uint32(header[8:])); size != itemSize || int(binary.LittleEndian.Uint32(header[12:])) != slotSize {
+		file.Close()
+		return nil, fmt.Errorf("mapped file %s holds items of %d bytes, not %d", path, size, itemSize)
+	}
+	capacity = int(binary.LittleEndian.Uint64(header[16:]))
+	length := int64(mappedHeaderSize) + int64(capacity)*int64(slotSize)
+	if info, err = file.Stat(); err != nil || info.Size() != length {
+		file.Close()
+		return nil, fmt.Errorf("%w: %d slots need %d bytes", ErrMappedCorrupt, capacity, length)
+	}
+
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(length), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("mapping %s: %w", path, err)
+	}
+
+	f := &MappedFile[T]{
+		file:     file,
+		data:     data,
+		itemSize: itemSize,
+		slotSize: slotSize,
+		used:     make([]bool, capacity),
+	}
+	for slot := capacity - 1; slot >= 0; slot-- {
+		if f.state(slot) == mappedLive {
+			f.used[slot] = true
+			f.live++
+			f.seq = max(f.seq, f.slotSeq(slot)+1)
+		} else {
+			f.free = append(f.free, slot)
+		}
+	}
+	return f, nil
+}
+
+// New allocates a zeroed item in the file. It is not durable, and does not
+// survive a crash, until it is committed with Msync.
+func (f *MappedFile[T]) New() (*T, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return nil, ErrMappedClosed
+	}
+	if len(f.free) == 0 {
+		return nil, fmt.Errorf("mapped file is full: %d items", len(f.used))
+	}
+	slot := f.free[len(f.free)-1]
+	f.free = f.free[:len(f.free)-1]
+	f.used[slot] = true
+	f.live++
+
+	clear(f.item(slot))
+	return (*T)(unsafe.Pointer(&f.data[f.offset(slot)+mappedSlotHeader])), nil
+}
+
+// Free releases an item allocated with New; it must not be used afterwards.
+// The release is durable after the next Sync, until which a crash may
+// recover the item in its last committed state.
+func (f *MappedFile[T]) Free(item *T) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	slot, err := f.slot(item)
+	if err != nil {
+		return err
+	}
+	f.setState(slot, mappedFree)
+	f.used[slot] = false
+	f.free = append(f.free, slot)
+	f.live--
+	return nil
+}
+
+// Msync commits items: each one's checksum and commit sequence are written
+// to its slot and the pages holding them are flushed to the file with
+// msync(MS_SYNC), adjacent pages in one call. When it returns the items
+// survive a crash, of the process or of the machine, as they are now.
+func (f *MappedFile[T]) Msync(items ...*T) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	slots := make([]int, 0, len(items))
+	for _, item := range items {
+		slot, err := f.slot(item)
+		if err != nil {
+			return err
+		}
+		f.commit(slot)
+		slots = append(slots, slot)
+	}
+	return f.msyncSlots(slots)
+}
+
+// Sync commits every allocated item, as Msync does, and flushes the whole
+// file, making every Free since the last Sync durable too. Torn items
+// reported by RecoverMapped are committed as they stand, so repair or Free
+// them first.
+func (f *MappedFile[T]) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return ErrMappedClosed
+	}
+	for slot, used := range f.used {
+		if used {
+			f.commit(slot)
+		}
+	}
+	return msync(f.data)
+}
+
+// Len returns the number of allocated items
+func (f *MappedFile[T]) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.live
+}
+
+// Capacity returns the number of items the file has room for
+func (f *MappedFile[T]) Capacity() int {
+	return len(f.used)
+}
+
+// Close unmaps and closes the file without committing anything. Every item
+// from it becomes invalid, so Close must only be called once no item from it
+// is referenced.
+func (f *MappedFile[T]) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	err := syscall.Munmap(f.data)
+	f.data = nil
+	if cerr := f.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// MappedRecovery reports what RecoverMapped found in a mapped file
+type MappedRecovery[T any] struct {
+	Recovered int // keys recovered into the list
+	// Items whose bytes do not match their last commit, left allocated for
+	// the caller to repair and Msync, or Free
+	Torn []*T
+	// Committed items superseded by a later commit under the same key, left
+	// allocated for the caller to Free
+	Superseded []*T
+	// Committed items refused by a context quota or the admission filter,
+	// left allocated for the caller to insert later or Free
+	Refused []*T
+}
+
+// RecoverMapped inserts every committed item of file whose bytes match their
+// checksum into the list, with the context returned by context, and reports
+// the rest. Where two committed items share a key the later commit wins. Run
+// it on an empty list after OpenMappedFile and before allocating new items.
+// The write lock is held for the whole recovery.
+func (sl *ZeroCopySkiplist[T, K, C]) RecoverMapped(file *MappedFile[T], context func(*T) C) (MappedRecovery[T], error) {
+	var recovery MappedRecovery[T]
+
+	file.mu.Lock()
+	type committed struct {
+		item *T
+		seq  uint64
+	}
+	var intact []committed
+	for slot, used := range file.used {
+		if !used || file.state(slot) != mappedLive {
+			continue
+		}
+		item := (*T)(unsafe.Pointer(&file.data[file.offset(slot)+mappedSlotHeader]))
+		if file.checksum(slot) != file.slotChecksum(slot) {
+			recovery.Torn = append(recovery.Torn, item)
+			continue
+		}
+		intact = append(intact, committed{item: item, seq: file.slotSeq(slot)})
+	}
+	file.mu.Unlock()
+	sort.Slice(intact, func(i, j int) bool { return intact[i].seq < intact[j].seq })
+
+	var frozen bool
+	hook, totalBytes, length := func() (func(int64, int), int64, int) {
+		sl.lock()
+		defer sl.unlock()
+		if sl.frozen {
+			frozen = true
+			return nil, 0, 0
+		}
+		for _, c := range intact {
+			key := sl.getKeyFromItem(c.item)
+			var previous *T
+			if current := sl.seek(key, true); current != nil && sl.cmpKey(current.key, key) == 0 {
+				previous = current.item
+			}
+			inserted, refused := sl.tryInsert(c.item, context(c.item))
+			switch {
+			case inserted:
+				recovery.Recovered++
+			case refused:
+				recovery.Refused = append(recovery.Refused, c.item)
+			case previous != nil && previous != c.item:
+				recovery.Superseded = append(recovery.Superseded, previous)
+			}
+		}
+		return sl.checkPressure()
+	}()
+
+	if hook != nil {
+		hook(totalBytes, length)
+	}
+	if frozen {
+		return recovery, ErrFrozen
+	}
+	return recovery, nil
+}
+
+// MsyncRange commits the items of the entries in r to file, as Msync does,
+// so a range modified in place can be made durable in one call. Every item
+// in the range must have been allocated from file.
+func (sl *ZeroCopySkiplist[T, K, C]) MsyncRange(file *MappedFile[T], r KeyRange[K]) error {
+	r.Min, r.Max = sl.normalize(r.Min), sl.normalize(r.Max)
+	var items []*T
+	sl.walkRange(r, func(ip *ItemPtr[T, K, C]) bool {
+		items = append(items, ip.item)
+		return true
+	})
+	return file.Msync(items...)
+}
+
+// offset returns the position of slot in the file
+func (f *MappedFile[T]) offset(slot int) int {
+	return mappedHeaderSize + slot*f.slotSize
+}
+
+// item returns the bytes of the item in slot
+func (f *MappedFile[T]) item(slot int) []byte {
+	start := f.offset(slot) + mappedSlotHeader
+	return f.data[start : start+f.itemSize]
+}
+
+// slot returns the slot holding item; the caller must hold the lock
+func (f *MappedFile[T]) slot(item *T) (int, error) {
+	if f.closed {
+		return 0, ErrMappedClosed
+	}
+	pos := int(uintptr(unsafe.Pointer(item))-uintptr(unsafe.Pointer(unsafe.SliceData(f.data)))) - mappedHeaderSize - mappedSlotHeader
+	if pos < 0 || pos%f.slotSize != 0 || pos/f.slotSize >= len(f.used) || !f.used[pos/f.slotSize] {
+		return 0, fmt.Errorf("item %p is not allocated from the mapped file", item)
+	}
+	return pos / f.slotSize, nil
+}
+
+// state returns the state of slot
+func (f *MappedFile[T]) state(slot int) uint32 {
+	return binary.LittleEndian.Uint32(f.data[f.offset(slot):])
+}
+
+// setState sets the state of slot
+func (f *MappedFile[T]) setState(slot int, state uint32) {
+	binary.LittleEndian.PutUint32(f.data[f.offset(slot):], state)
+}
+
+// slotChecksum returns the checksum stored for slot
+func (f *MappedFile[T]) slotChecksum(slot int) uint32 {
+	return binary.LittleEndian.Uint32(f.data[f.offset(slot)+4:])
+}
+
+// slotSeq returns the commit sequence stored for slot
+func (f *MappedFile[T]) slotSeq(slot int) uint64 {
+	return binary.LittleEndian.Uint64(f.data[f.offset(slot)+8:])
+}
+
+// checksum computes the CRC-32C of the commit sequence and item of slot
+func (f *MappedFile[T]) checksum(slot int) uint32 {
+	start := f.offset(slot) + 8
+	return crc32.Checksum(f.data[start:start+8+f.itemSize], crcTable)
+}
+
+// commit stamps slot with the next commit sequence and its checksum and
+// marks it live; the caller must hold the lock
+func (f *MappedFile[T]) commit(slot int) {
+	start := f.offset(slot)
+	binary.LittleEndian.PutUint64(f.data[start+8:], f.seq)
+	f.seq++
+	binary.LittleEndian.PutUint32(f.data[start+4:], f.checksum(slot))
+	f.setState(slot, mappedLive)
+}
+
+// msyncSlots flushes the pages holding slots, coalescing adjacent and
+// overlapping page ranges; the caller must hold the lock
+func (f *MappedFile[T]) msyncSlots(slots []int) error {
+	sort.Ints(slots)
+	page := syscall.Getpagesize()
+	start, end := -1, -1
+	for _, slot := range slots {
+		first := f.offset(slot) / page * page
+		last := f.offset(slot) + f.slotSize
+		if first > end {
+			if start >= 0 {
+				if err := msync(f.data[start:end]); err != nil {
+					return err
+				}
+			}
+			start = first
+		}
+		end = max(end, last)
+	}
+	if start < 0 {
+		return nil
+	}
+	return msync(f.data[start:end])
+}
+
+// msync flushes the mapped pages holding b, which must start on a page
+// boundary, to the file
+func msync(b []byte) error {
+	if err := unix.Msync(b, unix.MS_SYNC); err != nil {
+		return fmt.Errorf("msync: %w", err)
+	}
+	return nil
+}
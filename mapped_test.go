@@ -0,0 +1,337 @@
+package zerocopyskiplist
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+// mappedRecord is a pointer-free item whose Sum field ties it to its other
+// fields, so a record half way through an update can be recognised
+type mappedRecord struct {
+	ID  int64
+	Seq uint64
+	Sum uint64
+}
+
+// set updates r to seq, keeping the invariant
+func (r *mappedRecord) set(seq uint64) {
+	r.Seq = seq
+	r.Sum = uint64(r.ID)*31 + seq
+}
+
+// consistent reports whether r holds the invariant
+func (r *mappedRecord) consistent() bool {
+	return r.Sum == uint64(r.ID)*31+r.Seq
+}
+
+func newMappedTestList() *ZeroCopySkiplist[mappedRecord, int64, int] {
+	return MakeZeroCopySkiplist[mappedRecord, int64, int](16,
+		func(r *mappedRecord) int64 { return r.ID },
+		func(r *mappedRecord) int { return int(unsafe.Sizeof(*r)) },
+		func(a, b int64) int {
+			if a < b {
+				return -1
+			}
+			if a > b {
+				return 1
+			}
+			return 0
+		})
+}
+
+func openMappedTestFile(t *testing.T, path string) *MappedFile[mappedRecord] {
+	t.Helper()
+	file, err := OpenMappedFile[mappedRecord](path, 64)
+	if err != nil {
+		t.Fatalf("OpenMappedFile: %v", err)
+	}
+	return file
+}
+
+func TestMappedRecover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "items.map")
+	file := openMappedTestFile(t, path)
+	list := newMappedTestList()
+
+	for i := int64(1); i <= 10; i++ {
+		item, err := file.New()
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		item.ID = i
+		item.set(1)
+		list.Insert(item, 0)
+	}
+	if err := list.MsyncRange(file, KeyRange[int64]{Min: 1, Max: 10, MaxInclusive: true}); err != nil {
+		t.Fatalf("MsyncRange: %v", err)
+	}
+
+	// Committed again at a later state
+	p, _ := list.Find(2)
+	p.Item().set(2)
+	if err := file.Msync(p.Item()); err != nil {
+		t.Fatalf("Msync: %v", err)
+	}
+	// Committed by Sync
+	p, _ = list.Find(3)
+	p.Item().set(9)
+	// Freed and synced
+	p, _ = list.Find(4)
+	list.Delete(4)
+	if err := file.Free(p.Item()); err != nil {
+		t.Fatalf("Free: %v", err)
+	}
+	if err := file.Sync(); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	// Modified after its last commit
+	p, _ = list.Find(5)
+	p.Item().set(7)
+	// Allocated but never committed
+	item, _ := file.New()
+	item.ID = 11
+	item.set(1)
+	if file.Len() != 10 {
+		t.Errorf("Expected 10 allocated items, got %d", file.Len())
+	}
+	file.Close()
+
+	file = openMappedTestFile(t, path)
+	defer file.Close()
+	list = newMappedTestList()
+	recovery, err := list.RecoverMapped(file, func(*mappedRecord) int { return 0 })
+	if err != nil {
+		t.Fatalf("RecoverMapped: %v", err)
+	}
+	if recovery.Recovered != 8 || len(recovery.Torn) != 1 || recovery.Torn[0].ID != 5 {
+		t.Fatalf("Expected 8 recovered and key 5 torn, got %+v", recovery)
+	}
+	for id, seq := range map[int64]uint64{1: 1, 2: 2, 3: 9} {
+		p, _ := list.Find(id)
+		if p == nil || p.Item().Seq != seq {
+			t.Errorf("Expected key %d at seq %d after recovery", id, seq)
+		}
+	}
+	for _, id := range []int64{4, 5, 11} {
+		if p, _ := list.Find(id); p != nil {
+			t.Errorf("Key %d should not have been recovered", id)
+		}
+	}
+}
+
+func TestMappedTorn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "items.map")
+	file := openMappedTestFile(t, path)
+	item, _ := file.New()
+	item.ID = 1
+	item.set(1)
+	file.Msync(item)
+	// Half of an update reaches the file
+	item.Seq = 2
+	file.Close()
+
+	file = openMappedTestFile(t, path)
+	defer file.Close()
+	list := newMappedTestList()
+	recovery, err := list.RecoverMapped(file, func(*mappedRecord) int { return 0 })
+	if err != nil {
+		t.Fatalf("RecoverMapped: %v", err)
+	}
+	if recovery.Recovered != 0 || len(recovery.Torn) != 1 || list.Length() != 0 {
+		t.Fatalf("Expected one torn item and nothing recovered, got %+v", recovery)
+	}
+	torn := recovery.Torn[0]
+	torn.set(2)
+	if err := file.Msync(torn); err != nil {
+		t.Fatalf("Msync of repaired item: %v", err)
+	}
+	if _, err := newMappedTestList().RecoverMapped(file, func(*mappedRecord) int { return 0 }); err != nil {
+		t.Fatalf("RecoverMapped: %v", err)
+	}
+}
+
+func TestMappedSuperseded(t *testing.T) {
+	file := openMappedTestFile(t, filepath.Join(t.TempDir(), "items.map"))
+	defer file.Close()
+	older, _ := file.New()
+	newer, _ := file.New()
+	*newer = mappedRecord{ID: 1}
+	newer.set(2)
+	*older = mappedRecord{ID: 1}
+	older.set(1)
+	file.Msync(newer)
+	file.Msync(older)
+	newer.set(2)
+	file.Msync(newer)
+
+	list := newMappedTestList()
+	recovery, err := list.RecoverMapped(file, func(*mappedRecord) int { return 0 })
+	if err != nil {
+		t.Fatalf("RecoverMapped: %v", err)
+	}
+	if recovery.Recovered != 1 || len(recovery.Superseded) != 1 || recovery.Superseded[0] != older {
+		t.Fatalf("Expected the older commit to be superseded, got %+v", recovery)
+	}
+	if p, _ := list.Find(1); p == nil || p.Item() != newer {
+		t.Error("Expected the later commit to win")
+	}
+}
+
+func TestMappedRecoverRefused(t *testing.T) {
+	file := openMappedTestFile(t, filepath.Join(t.TempDir(), "items.map"))
+	defer file.Close()
+	for i := int64(1); i <= 3; i++ {
+		item, _ := file.New()
+		item.ID = i
+		item.set(1)
+		file.Msync(item)
+	}
+
+	list := newMappedTestList()
+	list.SetContextQuota(0, 2, 0, QuotaReject)
+	recovery, err := list.RecoverMapped(file, func(*mappedRecord) int { return 0 })
+	if err != nil {
+		t.Fatalf("RecoverMapped: %v", err)
+	}
+	if recovery.Recovered != 2 || len(recovery.Refused) != 1 || recovery.Refused[0].ID != 3 {
+		t.Fatalf("Expected 2 recovered and key 3 refused, got %+v", recovery)
+	}
+}
+
+func TestMappedErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "items.map")
+	if _, err := OpenMappedFile[TestItem](path, 8); err == nil {
+		t.Error("Expected an error for an item type with pointers")
+	}
+	if _, err := OpenMappedFile[mappedRecord](path, 0); err == nil {
+		t.Error("Expected an error for zero capacity")
+	}
+
+	file, err := OpenMappedFile[mappedRecord](path, 2)
+	if err != nil {
+		t.Fatalf("OpenMappedFile: %v", err)
+	}
+	file.New()
+	file.New()
+	if _, err := file.New(); err == nil {
+		t.Error("Expected an error from a full file")
+	}
+	if err := file.Msync(&mappedRecord{}); err == nil {
+		t.Error("Expected an error committing an item from elsewhere")
+	}
+	file.Close()
+	if _, err := file.New(); !errors.Is(err, ErrMappedClosed) {
+		t.Errorf("Expected ErrMappedClosed, got %v", err)
+	}
+
+	if _, err := OpenMappedFile[PaddedContext](path, 2); err == nil {
+		t.Error("Expected an error reopening for a different item size")
+	}
+	os.WriteFile(path, []byte(strings.Repeat("x", 200)), 0o644)
+	if _, err := OpenMappedFile[mappedRecord](path, 2); !errors.Is(err, ErrMappedCorrupt) {
+		t.Errorf("Expected ErrMappedCorrupt, got %v", err)
+	}
+}
+
+// mappedSoakWriter updates, frees and allocates records in the file at path,
+// committing each change, until it is killed
+func mappedSoakWriter(path string) error {
+	file, err := OpenMappedFile[mappedRecord](path, 64)
+	if err != nil {
+		return err
+	}
+	list := newMappedTestList()
+	recovery, err := list.RecoverMapped(file, func(*mappedRecord) int { return 0 })
+	if err != nil {
+		return err
+	}
+	for _, item := range append(recovery.Torn, recovery.Superseded...) {
+		file.Free(item)
+	}
+
+	for seq := uint64(1); ; seq++ {
+		id := rand.Int63n(32)
+		p, _ := list.Find(id)
+		switch {
+		case p == nil:
+			item, err := file.New()
+			if err != nil {
+				return err
+			}
+			item.ID = id
+			item.set(seq)
+			list.Insert(item, 0)
+			err = file.Msync(item)
+			if err != nil {
+				return err
+			}
+		case seq%5 == 0:
+			list.Delete(id)
+			if err := file.Free(p.Item()); err != nil {
+				return err
+			}
+		default:
+			p.Item().set(seq)
+			if err := file.Msync(p.Item()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func TestMappedCrashSoak(t *testing.T) {
+	if path := os.Getenv("ZCSL_MAPPED_SOAK"); path != "" {
+		fmt.Println(mappedSoakWriter(path))
+		os.Exit(1)
+	}
+
+	rounds := 10
+	if testing.Short() {
+		rounds = 3
+	}
+	path := filepath.Join(t.TempDir(), "items.map")
+	openMappedTestFile(t, path).Close()
+
+	for round := 0; round < rounds; round++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestMappedCrashSoak$")
+		cmd.Env = append(os.Environ(), "ZCSL_MAPPED_SOAK="+path)
+		var out strings.Builder
+		cmd.Stdout, cmd.Stderr = &out, &out
+		if err := cmd.Start(); err != nil {
+			t.Fatalf("Starting writer: %v", err)
+		}
+		time.Sleep(time.Duration(100+rand.Intn(200)) * time.Millisecond)
+		cmd.Process.Signal(syscall.SIGKILL)
+		if err := cmd.Wait(); cmd.ProcessState.Exited() {
+			t.Fatalf("Writer exited before it was killed: %v\n%s", err, out.String())
+		}
+
+		file := openMappedTestFile(t, path)
+		list := newMappedTestList()
+		recovery, err := list.RecoverMapped(file, func(*mappedRecord) int { return 0 })
+		if err != nil {
+			t.Fatalf("Round %d: RecoverMapped: %v", round, err)
+		}
+		// Only the record being changed when the writer died can be torn
+		if len(recovery.Torn) > 1 {
+			t.Errorf("Round %d: %d torn records", round, len(recovery.Torn))
+		}
+		for entry := range list.Entries() {
+			if !entry.Item.consistent() {
+				t.Errorf("Round %d: recovered record %+v was never committed", round, *entry.Item)
+			}
+		}
+		t.Logf("Round %d: %d recovered, %d torn", round, recovery.Recovered, len(recovery.Torn))
+		file.Close()
+	}
+}
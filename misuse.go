@@ -0,0 +1,153 @@
+// misuse.go - Detecting and reporting misuse of the list
+
+package zerocopyskiplist
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+// comparatorSampleRate is the fraction of inserts whose comparator results
+// are checked for consistency without WithComparatorCheck; zcslparanoid
+// builds check every insert
+const comparatorSampleRate = 1.0 / 64
+
+// ErrMisuse is wrapped by every *MisuseError
+var ErrMisuse = errors.New("skiplist misused")
+
+// MisusePolicy selects how the list reacts to misuse, see WithMisusePolicy
+type MisusePolicy int
+
+const (
+	MisuseDebugPanic   MisusePolicy = iota // panic in zcslparanoid builds, return errors otherwise
+	MisuseAlwaysPanic                      // panic with the *MisuseError
+	MisuseReturnErrors                     // return the *MisuseError and refuse the operation
+)
+
+// MisuseKind identifies what was misused
+type MisuseKind int
+
+const (
+	MisuseNilItem                MisuseKind = iota // a nil item was inserted
+	MisuseNegativeSize                             // the size function returned a negative size
+	MisuseInconsistentComparator                   // the comparator contradicted itself
+)
+
+// String returns the kind's name
+func (k MisuseKind) String() string {
+	switch k {
+	case MisuseNilItem:
+		return "nil item"
+	case MisuseNegativeSize:
+		return "negative size"
+	case MisuseInconsistentComparator:
+		return "inconsistent comparator"
+	default:
+		return fmt.Sprintf("MisuseKind(%d)", int(k))
+	}
+}
+
+// MisuseError describes a misuse of the list: an item or callback that would
+// otherwise corrupt the ordering or the byte accounting, or fail far from its
+// cause inside a key function or a writev. It wraps ErrMisuse.
+type MisuseError struct {
+	Op     string     // operation that detected the misuse
+	Kind   MisuseKind // what was misused
+	Detail string     // the offending values
+}
+
+// Error formats the misuse
+func (e *MisuseError) Error() string {
+	return fmt.Sprintf("zerocopyskiplist: %s: %s: %s", e.Op, e.Kind, e.Detail)
+}
+
+// Unwrap returns ErrMisuse
+func (e *MisuseError) Unwrap() error {
+	return ErrMisuse
+}
+
+// WithMisusePolicy sets how misuse found by Insert, InsertChecked and
+// BulkLoad is handled: nil items and negative item sizes are checked on every
+// insert, and a sampled fraction of inserts checks that the comparator orders
+// the new key consistently against itself and its neighbours. Every misuse is
+// counted in Stats.Misuses whatever the policy. The default,
+// MisuseDebugPanic, panics in zcslparanoid builds so tests fail at the
+// cause, and otherwise refuses the item and returns a *MisuseError.
+//
+// A comparator found inconsistent has already placed its key, so the list
+// may be out of order by the time the error is returned and should be
+// rebuilt with a corrected comparator.
+func WithMisusePolicy(policy MisusePolicy) Option {
+	return func(o *options) {
+		o.misusePolicy = policy
+	}
+}
+
+// misuse counts a misuse and, depending on the policy, panics with or returns
+// a *MisuseError describing it. It must not be called with the lock held.
+func (sl *ZeroCopySkiplist[T, K, C]) misuse(op string, kind MisuseKind, detail string) error {
+	sl.misuses.Add(1)
+	err := &MisuseError{Op: op, Kind: kind, Detail: detail}
+	switch sl.opts.misusePolicy {
+	case MisuseAlwaysPanic:
+		panic(err)
+	case MisuseDebugPanic:
+		if paranoidBuild {
+			panic(err)
+		}
+	}
+	return err
+}
+
+// checkItem reports a nil item or a negative item size as misuse by op
+func (sl *ZeroCopySkiplist[T, K, C]) checkItem(op string, item *T) error {
+	if item == nil {
+		return sl.misuse(op, MisuseNilItem, "inserting a nil item")
+	}
+	if size := sl.getItemSize(item); size < 0 {
+		return sl.misuse(op, MisuseNegativeSize, fmt.Sprintf("item with key %v has size %d", sl.getKeyFromItem(item), size))
+	}
+	return nil
+}
+
+// sampleComparator returns true if the current insert should check the comparator
+func (sl *ZeroCopySkiplist[T, K, C]) sampleComparator() bool {
+	rate := comparatorSampleRate
+	if sl.opts.comparatorRate > 0 {
+		rate = sl.opts.comparatorRate
+	}
+	return paranoidBuild || rate >= 1 || rand.Float64() < rate
+}
+
+// comparatorViolation checks that the comparator orders a just inserted or,
+// if inserted is false, replaced key consistently: equal to itself, found by
+// a search and strictly between its
+// neighbours in both argument orders, or across the wider window of
+// WithComparatorCheck. It returns a description of the first inconsistency,
+// or an empty string; the caller must hold the lock.
+func (sl *ZeroCopySkiplist[T, K, C]) comparatorViolation(key K, inserted bool) string {
+	if c := sl.cmpKey(key, key); c != 0 {
+		return fmt.Sprintf("comparing %v with itself returned %d", key, c)
+	}
+	node := sl.descendFromHeader(key, -1, nil).forward[0]
+	if node == nil || sl.cmpKey(node.key, key) != 0 {
+		if !inserted {
+			return "" // refused rather than replaced
+		}
+		return fmt.Sprintf("inserted key %v is not found by a search", key)
+	}
+	if sl.opts.comparatorRate > 0 {
+		if node.key != key {
+			return fmt.Sprintf("%v compares equal to %v but is not ==", key, node.key)
+		}
+		return sl.windowViolation(node)
+	}
+	if prev := node.backward; prev != nil && (sl.cmpKey(prev.key, key) >= 0 || sl.cmpKey(key, prev.key) <= 0) {
+		return fmt.Sprintf("%v and its predecessor %v do not compare in opposite orders", key, prev.key)
+	}
+	if next := node.forward[0]; next != nil && (sl.cmpKey(key, next.key) >= 0 || sl.cmpKey(next.key, key) <= 0) {
+		return fmt.Sprintf("%v and its successor %v do not compare in opposite orders", key, next.key)
+	}
+	return ""
+}
@@ -0,0 +1,82 @@
+package zerocopyskiplist
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMisuseReturnErrors(t *testing.T) {
+	sizes := map[int]int{2: -5}
+	getSize := func(item *TestItem) int {
+		if size, ok := sizes[item.ID]; ok {
+			return size
+		}
+		return getTestItemSize(item)
+	}
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getSize, compareInt, WithMisusePolicy(MisuseReturnErrors))
+	items := createTestItems(3)
+
+	var misuse *MisuseError
+	if inserted, err := skiplist.InsertChecked(nil, TestContext{}); inserted || !errors.As(err, &misuse) || misuse.Kind != MisuseNilItem {
+		t.Errorf("Inserting nil returned %v, %v", inserted, err)
+	}
+	if skiplist.Insert(items[1], TestContext{}) {
+		t.Error("An item with a negative size should be refused")
+	}
+	if _, err := skiplist.BulkLoad(items, nil); !errors.Is(err, ErrMisuse) {
+		t.Errorf("BulkLoad of a negative size returned %v", err)
+	}
+	if skiplist.Length() != 0 {
+		t.Errorf("Misused inserts left %d entries", skiplist.Length())
+	}
+	if n := skiplist.Stats().Misuses; n != 3 {
+		t.Errorf("Counted %d misuses, want 3", n)
+	}
+
+	skiplist.Freeze()
+	if _, err := skiplist.InsertChecked(items[0], TestContext{}); err != ErrFrozen {
+		t.Errorf("Inserting into a frozen list returned %v", err)
+	}
+}
+
+func TestMisuseInconsistentComparator(t *testing.T) {
+	// Every distinct key sorts before every other, so no pair compares in
+	// opposite orders
+	broken := func(a, b int) int {
+		if a == b {
+			return 0
+		}
+		return -1
+	}
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, broken, WithMisusePolicy(MisuseReturnErrors))
+
+	var err error
+	for _, item := range createTestItems(5000) {
+		if _, err = skiplist.InsertChecked(item, TestContext{}); err != nil {
+			break
+		}
+	}
+	var misuse *MisuseError
+	if !errors.As(err, &misuse) || misuse.Kind != MisuseInconsistentComparator {
+		t.Fatalf("Sampled comparator checks returned %v", err)
+	}
+	if skiplist.Stats().Misuses != 1 {
+		t.Errorf("Counted %d misuses, want 1", skiplist.Stats().Misuses)
+	}
+}
+
+func TestMisusePanic(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt, WithMisusePolicy(MisuseAlwaysPanic))
+	defer func() {
+		misuse, ok := recover().(*MisuseError)
+		if !ok || misuse.Kind != MisuseNilItem {
+			t.Errorf("Panicked with %v, want a nil item misuse", misuse)
+		}
+		if skiplist.Stats().Misuses != 1 {
+			t.Error("A panicking misuse should still be counted")
+		}
+		// The lock must not be left held
+		skiplist.Insert(createTestItems(1)[0], TestContext{})
+	}()
+	skiplist.Insert(nil, TestContext{})
+}
@@ -0,0 +1,108 @@
+// multilist.go - Operations spanning several skiplists
+
+package zerocopyskiplist
+
+import (
+	"cmp"
+	"slices"
+	"sync/atomic"
+)
+
+// nextLockOrder hands out each list's position in the canonical lock order
+var nextLockOrder atomic.Uint64
+
+// lockOrdered returns lists without duplicates, sorted into the canonical
+// lock order. Every operation that locks more than one list takes the locks
+// in this order, so no two of them can deadlock.
+func lockOrdered[T any, K comparable, C comparable](lists []*ZeroCopySkiplist[T, K, C]) []*ZeroCopySkiplist[T, K, C] {
+	ordered := slices.Clone(lists)
+	slices.SortFunc(ordered, func(a, b *ZeroCopySkiplist[T, K, C]) int {
+		return cmp.Compare(a.lockOrder, b.lockOrder)
+	})
+	return slices.Compact(ordered)
+}
+
+// SnapshotTogether returns copies of lists that all reflect the same instant:
+// the read locks of every list are held together while the copies are made,
+// so no mutation can fall between them. Copies share items with the originals
+// as Copy does, and are returned in the order of lists. The locks are taken
+// in a canonical order, so concurrent calls over overlapping lists cannot
+// deadlock.
+func SnapshotTogether[T any, K comparable, C comparable](lists ...*ZeroCopySkiplist[T, K, C]) []*ZeroCopySkiplist[T, K, C] {
+	ordered := lockOrdered(lists)
+	for _, sl := range ordered {
+		sl.rlock()
+	}
+	defer func() {
+		for _, sl := range ordered {
+			sl.runlock()
+		}
+	}()
+
+	copies := make(map[*ZeroCopySkiplist[T, K, C]]*ZeroCopySkiplist[T, K, C], len(ordered))
+	for _, sl := range ordered {
+		copies[sl] = sl.copy()
+	}
+	snapshots := make([]*ZeroCopySkiplist[T, K, C], len(lists))
+	for i, sl := range lists {
+		snapshots[i] = copies[sl]
+	}
+	return snapshots
+}
+
+// MoveTo removes the entry for key from sl and inserts its item and context
+// into dest as a single step: both write locks are held across the move, so
+// no reader of either list can observe the entry in neither list or in both.
+// An existing entry for the key in dest is replaced. It reports whether key
+// was present in sl and moved; nothing moves if either list is frozen, the
+// entry's context is over its quota in dest under QuotaReject or dest's
+// admission filter turns the entry away. Moving
+// to sl itself leaves the list unchanged.
+func (sl *ZeroCopySkiplist[T, K, C]) MoveTo(dest *ZeroCopySkiplist[T, K, C], key K) bool {
+	key = sl.normalize(key)
+	var found bool
+	hook, totalBytes, entries := func() (func(int64, int), int64, int) {
+		ordered := lockOrdered([]*ZeroCopySkiplist[T, K, C]{sl, dest})
+		for _, l := range ordered {
+			l.lock()
+		}
+		defer func() {
+			for _, l := range ordered {
+				l.unlock()
+			}
+		}()
+
+		node := sl.seek(key, true)
+		found = node != nil && sl.cmpKey(node.key, key) == 0 && !sl.frozen && !dest.frozen
+		if found && dest != sl && len(dest.quotas) > 0 && !dest.admitItem(node.item, node.context) {
+			found = false
+		}
+		// Ask dest's admission filter before unlinking, so a refused entry stays
+		// in sl rather than being lost from both lists
+		var destKey K
+		var destUpdate []*ItemPtr[T, K, C]
+		var destCurrent *ItemPtr[T, K, C]
+		if found && dest != sl {
+			destKey = dest.getKeyFromItem(node.item)
+			destUpdate = make([]*ItemPtr[T, K, C], dest.maxLevel+1)
+			destCurrent = dest.descend(destKey, -1, destUpdate).forward[0]
+			found = !dest.refused(node.item, destKey, node.context, destCurrent)
+		}
+		if found && dest != sl {
+			// Capture the entry first; unlink hands the node back to the pool.
+			// The item lives on in dest, so a lease on it does not defer the move.
+			item, context := node.item, node.context
+			update := make([]*ItemPtr[T, K, C], sl.maxLevel+1)
+			sl.descend(key, -1, update)
+			sl.unlink(node, update)
+			sl.checkPressure()
+			dest.insertAt(item, destKey, context, destUpdate, destCurrent)
+			return dest.checkPressure()
+		}
+		return nil, 0, 0
+	}()
+	if hook != nil {
+		hook(totalBytes, entries)
+	}
+	return found
+}
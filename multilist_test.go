@@ -0,0 +1,151 @@
+package zerocopyskiplist
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSnapshotTogether(t *testing.T) {
+	a := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	b := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	items := createTestItems(500)
+
+	// Every item goes into a and then into b, so at any single instant b holds
+	// the same items as a or one fewer
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, item := range items {
+			a.Insert(item, TestContext{})
+			b.Insert(item, TestContext{})
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 2; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				// Alternate argument order to exercise the canonical lock order
+				lists := []*ZeroCopySkiplist[TestItem, int, TestContext]{a, b}
+				if g%2 == 1 {
+					lists[0], lists[1] = b, a
+				}
+				snapshots := SnapshotTogether(lists...)
+				sa, sb := snapshots[0], snapshots[1]
+				if g%2 == 1 {
+					sa, sb = sb, sa
+				}
+				if diff := sa.Length() - sb.Length(); diff < 0 || diff > 1 {
+					t.Errorf("Inconsistent cut: a has %d items, b has %d", sa.Length(), sb.Length())
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	snapshots := SnapshotTogether(a, a, b)
+	if snapshots[0] != snapshots[1] || snapshots[0].Length() != 500 || snapshots[2].Length() != 500 {
+		t.Error("Repeated lists should share one snapshot")
+	}
+	if err := snapshots[2].Validate(); err != nil {
+		t.Errorf("Validate snapshot: %v", err)
+	}
+}
+
+func TestMoveTo(t *testing.T) {
+	hot := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	cold := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	items := createTestItems(200)
+	for _, item := range items {
+		hot.Insert(item, TestContext{AccessCount: item.ID})
+	}
+
+	if !hot.MoveTo(cold, 7) || hot.Length() != 199 || cold.Length() != 1 {
+		t.Fatalf("Move left hot=%d cold=%d", hot.Length(), cold.Length())
+	}
+	if ptr, ctx := cold.Find(7); ptr == nil || ptr.Item() != items[6] || ctx.AccessCount != 7 {
+		t.Error("Moved entry lost its item or context")
+	}
+	if hot.MoveTo(cold, 7) || hot.MoveTo(cold, 1000) {
+		t.Error("Moving a missing key should report false")
+	}
+	if !cold.MoveTo(cold, 7) || cold.Length() != 1 {
+		t.Error("Moving to the same list should leave it unchanged")
+	}
+
+	// Entries move back and forth in both directions while snapshots check
+	// that every entry is always in exactly one list
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for g := 0; g < 2; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for round := 0; round < 5; round++ {
+				for _, item := range items {
+					if (item.ID+round+g)%2 == 0 {
+						hot.MoveTo(cold, item.ID)
+					} else {
+						cold.MoveTo(hot, item.ID)
+					}
+				}
+			}
+		}(g)
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for {
+		snapshots := SnapshotTogether(hot, cold)
+		if total := snapshots[0].Length() + snapshots[1].Length(); total != len(items) {
+			t.Fatalf("Snapshot holds %d entries, want %d", total, len(items))
+		}
+		select {
+		case <-done:
+			if err := hot.Validate(); err != nil {
+				t.Errorf("Validate hot: %v", err)
+			}
+			if err := cold.Validate(); err != nil {
+				t.Errorf("Validate cold: %v", err)
+			}
+			return
+		default:
+		}
+	}
+}
+
+func TestMoveToRefusedByAdmission(t *testing.T) {
+	hot := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	cold := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	items := createTestItems(3)
+	hot.Insert(items[0], TestContext{})
+	cold.Insert(items[1], TestContext{})
+	cold.Insert(items[2], TestContext{})
+	cold.SetWeigher(func(*TestItem, TestContext) int64 { return 1 })
+	cold.SetWeightLimit(2)
+	cold.SetAdmissionFilter(func(*TestItem, TestContext, *ItemPtr[TestItem, int, TestContext]) bool {
+		return false
+	})
+
+	if hot.MoveTo(cold, 1) {
+		t.Error("A move refused by the destination's admission filter should report false")
+	}
+	if ptr, _ := hot.Find(1); ptr == nil || ptr.Item() != items[0] {
+		t.Error("A refused move should leave the entry in the source list")
+	}
+	if ptr, _ := cold.Find(1); ptr != nil || cold.Length() != 2 {
+		t.Error("A refused move should leave the destination unchanged")
+	}
+	if err := hot.Validate(); err != nil {
+		t.Errorf("Validate hot: %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+// mutations.go - Mutations queued during a traversal and applied after it
+
+package zerocopyskiplist
+
+// mutationKind is the operation of a queued mutation
+type mutationKind uint8
+
+const (
+	mutationDelete mutationKind = iota
+	mutationSetContext
+)
+
+// mutation is one queued operation
+type mutation[K comparable, C comparable] struct {
+	kind    mutationKind
+	key     K
+	context C
+}
+
+// Mutations queues deletes and context updates, typically from a traversal
+// callback, and applies them together under a single write lock. Queuing
+// never touches the list, so it is safe wherever calling into the list is
+// not, and a traversal sees the list unchanged by its own decisions. A
+// Mutations is not safe for concurrent use.
+type Mutations[T any, K comparable, C comparable] struct {
+	sl  *ZeroCopySkiplist[T, K, C]
+	ops []mutation[K, C]
+}
+
+// MutationResult reports what Mutations.Apply did
+type MutationResult[K comparable] struct {
+	Deleted     int // keys deleted
+	ContextsSet int // contexts updated
+	Missing     []K // keys not in the list, or whose update a quota rejected
+}
+
+// NewMutations returns an empty queue of mutations for the list
+func (sl *ZeroCopySkiplist[T, K, C]) NewMutations() *Mutations[T, K, C] {
+	return &Mutations[T, K, C]{sl: sl}
+}
+
+// DeferDelete queues the deletion of key
+func (m *Mutations[T, K, C]) DeferDelete(key K) {
+	m.ops = append(m.ops, mutation[K, C]{kind: mutationDelete, key: m.sl.normalize(key)})
+}
+
+// DeferSetContext queues an UpdateContext of key to context
+func (m *Mutations[T, K, C]) DeferSetContext(key K, context C) {
+	m.ops = append(m.ops, mutation[K, C]{kind: mutationSetContext, key: m.sl.normalize(key), context: context})
+}
+
+// Len returns the number of queued mutations
+func (m *Mutations[T, K, C]) Len() int {
+	return len(m.ops)
+}
+
+// Apply performs the queued mutations in order under one write lock, empties
+// the queue and reports the outcome. A frozen list applies nothing and
+// returns ErrFrozen, keeping the queue. A pressure hook crossed by the
+// mutations runs once, after the lock is released.
+func (m *Mutations[T, K, C]) Apply() (MutationResult[K], error) {
+	var result MutationResult[K]
+	sl := m.sl
+	var frozen bool
+	hook, totalBytes, length := func() (func(int64, int), int64, int) {
+		sl.lock()
+		defer sl.unlock()
+		if sl.frozen {
+			frozen = true
+			return nil, 0, 0
+		}
+		for _, op := range m.ops {
+			switch op.kind {
+			case mutationDelete:
+				if sl.delete(op.key) {
+					result.Deleted++
+					continue
+				}
+			case mutationSetContext:
+				if sl.updateContext(op.key, op.context) {
+					result.ContextsSet++
+					continue
+				}
+			}
+			result.Missing = append(result.Missing, op.key)
+		}
+		m.ops = m.ops[:0]
+		return sl.checkPressure()
+	}()
+
+	if hook != nil {
+		hook(totalBytes, length)
+	}
+	if frozen {
+		return result, ErrFrozen
+	}
+	return result, nil
+}
+
+// ForEachDeferred calls callback for every item in ascending key order, as
+// ForEach does, with a queue for mutations the callback decides on, and
+// applies them once the traversal is over.
+func (sl *ZeroCopySkiplist[T, K, C]) ForEachDeferred(callback func(ip *ItemPtr[T, K, C], m *Mutations[T, K, C]) bool) (MutationResult[K], error) {
+	m := sl.NewMutations()
+	sl.ForEach(func(ip *ItemPtr[T, K, C]) bool {
+		return callback(ip, m)
+	})
+	return m.Apply()
+}
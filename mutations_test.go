@@ -0,0 +1,56 @@
+package zerocopyskiplist
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestForEachDeferred(t *testing.T) {
+	sl := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	for _, item := range createTestItems(600) {
+		sl.Insert(item, TestContext{})
+	}
+
+	// Delete odd keys and mark multiples of 10; the traversal sees none of it
+	visited := 0
+	result, err := sl.ForEachDeferred(func(ip *ItemPtr[TestItem, int, TestContext], m *Mutations[TestItem, int, TestContext]) bool {
+		visited++
+		if ip.Key()%2 == 1 {
+			m.DeferDelete(ip.Key())
+		} else if ip.Key()%10 == 0 {
+			m.DeferSetContext(ip.Key(), TestContext{IsCached: true})
+		}
+		if sl.Length() != 600 {
+			t.Fatal("Mutations should not apply during the traversal")
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatalf("ForEachDeferred: %v", err)
+	}
+	if visited != 600 || result.Deleted != 300 || result.ContextsSet != 60 || len(result.Missing) != 0 {
+		t.Errorf("Unexpected result after visiting %d: %+v", visited, result)
+	}
+	if sl.Length() != 300 {
+		t.Errorf("Expected 300 items left, got %d", sl.Length())
+	}
+	if _, ctx := sl.Find(20); !ctx.IsCached {
+		t.Error("Context of key 20 should have been updated")
+	}
+
+	// Missing keys are reported, and a frozen list keeps the queue
+	m := sl.NewMutations()
+	m.DeferDelete(1)
+	m.DeferSetContext(3, TestContext{})
+	m.DeferDelete(2)
+	sl.Freeze()
+	if _, err := m.Apply(); !errors.Is(err, ErrFrozen) || m.Len() != 3 {
+		t.Errorf("Expected ErrFrozen with 3 mutations queued, got %v and %d", err, m.Len())
+	}
+	sl.Thaw()
+	result, err = m.Apply()
+	if err != nil || result.Deleted != 1 || !slices.Equal(result.Missing, []int{1, 3}) || m.Len() != 0 {
+		t.Errorf("Unexpected result %+v, %v with %d queued", result, err, m.Len())
+	}
+}
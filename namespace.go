@@ -0,0 +1,203 @@
+// namespace.go - Many logical lists over one set of shared infrastructure
+
+package zerocopyskiplist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Namespaces multiplexes many logical lists, typically one per tenant, over
+// shared infrastructure: one node pool when the lists are built WithNodePool,
+// and one set of background tasks, teardown steps and health checks, so a
+// single flusher, sweeper or WAL serves every namespace instead of one per
+// list.
+type Namespaces[T any, K comparable, C comparable] struct {
+	newList func() *ZeroCopySkiplist[T, K, C]
+	pool    sync.Pool // node pool shared by lists built WithNodePool
+	life    lifecycle
+	mu      sync.RWMutex
+	spaces  map[string]*Namespace[T, K, C]
+}
+
+// Namespace is one logical list of a Namespaces group. It embeds its own
+// list, so the whole list API is available on it.
+type Namespace[T any, K comparable, C comparable] struct {
+	*ZeroCopySkiplist[T, K, C]
+	name   string
+	mu     sync.Mutex
+	filter func(*ItemPtr[T, K, C]) bool
+}
+
+// NewNamespaces returns an empty group whose namespaces are built by newList,
+// e.g. a closure calling MakeOrderedZeroCopySkiplist with the group's options
+func NewNamespaces[T any, K comparable, C comparable](newList func() *ZeroCopySkiplist[T, K, C]) *Namespaces[T, K, C] {
+	return &Namespaces[T, K, C]{
+		newList: newList,
+		spaces:  make(map[string]*Namespace[T, K, C]),
+	}
+}
+
+// Namespace returns the namespace called name, creating it if it does not
+// exist. A namespace created after Close starts frozen.
+func (ns *Namespaces[T, K, C]) Namespace(name string) *Namespace[T, K, C] {
+	ns.mu.RLock()
+	n := ns.spaces[name]
+	ns.mu.RUnlock()
+	if n != nil {
+		return n
+	}
+
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	if n := ns.spaces[name]; n != nil {
+		return n
+	}
+	sl := ns.newList()
+	if sl.nodePool != nil {
+		sl.nodePool = &ns.pool
+	}
+	ns.life.mu.Lock()
+	if ns.life.closed {
+		sl.frozen = true
+	}
+	ns.life.mu.Unlock()
+
+	n = &Namespace[T, K, C]{ZeroCopySkiplist: sl, name: name}
+	ns.spaces[name] = n
+	return n
+}
+
+// Lookup returns the namespace called name if it exists
+func (ns *Namespaces[T, K, C]) Lookup(name string) (*Namespace[T, K, C], bool) {
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
+	n, ok := ns.spaces[name]
+	return n, ok
+}
+
+// Names returns the names of every namespace in sorted order
+func (ns *Namespaces[T, K, C]) Names() []string {
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
+	return slices.Sorted(maps.Keys(ns.spaces))
+}
+
+// Drop removes the namespace called name from the group and reports whether
+// it existed. Holders of the namespace can keep using its list, but the group
+// no longer flushes, reports or closes it.
+func (ns *Namespaces[T, K, C]) Drop(name string) bool {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
+	_, ok := ns.spaces[name]
+	delete(ns.spaces, name)
+	return ok
+}
+
+// snapshot returns the current namespaces in name order, so callers can work
+// on them without holding the group lock
+func (ns *Namespaces[T, K, C]) snapshot() []*Namespace[T, K, C] {
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
+	spaces := make([]*Namespace[T, K, C], 0, len(ns.spaces))
+	for _, name := range slices.Sorted(maps.Keys(ns.spaces)) {
+		spaces = append(spaces, ns.spaces[name])
+	}
+	return spaces
+}
+
+// Name returns the namespace's name
+func (n *Namespace[T, K, C]) Name() string {
+	return n.name
+}
+
+// SetFlushFilter sets the filter the group's flushes apply to this namespace,
+// as for CallbackToIovecSlice; nil, the default, flushes every item
+func (n *Namespace[T, K, C]) SetFlushFilter(filter func(*ItemPtr[T, K, C]) bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.filter = filter
+}
+
+// flushFilter returns the namespace's flush filter, never nil
+func (n *Namespace[T, K, C]) flushFilter() func(*ItemPtr[T, K, C]) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.filter == nil {
+		return func(*ItemPtr[T, K, C]) bool { return true }
+	}
+	return n.filter
+}
+
+// Stats returns the Stats of every namespace by name
+func (ns *Namespaces[T, K, C]) Stats() map[string]Stats {
+	stats := make(map[string]Stats)
+	for _, n := range ns.snapshot() {
+		stats[n.name] = n.ZeroCopySkiplist.Stats()
+	}
+	return stats
+}
+
+// ToIovecSlices returns, by namespace name, iovecs for the items each
+// namespace's flush filter selects, built as CallbackToIovecSlice builds them
+func (ns *Namespaces[T, K, C]) ToIovecSlices() map[string][]syscall.Iovec {
+	iovecs := make(map[string][]syscall.Iovec)
+	for _, n := range ns.snapshot() {
+		iovecs[n.name] = n.CallbackToIovecSlice(n.flushFilter())
+	}
+	return iovecs
+}
+
+// Go runs fn as a background task of the group, as ZeroCopySkiplist.Go does
+// for a single list
+func (ns *Namespaces[T, K, C]) Go(fn func(ctx context.Context)) error {
+	return ns.life.start(fn)
+}
+
+// OnClose registers a teardown step of the group, as ZeroCopySkiplist.OnClose
+// does for a single list
+func (ns *Namespaces[T, K, C]) OnClose(fn func(ctx context.Context) error) error {
+	return ns.life.onClose(fn)
+}
+
+// AddHealthCheck registers a health check of the group, as
+// ZeroCopySkiplist.AddHealthCheck does for a single list
+func (ns *Namespaces[T, K, C]) AddHealthCheck(name string, check func() error) {
+	ns.life.addHealthCheck(name, check)
+}
+
+// Heartbeat registers a liveness check of the group, as
+// ZeroCopySkiplist.Heartbeat does for a single list
+func (ns *Namespaces[T, K, C]) Heartbeat(name string, maxInterval time.Duration) func() {
+	return ns.life.heartbeat(name, maxInterval)
+}
+
+// Close tears the group down as ZeroCopySkiplist.Close does a single list:
+// it stops the group's background tasks, freezes every namespace and runs the
+// group's teardown steps. Tasks and steps registered on individual
+// namespaces are left to their own Close.
+func (ns *Namespaces[T, K, C]) Close(ctx context.Context) error {
+	return ns.life.close(ctx, func() {
+		for _, n := range ns.snapshot() {
+			n.Freeze()
+		}
+	})
+}
+
+// Healthy reports the group's own health as ZeroCopySkiplist.Healthy does,
+// joined with that of every namespace, prefixed by its name
+func (ns *Namespaces[T, K, C]) Healthy() error {
+	errs := []error{ns.life.healthy()}
+	for _, n := range ns.snapshot() {
+		if err := n.ZeroCopySkiplist.Healthy(); err != nil {
+			errs = append(errs, fmt.Errorf("namespace %s: %w", n.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
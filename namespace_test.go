@@ -0,0 +1,72 @@
+package zerocopyskiplist
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNamespaces(t *testing.T) {
+	ns := NewNamespaces(func() *ZeroCopySkiplist[TestItem, int, TestContext] {
+		return MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt, WithNodePool())
+	})
+	acme, globex := ns.Namespace("acme"), ns.Namespace("globex")
+	if ns.Namespace("acme") != acme {
+		t.Fatal("Namespace should return the existing namespace")
+	}
+	if acme.Name() != "acme" || acme.nodePool != globex.nodePool || acme.nodePool != &ns.pool {
+		t.Error("Namespaces should share the group's node pool")
+	}
+
+	// The same keys live independently in each namespace
+	items := createTestItems(10)
+	for _, item := range items {
+		acme.Insert(item, TestContext{IsCached: item.ID%2 == 0})
+	}
+	globex.Insert(items[0], TestContext{})
+	if acme.Length() != 10 || globex.Length() != 1 {
+		t.Errorf("Lengths %d and %d, want 10 and 1", acme.Length(), globex.Length())
+	}
+	if !slices.Equal(ns.Names(), []string{"acme", "globex"}) {
+		t.Errorf("Names = %v", ns.Names())
+	}
+	if stats := ns.Stats(); stats["acme"].Length != 10 || stats["globex"].Length != 1 {
+		t.Errorf("Per-namespace stats = %+v", stats)
+	}
+
+	acme.SetFlushFilter(func(ip *ItemPtr[TestItem, int, TestContext]) bool { return ip.Context().IsCached })
+	iovecs := ns.ToIovecSlices()
+	if len(iovecs["acme"]) != 5 || len(iovecs["globex"]) != 1 {
+		t.Errorf("Flushed %d and %d iovecs, want 5 and 1", len(iovecs["acme"]), len(iovecs["globex"]))
+	}
+
+	if !ns.Drop("globex") || ns.Drop("globex") {
+		t.Error("Drop should report whether the namespace existed")
+	}
+	if _, ok := ns.Lookup("globex"); ok {
+		t.Error("A dropped namespace should not be found")
+	}
+
+	stopped := false
+	ns.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		stopped = true
+	})
+	ns.AddHealthCheck("wal", func() error { return nil })
+	if err := ns.Healthy(); err != nil {
+		t.Errorf("Healthy = %v", err)
+	}
+	acme.AddHealthCheck("quota", func() error { return errors.New("over") })
+	if err := ns.Healthy(); err == nil || !strings.Contains(err.Error(), "namespace acme: quota: over") {
+		t.Errorf("Healthy should report namespace problems, got %v", err)
+	}
+
+	if err := ns.Close(context.Background()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if !stopped || !acme.Frozen() || !ns.Namespace("initech").Frozen() {
+		t.Error("Close should stop the group's tasks and freeze every namespace, including later ones")
+	}
+}
@@ -0,0 +1,43 @@
+// normalize.go - Normalizing keys on insert and lookup
+
+package zerocopyskiplist
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// WithKeyNormalizer stores and looks up every key in the form normalize
+// returns, so variants of a key such as different letter cases or
+// surrounding spaces address the same entry without every call site
+// normalizing: WithKeyNormalizer(strings.ToLower) makes a string-keyed list
+// case-insensitive. Item keys are normalized on insert, so Key, cursors,
+// scans and iteration order all see the normalized form, and keys passed to
+// lookups, deletes, pins, seeks and ranges are normalized before use. The
+// comparator sees only normalized keys. normalize must be idempotent, and the
+// list's constructor panics if its key type is not the list's K.
+func WithKeyNormalizer[K comparable](normalize func(K) K) Option {
+	return func(o *options) {
+		o.normalizeKey = normalize
+	}
+}
+
+// normalizer returns the WithKeyNormalizer function of opts, nil if none
+func normalizer[K comparable](opts options) func(K) K {
+	if opts.normalizeKey == nil {
+		return nil
+	}
+	normalize, ok := opts.normalizeKey.(func(K) K)
+	if !ok {
+		panic(fmt.Sprintf("WithKeyNormalizer for %T used with keys of type %v", opts.normalizeKey, reflect.TypeFor[K]()))
+	}
+	return normalize
+}
+
+// normalize returns key in the form stored in the list, see WithKeyNormalizer
+func (sl *ZeroCopySkiplist[T, K, C]) normalize(key K) K {
+	if sl.normalizeKey == nil {
+		return key
+	}
+	return sl.normalizeKey(key)
+}
@@ -0,0 +1,54 @@
+package zerocopyskiplist
+
+import (
+	"strings"
+	"testing"
+)
+
+type namedItem struct {
+	Name  string
+	Value int
+}
+
+func TestKeyNormalizer(t *testing.T) {
+	normalize := func(s string) string { return strings.ToLower(strings.TrimSpace(s)) }
+	skiplist := MakeOrderedZeroCopySkiplist[namedItem, string, TestContext](8,
+		func(item *namedItem) string { return item.Name },
+		func(*namedItem) int { return 16 },
+		WithKeyNormalizer(normalize))
+
+	apple := &namedItem{Name: "Apple", Value: 1}
+	skiplist.Insert(apple, TestContext{})
+	skiplist.Insert(&namedItem{Name: "banana"}, TestContext{})
+	if skiplist.Insert(&namedItem{Name: " APPLE ", Value: 2}, TestContext{}) || skiplist.Length() != 2 {
+		t.Fatalf("Inserting a variant of a key should replace it, Length %d", skiplist.Length())
+	}
+
+	if ptr, _ := skiplist.Find("aPPle"); ptr == nil || ptr.Item().Value != 2 || ptr.Key() != "apple" {
+		t.Errorf("Find of a variant = %v, want the replaced item under the normalized key", ptr)
+	}
+	if _, ok := skiplist.Get("BANANA "); !ok {
+		t.Error("Get of a variant failed")
+	}
+	if !skiplist.Pin("Banana") || !skiplist.Pinned("banana") || !skiplist.Unpin("BANANA") {
+		t.Error("Pins should apply to variants of a key")
+	}
+	if c := skiplist.SeekGE("B"); !c.Valid() || c.Key() != "banana" {
+		t.Error("SeekGE should normalize its key")
+	}
+	if !skiplist.Delete(" Banana") || skiplist.Length() != 1 {
+		t.Error("Delete of a variant failed")
+	}
+
+	// Copies keep normalizing
+	if ptr, _ := skiplist.Copy().Find("APPLE"); ptr == nil {
+		t.Error("Copy should look up variants")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("A normalizer for the wrong key type should panic")
+		}
+	}()
+	MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt, WithKeyNormalizer(normalize))
+}
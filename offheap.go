@@ -0,0 +1,294 @@
+// offheap.go - Off-heap slab allocator for item storage
+
+package zerocopyskiplist
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// offHeapAlign is the alignment of every off-heap allocation
+const offHeapAlign = 8
+
+// ErrOffHeapClosed is returned when allocating from a closed OffHeap
+var ErrOffHeapClosed = errors.New("off-heap allocator is closed")
+
+// OffHeap allocates item memory from anonymous mmap regions outside the Go
+// heap, so the garbage collector never scans or moves it. Items are carved out
+// of fixed-size slabs with a bump pointer; a slab is returned to the OS once
+// every allocation in it has been freed.
+//
+// Memory from an OffHeap must only hold pointer-free data: the garbage
+// collector does not see pointers stored there. OffHeapNew enforces this for
+// typed allocations. An OffHeap is safe for concurrent use.
+type OffHeap struct {
+	mu       sync.Mutex
+	slabSize int
+	slabs    []*offHeapSlab // sorted by base address
+	current  *offHeapSlab
+	mapped   int64
+	live     int64
+	closed   bool
+}
+
+// offHeapSlab is one mmap region
+type offHeapSlab struct {
+	mem  []byte
+	off  int   // bump offset of the next allocation
+	live int64 // bytes allocated and not yet freed
+}
+
+// NewOffHeap creates an allocator that maps memory in slabs of slabSize bytes,
+// rounded up to the page size. Allocations larger than a slab get their own region.
+func NewOffHeap(slabSize int) (*OffHeap, error) {
+	if slabSize <= 0 {
+		return nil, fmt.Errorf("invalid off-heap slab size: %d", slabSize)
+	}
+	pageSize := syscall.Getpagesize()
+	slabSize = (slabSize + pageSize - 1) / pageSize * pageSize
+	return &OffHeap{slabSize: slabSize}, nil
+}
+
+// Alloc returns size zeroed bytes of off-heap memory aligned to 8 bytes
+func (a *OffHeap) Alloc(size int) ([]byte, error) {
+	if size < 0 {
+		return nil, fmt.Errorf("invalid off-heap allocation size: %d", size)
+	}
+	rounded := alignOffHeap(size)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.closed {
+		return nil, ErrOffHeapClosed
+	}
+
+	slab := a.current
+	if slab == nil || len(slab.mem)-slab.off < rounded {
+		var err error
+		if slab, err = a.mapSlab(rounded); err != nil {
+			return nil, err
+		}
+		if rounded <= a.slabSize {
+			a.current = slab
+		}
+	}
+
+	b := slab.mem[slab.off : slab.off+size : slab.off+rounded]
+	slab.off += rounded
+	slab.live += int64(rounded)
+	a.live += int64(rounded)
+	return b, nil
+}
+
+// Free releases memory returned by Alloc; the memory must not be used afterwards
+func (a *OffHeap) Free(b []byte) {
+	if cap(b) == 0 {
+		return
+	}
+	a.free(unsafe.Pointer(unsafe.SliceData(b)), cap(b))
+}
+
+// free releases size bytes at ptr, unmapping the owning slab once it is empty
+func (a *OffHeap) free(ptr unsafe.Pointer, size int) {
+	rounded := int64(alignOffHeap(size))
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	i := a.slabIndex(uintptr(ptr))
+	if i < 0 {
+		return
+	}
+
+	slab := a.slabs[i]
+	slab.live -= rounded
+	a.live -= rounded
+	if slab.live > 0 {
+		return
+	}
+
+	if slab == a.current {
+		// Keep the current slab mapped and start filling it again
+		clear(slab.mem[:slab.off])
+		slab.off = 0
+		return
+	}
+	a.unmapSlab(i)
+}
+
+// Contains returns true if ptr points into memory mapped by this allocator
+func (a *OffHeap) Contains(ptr unsafe.Pointer) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.slabIndex(uintptr(ptr)) >= 0
+}
+
+// MappedBytes returns the number of bytes currently mapped from the OS
+func (a *OffHeap) MappedBytes() int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.mapped
+}
+
+// LiveBytes returns the number of allocated bytes not yet freed, including alignment padding
+func (a *OffHeap) LiveBytes() int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.live
+}
+
+// Close unmaps every slab. All memory handed out by the allocator becomes
+// invalid, so Close must only be called once no item from it is referenced.
+func (a *OffHeap) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var firstErr error
+	for _, slab := range a.slabs {
+		if err := syscall.Munmap(slab.mem); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	a.slabs = nil
+	a.current = nil
+	a.mapped = 0
+	a.live = 0
+	a.closed = true
+	return firstErr
+}
+
+// OffHeapNew allocates a zeroed T in off-heap memory. T must not contain
+// pointers, strings, slices, maps, channels, functions or interfaces.
+func OffHeapNew[T any](a *OffHeap) (*T, error) {
+	t := reflect.TypeFor[T]()
+	if typeHasPointers(t) {
+		return nil, fmt.Errorf("type %v contains pointers and cannot be stored off-heap", t)
+	}
+
+	b, err := a.Alloc(int(t.Size()))
+	if err != nil {
+		return nil, err
+	}
+	return (*T)(unsafe.Pointer(unsafe.SliceData(b))), nil
+}
+
+// OffHeapFree releases an item allocated with OffHeapNew
+func OffHeapFree[T any](a *OffHeap, item *T) {
+	a.free(unsafe.Pointer(item), int(unsafe.Sizeof(*item)))
+}
+
+// mapSlab maps a new slab able to hold at least size bytes; the caller must hold the lock
+func (a *OffHeap) mapSlab(size int) (*offHeapSlab, error) {
+	length := a.slabSize
+	if size > length {
+		pageSize := syscall.Getpagesize()
+		length = (size + pageSize - 1) / pageSize * pageSize
+	}
+
+	mem, err := syscall.Mmap(-1, 0, length, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE|syscall.MAP_ANON)
+	if err != nil {
+		return nil, fmt.Errorf("mapping off-heap slab of %d bytes: %w", length, err)
+	}
+
+	slab := &offHeapSlab{mem: mem}
+	base := slab.base()
+	i := sort.Search(len(a.slabs), func(i int) bool { return a.slabs[i].base() > base })
+	a.slabs = append(a.slabs, nil)
+	copy(a.slabs[i+1:], a.slabs[i:])
+	a.slabs[i] = slab
+	a.mapped += int64(length)
+	return slab, nil
+}
+
+// unmapSlab returns slab i to the OS; the caller must hold the lock
+func (a *OffHeap) unmapSlab(i int) {
+	slab := a.slabs[i]
+	a.mapped -= int64(len(slab.mem))
+	syscall.Munmap(slab.mem)
+	a.slabs = append(a.slabs[:i], a.slabs[i+1:]...)
+}
+
+// slabIndex returns the index of the slab containing addr, -1 if none does;
+// the caller must hold the lock
+func (a *OffHeap) slabIndex(addr uintptr) int {
+	i := sort.Search(len(a.slabs), func(i int) bool { return a.slabs[i].base() > addr }) - 1
+	if i < 0 || addr >= a.slabs[i].base()+uintptr(len(a.slabs[i].mem)) {
+		return -1
+	}
+	return i
+}
+
+// base returns the start address of the slab
+func (s *offHeapSlab) base() uintptr {
+	return uintptr(unsafe.Pointer(unsafe.SliceData(s.mem)))
+}
+
+// alignOffHeap rounds size up to the allocation alignment, minimum one unit
+func alignOffHeap(size int) int {
+	if size == 0 {
+		return offHeapAlign
+	}
+	return (size + offHeapAlign - 1) &^ (offHeapAlign - 1)
+}
+
+// typeHasPointers returns true if values of t contain anything the garbage collector traces
+func typeHasPointers(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Array:
+		return t.Len() > 0 && typeHasPointers(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if typeHasPointers(t.Field(i).Type) {
+				return true
+			}
+		}
+		return false
+	case reflect.Pointer, reflect.UnsafePointer, reflect.String, reflect.Slice, reflect.Map,
+		reflect.Chan, reflect.Func, reflect.Interface:
+		return true
+	default:
+		return false
+	}
+}
+
+// offHeapSpan is the address range of one slab chosen for evacuation
+type offHeapSpan struct {
+	base, end uintptr
+}
+
+// sparseSlabs returns the slabs whose live bytes are below maxUtilization of
+// the bytes carved out of them, sorted by address. A sparse current slab is
+// retired so that relocated items land in fresh slabs, and an empty one is
+// unmapped at once since no free will ever release it.
+func (a *OffHeap) sparseSlabs(maxUtilization float64) []offHeapSpan {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var spans []offHeapSpan
+	for i := 0; i < len(a.slabs); i++ {
+		slab := a.slabs[i]
+		used := len(slab.mem)
+		if slab == a.current {
+			used = slab.off
+		}
+		if used == 0 || float64(slab.live) >= maxUtilization*float64(used) {
+			continue
+		}
+		if slab == a.current {
+			a.current = nil
+			if slab.live == 0 {
+				a.unmapSlab(i)
+				i--
+				continue
+			}
+		}
+		spans = append(spans, offHeapSpan{base: slab.base(), end: slab.base() + uintptr(len(slab.mem))})
+	}
+	return spans
+}
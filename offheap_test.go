@@ -0,0 +1,126 @@
+package zerocopyskiplist
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// OffHeapRecord is a pointer-free item suitable for off-heap storage
+type OffHeapRecord struct {
+	ID      int64
+	Counter uint32
+	Flags   uint32
+	Payload [48]byte
+}
+
+func TestOffHeapSkiplist(t *testing.T) {
+	arena, err := NewOffHeap(4096)
+	if err != nil {
+		t.Fatalf("NewOffHeap: %v", err)
+	}
+	defer arena.Close()
+
+	skiplist := MakeZeroCopySkiplist[OffHeapRecord, int64, string](
+		16,
+		func(r *OffHeapRecord) int64 { return r.ID },
+		func(r *OffHeapRecord) int { return int(unsafe.Sizeof(*r)) },
+		func(a, b int64) int {
+			if a < b {
+				return -1
+			}
+			if a > b {
+				return 1
+			}
+			return 0
+		},
+	)
+
+	// Enough records to span several slabs
+	const numItems = 500
+	for i := numItems; i > 0; i-- {
+		record, err := OffHeapNew[OffHeapRecord](arena)
+		if err != nil {
+			t.Fatalf("OffHeapNew: %v", err)
+		}
+		if *record != (OffHeapRecord{}) {
+			t.Fatal("OffHeapNew should return zeroed memory")
+		}
+		record.ID = int64(i)
+		record.Payload[0] = byte(i)
+		skiplist.Insert(record, "hot")
+	}
+
+	if arena.MappedBytes() < numItems*int64(unsafe.Sizeof(OffHeapRecord{})) {
+		t.Errorf("Arena should map at least the live data, mapped %d bytes", arena.MappedBytes())
+	}
+
+	// Iovecs point straight at the off-heap records
+	iovecs := skiplist.ToIovecSlice("")
+	if len(iovecs) != numItems {
+		t.Fatalf("Expected %d iovecs, got %d", numItems, len(iovecs))
+	}
+	for i, iovec := range iovecs {
+		if !arena.Contains(unsafe.Pointer(iovec.Base)) {
+			t.Fatalf("Iovec %d does not point into the arena", i)
+		}
+		record := (*OffHeapRecord)(unsafe.Pointer(iovec.Base))
+		if record.ID != int64(i+1) || record.Payload[0] != byte(i+1) {
+			t.Fatalf("Iovec %d points at record %d", i, record.ID)
+		}
+	}
+
+	// Removing and freeing every record returns the slabs to the OS
+	for current := skiplist.First(); current != nil; {
+		record := current.Item()
+		current = current.Next()
+		skiplist.Delete(record.ID)
+		OffHeapFree(arena, record)
+	}
+	if arena.LiveBytes() != 0 {
+		t.Errorf("Expected no live bytes after freeing everything, got %d", arena.LiveBytes())
+	}
+	if arena.MappedBytes() > 4096 {
+		t.Errorf("Only the current slab should stay mapped, mapped %d bytes", arena.MappedBytes())
+	}
+}
+
+func TestOffHeapAlloc(t *testing.T) {
+	arena, err := NewOffHeap(1)
+	if err != nil {
+		t.Fatalf("NewOffHeap: %v", err)
+	}
+
+	small, err := arena.Alloc(3)
+	if err != nil || len(small) != 3 {
+		t.Fatalf("Alloc(3) = %d bytes, %v", len(small), err)
+	}
+	if uintptr(unsafe.Pointer(&small[0]))%offHeapAlign != 0 {
+		t.Error("Allocations should be aligned")
+	}
+
+	// Larger than a slab gets a dedicated region
+	large, err := arena.Alloc(3 * 4096)
+	if err != nil || len(large) != 3*4096 {
+		t.Fatalf("Alloc(large) = %d bytes, %v", len(large), err)
+	}
+	large[len(large)-1] = 1
+	mapped := arena.MappedBytes()
+	arena.Free(large)
+	if arena.MappedBytes() >= mapped {
+		t.Error("Freeing a dedicated region should unmap it")
+	}
+
+	if _, err := OffHeapNew[TestItem](arena); err == nil {
+		t.Error("OffHeapNew should reject types containing pointers")
+	}
+	if _, err := arena.Alloc(-1); err == nil {
+		t.Error("Alloc should reject negative sizes")
+	}
+
+	if err := arena.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := arena.Alloc(8); err != ErrOffHeapClosed {
+		t.Errorf("Alloc after Close should return ErrOffHeapClosed, got %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+// options.go - Construction options for the zero copy skiplist
+
+package zerocopyskiplist
+
+import (
+	"log/slog"
+	"reflect"
+	"time"
+)
+
+// Option configures optional skiplist behaviour at construction time
+type Option func(*options)
+
+// options holds the resolved construction options; Copy() carries them over
+type options struct {
+	levelBacklinks     bool
+	nodePool           bool
+	inlineKeys         bool
+	naturalOrder       bool // set by MakeOrderedZeroCopySkiplist, cmpKey is cmp.Compare
+	levelSequence      []int
+	recordLevels       bool
+	paranoidRate       float64
+	leakTracking       bool
+	detachedNodes      bool
+	iovMax             int
+	descending         bool
+	levelPolicy        LevelPolicy
+	pathCache          int
+	contentionTracking bool
+	latencyHistograms  bool
+	slowLockThreshold  time.Duration
+	slowLockLogger     *slog.Logger
+	contextStats       bool
+	hotspotLevel       int
+	hotspotHalfLife    time.Duration
+	clock              Clock
+	smallThreshold     int
+	collation          string
+	normalizeKey       any // a func(K) K, see WithKeyNormalizer
+	flushExactlyOnce   bool
+	contextLengths     int
+	misusePolicy       MisusePolicy
+	comparatorRate     float64
+}
+
+// buildOptions applies the given options over the defaults
+func buildOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithLevelBacklinks maintains backward links on every level instead of only
+// level 0, so reverse searches can descend from the end of the list as cheaply
+// as forward searches descend from the start. Costs one pointer per node level.
+func WithLevelBacklinks() Option {
+	return func(o *options) {
+		o.levelBacklinks = true
+	}
+}
+
+// WithNodePool recycles deleted nodes, together with their link slices, for
+// use by later inserts, saving two allocations per entry in workloads that
+// constantly insert and delete.
+//
+// A recycled node may be handed out again for an unrelated key, so an ItemPtr
+// must not be used once its item has been deleted.
+func WithNodePool() Option {
+	return func(o *options) {
+		o.nodePool = true
+	}
+}
+
+// WithInlineKeys caches each successor's key next to the forward pointer that
+// leads to it, so a search compares keys held in the node it is already at and
+// only dereferences the successor when it moves onto it. This trades one key
+// per node level for fewer cache misses during descent.
+//
+// The option only takes effect for small pointer-free keys (integers, fixed
+// size arrays and structs of at most 16 bytes); other key types silently keep
+// the default layout.
+func WithInlineKeys() Option {
+	return func(o *options) {
+		o.inlineKeys = true
+	}
+}
+
+// WithLevelSequence makes new nodes take their levels from levels, in order,
+// instead of generating them randomly; levels outside [0, maxLevel] are
+// clamped. Once the sequence is exhausted levels are random again. Combined
+// with a sequence captured by WithLevelRecording this reproduces a list's exact
+// topology, which makes pointer bugs found in production repeatable in tests.
+func WithLevelSequence(levels []int) Option {
+	levels = append([]int(nil), levels...)
+	return func(o *options) {
+		o.levelSequence = levels
+	}
+}
+
+// WithLevelPolicy chooses the levels of new nodes with policy instead of the
+// default GeometricLevels(0.5); see EveryKthLevels and AppendBiasedLevels.
+// Stats reports the resulting LevelCounts and EstimatedSearchSteps.
+func WithLevelPolicy(policy LevelPolicy) Option {
+	return func(o *options) {
+		o.levelPolicy = policy
+	}
+}
+
+// WithLevelRecording records the level of every new node so the sequence can
+// be retrieved with LevelSequence. The record grows by one entry per insert.
+func WithLevelRecording() Option {
+	return func(o *options) {
+		o.recordLevels = true
+	}
+}
+
+// WithParanoid verifies the links around the touched region after a sampled
+// fraction rate (0 < rate <= 1) of inserts and deletes, panicking with an
+// *InvariantViolation describing the region if they are inconsistent. Checks
+// are O(level) per sampled operation, cheap enough for staging environments.
+// Building with the zcslparanoid tag checks every operation of every list.
+func WithParanoid(rate float64) Option {
+	return func(o *options) {
+		o.paranoidRate = rate
+	}
+}
+
+// WithLeakTracking counts cursors, iovec slices and released nodes as they are
+// garbage collected, so Stats can report how many are still retained by the
+// application. Each tracked object carries a runtime cleanup, which adds a
+// little allocation and GC overhead; intended for debugging and soak tests.
+// Released nodes are not tracked WithNodePool, since recycled nodes are reused
+// rather than collected.
+func WithLeakTracking() Option {
+	return func(o *options) {
+		o.leakTracking = true
+	}
+}
+
+// WithDetachedNodes makes First, Last, Find and FindItem return a detached
+// copy of the node, taken under the read lock, instead of the live node. A
+// detached node carries the key, item pointer and context but no links: Next
+// and Prev return nil and SetContext changes only the copy. Reading it is safe
+// however the list changes afterwards, at the cost of one allocation per call;
+// use a Cursor or ForEach to walk the list.
+func WithDetachedNodes() Option {
+	return func(o *options) {
+		o.detachedNodes = true
+	}
+}
+
+// WithIovMax overrides the number of iovecs IovecChunks puts in each chunk,
+// which otherwise follows the platform limit reported by IovMax. Useful when
+// writes go through a layer with a lower limit than the kernel's.
+func WithIovMax(n int) Option {
+	return func(o *options) {
+		o.iovMax = n
+	}
+}
+
+// WithDescending orders the list from the largest key to the smallest, as
+// if the comparator were inverted, for newest-first lists. The list's order
+// is the only order every method knows: First, Cursor.Next, ForEach and the
+// flush helpers start from the largest key, and wherever a method speaks of
+// smaller or larger keys, minimums and maximums, such as Bounds, SeekGE,
+// KeyRange or DescendRange, it means earlier or later in that order.
+func WithDescending() Option {
+	return func(o *options) {
+		o.descending = true
+	}
+}
+
+// reverseCompare returns a comparator ordering keys opposite to cmpKey
+func reverseCompare[K any](cmpKey func(K, K) int) func(K, K) int {
+	return func(a, b K) int {
+		return cmpKey(b, a)
+	}
+}
+
+// maxInlineKeySize is the largest key type WithInlineKeys will cache
+const maxInlineKeySize = 16
+
+// inlineableKey returns true if K is small and pointer-free enough for WithInlineKeys
+func inlineableKey[K any]() bool {
+	t := reflect.TypeFor[K]()
+	return t.Size() <= maxInlineKeySize && !typeHasPointers(t)
+}
+
+// WithPathCache keeps the search paths of up to size recently searched keys,
+// slotted by key hash, so repeated lookups, updates and deletes of hot keys
+// skip the descent from the header. Any change to the list's links
+// invalidates every cached path, so it pays off for read-mostly lists; each
+// miss allocates a path of maxLevel+1 pointers. Stats reports the hit rate.
+func WithPathCache(size int) Option {
+	return func(o *options) {
+		o.pathCache = size
+	}
+}
+
+// WithContentionTracking counts write lock acquisitions and times those that
+// have to wait for another holder, reported by Stats as WriteLocks,
+// WriteLockWaits and WriteLockWaitTime. An uncontended acquisition costs one
+// extra TryLock; only waits are timed. Sustained contention says the list
+// should be partitioned, for example into Namespaces by tenant or as an
+// AdaptiveSkiplist that splits itself by key range, since a single list
+// serialises its writers.
+func WithContentionTracking() Option {
+	return func(o *options) {
+		o.contentionTracking = true
+	}
+}
+
+// WithLatencyHistograms records the latency of every Insert, lookup, Delete
+// and scan in a log-linear histogram per operation, reported in
+// Stats().Latency and by WritePrometheusLatency. Latencies include lock
+// waits, so the tail percentiles expose writer convoys that averages hide.
+// Each operation reads the clock twice and updates a few atomic counters.
+func WithLatencyHistograms() Option {
+	return func(o *options) {
+		o.latencyHistograms = true
+	}
+}
@@ -0,0 +1,93 @@
+// ordered.go - Natural-order skiplists with comparator-free fast paths
+
+package zerocopyskiplist
+
+import (
+	"cmp"
+	"reflect"
+	"unsafe"
+)
+
+// keyKind selects a specialised descent for keys compared in natural order
+type keyKind int
+
+const (
+	keyKindCustom keyKind = iota // call cmpKey
+	keyKindInt64                 // 64-bit signed integers
+	keyKindUint64                // 64-bit unsigned integers
+	keyKindString                // strings
+)
+
+// MakeOrderedZeroCopySkiplist creates a skiplist whose keys are ordered by
+// their natural order (cmp.Compare). When K is a 64-bit integer or a string
+// type, including named types based on them, searches compare keys inline
+// instead of calling the comparator through a function pointer.
+func MakeOrderedZeroCopySkiplist[T any, K cmp.Ordered, C comparable](
+	maxLevel int,
+	getKeyFromItem func(*T) K,
+	getItemSize func(*T) int,
+	opts ...Option,
+) *ZeroCopySkiplist[T, K, C] {
+	o := buildOptions(opts)
+	if o.descending {
+		// The inline descents assume ascending order
+		return newSkiplist[T, K, C](maxLevel, getKeyFromItem, getItemSize, reverseCompare(cmp.Compare[K]), o)
+	}
+	o.naturalOrder = true
+	return newSkiplist[T, K, C](maxLevel, getKeyFromItem, getItemSize, cmp.Compare[K], o)
+}
+
+// orderedKeyKind returns the specialised descent usable for K in natural order
+func orderedKeyKind[K any]() keyKind {
+	t := reflect.TypeFor[K]()
+	switch t.Kind() {
+	case reflect.Int, reflect.Int64:
+		if t.Size() == 8 {
+			return keyKindInt64
+		}
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		if t.Size() == 8 {
+			return keyKindUint64
+		}
+	case reflect.String:
+		return keyKindString
+	}
+	return keyKindCustom
+}
+
+// descendOrdered is descend specialised for keys whose representation is O and
+// which compare in natural order; the caller must hold the lock
+func descendOrdered[O int64 | uint64 | string, T any, K comparable, C comparable](
+	sl *ZeroCopySkiplist[T, K, C],
+	key K,
+	bound int,
+	update []*ItemPtr[T, K, C],
+) *ItemPtr[T, K, C] {
+	target := *(*O)(unsafe.Pointer(&key))
+	inclusive := bound == 0
+	current := sl.header
+
+	for i := sl.level; i >= 0; i-- {
+		for {
+			next := current.forward[i]
+			if next == nil {
+				break
+			}
+
+			var nextKey O
+			if sl.inlineKeys {
+				nextKey = *(*O)(unsafe.Pointer(&current.fkeys[i]))
+			} else {
+				nextKey = *(*O)(unsafe.Pointer(&next.key))
+			}
+			if nextKey > target || (nextKey == target && !inclusive) {
+				break
+			}
+			current = next
+		}
+		if update != nil {
+			update[i] = current
+		}
+	}
+	return current
+}
@@ -0,0 +1,169 @@
+package zerocopyskiplist
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// OrderedID is a named key type based on a specialised kind
+type OrderedID uint64
+
+func TestOrderedKeyKinds(t *testing.T) {
+	if kind := orderedKeyKind[int](); kind != keyKindInt64 {
+		t.Errorf("int should use the int64 fast path, got %d", kind)
+	}
+	if kind := orderedKeyKind[OrderedID](); kind != keyKindUint64 {
+		t.Errorf("named uint64 should use the uint64 fast path, got %d", kind)
+	}
+	if kind := orderedKeyKind[string](); kind != keyKindString {
+		t.Errorf("string should use the string fast path, got %d", kind)
+	}
+	if kind := orderedKeyKind[float64](); kind != keyKindCustom {
+		t.Errorf("float64 should use the comparator, got %d", kind)
+	}
+}
+
+func TestOrderedSkiplistMatchesComparator(t *testing.T) {
+	for _, inline := range []bool{false, true} {
+		var opts []Option
+		if inline {
+			opts = append(opts, WithInlineKeys())
+		}
+		ordered := MakeOrderedZeroCopySkiplist[TestItem, int, TestContext](
+			12,
+			getKeyFromTestItem,
+			getTestItemSize,
+			opts...,
+		)
+		custom := MakeZeroCopySkiplist[TestItem, int, TestContext](
+			12,
+			getKeyFromTestItem,
+			getTestItemSize,
+			compareInt,
+		)
+
+		rng := rand.New(rand.NewSource(11))
+		for i := 0; i < 3000; i++ {
+			id := rng.Intn(1000) - 500 // include negative keys
+			item := &TestItem{ID: id}
+			if rng.Intn(3) == 0 {
+				if ordered.Delete(id) != custom.Delete(id) {
+					t.Fatalf("inline=%v: Delete(%d) results differ", inline, id)
+				}
+			} else if ordered.Insert(item, TestContext{}) != custom.Insert(item, TestContext{}) {
+				t.Fatalf("inline=%v: Insert(%d) results differ", inline, id)
+			}
+		}
+		if err := ordered.Validate(); err != nil {
+			t.Fatalf("inline=%v: Validate: %v", inline, err)
+		}
+
+		for key := -510; key <= 510; key++ {
+			if (ordered.FindItem(key) == nil) != (custom.FindItem(key) == nil) {
+				t.Errorf("inline=%v: FindItem(%d) results differ", inline, key)
+			}
+			a, b := ordered.SeekGE(key), custom.SeekGE(key)
+			if a.Valid() != b.Valid() || a.Key() != b.Key() {
+				t.Errorf("inline=%v: SeekGE(%d) results differ", inline, key)
+			}
+		}
+	}
+}
+
+func TestOrderedStringKeys(t *testing.T) {
+	skiplist := MakeOrderedZeroCopySkiplist[TestItem, string, int](
+		12,
+		func(item *TestItem) string { return item.Value },
+		getTestItemSize,
+	)
+
+	for _, item := range createTestItems(100) {
+		skiplist.Insert(item, item.ID)
+	}
+	if err := skiplist.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+
+	// value_1, value_10, value_100, value_11 ... in string order
+	c := skiplist.SeekGE("value_1")
+	expected := []string{"value_1", "value_10", "value_100", "value_11"}
+	for i, key := range expected {
+		if !c.Valid() || c.Key() != key {
+			t.Fatalf("Expected %s at position %d, got %s", key, i, c.Key())
+		}
+		c.Next()
+	}
+	if found, ctx := skiplist.Find("value_42"); found == nil || ctx != 42 {
+		t.Error("Should find value_42 with its context")
+	}
+}
+
+func BenchmarkFindOrdered(b *testing.B) {
+	builders := map[string]func() *ZeroCopySkiplist[TestItem, int, TestContext]{
+		"Comparator": func() *ZeroCopySkiplist[TestItem, int, TestContext] {
+			return MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt)
+		},
+		"Ordered": func() *ZeroCopySkiplist[TestItem, int, TestContext] {
+			return MakeOrderedZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize)
+		},
+	}
+	for _, name := range []string{"Comparator", "Ordered"} {
+		b.Run(name, func(b *testing.B) {
+			skiplist := builders[name]()
+			items := make([]TestItem, 10000)
+			for i := range items {
+				items[i] = TestItem{ID: i, Value: fmt.Sprintf("value_%d", i)}
+				skiplist.Insert(&items[i], TestContext{})
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = skiplist.FindItem(i % len(items))
+			}
+		})
+	}
+}
+
+func TestDescending(t *testing.T) {
+	lists := map[string]*ZeroCopySkiplist[TestItem, int, TestContext]{
+		"custom":  MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt, WithDescending()),
+		"ordered": MakeOrderedZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, WithDescending()),
+	}
+	for name, sl := range lists {
+		items := createTestItems(300)
+		for _, i := range rand.Perm(len(items)) {
+			sl.Insert(items[i], TestContext{})
+		}
+
+		if sl.First().Key() != 300 || sl.Last().Key() != 1 || sl.First().Next().Key() != 299 || sl.Last().Prev().Key() != 2 {
+			t.Errorf("%s: First/Last/Next/Prev should follow descending order", name)
+		}
+		if first, last, _ := sl.Bounds(); first != 300 || last != 1 {
+			t.Errorf("%s: Bounds = %d, %d; want 300, 1", name, first, last)
+		}
+		want := 300
+		sl.ForEach(func(ip *ItemPtr[TestItem, int, TestContext]) bool {
+			if ip.Key() != want {
+				t.Fatalf("%s: ForEach yielded %d, want %d", name, ip.Key(), want)
+			}
+			want--
+			return true
+		})
+		if c := sl.SeekGE(150); c.Key() != 150 || !c.Next() || c.Key() != 149 {
+			t.Errorf("%s: Cursor should move towards smaller keys", name)
+		}
+		if found, _ := sl.Find(42); found == nil || found.Key() != 42 {
+			t.Errorf("%s: Find(42) failed", name)
+		}
+		if !sl.Delete(300) || sl.First().Key() != 299 {
+			t.Errorf("%s: Delete of the first key failed", name)
+		}
+		if copied := sl.Copy(); copied.First().Key() != 299 || copied.Validate() != nil {
+			t.Errorf("%s: Copy should keep descending order", name)
+		}
+		if err := sl.Validate(); err != nil {
+			t.Errorf("%s: Validate: %v", name, err)
+		}
+	}
+}
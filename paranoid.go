@@ -0,0 +1,116 @@
+// paranoid.go - Sampled local invariant checks
+
+package zerocopyskiplist
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// paranoidContext is the number of nodes shown either side of a violation
+const paranoidContext = 3
+
+// InvariantViolation is the panic value raised when a paranoid check finds the
+// links around a node inconsistent
+type InvariantViolation struct {
+	Op       string // operation that touched the region
+	Key      any    // key of the node being checked
+	Level    int    // level on which the violation was found
+	Detail   string // what is wrong
+	Region   string // keys and levels of the nodes around the violation
+	ListInfo string // list level and length at the time
+}
+
+// Error formats the violation with its diagnostics
+func (v *InvariantViolation) Error() string {
+	return fmt.Sprintf("zerocopyskiplist: invariant violated after %s at key %v level %d: %s\n  list: %s\n  region: %s",
+		v.Op, v.Key, v.Level, v.Detail, v.ListInfo, v.Region)
+}
+
+// sampleParanoid returns true if the current operation should be checked
+func (sl *ZeroCopySkiplist[T, K, C]) sampleParanoid() bool {
+	if paranoidBuild {
+		return true
+	}
+	rate := sl.opts.paranoidRate
+	return rate > 0 && (rate >= 1 || rand.Float64() < rate)
+}
+
+// checkAround verifies the links of node on every level it occupies and panics
+// with an *InvariantViolation on the first inconsistency; the caller must hold the lock
+func (sl *ZeroCopySkiplist[T, K, C]) checkAround(op string, node *ItemPtr[T, K, C]) {
+	if detail, level := sl.localViolation(node); detail != "" {
+		panic(&InvariantViolation{
+			Op:       op,
+			Key:      node.key,
+			Level:    level,
+			Detail:   detail,
+			Region:   sl.describeRegion(node),
+			ListInfo: fmt.Sprintf("level %d of max %d, length %d", sl.level, sl.maxLevel, sl.length),
+		})
+	}
+}
+
+// localViolation returns a description of the first broken link around node
+// and the level it was found on, or an empty string if the links are sound
+func (sl *ZeroCopySkiplist[T, K, C]) localViolation(node *ItemPtr[T, K, C]) (string, int) {
+	if node.level < 0 || len(node.forward) != node.level+1 {
+		return fmt.Sprintf("node level %d has %d forward links", node.level, len(node.forward)), node.level
+	}
+
+	for i := 0; i <= node.level; i++ {
+		next := node.forward[i]
+		if next == nil {
+			if sl.tails[i] != node {
+				return "last node on the level is not the level tail", i
+			}
+		} else {
+			if sl.cmpKey(node.key, next.key) >= 0 {
+				return fmt.Sprintf("successor key %v does not sort after it", next.key), i
+			}
+			if next.level < i {
+				return fmt.Sprintf("successor %v has level %d", next.key, next.level), i
+			}
+			if (i == 0 || sl.opts.levelBacklinks) && next.prevAt(i) != node {
+				return fmt.Sprintf("successor %v does not link back to it", next.key), i
+			}
+			if sl.inlineKeys && node.fkeys[i] != next.key {
+				return fmt.Sprintf("inline key %v does not match successor %v", node.fkeys[i], next.key), i
+			}
+		}
+
+		if i > 0 && !sl.opts.levelBacklinks {
+			continue
+		}
+		if prev := node.prevAt(i); prev == nil {
+			if sl.header.forward[i] != node {
+				return "node without a predecessor is not first on the level", i
+			}
+		} else {
+			if prev.level < i || prev.forward[i] != node {
+				return fmt.Sprintf("predecessor %v does not link forward to it", prev.key), i
+			}
+			if sl.cmpKey(prev.key, node.key) >= 0 {
+				return fmt.Sprintf("predecessor key %v does not sort before it", prev.key), i
+			}
+		}
+	}
+	return "", 0
+}
+
+// describeRegion lists the keys and levels of the nodes around node on level 0
+func (sl *ZeroCopySkiplist[T, K, C]) describeRegion(node *ItemPtr[T, K, C]) string {
+	var before []string
+	for prev, n := node.backward, 0; prev != nil && n < paranoidContext; prev, n = prev.backward, n+1 {
+		before = append([]string{fmt.Sprintf("%v(L%d)", prev.key, prev.level)}, before...)
+	}
+
+	parts := append(before, fmt.Sprintf("[%v(L%d)]", node.key, node.level))
+	next := node.forward
+	for n := 0; len(next) > 0 && next[0] != nil && n < paranoidContext; n++ {
+		parts = append(parts, fmt.Sprintf("%v(L%d)", next[0].key, next[0].level))
+		next = next[0].forward
+	}
+	return strings.Join(parts, " ")
+}
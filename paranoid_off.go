@@ -0,0 +1,8 @@
+//go:build !zcslparanoid
+
+// paranoid_off.go - Default build without forced paranoid checks
+
+package zerocopyskiplist
+
+// paranoidBuild forces local invariant checks after every insert and delete
+const paranoidBuild = false
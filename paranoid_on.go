@@ -0,0 +1,8 @@
+//go:build zcslparanoid
+
+// paranoid_on.go - Build-tag switch enabling paranoid checks on every operation
+
+package zerocopyskiplist
+
+// paranoidBuild forces local invariant checks after every insert and delete
+const paranoidBuild = true
@@ -0,0 +1,64 @@
+package zerocopyskiplist
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestParanoidChurn(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		8,
+		getKeyFromTestItem,
+		getTestItemSize,
+		compareInt,
+		WithParanoid(1),
+		WithLevelBacklinks(),
+		WithInlineKeys(),
+	)
+
+	items := createTestItems(500)
+	rng := rand.New(rand.NewSource(13))
+	for _, i := range rng.Perm(len(items)) {
+		skiplist.Insert(items[i], TestContext{})
+	}
+	for _, i := range rng.Perm(len(items)) {
+		skiplist.Delete(items[i].ID)
+	}
+}
+
+func TestParanoidDetectsCorruption(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		8,
+		getKeyFromTestItem,
+		getTestItemSize,
+		compareInt,
+		WithParanoid(1),
+		WithLevelSequence(make([]int, 10)),
+	)
+
+	// All nodes on level 0 only, so the search for 50 never passes 60
+	for i := 1; i <= 10; i++ {
+		skiplist.Insert(&TestItem{ID: i * 10}, TestContext{})
+	}
+
+	// Scramble the key of 60, then delete its predecessor so 40 links to it
+	skiplist.FindItem(60).key = 5
+
+	defer func() {
+		violation, ok := recover().(*InvariantViolation)
+		if !ok {
+			t.Fatal("Expected an *InvariantViolation panic")
+		}
+		if violation.Op != "delete" {
+			t.Errorf("Expected the violation to be reported for delete, got %s", violation.Op)
+		}
+		message := violation.Error()
+		for _, want := range []string{"successor key 5", "[40(L", "length 10"} {
+			if !strings.Contains(message, want) {
+				t.Errorf("Diagnostics should contain %q:\n%s", want, message)
+			}
+		}
+	}()
+	skiplist.Delete(50)
+}
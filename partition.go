@@ -0,0 +1,154 @@
+// partition.go - Flushing items to several descriptors in a durable order
+
+package zerocopyskiplist
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+)
+
+// ErrPartitionCycle is returned by NewPartitionedFlusher when the After
+// constraints of its partitions form a cycle
+var ErrPartitionCycle = errors.New("partition ordering has a cycle")
+
+// Partition is one destination of a PartitionedFlusher: the items whose
+// context Match accepts are written to FD. After names the partitions whose
+// data must be durable before any of this partition's is written, such as an
+// index partition listing its data partition.
+type Partition[C comparable] struct {
+	Name  string
+	FD    uintptr
+	Match func(C) bool
+	After []string
+}
+
+// PartitionReport records what a PartitionedFlusher wrote to one partition
+type PartitionReport struct {
+	Name    string
+	Entries int
+	Bytes   int64
+	Synced  bool // the partition was fsynced after its data was written
+}
+
+// PartitionedFlusher writes items to partitions chosen by their context with
+// writev, enforcing the partitions' After ordering with fsync barriers.
+// Partitions are flushed in stages: a stage holds every partition whose After
+// partitions were all in earlier stages, its partitions are written and then
+// fsynced together, and the next stage starts only once every fsync of the
+// stage has succeeded. Partitions with no ordering between them therefore
+// share a stage and never wait on each other's fsync.
+type PartitionedFlusher[T any, K comparable, C comparable] struct {
+	sl         *ZeroCopySkiplist[T, K, C]
+	partitions []Partition[C]
+	stages     [][]int // indexes into partitions, in flush order
+	retry      RetryPolicy
+	fsync      func(fd uintptr) error
+}
+
+// NewPartitionedFlusher returns a flusher for partitions, writing with retry
+// as for NewIovecWriter. An item goes to the first partition whose Match
+// accepts its context and is left out if none does. It returns an error if a
+// name is repeated, an After name is unknown, or the ordering has a cycle.
+func (sl *ZeroCopySkiplist[T, K, C]) NewPartitionedFlusher(partitions []Partition[C], retry RetryPolicy) (*PartitionedFlusher[T, K, C], error) {
+	index := make(map[string]int, len(partitions))
+	for i, p := range partitions {
+		if _, ok := index[p.Name]; ok {
+			return nil, fmt.Errorf("partition %q is defined twice", p.Name)
+		}
+		index[p.Name] = i
+	}
+
+	// Each stage is the partitions whose After partitions are all in earlier
+	// stages; placed is only updated between stages
+	placed := make([]bool, len(partitions))
+	var stages [][]int
+	for remaining := len(partitions); remaining > 0; {
+		var stage []int
+		for i, p := range partitions {
+			if placed[i] {
+				continue
+			}
+			ready := true
+			for _, name := range p.After {
+				j, ok := index[name]
+				if !ok {
+					return nil, fmt.Errorf("partition %q is ordered after unknown partition %q", p.Name, name)
+				}
+				if !placed[j] {
+					ready = false
+				}
+			}
+			if ready {
+				stage = append(stage, i)
+			}
+		}
+		if len(stage) == 0 {
+			return nil, ErrPartitionCycle
+		}
+		for _, i := range stage {
+			placed[i] = true
+		}
+		remaining -= len(stage)
+		stages = append(stages, stage)
+	}
+
+	return &PartitionedFlusher[T, K, C]{
+		sl:         sl,
+		partitions: partitions,
+		stages:     stages,
+		retry:      retry,
+		fsync:      func(fd uintptr) error { return syscall.Fsync(int(fd)) },
+	}, nil
+}
+
+// Flush writes the items the callback accepts to their partitions in key
+// order, stage by stage, and returns a report per partition in the order
+// they were given. The items are gathered in one pass as for
+// CallbackToIovecSlice, so every partition reflects the same scan. A write
+// or fsync failure stops the flush before the next stage is written, so a
+// partition is never written ahead of the data it is ordered after; the
+// error names the partition and the reports show how far the flush got.
+func (f *PartitionedFlusher[T, K, C]) Flush(callback func(*ItemPtr[T, K, C]) bool) ([]PartitionReport, error) {
+	var owners []int
+	iovecs := f.sl.CallbackToIovecSlice(func(ip *ItemPtr[T, K, C]) bool {
+		if !callback(ip) {
+			return false
+		}
+		for i, p := range f.partitions {
+			if p.Match(ip.Context()) {
+				owners = append(owners, i)
+				return true
+			}
+		}
+		return false
+	})
+
+	split := make([][]syscall.Iovec, len(f.partitions))
+	reports := make([]PartitionReport, len(f.partitions))
+	for i, p := range f.partitions {
+		reports[i].Name = p.Name
+	}
+	for i, iovec := range iovecs {
+		split[owners[i]] = append(split[owners[i]], iovec)
+		reports[owners[i]].Entries++
+	}
+
+	for _, stage := range f.stages {
+		for _, i := range stage {
+			w := f.sl.NewIovecWriter(f.partitions[i].FD, split[i], f.retry)
+			err := w.Flush()
+			reports[i].Bytes = w.Written()
+			if err != nil {
+				return reports, fmt.Errorf("writing partition %q: %w", f.partitions[i].Name, err)
+			}
+		}
+		for _, i := range stage {
+			if err := f.fsync(f.partitions[i].FD); err != nil {
+				return reports, fmt.Errorf("syncing partition %q: %w", f.partitions[i].Name, err)
+			}
+			reports[i].Synced = true
+		}
+	}
+	return reports, nil
+}
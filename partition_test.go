@@ -0,0 +1,114 @@
+package zerocopyskiplist
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestPartitionedFlusher(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	for _, item := range createTestItems(30) {
+		skiplist.Insert(item, TestContext{AccessCount: item.ID % 3})
+	}
+
+	dir := t.TempDir()
+	files := make(map[string]*os.File)
+	for _, name := range []string{"data", "index", "meta"} {
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		defer f.Close()
+		files[name] = f
+	}
+	partitions := []Partition[TestContext]{
+		{Name: "index", FD: files["index"].Fd(), Match: func(c TestContext) bool { return c.AccessCount == 1 }, After: []string{"data"}},
+		{Name: "data", FD: files["data"].Fd(), Match: func(c TestContext) bool { return c.AccessCount == 0 }},
+		{Name: "meta", FD: files["meta"].Fd(), Match: func(c TestContext) bool { return true }},
+	}
+	flusher, err := skiplist.NewPartitionedFlusher(partitions, nil)
+	if err != nil {
+		t.Fatalf("NewPartitionedFlusher: %v", err)
+	}
+
+	// Record which partitions had been written whenever one is synced
+	size := func(name string) int64 {
+		info, _ := files[name].Stat()
+		return info.Size()
+	}
+	var synced []string
+	flusher.fsync = func(fd uintptr) error {
+		for name, f := range files {
+			if f.Fd() == fd {
+				if name == "data" && size("index") != 0 {
+					t.Error("Index partition was written before data was synced")
+				}
+				synced = append(synced, name)
+			}
+		}
+		return syscall.Fsync(int(fd))
+	}
+
+	reports, err := flusher.Flush(func(*ItemPtr[TestItem, int, TestContext]) bool { return true })
+	if err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	itemSize := int64(getTestItemSize(&TestItem{}))
+	for _, report := range reports {
+		if report.Entries != 10 || report.Bytes != 10*itemSize || !report.Synced || size(report.Name) != report.Bytes {
+			t.Errorf("Report %+v, want 10 synced entries of %d bytes on disk", report, 10*itemSize)
+		}
+	}
+	if len(synced) != 3 || synced[2] != "index" {
+		t.Errorf("Sync order = %v, want index last", synced)
+	}
+
+	// A failed barrier stops later stages from being written
+	failure := errors.New("disk on fire")
+	flusher.fsync = func(uintptr) error { return failure }
+	reports, err = flusher.Flush(func(*ItemPtr[TestItem, int, TestContext]) bool { return true })
+	if !errors.Is(err, failure) || reports[0].Bytes != 0 || reports[0].Synced {
+		t.Errorf("Flush with a failing fsync = %+v, %v; want the index partition unwritten", reports, err)
+	}
+}
+
+func TestPartitionedFlusherOrdering(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	matchAll := func(TestContext) bool { return true }
+
+	_, err := skiplist.NewPartitionedFlusher([]Partition[TestContext]{
+		{Name: "a", Match: matchAll, After: []string{"b"}},
+		{Name: "b", Match: matchAll, After: []string{"a"}},
+	}, nil)
+	if !errors.Is(err, ErrPartitionCycle) {
+		t.Errorf("Cyclic ordering should return ErrPartitionCycle, got %v", err)
+	}
+	if _, err := skiplist.NewPartitionedFlusher([]Partition[TestContext]{{Name: "a", Match: matchAll, After: []string{"x"}}}, nil); err == nil {
+		t.Error("Ordering after an unknown partition should fail")
+	}
+	if _, err := skiplist.NewPartitionedFlusher([]Partition[TestContext]{{Name: "a", Match: matchAll}, {Name: "a", Match: matchAll}}, nil); err == nil {
+		t.Error("A repeated partition name should fail")
+	}
+
+	flusher, err := skiplist.NewPartitionedFlusher([]Partition[TestContext]{
+		{Name: "c", Match: matchAll, After: []string{"a", "b"}},
+		{Name: "a", Match: matchAll},
+		{Name: "b", Match: matchAll, After: []string{"a"}},
+		{Name: "d", Match: matchAll},
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewPartitionedFlusher: %v", err)
+	}
+	want := [][]int{{1, 3}, {2}, {0}}
+	if len(flusher.stages) != len(want) {
+		t.Fatalf("Stages = %v, want %v", flusher.stages, want)
+	}
+	for i := range want {
+		if len(flusher.stages[i]) != len(want[i]) || flusher.stages[i][0] != want[i][0] {
+			t.Errorf("Stages = %v, want %v", flusher.stages, want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// pathcache.go - Cache of recent search paths for hot keys
+
+package zerocopyskiplist
+
+import (
+	"hash/maphash"
+	"sync/atomic"
+)
+
+// pathCache remembers, for recently searched keys, the predecessor of the key
+// on every level. Slots are chosen by key hash and hold immutable entries
+// published atomically, so readers holding only the read lock can fill them
+// concurrently; the last writer of a slot wins. An entry is valid while the
+// list's generation, bumped by every link change, still matches.
+type pathCache[T any, K comparable, C comparable] struct {
+	seed   maphash.Seed
+	slots  []atomic.Pointer[pathEntry[T, K, C]]
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// pathEntry is the search path for key as of generation
+type pathEntry[T any, K comparable, C comparable] struct {
+	key        K
+	generation uint64
+	update     []*ItemPtr[T, K, C] // update[i] is the last node before key on level i
+}
+
+// newPathCache returns a cache of size slots, nil if size is not positive
+func newPathCache[T any, K comparable, C comparable](size int) *pathCache[T, K, C] {
+	if size <= 0 {
+		return nil
+	}
+	return &pathCache[T, K, C]{
+		seed:  maphash.MakeSeed(),
+		slots: make([]atomic.Pointer[pathEntry[T, K, C]], size),
+	}
+}
+
+// slot returns the slot for key
+func (pc *pathCache[T, K, C]) slot(key K) *atomic.Pointer[pathEntry[T, K, C]] {
+	return &pc.slots[maphash.Comparable(pc.seed, key)%uint64(len(pc.slots))]
+}
+
+// descendCached is descend with bound -1 served from the path cache when it
+// holds a current path for key, and remembered there otherwise; the caller
+// must hold the lock
+func (sl *ZeroCopySkiplist[T, K, C]) descendCached(key K, update []*ItemPtr[T, K, C]) *ItemPtr[T, K, C] {
+	pc := sl.paths
+	slot := pc.slot(key)
+	if e := slot.Load(); e != nil && e.generation == sl.generation && e.key == key {
+		pc.hits.Add(1)
+		if update != nil {
+			copy(update, e.update)
+		}
+		return e.update[0]
+	}
+
+	pc.misses.Add(1)
+	path := make([]*ItemPtr[T, K, C], sl.maxLevel+1)
+	current := sl.descendFromHeader(key, -1, path)
+	if update != nil {
+		copy(update, path[:sl.level+1])
+	}
+	slot.Store(&pathEntry[T, K, C]{key: key, generation: sl.generation, update: path[:sl.level+1]})
+	return current
+}
@@ -0,0 +1,90 @@
+package zerocopyskiplist
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+func TestPathCache(t *testing.T) {
+	sl := MakeOrderedZeroCopySkiplist[TestItem, int, TestContext](12, getKeyFromTestItem, getTestItemSize, WithPathCache(64))
+	items := createTestItems(1000)
+	for _, item := range items {
+		sl.Insert(item, TestContext{})
+	}
+
+	// Repeated lookups of a hot key are served from the cache
+	before := sl.Stats()
+	for range 10 {
+		if node, _ := sl.Find(500); node == nil || node.Key() != 500 {
+			t.Fatal("Find(500) failed")
+		}
+	}
+	stats := sl.Stats()
+	if hits, misses := stats.PathCacheHits-before.PathCacheHits, stats.PathCacheMisses-before.PathCacheMisses; hits != 9 || misses != 1 {
+		t.Errorf("Expected 9 hits and 1 miss, got %d and %d", hits, misses)
+	}
+
+	// The delete misses, then its link changes invalidate the cached path
+	sl.Delete(499)
+	if node, _ := sl.Find(500); node == nil || node.Key() != 500 {
+		t.Fatal("Find(500) after a delete failed")
+	}
+	if misses := sl.Stats().PathCacheMisses - stats.PathCacheMisses; misses != 2 {
+		t.Errorf("Expected the delete to invalidate the path, got %d misses", misses)
+	}
+
+	// Random writes interleaved with hot reads leave the list consistent
+	rng := rand.New(rand.NewSource(11))
+	present := make(map[int]bool)
+	for _, item := range items {
+		present[item.ID] = item.ID != 499
+	}
+	for range 5000 {
+		key := 1 + rng.Intn(len(items))
+		switch rng.Intn(3) {
+		case 0:
+			present[key] = !sl.Delete(key) && present[key]
+		case 1:
+			sl.Insert(items[key-1], TestContext{})
+			present[key] = true
+		}
+		if node, _ := sl.Find(key); (node != nil) != present[key] {
+			t.Fatalf("Find(%d) disagrees with the expected presence %v", key, present[key])
+		}
+	}
+	if err := sl.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+}
+
+func TestPathCacheConcurrentReaders(t *testing.T) {
+	sl := MakeZeroCopySkiplist[TestItem, int, TestContext](12, getKeyFromTestItem, getTestItemSize, compareInt, WithPathCache(8))
+	items := createTestItems(2000)
+	for _, item := range items {
+		sl.Insert(item, TestContext{})
+	}
+
+	var wg sync.WaitGroup
+	for g := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(int64(g)))
+			for range 2000 {
+				key := 1 + rng.Intn(16)
+				if node, _ := sl.Find(key); node == nil || node.Key() != key {
+					t.Errorf("Find(%d) failed", key)
+					return
+				}
+			}
+		}()
+	}
+	for i := range 200 {
+		sl.Delete(1000 + i)
+	}
+	wg.Wait()
+	if stats := sl.Stats(); stats.PathCacheHits == 0 {
+		t.Errorf("Expected hot keys to hit the cache, got %+v", stats)
+	}
+}
@@ -0,0 +1,55 @@
+// pin.go - Protecting entries from eviction while their buffers are in use
+
+package zerocopyskiplist
+
+// Pin marks the entry for key ineligible for eviction by ShedOldest and
+// QuotaEvictOldest quotas, typically while I/O references its item, and
+// returns false if the key is not in the list. Pins nest: the entry stays
+// pinned until Unpin has been called as many times as Pin. An explicit
+// Delete, Clear or MoveTo still removes a pinned entry, dropping its pins.
+func (sl *ZeroCopySkiplist[T, K, C]) Pin(key K) bool {
+	key = sl.normalize(key)
+	sl.lock()
+	defer sl.unlock()
+
+	node := sl.seek(key, true)
+	if node == nil || sl.cmpKey(node.key, key) != 0 {
+		return false
+	}
+	if sl.pins == nil {
+		sl.pins = make(map[K]int)
+	}
+	sl.pins[node.key]++
+	return true
+}
+
+// Unpin releases one pin on key and returns false if it was not pinned
+func (sl *ZeroCopySkiplist[T, K, C]) Unpin(key K) bool {
+	key = sl.normalize(key)
+	sl.lock()
+	defer sl.unlock()
+
+	n, ok := sl.pins[key]
+	if !ok {
+		return false
+	}
+	if n <= 1 {
+		delete(sl.pins, key)
+	} else {
+		sl.pins[key] = n - 1
+	}
+	return true
+}
+
+// Pinned returns true if key is pinned
+func (sl *ZeroCopySkiplist[T, K, C]) Pinned(key K) bool {
+	key = sl.normalize(key)
+	sl.rlock()
+	defer sl.runlock()
+	return sl.pinned(key)
+}
+
+// pinned returns true if key is pinned; the caller must hold the lock
+func (sl *ZeroCopySkiplist[T, K, C]) pinned(key K) bool {
+	return len(sl.pins) > 0 && sl.pins[key] > 0
+}
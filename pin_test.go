@@ -0,0 +1,82 @@
+package zerocopyskiplist
+
+import "testing"
+
+func TestPin(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		16,
+		getKeyFromTestItem,
+		func(item *TestItem) int { return len(item.Data) },
+		compareInt,
+	)
+	for i := 1; i <= 10; i++ {
+		skiplist.Insert(&TestItem{ID: i, Data: make([]byte, 8)}, TestContext{})
+	}
+
+	if skiplist.Pin(42) {
+		t.Error("Pin of a missing key should return false")
+	}
+	if !skiplist.Pin(2) || !skiplist.Pin(2) || !skiplist.Pin(5) {
+		t.Fatal("Pin of present keys should return true")
+	}
+	if stats := skiplist.Stats(); stats.PinnedEntries != 2 {
+		t.Errorf("PinnedEntries = %d, want 2", stats.PinnedEntries)
+	}
+
+	// Shedding 40 bytes skips 2 and 5 and deletes 1, 3, 4, 6 and 7
+	freed, count := skiplist.ShedOldest(40)
+	if freed != 40 || count != 5 {
+		t.Errorf("Freed %d bytes in %d items, want 40 in 5", freed, count)
+	}
+	var keys []int
+	skiplist.ForEach(func(ip *ItemPtr[TestItem, int, TestContext]) bool {
+		keys = append(keys, ip.Key())
+		return true
+	})
+	if len(keys) != 5 || keys[0] != 2 || keys[1] != 5 || keys[2] != 8 {
+		t.Errorf("Keys after ShedOldest = %v, want [2 5 8 9 10]", keys)
+	}
+	if err := skiplist.Validate(); err != nil {
+		t.Errorf("Validate after ShedOldest: %v", err)
+	}
+
+	// Pins nest
+	if !skiplist.Unpin(2) || !skiplist.Pinned(2) {
+		t.Error("A key pinned twice should stay pinned after one Unpin")
+	}
+	if !skiplist.Unpin(2) || skiplist.Pinned(2) || skiplist.Unpin(2) {
+		t.Error("A key should be unpinned after matching Unpins")
+	}
+
+	// An explicit Delete still removes a pinned entry and drops its pin
+	if !skiplist.Delete(5) || skiplist.Pinned(5) {
+		t.Error("Delete should remove a pinned entry and its pin")
+	}
+	if stats := skiplist.Stats(); stats.PinnedEntries != 0 {
+		t.Errorf("PinnedEntries after Delete = %d, want 0", stats.PinnedEntries)
+	}
+}
+
+func TestPinQuotaEviction(t *testing.T) {
+	sl := newQuotaList()
+	context := TestContext{AccessCount: 1}
+	sl.SetContextQuota(context, 2, 0, QuotaEvictOldest)
+	sl.Insert(&TestItem{ID: 1, Data: make([]byte, 10)}, context)
+	sl.Insert(&TestItem{ID: 2, Data: make([]byte, 10)}, context)
+	sl.Pin(1)
+
+	if !sl.Insert(&TestItem{ID: 3, Data: make([]byte, 10)}, context) {
+		t.Fatal("Insert should evict the unpinned entry")
+	}
+	if sl.FindItem(1) == nil || sl.FindItem(2) != nil {
+		t.Error("Eviction should skip the pinned entry")
+	}
+
+	sl.Pin(3)
+	if sl.Insert(&TestItem{ID: 4, Data: make([]byte, 10)}, context) {
+		t.Error("Insert should be rejected when every entry is pinned")
+	}
+	if err := sl.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+// plan.go - Dry-run sizing of flushes
+
+package zerocopyskiplist
+
+// FlushLimits are the budgets a flush is split under. Zero fields are unlimited.
+type FlushLimits struct {
+	MaxSegmentBytes   int64 // bytes per output segment (file, blob or part)
+	MaxSegmentEntries int   // items per output segment
+	IovMax            int   // iovecs per write; zero uses the list's limit, as IovecChunks does
+}
+
+// FlushPlan describes the output a flush would produce
+type FlushPlan struct {
+	Entries      int
+	Bytes        int64
+	Segments     int
+	Chunks       int   // vectored writes, one iovec per item, none spanning segments
+	LargestItem  int   // size of the largest item
+	LargestBytes int64 // bytes in the largest segment
+}
+
+// PlanFlush computes, without writing anything, the plan for flushing the
+// items the filter selects (all items when filter is nil) in key order under
+// limits. Items are sized with the list's getItemSize function, matching the
+// iovec flush path. A new segment starts whenever the next item would take
+// the current one over a limit; an item larger than MaxSegmentBytes gets a
+// segment of its own. The list is scanned in batches as for ForEach, so a list
+// written concurrently may flush differently from its plan.
+func (sl *ZeroCopySkiplist[T, K, C]) PlanFlush(filter func(*ItemPtr[T, K, C]) bool, limits FlushLimits) FlushPlan {
+	iovMax := limits.IovMax
+	if iovMax <= 0 {
+		iovMax = sl.iovMax()
+	}
+
+	var plan FlushPlan
+	var segmentBytes int64
+	segmentEntries := 0
+	closeSegment := func() {
+		if segmentEntries == 0 {
+			return
+		}
+		plan.Segments++
+		plan.Chunks += (segmentEntries + iovMax - 1) / iovMax
+		plan.LargestBytes = max(plan.LargestBytes, segmentBytes)
+		segmentBytes, segmentEntries = 0, 0
+	}
+
+	sl.ForEach(func(ip *ItemPtr[T, K, C]) bool {
+		if filter != nil && !filter(ip) {
+			return true
+		}
+		size := sl.getItemSize(ip.item)
+		overBytes := limits.MaxSegmentBytes > 0 && segmentBytes+int64(size) > limits.MaxSegmentBytes
+		overEntries := limits.MaxSegmentEntries > 0 && segmentEntries >= limits.MaxSegmentEntries
+		if overBytes || overEntries {
+			closeSegment()
+		}
+		segmentBytes += int64(size)
+		segmentEntries++
+		plan.Entries++
+		plan.Bytes += int64(size)
+		plan.LargestItem = max(plan.LargestItem, size)
+		return true
+	})
+	closeSegment()
+	return plan
+}
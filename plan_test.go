@@ -0,0 +1,37 @@
+package zerocopyskiplist
+
+import "testing"
+
+func TestPlanFlush(t *testing.T) {
+	sl := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, func(item *TestItem) int { return len(item.Data) }, compareInt, WithIovMax(3))
+	if plan := sl.PlanFlush(nil, FlushLimits{}); plan != (FlushPlan{}) {
+		t.Errorf("Plan for an empty list = %+v", plan)
+	}
+
+	// Ten items of 100 bytes, then one of 1000
+	for i := 1; i <= 10; i++ {
+		sl.Insert(&TestItem{ID: i, Data: make([]byte, 100)}, TestContext{IsCached: i%2 == 0})
+	}
+	sl.Insert(&TestItem{ID: 11, Data: make([]byte, 1000)}, TestContext{})
+
+	tests := []struct {
+		name   string
+		filter func(*ItemPtr[TestItem, int, TestContext]) bool
+		limits FlushLimits
+		want   FlushPlan
+	}{
+		{"unlimited", nil, FlushLimits{},
+			FlushPlan{Entries: 11, Bytes: 2000, Segments: 1, Chunks: 4, LargestItem: 1000, LargestBytes: 2000}},
+		{"segment bytes", nil, FlushLimits{MaxSegmentBytes: 450},
+			FlushPlan{Entries: 11, Bytes: 2000, Segments: 4, Chunks: 6, LargestItem: 1000, LargestBytes: 1000}},
+		{"segment entries", nil, FlushLimits{MaxSegmentEntries: 5, IovMax: 10},
+			FlushPlan{Entries: 11, Bytes: 2000, Segments: 3, Chunks: 3, LargestItem: 1000, LargestBytes: 1000}},
+		{"filtered", func(ip *ItemPtr[TestItem, int, TestContext]) bool { return ip.Context().IsCached }, FlushLimits{MaxSegmentEntries: 2},
+			FlushPlan{Entries: 5, Bytes: 500, Segments: 3, Chunks: 3, LargestItem: 100, LargestBytes: 200}},
+	}
+	for _, tt := range tests {
+		if got := sl.PlanFlush(tt.filter, tt.limits); got != tt.want {
+			t.Errorf("%s: PlanFlush = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
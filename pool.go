@@ -0,0 +1,74 @@
+// pool.go - Node allocation and recycling
+
+package zerocopyskiplist
+
+// allocNode returns an empty node with link slices sized for level, reusing a
+// recycled node when the list was built WithNodePool
+func (sl *ZeroCopySkiplist[T, K, C]) allocNode(level int) *ItemPtr[T, K, C] {
+	var node *ItemPtr[T, K, C]
+	if sl.nodePool != nil {
+		node, _ = sl.nodePool.Get().(*ItemPtr[T, K, C])
+	}
+	sl.nodesAllocated++
+	if node == nil {
+		node = &ItemPtr[T, K, C]{}
+	} else {
+		sl.nodesRecycled++
+	}
+
+	sl.sizeLinks(node, level)
+	sl.levelCounts[level]++
+	return node
+}
+
+// sizeLinks resizes node's link slices for level, reusing their capacity,
+// and sets its level
+func (sl *ZeroCopySkiplist[T, K, C]) sizeLinks(node *ItemPtr[T, K, C], level int) {
+	if cap(node.forward) > level {
+		node.forward = node.forward[:level+1]
+	} else {
+		node.forward = make([]*ItemPtr[T, K, C], level+1)
+	}
+
+	if sl.opts.levelBacklinks && level > 0 {
+		if cap(node.backlinks) >= level {
+			node.backlinks = node.backlinks[:level]
+		} else {
+			node.backlinks = make([]*ItemPtr[T, K, C], level)
+		}
+	} else {
+		node.backlinks = nil
+	}
+
+	if sl.inlineKeys {
+		if cap(node.fkeys) > level {
+			node.fkeys = node.fkeys[:level+1]
+		} else {
+			node.fkeys = make([]K, level+1)
+		}
+	}
+
+	node.level = level
+}
+
+// releaseNode returns an unlinked node to the pool, clearing every reference
+// it holds so recycled nodes never keep items or other nodes alive
+func (sl *ZeroCopySkiplist[T, K, C]) releaseNode(node *ItemPtr[T, K, C]) {
+	if sl.nodePool == nil {
+		if sl.leaks != nil {
+			trackNode(sl.leaks, node)
+		}
+		return
+	}
+
+	var zeroKey K
+	var zeroContext C
+	node.item = nil
+	node.key = zeroKey
+	node.context = zeroContext
+	node.backward = nil
+	clear(node.forward)
+	clear(node.backlinks)
+	clear(node.fkeys)
+	sl.nodePool.Put(node)
+}
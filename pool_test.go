@@ -0,0 +1,117 @@
+package zerocopyskiplist
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestNodePoolChurn(t *testing.T) {
+	for _, backlinks := range []bool{false, true} {
+		opts := []Option{WithNodePool()}
+		if backlinks {
+			opts = append(opts, WithLevelBacklinks())
+		}
+		skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+			8,
+			getKeyFromTestItem,
+			getTestItemSize,
+			compareInt,
+			opts...,
+		)
+
+		// Constant insert+delete churn over a sliding window of keys
+		items := createTestItems(2000)
+		rng := rand.New(rand.NewSource(7))
+		for i, item := range items {
+			skiplist.Insert(item, TestContext{AccessCount: i})
+			if i >= 100 {
+				skiplist.Delete(items[i-100-rng.Intn(1)].ID)
+			}
+			if i%97 == 0 {
+				if err := skiplist.Validate(); err != nil {
+					t.Fatalf("backlinks=%v: Validate at step %d: %v", backlinks, i, err)
+				}
+			}
+		}
+
+		if skiplist.Length() != 100 {
+			t.Errorf("backlinks=%v: expected 100 items after churn, got %d", backlinks, skiplist.Length())
+		}
+		for i := 1900; i < 2000; i++ {
+			found, ctx := skiplist.Find(items[i].ID)
+			if found == nil || found.Item() != items[i] || ctx.AccessCount != i {
+				t.Errorf("backlinks=%v: wrong entry for key %d after churn", backlinks, items[i].ID)
+			}
+		}
+		if err := skiplist.Validate(); err != nil {
+			t.Errorf("backlinks=%v: Validate after churn: %v", backlinks, err)
+		}
+	}
+}
+
+func TestReleaseNodeClearsReferences(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		8,
+		getKeyFromTestItem,
+		getTestItemSize,
+		compareInt,
+		WithNodePool(),
+		WithLevelBacklinks(),
+	)
+
+	for _, item := range createTestItems(50) {
+		skiplist.Insert(item, TestContext{MetadataKey: "x"})
+	}
+
+	node := skiplist.FindItem(25)
+	skiplist.Delete(25)
+
+	if node.item != nil || node.context != (TestContext{}) || node.backward != nil {
+		t.Error("Released node should not reference its item, context or neighbours")
+	}
+	for _, next := range node.forward {
+		if next != nil {
+			t.Error("Released node should have no forward links")
+		}
+	}
+	for _, prev := range node.backlinks {
+		if prev != nil {
+			t.Error("Released node should have no backlinks")
+		}
+	}
+}
+
+func BenchmarkInsertDeleteChurn(b *testing.B) {
+	for _, pooled := range []bool{false, true} {
+		name := "Unpooled"
+		var opts []Option
+		if pooled {
+			name = "Pooled"
+			opts = append(opts, WithNodePool())
+		}
+		b.Run(name, func(b *testing.B) {
+			skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+				16,
+				getKeyFromTestItem,
+				getTestItemSize,
+				compareInt,
+				opts...,
+			)
+
+			const window = 1000
+			items := make([]TestItem, window*2)
+			for i := range items {
+				items[i].ID = i
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				skiplist.Insert(&items[i%len(items)], TestContext{})
+				if i >= window {
+					skiplist.Delete((i - window) % len(items))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package zerocopyskiplist
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestPrefetchScans(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		16,
+		getKeyFromTestItem,
+		getTestItemSize,
+		compareInt,
+		WithLevelBacklinks(),
+	)
+
+	// Short lists where the prefetch target runs off the end, and lengths around the batch size
+	for _, n := range []int{0, 1, 2, 3, 4, callbackBatchSize - 1, callbackBatchSize + 1} {
+		for !skiplist.IsEmpty() {
+			skiplist.Delete(skiplist.First().Key())
+		}
+		for _, item := range createTestItems(n) {
+			skiplist.Insert(item, TestContext{})
+		}
+
+		if got := len(skiplist.ToIovecSlice(TestContext{})); got != n {
+			t.Errorf("Expected %d iovecs for %d items, got %d", n, n, got)
+		}
+		count := 0
+		skiplist.DescendRange(n, 1, func(item *ItemPtr[TestItem, int, TestContext]) bool {
+			if item.Key() != n-count {
+				t.Errorf("Expected key %d at position %d of %d, got %d", n-count, count, n, item.Key())
+			}
+			count++
+			return true
+		})
+		if count != n {
+			t.Errorf("Expected %d items descending, got %d", n, count)
+		}
+	}
+}
+
+// BenchmarkScanScattered scans a list whose nodes were allocated in random key
+// order, so every step of the walk is a likely cache miss
+func BenchmarkScanScattered(b *testing.B) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		20,
+		getKeyFromTestItem,
+		getTestItemSize,
+		compareInt,
+	)
+
+	const numItems = 1 << 20
+	items := make([]TestItem, numItems)
+	rng := rand.New(rand.NewSource(5))
+	for _, i := range rng.Perm(numItems) {
+		items[i].ID = i
+		skiplist.Insert(&items[i], TestContext{})
+	}
+
+	for _, enabled := range []bool{false, true} {
+		b.Run(fmt.Sprintf("prefetch=%t", enabled), func(b *testing.B) {
+			defer func(was bool) { prefetchScans = was }(prefetchScans)
+			prefetchScans = enabled
+			for b.Loop() {
+				_ = skiplist.ToIovecSlice(TestContext{})
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// pressure.go - Memory accounting, pressure notification and shedding
+
+package zerocopyskiplist
+
+// pressureHook holds the thresholds and callback registered with SetPressureHook
+type pressureHook struct {
+	maxBytes   int64
+	maxEntries int
+	hook       func(totalBytes int64, entries int)
+	over       bool // usage was above a threshold after the last mutation
+}
+
+// TotalItemBytes returns the sum of getItemSize over all items in the list,
+// maintained incrementally. Items must not change size while they are indexed.
+func (sl *ZeroCopySkiplist[T, K, C]) TotalItemBytes() int64 {
+	sl.rlockFlat()
+	defer sl.runlock()
+	return sl.totalBytes
+}
+
+// SetPressureHook registers hook to be called when TotalItemBytes rises above
+// maxBytes or Length rises above maxEntries; a threshold <= 0 is ignored.
+//
+// The hook fires once per crossing, when a mutation takes usage from within
+// both limits to above either, and is re-armed once usage drops back within
+// both limits. It runs in the goroutine that made the mutation, after the
+// write lock has been released, so it may call ShedOldest or any other method.
+// Passing a nil hook removes the registration.
+func (sl *ZeroCopySkiplist[T, K, C]) SetPressureHook(maxBytes int64, maxEntries int, hook func(totalBytes int64, entries int)) {
+	sl.lock()
+	defer sl.unlock()
+
+	sl.pressure = pressureHook{
+		maxBytes:   maxBytes,
+		maxEntries: maxEntries,
+		hook:       hook,
+	}
+	sl.pressure.over = sl.overPressure()
+}
+
+// ShedOldest deletes items from the head of the list until at least bytes of
+// item data have been freed or the list is empty, returning the bytes freed
+// and the number of items deleted. Pinned and leased items are skipped.
+func (sl *ZeroCopySkiplist[T, K, C]) ShedOldest(bytes int64) (int64, int) {
+	sl.lock()
+	defer sl.unlock()
+	if sl.frozen {
+		return 0, 0
+	}
+
+	freed, count := sl.shed(func(freed int64) bool { return freed >= bytes })
+	sl.checkPressure()
+	return freed, count
+}
+
+// shed deletes evictable items from the head of the list until enough,
+// given the item bytes freed so far, returns true or the list is exhausted,
+// returning the bytes freed and the number of items deleted; the caller must
+// hold the write lock
+func (sl *ZeroCopySkiplist[T, K, C]) shed(enough func(freed int64) bool) (int64, int) {
+	update := make([]*ItemPtr[T, K, C], sl.maxLevel+1)
+	for i := range update {
+		update[i] = sl.header
+	}
+
+	var freed int64
+	count := 0
+	skipped := false
+	for node := sl.header.forward[0]; !enough(freed) && node != nil; {
+		next := node.forward[0]
+		if !sl.evictable(node.key) {
+			skipped = true
+		} else {
+			// Past a skipped item the victim is no longer the head
+			if skipped {
+				sl.descend(node.key, -1, update)
+			}
+			freed += int64(sl.getItemSize(node.item))
+			sl.countEviction(node.context)
+			sl.unlink(node, update)
+			count++
+		}
+		node = next
+	}
+	return freed, count
+}
+
+// checkPressure enforces the weight limit and updates the pressure state
+// after a mutation, and returns the hook and its arguments if a threshold was
+// just crossed; the caller must hold the write lock and call the hook after
+// releasing it
+func (sl *ZeroCopySkiplist[T, K, C]) checkPressure() (func(int64, int), int64, int) {
+	sl.enforceWeightLimit()
+	if sl.pressure.hook == nil {
+		return nil, 0, 0
+	}
+
+	over := sl.overPressure()
+	crossed := over && !sl.pressure.over
+	sl.pressure.over = over
+	if !crossed {
+		return nil, 0, 0
+	}
+	return sl.pressure.hook, sl.totalBytes, sl.length
+}
+
+// overPressure returns true if usage is above either configured threshold
+func (sl *ZeroCopySkiplist[T, K, C]) overPressure() bool {
+	return (sl.pressure.maxBytes > 0 && sl.totalBytes > sl.pressure.maxBytes) ||
+		(sl.pressure.maxEntries > 0 && sl.length > sl.pressure.maxEntries)
+}
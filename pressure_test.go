@@ -0,0 +1,148 @@
+package zerocopyskiplist
+
+import "testing"
+
+func TestTotalItemBytes(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		16,
+		getKeyFromTestItem,
+		func(item *TestItem) int { return len(item.Data) },
+		compareInt,
+	)
+
+	skiplist.Insert(&TestItem{ID: 1, Data: make([]byte, 10)}, TestContext{})
+	skiplist.Insert(&TestItem{ID: 2, Data: make([]byte, 20)}, TestContext{})
+	if got := skiplist.TotalItemBytes(); got != 30 {
+		t.Errorf("Expected 30 bytes after inserts, got %d", got)
+	}
+
+	// Replacing an item accounts for the size difference
+	skiplist.Insert(&TestItem{ID: 1, Data: make([]byte, 15)}, TestContext{})
+	if got := skiplist.TotalItemBytes(); got != 35 {
+		t.Errorf("Expected 35 bytes after replace, got %d", got)
+	}
+
+	skiplist.Delete(2)
+	if got := skiplist.TotalItemBytes(); got != 15 {
+		t.Errorf("Expected 15 bytes after delete, got %d", got)
+	}
+}
+
+func TestPressureHook(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		16,
+		getKeyFromTestItem,
+		func(item *TestItem) int { return len(item.Data) },
+		compareInt,
+	)
+
+	fired := 0
+	var firedBytes int64
+	skiplist.SetPressureHook(100, 0, func(totalBytes int64, entries int) {
+		fired++
+		firedBytes = totalBytes
+		// Shedding from the hook must not deadlock
+		skiplist.ShedOldest(totalBytes - 50)
+	})
+
+	for i := 1; i <= 10; i++ {
+		skiplist.Insert(&TestItem{ID: i, Data: make([]byte, 10)}, TestContext{})
+	}
+	if fired != 0 {
+		t.Errorf("Hook should not fire at exactly the threshold, fired %d times", fired)
+	}
+
+	skiplist.Insert(&TestItem{ID: 11, Data: make([]byte, 10)}, TestContext{})
+	if fired != 1 || firedBytes != 110 {
+		t.Fatalf("Hook should fire once with 110 bytes, fired %d times with %d", fired, firedBytes)
+	}
+	if got := skiplist.TotalItemBytes(); got != 50 {
+		t.Errorf("Hook should have shed down to 50 bytes, got %d", got)
+	}
+	if first := skiplist.First(); first.Key() != 7 {
+		t.Errorf("Shedding should remove the oldest keys first, first key is %d", first.Key())
+	}
+
+	// Entry threshold
+	skiplist.SetPressureHook(0, 6, func(totalBytes int64, entries int) {
+		fired++
+	})
+	skiplist.Insert(&TestItem{ID: 12, Data: make([]byte, 1)}, TestContext{})
+	skiplist.Insert(&TestItem{ID: 13, Data: make([]byte, 1)}, TestContext{})
+	if fired != 2 {
+		t.Errorf("Entry threshold should fire once, total fired %d", fired)
+	}
+
+	// Re-arms after dropping back within the limits
+	skiplist.Delete(13)
+	skiplist.Delete(12)
+	skiplist.Insert(&TestItem{ID: 12, Data: make([]byte, 1)}, TestContext{})
+	skiplist.Insert(&TestItem{ID: 13, Data: make([]byte, 1)}, TestContext{})
+	if fired != 3 {
+		t.Errorf("Hook should fire again after re-arming, total fired %d", fired)
+	}
+	if err := skiplist.Validate(); err != nil {
+		t.Errorf("Validate after shedding: %v", err)
+	}
+}
+
+func TestMutationPanicReleasesLock(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		16,
+		func(item *TestItem) int {
+			if item.ID < 0 {
+				panic("bad key")
+			}
+			return item.ID
+		},
+		getTestItemSize,
+		compareInt,
+	)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Insert should pass on the key function's panic")
+			}
+		}()
+		skiplist.Insert(&TestItem{ID: -1}, TestContext{})
+	}()
+
+	// The write lock was released, so the list stays usable
+	if !skiplist.Insert(&TestItem{ID: 1}, TestContext{}) || skiplist.Length() != 1 {
+		t.Errorf("Insert after a panicking insert failed, length %d", skiplist.Length())
+	}
+}
+
+func TestShedOldest(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		16,
+		getKeyFromTestItem,
+		func(item *TestItem) int { return len(item.Data) },
+		compareInt,
+		WithLevelBacklinks(),
+	)
+
+	for i := 1; i <= 100; i++ {
+		skiplist.Insert(&TestItem{ID: i, Data: make([]byte, 8)}, TestContext{})
+	}
+
+	freed, count := skiplist.ShedOldest(20)
+	if freed != 24 || count != 3 {
+		t.Errorf("Expected 24 bytes in 3 items freed, got %d bytes in %d items", freed, count)
+	}
+	if skiplist.Length() != 97 || skiplist.First().Key() != 4 {
+		t.Error("ShedOldest should remove the first 3 items")
+	}
+	if err := skiplist.Validate(); err != nil {
+		t.Errorf("Validate after ShedOldest: %v", err)
+	}
+
+	freed, count = skiplist.ShedOldest(1 << 20)
+	if freed != 97*8 || count != 97 || !skiplist.IsEmpty() {
+		t.Errorf("Shedding more than the total should empty the list, freed %d bytes in %d items", freed, count)
+	}
+	if skiplist.TotalItemBytes() != 0 {
+		t.Errorf("Empty list should have 0 bytes, got %d", skiplist.TotalItemBytes())
+	}
+}
@@ -0,0 +1,114 @@
+// publish.go - Immutable handles for lock-free concurrent reads
+
+package zerocopyskiplist
+
+import (
+	"iter"
+	"syscall"
+)
+
+// Frozen is an immutable skiplist published by Publish. It owns a private
+// copy of the nodes that nothing ever writes, so any number of goroutines may
+// read it with no lock at all: a Find costs the comparisons of the descent
+// and none of the atomic operations of the list's RWMutex. It shares items
+// with the list it was published from, which must not be modified while the
+// handle is in use.
+//
+// Unlike a SealedList it keeps the skiplist's levels, inline keys and ordered
+// key fast paths, so searches behave exactly as on the list. Hand a handle to
+// readers through a channel or an atomic.Pointer and publish a fresh one when
+// the list has moved on, see Generation.
+type Frozen[T any, K comparable, C comparable] struct {
+	sl         *ZeroCopySkiplist[T, K, C]
+	generation uint64
+}
+
+// Publish returns an immutable handle on the list's current entries, for
+// fan-out query serving after a build phase. The read lock is held only while
+// the nodes are copied; the list is left unchanged and may keep being written.
+func (sl *ZeroCopySkiplist[T, K, C]) Publish() *Frozen[T, K, C] {
+	sl.rlock()
+	defer sl.runlock()
+	return &Frozen[T, K, C]{sl: sl.copy(), generation: sl.version}
+}
+
+// Generation returns the list's Generation when the handle was published
+func (f *Frozen[T, K, C]) Generation() uint64 {
+	return f.generation
+}
+
+// Length returns the number of entries
+func (f *Frozen[T, K, C]) Length() int {
+	return f.sl.length
+}
+
+// TotalBytes returns the sum of the item sizes
+func (f *Frozen[T, K, C]) TotalBytes() int64 {
+	return f.sl.totalBytes
+}
+
+// Get returns the entry for key and whether it was found
+func (f *Frozen[T, K, C]) Get(key K) (Entry[T, K, C], bool) {
+	key = f.sl.normalize(key)
+	current := f.seek(key)
+	if current == nil || f.sl.cmpKey(current.key, key) != 0 {
+		return Entry[T, K, C]{}, false
+	}
+	return current.Entry(), true
+}
+
+// Find returns the item and context for key, nil if it is not present
+func (f *Frozen[T, K, C]) Find(key K) (*T, C) {
+	entry, _ := f.Get(key)
+	return entry.Item, entry.Context
+}
+
+// Entries returns an iterator over every entry in ascending key order
+func (f *Frozen[T, K, C]) Entries() iter.Seq[Entry[T, K, C]] {
+	return f.walk(f.sl.header.forward[0])
+}
+
+// EntriesFrom returns an iterator over the entries with keys >= key, in
+// ascending key order
+func (f *Frozen[T, K, C]) EntriesFrom(key K) iter.Seq[Entry[T, K, C]] {
+	return f.walk(f.seek(f.sl.normalize(key)))
+}
+
+// CallbackToIovecSlice generates an iovec for every entry the callback
+// accepts, in key order
+func (f *Frozen[T, K, C]) CallbackToIovecSlice(callback func(Entry[T, K, C]) bool) []syscall.Iovec {
+	var iovecs []syscall.Iovec
+	for current := f.sl.header.forward[0]; current != nil; current = current.forward[0] {
+		if entry := current.Entry(); callback(entry) {
+			iovecs = append(iovecs, f.sl.iovec(entry.Item))
+		}
+	}
+	return iovecs
+}
+
+// ToIovecSlice generates an iovec for every entry, in key order
+func (f *Frozen[T, K, C]) ToIovecSlice() []syscall.Iovec {
+	iovecs := make([]syscall.Iovec, 0, f.sl.length)
+	for current := f.sl.header.forward[0]; current != nil; current = current.forward[0] {
+		iovecs = append(iovecs, f.sl.iovec(current.item))
+	}
+	return iovecs
+}
+
+// seek returns the first node with a key >= key, nil if there is none. It
+// descends from the header directly, bypassing the path cache and access
+// tracking that would write to the list.
+func (f *Frozen[T, K, C]) seek(key K) *ItemPtr[T, K, C] {
+	return f.sl.descendFromHeader(key, -1, nil).forward[0]
+}
+
+// walk returns an iterator over the entries from node onwards
+func (f *Frozen[T, K, C]) walk(node *ItemPtr[T, K, C]) iter.Seq[Entry[T, K, C]] {
+	return func(yield func(Entry[T, K, C]) bool) {
+		for current := node; current != nil; current = current.forward[0] {
+			if !yield(current.Entry()) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package zerocopyskiplist
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestPublish(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt, WithPathCache(8))
+	items := createTestItems(1000)
+	for _, item := range items {
+		skiplist.Insert(item, TestContext{AccessCount: item.ID})
+	}
+	frozen := skiplist.Publish()
+	if frozen.Length() != 1000 || frozen.Generation() != skiplist.Generation() || frozen.TotalBytes() != skiplist.Stats().TotalItemBytes {
+		t.Fatalf("Published %d entries at generation %d", frozen.Length(), frozen.Generation())
+	}
+
+	// Readers need no lock while the list keeps being written
+	var wg sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 1; i <= 1000; i++ {
+				item, context := frozen.Find(i)
+				if item != items[i-1] || context.AccessCount != i {
+					t.Errorf("Find(%d) = %v, %v", i, item, context)
+					return
+				}
+			}
+		}()
+	}
+	for i := 1; i <= 1000; i += 2 {
+		skiplist.Delete(i)
+	}
+	wg.Wait()
+
+	if entry, ok := frozen.Get(1); !ok || entry.Item != items[0] {
+		t.Error("Deletes from the list should not reach the published handle")
+	}
+	if _, ok := frozen.Get(1001); ok {
+		t.Error("Get of a missing key should fail")
+	}
+	if frozen.Generation() == skiplist.Generation() {
+		t.Error("The list's generation should move on from the handle's")
+	}
+
+	next := 500
+	for entry := range frozen.EntriesFrom(500) {
+		if entry.Key != next {
+			t.Fatalf("EntriesFrom yielded %d, want %d", entry.Key, next)
+		}
+		next++
+	}
+	if next != 1001 {
+		t.Errorf("EntriesFrom stopped at %d", next)
+	}
+	count := 0
+	for range frozen.Entries() {
+		count++
+	}
+	even := frozen.CallbackToIovecSlice(func(e Entry[TestItem, int, TestContext]) bool { return e.Key%2 == 0 })
+	if count != 1000 || len(even) != 500 || len(frozen.ToIovecSlice()) != 1000 {
+		t.Errorf("Entries yielded %d, %d even iovecs", count, len(even))
+	}
+}
@@ -0,0 +1,150 @@
+// quota.go - Per-context entry and byte limits
+
+package zerocopyskiplist
+
+// QuotaPolicy selects what happens to a write that would take a context over
+// its quota
+type QuotaPolicy int
+
+const (
+	// QuotaReject refuses the write, leaving the list unchanged
+	QuotaReject QuotaPolicy = iota
+	// QuotaEvictOldest deletes the context's entries with the smallest keys
+	// until the write fits, skipping pinned and leased entries; when they
+	// cannot make room it evicts nothing and refuses the write
+	QuotaEvictOldest
+)
+
+// contextQuota is the limit and current usage of one context
+type contextQuota struct {
+	maxEntries int
+	maxBytes   int64
+	policy     QuotaPolicy
+	entries    int
+	bytes      int64
+}
+
+// SetContextQuota limits the entries carrying context to maxEntries and their
+// item bytes to maxBytes, a limit <= 0 being ignored, and applies policy to
+// writes that would exceed either, so one tenant cannot crowd out the others.
+// Setting a quota again replaces it; entries already over a lowered quota are
+// kept until the next write to the context.
+//
+// Quotas are enforced by Insert, BulkLoad, LoadSnapshot, UpdateContext and
+// MoveTo; a rejected Insert or UpdateContext returns false and a rejected
+// MoveTo leaves the entry where it was. ItemPtr.SetContext bypasses them.
+// Setting a quota scans the list once to measure the context's usage, and
+// eviction walks the list from the smallest key to find the context's
+// entries. Copies of the list do not inherit quotas.
+func (sl *ZeroCopySkiplist[T, K, C]) SetContextQuota(context C, maxEntries int, maxBytes int64, policy QuotaPolicy) {
+	sl.lock()
+	defer sl.unlock()
+
+	q := &contextQuota{maxEntries: maxEntries, maxBytes: maxBytes, policy: policy}
+	for node := sl.header.forward[0]; node != nil; node = node.forward[0] {
+		if node.context == context {
+			q.entries++
+			q.bytes += int64(sl.getItemSize(node.item))
+		}
+	}
+	if sl.quotas == nil {
+		sl.quotas = make(map[C]*contextQuota)
+	}
+	sl.quotas[context] = q
+}
+
+// RemoveContextQuota lifts the quota on context
+func (sl *ZeroCopySkiplist[T, K, C]) RemoveContextQuota(context C) {
+	sl.lock()
+	defer sl.unlock()
+	delete(sl.quotas, context)
+}
+
+// ContextUsage returns the entries and item bytes carrying context, false if
+// the context has no quota and so is not tracked
+func (sl *ZeroCopySkiplist[T, K, C]) ContextUsage(context C) (entries int, bytes int64, ok bool) {
+	sl.rlock()
+	defer sl.runlock()
+
+	q := sl.quotas[context]
+	if q == nil {
+		return 0, 0, false
+	}
+	return q.entries, q.bytes, true
+}
+
+// admitItem applies the quota of context to a write of item, which may
+// replace the existing entry for its key, evicting entries if the policy
+// allows, and returns true if the write fits; the caller must hold the write
+// lock
+func (sl *ZeroCopySkiplist[T, K, C]) admitItem(item *T, context C) bool {
+	if sl.quotas[context] == nil {
+		return true
+	}
+	key := sl.getKeyFromItem(item)
+	existing := sl.seek(key, true)
+	if existing != nil && sl.cmpKey(existing.key, key) != 0 {
+		existing = nil
+	}
+	return sl.admit(context, int64(sl.getItemSize(item)), existing)
+}
+
+// admit returns true if an entry of size bytes fits within the quota of
+// context, counting replacing (which may be nil) as gone, after evicting
+// other entries of the context if its policy allows; the caller must hold the
+// write lock
+func (sl *ZeroCopySkiplist[T, K, C]) admit(context C, size int64, replacing *ItemPtr[T, K, C]) bool {
+	q := sl.quotas[context]
+	if q == nil {
+		return true
+	}
+
+	// fits reports whether the write fits once freed entries of freedBytes
+	// have gone
+	fits := func(freed int, freedBytes int64) bool {
+		entries, bytes := q.entries+1-freed, q.bytes+size-freedBytes
+		if replacing != nil && replacing.context == context {
+			entries--
+			bytes -= int64(sl.getItemSize(replacing.item))
+		}
+		return (q.maxEntries <= 0 || entries <= q.maxEntries) && (q.maxBytes <= 0 || bytes <= q.maxBytes)
+	}
+	if fits(0, 0) {
+		return true
+	}
+	if q.policy != QuotaEvictOldest || (q.maxBytes > 0 && size > q.maxBytes) {
+		return false
+	}
+
+	// Choose the victims first, so nothing is evicted for a write that
+	// pinned or leased entries would still keep out
+	var victims []*ItemPtr[T, K, C]
+	var freedBytes int64
+	for node := sl.header.forward[0]; node != nil && !fits(len(victims), freedBytes); node = node.forward[0] {
+		if node.context == context && node != replacing && sl.evictable(node.key) {
+			victims = append(victims, node)
+			freedBytes += int64(sl.getItemSize(node.item))
+		}
+	}
+	if !fits(len(victims), freedBytes) {
+		return false
+	}
+
+	update := make([]*ItemPtr[T, K, C], sl.maxLevel+1)
+	for _, node := range victims {
+		sl.descend(node.key, -1, update)
+		sl.countEviction(node.context)
+		sl.unlink(node, update)
+	}
+	return true
+}
+
+// chargeQuota adds an entry of size bytes to the usage of context, or removes
+// one when sign is -1, if the context has a quota; the caller must hold the
+// write lock
+func (sl *ZeroCopySkiplist[T, K, C]) chargeQuota(context C, size int64, sign int) {
+	if q := sl.quotas[context]; q != nil {
+		q.entries += sign
+		q.bytes += int64(sign) * size
+	}
+}
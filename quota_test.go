@@ -0,0 +1,142 @@
+package zerocopyskiplist
+
+import "testing"
+
+// newQuotaList returns a list whose items are sized by the length of their Data
+func newQuotaList() *ZeroCopySkiplist[TestItem, int, TestContext] {
+	return MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, func(item *TestItem) int { return len(item.Data) }, compareInt)
+}
+
+func TestContextQuotaReject(t *testing.T) {
+	sl := newQuotaList()
+	noisy, quiet := TestContext{AccessCount: 1}, TestContext{AccessCount: 2}
+	sl.Insert(&TestItem{ID: 1, Data: make([]byte, 10)}, noisy)
+	sl.SetContextQuota(noisy, 3, 35, QuotaReject)
+	if entries, bytes, ok := sl.ContextUsage(noisy); !ok || entries != 1 || bytes != 10 {
+		t.Errorf("Usage measured when the quota was set = %d, %d, %v", entries, bytes, ok)
+	}
+	if _, _, ok := sl.ContextUsage(quiet); ok {
+		t.Error("A context without a quota should not be tracked")
+	}
+
+	for i := 2; i <= 5; i++ {
+		sl.Insert(&TestItem{ID: i, Data: make([]byte, 10)}, noisy)
+	}
+	if entries, bytes, _ := sl.ContextUsage(noisy); entries != 3 || bytes != 30 || sl.Length() != 3 {
+		t.Errorf("Usage after filling = %d entries, %d bytes, length %d; want 3, 30, 3", entries, bytes, sl.Length())
+	}
+
+	// Other contexts are unaffected, and replacing within the quota is allowed
+	for i := 10; i < 20; i++ {
+		sl.Insert(&TestItem{ID: i, Data: make([]byte, 10)}, quiet)
+	}
+	sl.Insert(&TestItem{ID: 2, Data: make([]byte, 15)}, noisy)
+	if len(sl.FindItem(2).Item().Data) != 15 {
+		t.Error("Replacing an entry within the quota should succeed")
+	}
+	if _, bytes, _ := sl.ContextUsage(noisy); bytes != 35 {
+		t.Errorf("Bytes after replacement = %d, want 35", bytes)
+	}
+	if sl.Insert(&TestItem{ID: 3, Data: make([]byte, 20)}, noisy) {
+		t.Error("Growing an entry over the byte quota should be rejected")
+	}
+	if sl.UpdateContext(10, noisy) {
+		t.Error("Moving an entry into a full context should be rejected")
+	}
+	if _, ctx := sl.Find(10); ctx != quiet {
+		t.Error("A rejected UpdateContext should leave the context unchanged")
+	}
+	if n, _ := sl.BulkLoad([]*TestItem{{ID: 30, Data: make([]byte, 1)}}, []TestContext{noisy}); n != 0 {
+		t.Error("BulkLoad should respect quotas")
+	}
+
+	dest := newQuotaList()
+	dest.SetContextQuota(quiet, 1, 0, QuotaReject)
+	if !sl.MoveTo(dest, 10) || sl.MoveTo(dest, 11) {
+		t.Error("MoveTo should move only while the destination quota allows")
+	}
+	if _, ctx := sl.Find(11); ctx != quiet {
+		t.Error("A rejected MoveTo should leave the entry in the source list")
+	}
+
+	sl.Delete(1)
+	if entries, bytes, _ := sl.ContextUsage(noisy); entries != 2 || bytes != 25 {
+		t.Errorf("Usage after delete = %d, %d; want 2, 25", entries, bytes)
+	}
+	sl.RemoveContextQuota(noisy)
+	if !sl.Insert(&TestItem{ID: 40, Data: make([]byte, 100)}, noisy) {
+		t.Error("Inserts should succeed once the quota is removed")
+	}
+	if err := sl.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+func TestContextQuotaEvictOldest(t *testing.T) {
+	sl := newQuotaList()
+	noisy, quiet := TestContext{AccessCount: 1}, TestContext{AccessCount: 2}
+	sl.SetContextQuota(noisy, 3, 0, QuotaEvictOldest)
+
+	for i := 1; i <= 10; i++ {
+		context := noisy
+		if i%2 == 0 {
+			context = quiet
+		}
+		sl.Insert(&TestItem{ID: i, Data: make([]byte, 10)}, context)
+	}
+
+	// Odd keys are noisy: 1, 3 and 5 were evicted to make room for 7 and 9
+	var noisyKeys []int
+	sl.ForEach(func(ip *ItemPtr[TestItem, int, TestContext]) bool {
+		if ip.Context() == noisy {
+			noisyKeys = append(noisyKeys, ip.Key())
+		}
+		return true
+	})
+	if len(noisyKeys) != 3 || noisyKeys[0] != 5 || noisyKeys[2] != 9 || sl.Length() != 8 {
+		t.Errorf("Noisy keys %v in a list of %d, want [5 7 9] in 8", noisyKeys, sl.Length())
+	}
+	if !sl.UpdateContext(2, noisy) || sl.FindItem(5) != nil {
+		t.Error("UpdateContext into a full context should evict its oldest entry")
+	}
+	if entries, bytes, _ := sl.ContextUsage(noisy); entries != 3 || bytes != 30 {
+		t.Errorf("Usage = %d, %d; want 3, 30", entries, bytes)
+	}
+
+	sl.SetContextQuota(noisy, 0, 15, QuotaEvictOldest)
+	if sl.Insert(&TestItem{ID: 50, Data: make([]byte, 20)}, noisy) {
+		t.Error("An item larger than the whole byte quota should be rejected")
+	}
+	if entries, _, _ := sl.ContextUsage(noisy); entries != 3 {
+		t.Errorf("A rejected item should evict nothing, %d entries left", entries)
+	}
+	sl.Clear()
+	if entries, _, _ := sl.ContextUsage(noisy); entries != 0 {
+		t.Errorf("Usage after Clear = %d entries", entries)
+	}
+	if err := sl.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+func TestContextQuotaEvictNothingWithoutRoom(t *testing.T) {
+	sl := newQuotaList()
+	noisy := TestContext{AccessCount: 1}
+	for i := 1; i <= 3; i++ {
+		sl.Insert(&TestItem{ID: i, Data: make([]byte, 10)}, noisy)
+	}
+	sl.Pin(2)
+	sl.Pin(3)
+
+	// Evicting key 1 frees 10 bytes, too few for a 25 byte item
+	sl.SetContextQuota(noisy, 0, 30, QuotaEvictOldest)
+	if sl.Insert(&TestItem{ID: 4, Data: make([]byte, 25)}, noisy) {
+		t.Error("An insert pinned entries keep out should be refused")
+	}
+	if sl.FindItem(1) == nil || sl.Length() != 3 {
+		t.Error("A refused insert should evict nothing")
+	}
+	if !sl.Insert(&TestItem{ID: 5, Data: make([]byte, 10)}, noisy) || sl.FindItem(1) != nil {
+		t.Error("An insert that eviction makes room for should evict the oldest entry")
+	}
+}
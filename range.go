@@ -0,0 +1,95 @@
+// range.go - Key range iteration for the zero copy skiplist
+
+package zerocopyskiplist
+
+// ForEach calls callback for every item in ascending key order, stopping
+// early when callback returns false. Items are snapshotted in batches as for
+// CallbackToIovecSlice, so callback runs with no lock held and may mutate the list.
+func (sl *ZeroCopySkiplist[T, K, C]) ForEach(callback func(*ItemPtr[T, K, C]) bool) {
+	defer sl.endOp(OperationScan, sl.startOp())
+	batch := make([]ItemPtr[T, K, C], 0, callbackBatchSize)
+	var lastKey K
+	resume := false
+	for {
+		batch = sl.snapshotBatch(batch[:0], lastKey, resume)
+		for i := range batch {
+			if !callback(&batch[i]) {
+				return
+			}
+		}
+		if len(batch) < callbackBatchSize {
+			return
+		}
+		lastKey = batch[len(batch)-1].key
+		resume = true
+	}
+}
+
+// ScanPrefix calls callback, in ascending key order, for every item whose key
+// hasPrefix reports as starting with prefix, stopping early when callback
+// returns false. hasPrefix(key, prefix) must describe a contiguous run of keys
+// that starts at prefix itself in the list's order, as strings.HasPrefix does
+// for string keys or a comparison of leading fields does for composite keys:
+// the scan seeks to the first key >= prefix and ends at the first key that
+// does not match. Items are snapshotted in batches as for ForEach, so
+// callback runs with no lock held and may mutate the list.
+func (sl *ZeroCopySkiplist[T, K, C]) ScanPrefix(prefix K, hasPrefix func(key, prefix K) bool, callback func(*ItemPtr[T, K, C]) bool) {
+	prefix = sl.normalize(prefix)
+	batch := make([]ItemPtr[T, K, C], 0, callbackBatchSize)
+	from, inclusive := prefix, true
+	for {
+		batch = sl.snapshotFrom(batch[:0], from, inclusive)
+		for i := range batch {
+			if !hasPrefix(batch[i].key, prefix) || !callback(&batch[i]) {
+				return
+			}
+		}
+		if len(batch) < callbackBatchSize {
+			return
+		}
+		from, inclusive = batch[len(batch)-1].key, false
+	}
+}
+
+// DescendRange calls callback for every item with min <= key <= max in
+// descending key order, stopping early when callback returns false.
+//
+// Like CallbackToIovecSlice, items are snapshotted in batches under the read
+// lock and callback runs with no lock held, so it may mutate the list. The
+// walk uses backward links from the largest key <= max, so finding the latest
+// entries before a key costs one descent rather than a scan from the start.
+func (sl *ZeroCopySkiplist[T, K, C]) DescendRange(max, min K, callback func(*ItemPtr[T, K, C]) bool) {
+	max, min = sl.normalize(max), sl.normalize(min)
+	batch := make([]ItemPtr[T, K, C], 0, callbackBatchSize)
+	from, inclusive := max, true
+	for {
+		batch = sl.snapshotDescending(batch[:0], from, inclusive, min)
+		for i := range batch {
+			if !callback(&batch[i]) {
+				return
+			}
+		}
+		if len(batch) < callbackBatchSize {
+			return
+		}
+		from, inclusive = batch[len(batch)-1].key, false
+	}
+}
+
+// snapshotDescending appends copies of up to cap(dst) nodes to dst, walking
+// backwards from the last node <= from (or < from when not inclusive) and
+// stopping before the first node with a key below min
+func (sl *ZeroCopySkiplist[T, K, C]) snapshotDescending(dst []ItemPtr[T, K, C], from K, inclusive bool, min K) []ItemPtr[T, K, C] {
+	sl.rlock()
+	defer sl.runlock()
+
+	current := sl.seekLast(from, inclusive)
+	touched := 0
+	for current != nil && len(dst) < cap(dst) && sl.cmpKey(current.key, min) >= 0 {
+		touched += prefetch(current, -1)
+		dst = append(dst, current.detach())
+		current = current.backward
+	}
+	prefetchSink.Add(int64(touched))
+	return dst
+}
@@ -0,0 +1,192 @@
+package zerocopyskiplist
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDescendRange(t *testing.T) {
+	for _, backlinks := range []bool{false, true} {
+		var opts []Option
+		if backlinks {
+			opts = append(opts, WithLevelBacklinks())
+		}
+		skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+			16,
+			getKeyFromTestItem,
+			getTestItemSize,
+			compareInt,
+			opts...,
+		)
+
+		// Use more items than a single batch so the walk has to resume
+		const numItems = callbackBatchSize*2 + 11
+		for _, item := range createTestItems(numItems) {
+			skiplist.Insert(item, TestContext{})
+		}
+
+		// Full range, descending
+		var keys []int
+		skiplist.DescendRange(numItems, 1, func(item *ItemPtr[TestItem, int, TestContext]) bool {
+			keys = append(keys, item.Key())
+			return true
+		})
+		if len(keys) != numItems {
+			t.Fatalf("backlinks=%v: expected %d keys, got %d", backlinks, numItems, len(keys))
+		}
+		for i, key := range keys {
+			if key != numItems-i {
+				t.Fatalf("backlinks=%v: expected key %d at position %d, got %d", backlinks, numItems-i, i, key)
+			}
+		}
+
+		// Bounds outside the stored keys are clamped
+		count := 0
+		skiplist.DescendRange(numItems+100, -100, func(item *ItemPtr[TestItem, int, TestContext]) bool {
+			count++
+			return true
+		})
+		if count != numItems {
+			t.Errorf("backlinks=%v: expected %d keys for a wide range, got %d", backlinks, numItems, count)
+		}
+
+		// Latest 5 entries before key 300
+		keys = keys[:0]
+		skiplist.DescendRange(299, 1, func(item *ItemPtr[TestItem, int, TestContext]) bool {
+			keys = append(keys, item.Key())
+			return len(keys) < 5
+		})
+		expected := []int{299, 298, 297, 296, 295}
+		if len(keys) != len(expected) {
+			t.Fatalf("backlinks=%v: expected %v, got %v", backlinks, expected, keys)
+		}
+		for i := range expected {
+			if keys[i] != expected[i] {
+				t.Errorf("backlinks=%v: expected %v, got %v", backlinks, expected, keys)
+				break
+			}
+		}
+
+		// Empty and inverted ranges
+		skiplist.DescendRange(5, 10, func(item *ItemPtr[TestItem, int, TestContext]) bool {
+			t.Errorf("backlinks=%v: inverted range should not call the callback", backlinks)
+			return true
+		})
+	}
+}
+
+func TestDescendRangeMutatingCallback(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		16,
+		getKeyFromTestItem,
+		getTestItemSize,
+		compareInt,
+	)
+
+	const numItems = callbackBatchSize + 50
+	for _, item := range createTestItems(numItems) {
+		skiplist.Insert(item, TestContext{})
+	}
+
+	// Delete everything above 100 while walking it
+	skiplist.DescendRange(numItems, 101, func(item *ItemPtr[TestItem, int, TestContext]) bool {
+		return skiplist.Delete(item.Key())
+	})
+
+	if skiplist.Length() != 100 {
+		t.Errorf("Expected length 100 after deleting in callback, got %d", skiplist.Length())
+	}
+	if err := skiplist.Validate(); err != nil {
+		t.Errorf("Validate after deleting in callback: %v", err)
+	}
+}
+
+// userKey is a composite key ordered by tenant and then by ID
+type userKey struct {
+	Tenant uint32
+	ID     uint64
+}
+
+func TestScanPrefix(t *testing.T) {
+	sl := MakeOrderedZeroCopySkiplist[string, string, int](8, func(s *string) string { return *s }, func(s *string) int { return len(*s) })
+	for _, key := range []string{"order:1", "user:", "user:alice", "user:bob", "userx", "users:9", "apple"} {
+		sl.Insert(&key, 0)
+	}
+	// Enough matches to span several snapshot batches
+	for i := 0; i < 600; i++ {
+		key := fmt.Sprintf("user:z%04d", i)
+		sl.Insert(&key, 0)
+	}
+
+	var got []string
+	sl.ScanPrefix("user:", strings.HasPrefix, func(ip *ItemPtr[string, string, int]) bool {
+		got = append(got, ip.Key())
+		return true
+	})
+	if len(got) != 603 || got[0] != "user:" || got[1] != "user:alice" || got[602] != "user:z0599" {
+		t.Errorf("ScanPrefix returned %d keys starting %v", len(got), got[:min(3, len(got))])
+	}
+
+	count := 0
+	sl.ScanPrefix("user:", strings.HasPrefix, func(*ItemPtr[string, string, int]) bool {
+		count++
+		return count < 2
+	})
+	if count != 2 {
+		t.Errorf("Callback returning false should stop the scan, ran %d times", count)
+	}
+	sl.ScanPrefix("zebra", strings.HasPrefix, func(*ItemPtr[string, string, int]) bool {
+		t.Error("No key has the prefix zebra")
+		return true
+	})
+
+	// A composite key scanned by its leading field
+	users := MakeZeroCopySkiplist[userKey, userKey, int](8, func(k *userKey) userKey { return *k }, func(*userKey) int { return 12 },
+		func(a, b userKey) int {
+			if a.Tenant != b.Tenant {
+				return int(a.Tenant) - int(b.Tenant)
+			}
+			return int(a.ID) - int(b.ID)
+		})
+	for tenant := uint32(1); tenant <= 3; tenant++ {
+		for id := uint64(1); id <= 4; id++ {
+			users.Insert(&userKey{tenant, id}, 0)
+		}
+	}
+	var ids []uint64
+	users.ScanPrefix(userKey{Tenant: 2}, func(key, prefix userKey) bool { return key.Tenant == prefix.Tenant },
+		func(ip *ItemPtr[userKey, userKey, int]) bool {
+			ids = append(ids, ip.Key().ID)
+			return true
+		})
+	if !slices.Equal(ids, []uint64{1, 2, 3, 4}) {
+		t.Errorf("Tenant 2 IDs = %v", ids)
+	}
+}
+
+func TestForEachDetachedCopies(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt, WithLevelBacklinks())
+	items := createTestItems(200)
+	for _, item := range items {
+		skiplist.Insert(item, TestContext{})
+	}
+
+	// A writer unlinks nodes while callbacks run without the lock; the
+	// copies they get must not reach the live links
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, item := range items {
+			skiplist.Delete(item.ID)
+		}
+	}()
+	skiplist.ForEach(func(ip *ItemPtr[TestItem, int, TestContext]) bool {
+		if !ip.Detached() || ip.Next() != nil || ip.Prev() != nil {
+			t.Fatal("ForEach should hand callbacks detached copies")
+		}
+		return true
+	})
+	<-done
+}
@@ -0,0 +1,90 @@
+// rangelock.go - Advisory key-range locks for multi-step protocols
+
+package zerocopyskiplist
+
+import "sync"
+
+// rangeLocks are the key ranges held with LockRange
+type rangeLocks[K comparable] struct {
+	mu   sync.Mutex
+	held []heldRange[K]
+}
+
+// heldRange is one range held with LockRange; done is closed when it is unlocked
+type heldRange[K comparable] struct {
+	min, max K
+	done     chan struct{}
+}
+
+// LockRange takes an advisory lock on the keys from min to max inclusive,
+// waiting while any overlapping range is held. Ranges that do not overlap are
+// held at the same time, so writers working on disjoint spans never wait on
+// each other.
+//
+// The lock is advisory: it does not stop Insert, Delete or any other method
+// from changing keys in the range, it only excludes other holders of
+// overlapping ranges. Writers that take the range covering their keys, a
+// single key as LockRange(key, key), make a multi-step read-modify-write over
+// the range atomic with respect to each other. Range locks are independent of
+// the list's own lock, so the holder may call any method while holding one.
+// Waiters are not served in order.
+func (sl *ZeroCopySkiplist[T, K, C]) LockRange(min, max K) {
+	min, max = sl.rangeBounds(min, max)
+	for {
+		wait := sl.claimRange(min, max)
+		if wait == nil {
+			return
+		}
+		<-wait
+	}
+}
+
+// TryLockRange takes the advisory lock on min to max as LockRange does if no
+// overlapping range is held, and returns false without waiting otherwise
+func (sl *ZeroCopySkiplist[T, K, C]) TryLockRange(min, max K) bool {
+	return sl.claimRange(sl.rangeBounds(min, max)) == nil
+}
+
+// UnlockRange releases the range locked by LockRange or TryLockRange with
+// the same bounds, waking the waiters on it. It panics if no such range is
+// held, as unlocking an unlocked sync.Mutex does. Any goroutine may unlock a
+// range, not only the one that locked it.
+func (sl *ZeroCopySkiplist[T, K, C]) UnlockRange(min, max K) {
+	min, max = sl.rangeBounds(min, max)
+	r := &sl.ranges
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, h := range r.held {
+		if sl.cmpKey(h.min, min) == 0 && sl.cmpKey(h.max, max) == 0 {
+			close(h.done)
+			r.held = append(r.held[:i], r.held[i+1:]...)
+			return
+		}
+	}
+	panic("zerocopyskiplist: UnlockRange of a range that is not locked")
+}
+
+// claimRange holds min to max and returns nil if no held range overlaps it,
+// or returns a channel closed when an overlapping range is released
+func (sl *ZeroCopySkiplist[T, K, C]) claimRange(min, max K) <-chan struct{} {
+	r := &sl.ranges
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, h := range r.held {
+		if sl.cmpKey(h.min, max) <= 0 && sl.cmpKey(min, h.max) <= 0 {
+			return h.done
+		}
+	}
+	r.held = append(r.held, heldRange[K]{min: min, max: max, done: make(chan struct{})})
+	return nil
+}
+
+// rangeBounds normalizes min and max and orders them as the list does, so
+// ranges on WithDescending lists may be given either way round
+func (sl *ZeroCopySkiplist[T, K, C]) rangeBounds(min, max K) (K, K) {
+	min, max = sl.normalize(min), sl.normalize(max)
+	if sl.cmpKey(min, max) > 0 {
+		return max, min
+	}
+	return min, max
+}
@@ -0,0 +1,75 @@
+package zerocopyskiplist
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLockRange(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+
+	skiplist.LockRange(10, 20)
+	if !skiplist.TryLockRange(21, 30) {
+		t.Error("A disjoint range should be lockable")
+	}
+	if skiplist.TryLockRange(20, 25) || skiplist.TryLockRange(0, 10) || skiplist.TryLockRange(15, 15) {
+		t.Error("Overlapping ranges should not be lockable")
+	}
+	if skiplist.TryLockRange(40, 25) {
+		t.Error("Reversed bounds should overlap as the ordered range does")
+	}
+
+	// A waiter runs once the overlapping range is released
+	acquired := make(chan struct{})
+	go func() {
+		skiplist.LockRange(15, 25)
+		close(acquired)
+	}()
+	skiplist.UnlockRange(10, 20)
+	select {
+	case <-acquired:
+		t.Fatal("Waiter acquired while 21-30 was still held")
+	case <-time.After(10 * time.Millisecond):
+	}
+	skiplist.UnlockRange(30, 21)
+	<-acquired
+	skiplist.UnlockRange(15, 25)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Unlocking a range that is not held should panic")
+		}
+	}()
+	skiplist.UnlockRange(1, 2)
+}
+
+func TestLockRangeReadModifyWrite(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	for _, item := range createTestItems(4) {
+		skiplist.Insert(item, TestContext{})
+	}
+
+	// Increments of each key's context only add up when the read and the
+	// write are made atomic by the range lock
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				key := i%4 + 1
+				skiplist.LockRange(key, key)
+				_, context := skiplist.Find(key)
+				skiplist.UpdateContext(key, TestContext{AccessCount: context.AccessCount + 1})
+				skiplist.UnlockRange(key, key)
+			}
+		}()
+	}
+	wg.Wait()
+	for key := 1; key <= 4; key++ {
+		if _, context := skiplist.Find(key); context.AccessCount != 400 {
+			t.Errorf("Key %d counted %d increments, want 400", key, context.AccessCount)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+// recency.go - Moving entries between keys, and an LRU ordered by recency
+
+package zerocopyskiplist
+
+import (
+	"iter"
+	"sync"
+)
+
+// ReplaceKey moves the entry at old to the key of item, which takes the
+// entry's place with the entry's context, under a single write lock, so
+// readers find the entry at one key or the other and never both or neither.
+// It returns false and changes nothing if old is absent, pinned or leased,
+// if another entry holds item's key, or if the list is frozen. Quotas and the
+// admission filter are not consulted, as the entry is moved rather than
+// added; the move counts as a delete and an insert for Generation and
+// FlushExactlyOnce.
+func (sl *ZeroCopySkiplist[T, K, C]) ReplaceKey(old K, item *T) bool {
+	old = sl.normalize(old)
+	defer sl.endOp(OperationInsert, sl.startOp())
+	var moved bool
+	hook, totalBytes, entries := func() (func(int64, int), int64, int) {
+		sl.lock()
+		defer sl.unlock()
+		moved = !sl.frozen && sl.replaceKey(old, item)
+		return sl.checkPressure()
+	}()
+
+	if hook != nil {
+		hook(totalBytes, entries)
+	}
+	return moved
+}
+
+// replaceKey performs ReplaceKey; the caller must hold the write lock
+func (sl *ZeroCopySkiplist[T, K, C]) replaceKey(old K, item *T) bool {
+	update := make([]*ItemPtr[T, K, C], sl.maxLevel+1)
+	current := sl.descend(old, -1, update).forward[0]
+	if current == nil || sl.cmpKey(current.key, old) != 0 || !sl.evictable(old) {
+		return false
+	}
+	key := sl.getKeyFromItem(item)
+	if sl.cmpKey(key, old) != 0 {
+		if taken := sl.seek(key, true); taken != nil && sl.cmpKey(taken.key, key) == 0 {
+			return false
+		}
+	}
+
+	context := current.context
+	sl.unlink(current, update)
+	target := sl.descend(key, -1, update).forward[0]
+	sl.insertAt(item, key, context, update, target)
+	return true
+}
+
+// recencyEntry is an item of a RecencyList with the stamp it is ordered by
+type recencyEntry[T any, ID comparable] struct {
+	item  *T
+	id    ID
+	stamp uint64
+}
+
+// RecencyList is a least-recently-used index built on a skiplist keyed by a
+// recency stamp: every Add and Touch gives an entry a new, higher stamp and
+// moves it to the tail with ReplaceKey, so the head is always the least
+// recently used entry and eviction pops it. Entries are identified by an ID
+// taken from the item. The facade adds one small allocation per entry and per
+// touch; the underlying list and its options, such as WithNodePool or
+// WithLatencyHistograms, are used as usual. It is safe for concurrent use.
+type RecencyList[T any, ID comparable, C comparable] struct {
+	mu    sync.Mutex
+	list  *ZeroCopySkiplist[recencyEntry[T, ID], uint64, C]
+	byID  map[ID]*recencyEntry[T, ID]
+	getID func(*T) ID
+	stamp uint64 // the last stamp issued
+}
+
+// NewRecencyList returns an empty RecencyList identifying items by getID and
+// sizing them with getItemSize, whose list is built with maxLevel and opts
+func NewRecencyList[T any, ID comparable, C comparable](maxLevel int, getID func(*T) ID, getItemSize func(*T) int, opts ...Option) *RecencyList[T, ID, C] {
+	return &RecencyList[T, ID, C]{
+		list: MakeOrderedZeroCopySkiplist[recencyEntry[T, ID], uint64, C](maxLevel,
+			func(e *recencyEntry[T, ID]) uint64 { return e.stamp },
+			func(e *recencyEntry[T, ID]) int { return getItemSize(e.item) },
+			opts...),
+		byID:  make(map[ID]*recencyEntry[T, ID]),
+		getID: getID,
+	}
+}
+
+// Add inserts item as the most recently used entry, replacing any entry with
+// the same ID, and returns true if it was added. It returns false if the list
+// refused the insert, as a frozen list or an admission filter does, leaving
+// any existing entry for the ID in place.
+func (r *RecencyList[T, ID, C]) Add(item *T, context C) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := r.getID(item)
+	r.stamp++
+	entry := &recencyEntry[T, ID]{item: item, id: id, stamp: r.stamp}
+	if !r.list.Insert(entry, context) {
+		return false
+	}
+	if old := r.byID[id]; old != nil {
+		r.list.Delete(old.stamp)
+	}
+	r.byID[id] = entry
+	return true
+}
+
+// Touch marks the entry for id as the most recently used, returning false if
+// there is none
+func (r *RecencyList[T, ID, C]) Touch(id ID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.touch(id)
+}
+
+// touch performs Touch; the caller must hold r.mu
+func (r *RecencyList[T, ID, C]) touch(id ID) bool {
+	old := r.byID[id]
+	if old == nil {
+		return false
+	}
+	r.stamp++
+	entry := &recencyEntry[T, ID]{item: old.item, id: id, stamp: r.stamp}
+	if !r.list.ReplaceKey(old.stamp, entry) {
+		return false
+	}
+	r.byID[id] = entry
+	return true
+}
+
+// Get returns the item and context for id and marks it the most recently
+// used
+func (r *RecencyList[T, ID, C]) Get(id ID) (*T, C, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.touch(id) {
+		var zero C
+		return nil, zero, false
+	}
+	return r.peek(id)
+}
+
+// Peek returns the item and context for id without changing its recency
+func (r *RecencyList[T, ID, C]) Peek(id ID) (*T, C, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.peek(id)
+}
+
+// peek performs Peek; the caller must hold r.mu
+func (r *RecencyList[T, ID, C]) peek(id ID) (*T, C, bool) {
+	if entry := r.byID[id]; entry != nil {
+		if found, context := r.list.Find(entry.stamp); found != nil {
+			return entry.item, context, true
+		}
+	}
+	var zero C
+	return nil, zero, false
+}
+
+// Remove deletes the entry for id, returning false if there is none
+func (r *RecencyList[T, ID, C]) Remove(id ID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry := r.byID[id]
+	if entry == nil {
+		return false
+	}
+	delete(r.byID, id)
+	return r.list.Delete(entry.stamp)
+}
+
+// EvictOldest removes and returns the least recently used entry, false if
+// the list is empty
+func (r *RecencyList[T, ID, C]) EvictOldest() (*T, C, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	first, ok := r.list.FirstEntry()
+	if !ok || !r.list.Delete(first.Key) {
+		var zero C
+		return nil, zero, false
+	}
+	delete(r.byID, first.Item.id)
+	return first.Item.item, first.Context, true
+}
+
+// Len returns the number of entries
+func (r *RecencyList[T, ID, C]) Len() int {
+	return r.list.Length()
+}
+
+// All returns an iterator over the items and contexts from the least to the
+// most recently used. Like Entries it runs the loop body without the list's
+// lock, and touching entries during the loop moves them ahead of the
+// iteration, so they are seen again.
+func (r *RecencyList[T, ID, C]) All() iter.Seq2[*T, C] {
+	return func(yield func(*T, C) bool) {
+		for entry := range r.list.Entries() {
+			if !yield(entry.Item.item, entry.Context) {
+				return
+			}
+		}
+	}
+}
+
+// Stats returns the statistics of the underlying list
+func (r *RecencyList[T, ID, C]) Stats() Stats {
+	return r.list.Stats()
+}
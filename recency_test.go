@@ -0,0 +1,83 @@
+package zerocopyskiplist
+
+import "testing"
+
+func TestReplaceKey(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	for _, item := range createTestItems(5) {
+		skiplist.Insert(item, TestContext{AccessCount: item.ID})
+	}
+
+	moved := &TestItem{ID: 10}
+	if !skiplist.ReplaceKey(2, moved) {
+		t.Fatal("ReplaceKey to a free key should succeed")
+	}
+	if contains(skiplist, 2) || skiplist.Length() != 5 {
+		t.Error("The old key should be gone")
+	}
+	if found, context := skiplist.Find(10); found == nil || found.Item() != moved || context.AccessCount != 2 {
+		t.Error("The entry should move to the new key with its context")
+	}
+	if last := skiplist.Last(); last == nil || last.Key() != 10 {
+		t.Error("The moved entry should be linked at its new position")
+	}
+
+	if skiplist.ReplaceKey(3, &TestItem{ID: 4}) || skiplist.ReplaceKey(7, &TestItem{ID: 8}) {
+		t.Error("ReplaceKey onto a taken key or from a missing one should fail")
+	}
+	skiplist.Pin(3)
+	if skiplist.ReplaceKey(3, &TestItem{ID: 30}) {
+		t.Error("A pinned entry should not move")
+	}
+	if !skiplist.ReplaceKey(4, &TestItem{ID: 4, Value: "same key"}) {
+		t.Error("ReplaceKey to the same key should replace the item")
+	}
+	if err := skiplist.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecencyList(t *testing.T) {
+	lru := NewRecencyList[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize)
+	for _, item := range createTestItems(5) {
+		lru.Add(item, TestContext{AccessCount: item.ID})
+	}
+	lru.Touch(1)
+	if item, context, ok := lru.Get(3); !ok || item.ID != 3 || context.AccessCount != 3 {
+		t.Errorf("Get(3) = %v, %v, %v", item, context, ok)
+	}
+	lru.Add(&TestItem{ID: 2, Value: "replaced"}, TestContext{})
+	if lru.Len() != 5 || lru.Touch(9) {
+		t.Errorf("Len = %d after replacing an ID; Touch of an unknown ID should fail", lru.Len())
+	}
+	if _, _, ok := lru.Peek(4); !ok {
+		t.Error("Peek should find an entry")
+	}
+
+	var order []int
+	for item := range lru.All() {
+		order = append(order, item.ID)
+	}
+	want := []int{4, 5, 1, 3, 2}
+	for i := range want {
+		if len(order) != len(want) || order[i] != want[i] {
+			t.Fatalf("Recency order = %v, want %v", order, want)
+		}
+	}
+
+	if item, _, ok := lru.EvictOldest(); !ok || item.ID != 4 {
+		t.Errorf("EvictOldest = %v, want 4 despite the Peek", item)
+	}
+	if !lru.Remove(5) || lru.Remove(5) {
+		t.Error("Remove should succeed once")
+	}
+	for lru.Len() > 0 {
+		lru.EvictOldest()
+	}
+	if _, _, ok := lru.EvictOldest(); ok {
+		t.Error("EvictOldest on an empty list should fail")
+	}
+	if _, _, ok := lru.Get(1); ok {
+		t.Error("Evicted entries should be gone")
+	}
+}
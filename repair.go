@@ -0,0 +1,144 @@
+// repair.go - Rebuilding the links of a damaged region in place
+
+package zerocopyskiplist
+
+import (
+	"fmt"
+	"slices"
+)
+
+// RepairFrom rebuilds the links of every node from key onwards, for a list
+// that Validate or a paranoid check found damaged there, and returns the keys
+// of the nodes it had to excise. The nodes before key are trusted and left
+// untouched, so key should be at or before the first broken region reported.
+//
+// Every node reachable from the region on any level is collected, stopping at
+// cycles and truncated link slices, so a node that dropped off level 0 but is
+// still linked on a higher one is recovered. Nodes without an item, already
+// unlinked, or whose key no longer matches their item are excised, as is all
+// but the first node found for a duplicated key. The survivors are relinked
+// in key order with their existing levels, the length, byte and level counts
+// and context quotas are recounted, and the error of a final Validate is
+// returned if the list is still unsound. Nodes reachable from nowhere are
+// lost without being reported, and nodes found in the region with keys
+// before key are left alone, as they belong to the trusted part of the list.
+//
+// The write lock is held throughout. Repair is allowed on a frozen list.
+func (sl *ZeroCopySkiplist[T, K, C]) RepairFrom(key K) ([]K, error) {
+	key = sl.normalize(key)
+	sl.lock()
+	defer sl.unlock()
+
+	update := make([]*ItemPtr[T, K, C], sl.maxLevel+1)
+	sl.descend(key, -1, update)
+	for i := range update {
+		if update[i] == nil {
+			update[i] = sl.header
+		}
+	}
+
+	// Collect the nodes after the trusted region, level 0 first so the node
+	// still on level 0 wins when a key is duplicated
+	var nodes []*ItemPtr[T, K, C]
+	seen := make(map[*ItemPtr[T, K, C]]bool)
+	for i := 0; i <= sl.maxLevel; i++ {
+		visited := make(map[*ItemPtr[T, K, C]]bool)
+		for node := update[i]; i < len(node.forward) && node.forward[i] != nil; node = node.forward[i] {
+			next := node.forward[i]
+			if visited[next] {
+				break
+			}
+			visited[next] = true
+			if !seen[next] {
+				seen[next] = true
+				nodes = append(nodes, next)
+			}
+		}
+	}
+
+	var lost []K
+	excise := func(node *ItemPtr[T, K, C]) {
+		lost = append(lost, node.key)
+		node.level = unlinkedLevel
+	}
+	kept := nodes[:0]
+	for _, node := range nodes {
+		switch {
+		case sl.cmpKey(node.key, key) < 0:
+			// Part of the trusted region, reached through a bad link
+		case node.item == nil || node.level < 0 || sl.cmpKey(node.key, sl.getKeyFromItem(node.item)) != 0:
+			excise(node)
+		default:
+			kept = append(kept, node)
+		}
+	}
+	slices.SortStableFunc(kept, func(a, b *ItemPtr[T, K, C]) int {
+		return sl.cmpKey(a.key, b.key)
+	})
+	unique := kept[:0]
+	for _, node := range kept {
+		if len(unique) > 0 && sl.cmpKey(unique[len(unique)-1].key, node.key) == 0 {
+			excise(node)
+			continue
+		}
+		unique = append(unique, node)
+	}
+
+	// Relink the survivors after the trusted region
+	prev := update
+	for _, node := range unique {
+		level := min(node.level, sl.maxLevel)
+		sl.sizeLinks(node, level)
+		for i := 0; i <= level; i++ {
+			sl.link(prev[i], i, node)
+			node.setPrevAt(i, sl.nodeOrNil(prev[i]))
+			prev[i] = node
+		}
+	}
+	for i := range prev {
+		sl.link(prev[i], i, nil)
+		sl.tails[i] = sl.nodeOrNil(prev[i])
+	}
+
+	sl.recount()
+	if err := sl.validate(); err != nil {
+		return lost, fmt.Errorf("list still damaged after repair from %v: %w", key, err)
+	}
+	return lost, nil
+}
+
+// recount recomputes the length, item bytes, list level, level counts,
+// quota usage and context stats usage from the level 0 chain; the caller
+// must hold the write lock
+func (sl *ZeroCopySkiplist[T, K, C]) recount() {
+	sl.length = 0
+	sl.totalBytes = 0
+	sl.totalWeight = 0
+	clear(sl.levelCounts)
+	for _, q := range sl.quotas {
+		q.entries, q.bytes = 0, 0
+	}
+	for _, cs := range sl.contextStats {
+		cs.Entries, cs.Bytes = 0, 0
+	}
+	if sl.lengths != nil {
+		sl.lengths.reset()
+	}
+	for node := sl.header.forward[0]; node != nil; node = node.forward[0] {
+		size := int64(sl.getItemSize(node.item))
+		sl.length++
+		sl.totalBytes += size
+		sl.levelCounts[node.level]++
+		sl.chargeQuota(node.context, size, 1)
+		sl.chargeContext(node.context, size, 1, false)
+		sl.chargeWeight(node.item, node.context, 1)
+	}
+
+	sl.level = 0
+	for i := sl.maxLevel; i > 0; i-- {
+		if sl.header.forward[i] != nil {
+			sl.level = i
+			break
+		}
+	}
+}
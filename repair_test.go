@@ -0,0 +1,97 @@
+package zerocopyskiplist
+
+import (
+	"slices"
+	"testing"
+)
+
+// newRepairList returns a list of keys 1 to n where key k reaches the level of
+// the number of times 2 divides k, with backlinks and inline keys
+func newRepairList(n int) (*ZeroCopySkiplist[TestItem, int, TestContext], []*TestItem) {
+	sl := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt,
+		WithLevelPolicy(EveryKthLevels(2)), WithLevelBacklinks(), WithInlineKeys())
+	items := createTestItems(n)
+	sl.BulkLoad(items, nil)
+	return sl, items
+}
+
+// nodeAt returns the live node for key
+func nodeAt(sl *ZeroCopySkiplist[TestItem, int, TestContext], key int) *ItemPtr[TestItem, int, TestContext] {
+	node, _ := sl.Find(key)
+	return node
+}
+
+func TestRepairFrom(t *testing.T) {
+	sl, items := newRepairList(200)
+	sl.SetContextQuota(TestContext{}, 0, 0, QuotaReject)
+
+	// Level 0 skips keys 101 to 104, key 150 now holds the item of key 10 and
+	// a level 1 link points back into the region
+	sl.link(nodeAt(sl, 100), 0, nodeAt(sl, 105))
+	nodeAt(sl, 150).item = items[9]
+	sl.link(nodeAt(sl, 170), 1, nodeAt(sl, 120))
+	if sl.Validate() == nil {
+		t.Fatal("Expected the damaged list to fail validation")
+	}
+
+	lost, err := sl.RepairFrom(90)
+	if err != nil {
+		t.Fatalf("RepairFrom: %v", err)
+	}
+	if !slices.Equal(lost, []int{150}) {
+		t.Errorf("Expected key 150 to be excised, got %v", lost)
+	}
+
+	// Keys 102 and 104 were still linked on level 1; 101 and 103 only lived
+	// on level 0. The bad level 1 link is dropped without losing the nodes after it
+	for _, key := range []int{90, 100, 102, 104, 105, 149, 151, 170, 171, 200} {
+		if nodeAt(sl, key) == nil {
+			t.Errorf("Key %d should survive the repair", key)
+		}
+	}
+	for _, key := range []int{101, 103, 150} {
+		if nodeAt(sl, key) != nil {
+			t.Errorf("Key %d should be gone after the repair", key)
+		}
+	}
+	length := sl.Length()
+	if entries, _, _ := sl.ContextUsage(TestContext{}); entries != length {
+		t.Errorf("Quota usage %d does not match the recounted length %d", entries, length)
+	}
+	if counts := sl.Stats().LevelCounts; counts[0]+counts[1]+counts[2] > length {
+		t.Errorf("Level counts %v exceed the length %d", counts, length)
+	}
+
+	// The repaired list takes new writes
+	sl.Insert(items[100], TestContext{})
+	if err := sl.Validate(); err != nil {
+		t.Errorf("Validate after insert: %v", err)
+	}
+}
+
+func TestRepairFromDuplicatesAndCycles(t *testing.T) {
+	sl, _ := newRepairList(64)
+
+	// Key 40 is linked in twice and level 0 loops back from 50 to 20
+	n20, n30, n31, n40, n50 := nodeAt(sl, 20), nodeAt(sl, 30), nodeAt(sl, 31), nodeAt(sl, 40), nodeAt(sl, 50)
+	dup := &ItemPtr[TestItem, int, TestContext]{item: n40.item, key: 40}
+	sl.sizeLinks(dup, 0)
+	sl.link(n30, 0, dup)
+	dup.forward[0] = n31
+	sl.link(n50, 0, n20)
+
+	lost, err := sl.RepairFrom(1)
+	if err != nil {
+		t.Fatalf("RepairFrom: %v", err)
+	}
+	if !slices.Equal(lost, []int{40}) {
+		t.Errorf("Expected the duplicate key 40 to be excised, got %v", lost)
+	}
+	if n40.level != unlinkedLevel || nodeAt(sl, 40) != dup {
+		t.Error("The node found first on level 0 should win the duplicated key")
+	}
+	// Only the even keys after 50 were still linked above level 0
+	if n := sl.Length(); n != 50+7 {
+		t.Errorf("Expected 57 keys after the repair, got %d", n)
+	}
+}
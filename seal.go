@@ -0,0 +1,125 @@
+// seal.go - Compact immutable copies of finished lists
+
+package zerocopyskiplist
+
+import (
+	"iter"
+	"sort"
+	"syscall"
+)
+
+// SealedList is an immutable, compact copy of a skiplist's entries: one
+// sorted array with no nodes or links, searched by binary search. It shares
+// items with the list it was sealed from and, being immutable, needs no lock,
+// so any number of goroutines may read it.
+type SealedList[T any, K comparable, C comparable] struct {
+	entries    []Entry[T, K, C]
+	cmpKey     func(K, K) int
+	iovec      func(*T) syscall.Iovec
+	totalBytes int64
+}
+
+// Seal returns a SealedList holding the list's current entries, for keeping
+// a finished list for long-term reads at a fraction of its memory: an entry
+// costs its key, item pointer and context with no per-node allocation. The
+// list itself is left unchanged; drop it, or Clear it, to reclaim its nodes.
+// The items must not be modified while the sealed list is in use.
+func (sl *ZeroCopySkiplist[T, K, C]) Seal() *SealedList[T, K, C] {
+	sl.rlock()
+	defer sl.runlock()
+
+	entries := make([]Entry[T, K, C], 0, sl.length)
+	for current := sl.header.forward[0]; current != nil; current = current.forward[0] {
+		entries = append(entries, current.Entry())
+	}
+	return &SealedList[T, K, C]{
+		entries:    entries,
+		cmpKey:     sl.cmpKey,
+		iovec:      sl.iovec,
+		totalBytes: sl.totalBytes,
+	}
+}
+
+// Length returns the number of entries
+func (s *SealedList[T, K, C]) Length() int {
+	return len(s.entries)
+}
+
+// TotalBytes returns the sum of the item sizes
+func (s *SealedList[T, K, C]) TotalBytes() int64 {
+	return s.totalBytes
+}
+
+// At returns the i'th entry in key order; it panics if i is out of range
+func (s *SealedList[T, K, C]) At(i int) Entry[T, K, C] {
+	return s.entries[i]
+}
+
+// Search returns the index of the first entry whose key is >= key, Length if
+// there is none
+func (s *SealedList[T, K, C]) Search(key K) int {
+	return sort.Search(len(s.entries), func(i int) bool {
+		return s.cmpKey(s.entries[i].Key, key) >= 0
+	})
+}
+
+// Get returns the entry for key and whether it was found
+func (s *SealedList[T, K, C]) Get(key K) (Entry[T, K, C], bool) {
+	i := s.Search(key)
+	if i == len(s.entries) || s.cmpKey(s.entries[i].Key, key) != 0 {
+		return Entry[T, K, C]{}, false
+	}
+	return s.entries[i], true
+}
+
+// Entries returns an iterator over every entry in ascending key order
+func (s *SealedList[T, K, C]) Entries() iter.Seq[Entry[T, K, C]] {
+	return s.Range(0, len(s.entries))
+}
+
+// EntriesFrom returns an iterator over the entries with keys >= key, in
+// ascending key order
+func (s *SealedList[T, K, C]) EntriesFrom(key K) iter.Seq[Entry[T, K, C]] {
+	return s.Range(s.Search(key), len(s.entries))
+}
+
+// Range returns an iterator over the entries at indexes [from, to), clamped
+// to the list
+func (s *SealedList[T, K, C]) Range(from, to int) iter.Seq[Entry[T, K, C]] {
+	from, to = max(from, 0), min(to, len(s.entries))
+	return func(yield func(Entry[T, K, C]) bool) {
+		for i := from; i < to; i++ {
+			if !yield(s.entries[i]) {
+				return
+			}
+		}
+	}
+}
+
+// CallbackToIovecSlice generates an iovec for every entry the callback
+// accepts, in key order, as the list's CallbackToIovecSlice does
+func (s *SealedList[T, K, C]) CallbackToIovecSlice(callback func(Entry[T, K, C]) bool) []syscall.Iovec {
+	var iovecs []syscall.Iovec
+	for _, entry := range s.entries {
+		if callback(entry) {
+			iovecs = append(iovecs, s.iovec(entry.Item))
+		}
+	}
+	return iovecs
+}
+
+// ToIovecSlice generates an iovec for every entry, in key order
+func (s *SealedList[T, K, C]) ToIovecSlice() []syscall.Iovec {
+	iovecs := make([]syscall.Iovec, len(s.entries))
+	for i, entry := range s.entries {
+		iovecs[i] = s.iovec(entry.Item)
+	}
+	return iovecs
+}
+
+// ToContextIovecSlice generates an iovec for every entry with context, in key order
+func (s *SealedList[T, K, C]) ToContextIovecSlice(context C) []syscall.Iovec {
+	return s.CallbackToIovecSlice(func(entry Entry[T, K, C]) bool {
+		return entry.Context == context
+	})
+}
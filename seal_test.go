@@ -0,0 +1,59 @@
+package zerocopyskiplist
+
+import (
+	"slices"
+	"testing"
+	"unsafe"
+)
+
+func TestSeal(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt)
+	items := createTestItems(100)
+	for _, item := range items {
+		if item.ID%10 != 0 {
+			skiplist.Insert(item, TestContext{AccessCount: item.ID % 3})
+		}
+	}
+	sealed := skiplist.Seal()
+
+	// The sealed list is independent of later changes to the list
+	skiplist.Delete(1)
+	if sealed.Length() != 90 || sealed.TotalBytes() != int64(90*getTestItemSize(items[0])) {
+		t.Errorf("Sealed Length = %d, TotalBytes = %d", sealed.Length(), sealed.TotalBytes())
+	}
+	if entry, ok := sealed.Get(1); !ok || entry.Item != items[0] || entry.Context.AccessCount != 1 {
+		t.Errorf("Get(1) = %+v, %v", entry, ok)
+	}
+	if _, ok := sealed.Get(10); ok {
+		t.Error("Get of a missing key should fail")
+	}
+	if i := sealed.Search(10); sealed.At(i).Key != 11 {
+		t.Errorf("Search(10) = %d, entry %d; want the entry for 11", i, sealed.At(i).Key)
+	}
+	if i := sealed.Search(1000); i != sealed.Length() {
+		t.Errorf("Search past the end = %d, want Length", i)
+	}
+
+	var keys []int
+	for entry := range sealed.EntriesFrom(95) {
+		keys = append(keys, entry.Key)
+	}
+	if !slices.Equal(keys, []int{95, 96, 97, 98, 99}) {
+		t.Errorf("EntriesFrom(95) = %v", keys)
+	}
+	count := 0
+	for range sealed.Entries() {
+		count++
+	}
+	if count != 90 {
+		t.Errorf("Entries yielded %d, want 90", count)
+	}
+
+	iovecs := sealed.ToIovecSlice()
+	if len(iovecs) != 90 || iovecs[0].Base != (*byte)(unsafe.Pointer(items[0])) || iovecs[0].Len != uint64(getTestItemSize(items[0])) {
+		t.Errorf("ToIovecSlice does not point at the items")
+	}
+	if n := len(sealed.ToContextIovecSlice(TestContext{AccessCount: 0})); n != 30 {
+		t.Errorf("ToContextIovecSlice = %d iovecs, want 30", n)
+	}
+}
@@ -0,0 +1,263 @@
+// shared.go - Read-only sharing of a published list between processes
+
+package zerocopyskiplist
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// ErrSharedCorrupt is returned when a shared segment is malformed
+var ErrSharedCorrupt = errors.New("shared segment is corrupt")
+
+// sharedMagic starts every shared segment
+var sharedMagic = [8]byte{'Z', 'C', 'S', 'L', 'S', 'H', 'M', '1'}
+
+// sharedHeaderSize is the size of the segment header, which is followed by
+// the head node at the same offset in every segment
+const sharedHeaderSize = 32
+
+// sharedNodeFixed is the size of a node record before its forward offsets:
+// level, key length and value length as uint32 plus padding
+const sharedNodeFixed = 16
+
+// PublishShared writes the list to path in a layout that other processes can
+// map read-only and search in place with OpenShared. Every node becomes a
+// record at a fixed offset holding its level, its key encoded with keys, its
+// item and context encoded with codec, and the file offsets of its successor
+// on each level, so the reader follows offsets exactly as the list follows
+// pointers.
+//
+// The segment is written to a temporary file and renamed over path, so readers
+// that already have it open keep a consistent view and reopen to see the new
+// one. Placing path on a tmpfs such as /dev/shm keeps the data in memory. The
+// read lock is held while the segment is built.
+func (sl *ZeroCopySkiplist[T, K, C]) PublishShared(path string, keys KeyCodec[K], codec ItemCodec[T, C]) error {
+	buf, err := sl.buildShared(keys, codec)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// buildShared lays the list out as a shared segment. Each new node patches
+// the forward offset slots left open by the last node seen on each of its
+// levels, so no pointer to offset map is needed.
+func (sl *ZeroCopySkiplist[T, K, C]) buildShared(keys KeyCodec[K], codec ItemCodec[T, C]) ([]byte, error) {
+	sl.rlock()
+	defer sl.runlock()
+
+	buf := make([]byte, sharedHeaderSize, sharedHeaderSize+sharedNodeFixed+8*(sl.maxLevel+1))
+	copy(buf, sharedMagic[:])
+	binary.LittleEndian.PutUint64(buf[8:], uint64(sl.length))
+	binary.LittleEndian.PutUint32(buf[16:], uint32(sl.level))
+	binary.LittleEndian.PutUint32(buf[20:], uint32(sl.maxLevel))
+
+	// open[i] is the position of the level i forward slot of the last node on level i
+	open := make([]int, sl.maxLevel+1)
+	buf = appendSharedNode(buf, sl.maxLevel, nil, nil, open)
+
+	var key []byte
+	for current := sl.header.forward[0]; current != nil; current = current.forward[0] {
+		key = keys.AppendKey(key[:0], current.key)
+		value, err := codec.Encode(nil, current.item, current.context)
+		if err != nil {
+			return nil, fmt.Errorf("encoding key %v: %w", current.key, err)
+		}
+
+		offset := uint64(len(buf))
+		for i := 0; i <= current.level; i++ {
+			binary.LittleEndian.PutUint64(buf[open[i]:], offset)
+		}
+		buf = appendSharedNode(buf, current.level, key, value, open)
+	}
+	return buf, nil
+}
+
+// appendSharedNode appends a node record with zero forward offsets, padded to
+// 8 bytes, and records the positions of its forward slots in open
+func appendSharedNode(buf []byte, level int, key, value []byte, open []int) []byte {
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(level))
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(key)))
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(value)))
+	buf = binary.LittleEndian.AppendUint32(buf, 0)
+	for i := 0; i <= level; i++ {
+		open[i] = len(buf)
+		buf = binary.LittleEndian.AppendUint64(buf, 0)
+	}
+	buf = append(buf, key...)
+	buf = append(buf, value...)
+	for len(buf)%8 != 0 {
+		buf = append(buf, 0)
+	}
+	return buf
+}
+
+// SharedReader searches a segment written by PublishShared through a
+// read-only memory mapping, without copying it into the process. Methods may
+// be called concurrently until Close.
+type SharedReader[K comparable] struct {
+	data     []byte
+	keys     KeyCodec[K]
+	cmpKey   func(K, K) int
+	length   int
+	level    int
+	maxLevel int
+}
+
+// OpenShared maps the segment at path read-only. keys and cmpKey must match
+// the publishing list's key codec and ordering.
+func OpenShared[K comparable](path string, keys KeyCodec[K], cmpKey func(K, K) int) (*SharedReader[K], error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() < sharedHeaderSize+sharedNodeFixed {
+		return nil, fmt.Errorf("%w: %d bytes", ErrSharedCorrupt, info.Size())
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(info.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, fmt.Errorf("mapping %s: %w", path, err)
+	}
+
+	r := &SharedReader[K]{
+		data:     data,
+		keys:     keys,
+		cmpKey:   cmpKey,
+		length:   int(binary.LittleEndian.Uint64(data[8:])),
+		level:    int(binary.LittleEndian.Uint32(data[16:])),
+		maxLevel: int(binary.LittleEndian.Uint32(data[20:])),
+	}
+	if [8]byte(data[:8]) != sharedMagic || r.level > r.maxLevel ||
+		sharedHeaderSize+sharedNodeFixed+8*(r.maxLevel+1) > len(data) {
+		syscall.Munmap(data)
+		return nil, fmt.Errorf("%w: bad header", ErrSharedCorrupt)
+	}
+	return r, nil
+}
+
+// Len returns the number of items in the segment
+func (r *SharedReader[K]) Len() int {
+	return r.length
+}
+
+// Close unmaps the segment; slices returned by Find and Scan become invalid
+func (r *SharedReader[K]) Close() error {
+	data := r.data
+	r.data = nil
+	return syscall.Munmap(data)
+}
+
+// Find returns the encoded item and context stored under key, which can be
+// decoded with the publisher's ItemCodec. The slice points into the mapping.
+func (r *SharedReader[K]) Find(key K) ([]byte, bool, error) {
+	offset, err := r.seek(key)
+	if err != nil || offset == 0 {
+		return nil, false, err
+	}
+	nodeKey, value, err := r.node(offset)
+	if err != nil || r.cmpKey(nodeKey, key) != 0 {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+// Scan calls fn for every item with a key >= from in ascending key order
+// until fn returns false. Values point into the mapping.
+func (r *SharedReader[K]) Scan(from K, fn func(key K, value []byte) bool) error {
+	offset, err := r.seek(from)
+	for err == nil && offset != 0 {
+		var key K
+		var value []byte
+		if key, value, err = r.node(offset); err != nil || !fn(key, value) {
+			break
+		}
+		offset, err = r.forward(offset, 0)
+	}
+	return err
+}
+
+// seek returns the offset of the first node with a key >= key, or 0
+func (r *SharedReader[K]) seek(key K) (uint64, error) {
+	current := uint64(sharedHeaderSize)
+	for i := r.level; i >= 0; i-- {
+		for {
+			next, err := r.forward(current, i)
+			if err != nil {
+				return 0, err
+			}
+			if next == 0 {
+				break
+			}
+			nextKey, _, err := r.node(next)
+			if err != nil {
+				return 0, err
+			}
+			if r.cmpKey(nextKey, key) >= 0 {
+				break
+			}
+			current = next
+		}
+	}
+	return r.forward(current, 0)
+}
+
+// forward returns the level i successor offset of the node at offset
+func (r *SharedReader[K]) forward(offset uint64, i int) (uint64, error) {
+	slot := offset + sharedNodeFixed + 8*uint64(i)
+	if slot+8 > uint64(len(r.data)) {
+		return 0, fmt.Errorf("%w: node offset %d", ErrSharedCorrupt, offset)
+	}
+	next := binary.LittleEndian.Uint64(r.data[slot:])
+	if next != 0 && next <= offset {
+		return 0, fmt.Errorf("%w: backward link at offset %d", ErrSharedCorrupt, offset)
+	}
+	return next, nil
+}
+
+// node decodes the key and returns the value of the node at offset
+func (r *SharedReader[K]) node(offset uint64) (K, []byte, error) {
+	var key K
+	if offset+sharedNodeFixed > uint64(len(r.data)) {
+		return key, nil, fmt.Errorf("%w: node offset %d", ErrSharedCorrupt, offset)
+	}
+	level := uint64(binary.LittleEndian.Uint32(r.data[offset:]))
+	keyLen := uint64(binary.LittleEndian.Uint32(r.data[offset+4:]))
+	valueLen := uint64(binary.LittleEndian.Uint32(r.data[offset+8:]))
+	start := offset + sharedNodeFixed + 8*(level+1)
+	if level > uint64(r.maxLevel) || start+keyLen+valueLen > uint64(len(r.data)) {
+		return key, nil, fmt.Errorf("%w: node at offset %d overruns the segment", ErrSharedCorrupt, offset)
+	}
+	key, err := r.keys.DecodeKey(r.data[start : start+keyLen])
+	if err != nil {
+		return key, nil, err
+	}
+	return key, r.data[start+keyLen : start+keyLen+valueLen : start+keyLen+valueLen], nil
+}
@@ -0,0 +1,132 @@
+package zerocopyskiplist
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// intKeys encodes int keys as 8 byte little-endian integers
+type intKeys struct{}
+
+func (intKeys) AppendKey(dst []byte, key int) []byte {
+	return binary.LittleEndian.AppendUint64(dst, uint64(key))
+}
+
+func (intKeys) DecodeKey(raw []byte) (int, error) {
+	if len(raw) != 8 {
+		return 0, fmt.Errorf("key of %d bytes", len(raw))
+	}
+	return int(binary.LittleEndian.Uint64(raw)), nil
+}
+
+func publishTestSegment(t *testing.T) string {
+	skiplist := newSnapshotTestList()
+	for i, item := range createTestItems(1000) {
+		if i%2 == 0 {
+			skiplist.Insert(item, TestContext{AccessCount: i})
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "segment")
+	if err := skiplist.PublishShared(path, intKeys{}, testItemCodecV2{}); err != nil {
+		t.Fatalf("PublishShared: %v", err)
+	}
+	return path
+}
+
+func TestSharedReader(t *testing.T) {
+	path := publishTestSegment(t)
+	reader, err := OpenShared[int](path, intKeys{}, compareInt)
+	if err != nil {
+		t.Fatalf("OpenShared: %v", err)
+	}
+	defer reader.Close()
+
+	if reader.Len() != 500 {
+		t.Errorf("Expected 500 items, got %d", reader.Len())
+	}
+	for key := 0; key <= 1001; key++ {
+		value, found, err := reader.Find(key)
+		if err != nil {
+			t.Fatalf("Find(%d): %v", key, err)
+		}
+		if found != (key%2 == 1 && key < 1000) {
+			t.Fatalf("Find(%d) found %v", key, found)
+		}
+		if found {
+			item, ctx, err := testItemCodecV2{}.Decode(value)
+			if err != nil || item.ID != key || ctx.AccessCount != key-1 {
+				t.Fatalf("Find(%d) decoded %+v %+v %v", key, item, ctx, err)
+			}
+		}
+	}
+
+	next := 501
+	err = reader.Scan(500, func(key int, value []byte) bool {
+		if key != next {
+			t.Fatalf("Scan expected key %d, got %d", next, key)
+		}
+		next += 2
+		return key < 600
+	})
+	if err != nil || next != 603 {
+		t.Errorf("Scan stopped at %d: %v", next, err)
+	}
+
+	// Republishing replaces the file without disturbing the open mapping
+	empty := newSnapshotTestList()
+	if err := empty.PublishShared(path, intKeys{}, testItemCodecV2{}); err != nil {
+		t.Fatalf("PublishShared: %v", err)
+	}
+	if _, found, _ := reader.Find(501); !found {
+		t.Error("An open reader should keep its view after republishing")
+	}
+}
+
+func TestSharedReaderOtherProcess(t *testing.T) {
+	if path := os.Getenv("ZCSL_SHARED_SEGMENT"); path != "" {
+		reader, err := OpenShared[int](path, intKeys{}, compareInt)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		value, found, err := reader.Find(777)
+		if item, _, _ := (testItemCodecV2{}).Decode(value); !found || err != nil || item.Value != "value_777" {
+			fmt.Println("key 777 not found in shared segment")
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestSharedReaderOtherProcess$")
+	cmd.Env = append(os.Environ(), "ZCSL_SHARED_SEGMENT="+publishTestSegment(t))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("Reader process failed: %v\n%s", err, out)
+	}
+}
+
+func TestSharedReaderCorrupt(t *testing.T) {
+	path := publishTestSegment(t)
+	data, _ := os.ReadFile(path)
+	// Point the head's level 0 link back at the header
+	binary.LittleEndian.PutUint64(data[sharedHeaderSize+sharedNodeFixed:], 8)
+	os.WriteFile(path, data, 0o644)
+
+	reader, err := OpenShared[int](path, intKeys{}, compareInt)
+	if err != nil {
+		t.Fatalf("OpenShared: %v", err)
+	}
+	defer reader.Close()
+	if err := reader.Scan(0, func(int, []byte) bool { return true }); err == nil {
+		t.Error("Expected an error following a corrupt link")
+	}
+
+	os.WriteFile(path, []byte("not a segment"), 0o644)
+	if _, err := OpenShared[int](path, intKeys{}, compareInt); err == nil {
+		t.Error("Expected an error opening a malformed segment")
+	}
+}
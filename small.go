@@ -0,0 +1,147 @@
+// small.go - Slice storage for lists that stay small
+
+package zerocopyskiplist
+
+import "slices"
+
+// WithSmallListThreshold keeps the list's entries in a sorted slice while it
+// holds at most n of them, with no nodes at all: a tiny list then costs one
+// slice element per entry instead of a node and its links, and lookups binary
+// search the slice. When an insert takes the list past n entries the nodes
+// are built in one balanced pass, as Rebalance does, and from then on new
+// nodes are promoted by the level policy as usual. The list does not return
+// to the slice when it shrinks, until Clear empties it. This suits many tiny
+// lists, such as one per session, whose node overhead would otherwise
+// dominate their memory.
+//
+// Insert, Delete, Find, FindItem, Get, UpdateContext, First, Last, Bounds,
+// Length, IsEmpty, TotalItemBytes, Stats, Validate, Copy and ascending scans
+// (ForEach, Entries, CallbackToIovecSlice and the other iovec and Arrow
+// exports) work on the slice; the ItemPtrs they hand out are
+// detached copies, as WithDetachedNodes. Any other operation builds the
+// nodes first, as does setting quotas, weights, admission, pins or leases,
+// so the list leaves slice storage for good; once any of those is set up,
+// Clear leaves the list in nodes too. The option has no effect
+// WithExactlyOnceFlush, whose version log records nodes.
+func WithSmallListThreshold(n int) Option {
+	return func(o *options) {
+		o.smallThreshold = n
+	}
+}
+
+// smallEntry is an entry of a list in small list storage
+type smallEntry[T any, K comparable, C comparable] struct {
+	key     K
+	item    *T
+	context C
+}
+
+// startsSmall reports whether a new or cleared list keeps its entries in
+// small list storage: not once quotas, weights, admission, pins or leases,
+// which slice storage does not enforce, have been set up
+func (sl *ZeroCopySkiplist[T, K, C]) startsSmall() bool {
+	return sl.opts.smallThreshold > 0 && !sl.opts.flushExactlyOnce &&
+		len(sl.quotas) == 0 && sl.weigher == nil && sl.weightLimit <= 0 &&
+		sl.admitFilter == nil && sl.sketch == nil && len(sl.pins) == 0 && len(sl.leases) == 0
+}
+
+// smallIndex returns the position of key in the small list storage and
+// whether it is there; the caller must hold the lock
+func (sl *ZeroCopySkiplist[T, K, C]) smallIndex(key K) (int, bool) {
+	return slices.BinarySearchFunc(sl.small, key, func(e smallEntry[T, K, C], key K) int {
+		return sl.cmpKey(e.key, key)
+	})
+}
+
+// smallResult returns entry i of the small list storage as handed to
+// callers, a detached copy; the caller must hold the lock
+func (sl *ZeroCopySkiplist[T, K, C]) smallResult(i int) *ItemPtr[T, K, C] {
+	e := &sl.small[i]
+	return &ItemPtr[T, K, C]{item: e.item, key: e.key, context: e.context, level: unlinkedLevel}
+}
+
+// insertSmall performs insert on a list in small list storage, reporting in
+// full, without inserting, that key is new and the slice is at the
+// threshold; the caller must hold the write lock
+func (sl *ZeroCopySkiplist[T, K, C]) insertSmall(item *T, context C) (inserted, full bool) {
+	key := sl.getKeyFromItem(item)
+	i, found := sl.smallIndex(key)
+	if !found {
+		if len(sl.small) >= sl.opts.smallThreshold {
+			return false, true
+		}
+		sl.addSmall(i, item, key, context)
+		return true, false
+	}
+
+	e := &sl.small[i]
+	size, old := sl.getItemSize(item), sl.getItemSize(e.item)
+	sl.chargeContext(e.context, int64(old), -1, false)
+	sl.chargeContext(context, int64(size), 1, true)
+	sl.totalBytes += int64(size - old)
+	sl.version++
+	e.item, e.context = item, context
+	sl.countChange(size)
+	return false, false
+}
+
+// addSmall inserts a new entry at position i of the small list storage with
+// the bookkeeping insertAt does for a node; the caller must hold the write
+// lock
+func (sl *ZeroCopySkiplist[T, K, C]) addSmall(i int, item *T, key K, context C) {
+	size := sl.getItemSize(item)
+	sl.small = slices.Insert(sl.small, i, smallEntry[T, K, C]{key: key, item: item, context: context})
+	sl.generation++
+	sl.version++
+	sl.length++
+	sl.totalBytes += int64(size)
+	sl.chargeContext(context, int64(size), 1, true)
+	sl.countChange(size)
+}
+
+// deleteSmall performs delete on a list in small list storage; the caller
+// must hold the write lock
+func (sl *ZeroCopySkiplist[T, K, C]) deleteSmall(key K) bool {
+	i, found := sl.smallIndex(key)
+	if !found {
+		return false
+	}
+	e := sl.small[i]
+	size := sl.getItemSize(e.item)
+	sl.small = slices.Delete(sl.small, i, i+1)
+	sl.generation++
+	sl.version++
+	sl.length--
+	sl.totalBytes -= int64(size)
+	sl.chargeContext(e.context, int64(size), -1, false)
+	return true
+}
+
+// updateSmall performs updateContext on a list in small list storage; the
+// caller must hold the write lock
+func (sl *ZeroCopySkiplist[T, K, C]) updateSmall(key K, context C) bool {
+	i, found := sl.smallIndex(key)
+	if !found {
+		return false
+	}
+	e := &sl.small[i]
+	size := sl.getItemSize(e.item)
+	sl.chargeContext(e.context, int64(size), -1, false)
+	sl.chargeContext(context, int64(size), 1, false)
+	sl.version++
+	e.context = context
+	sl.countChange(size)
+	return true
+}
+
+// materialize moves a list out of small list storage, building a node for
+// every entry with levels balanced as Rebalance does; the entries are
+// already accounted for. The caller must hold the write lock.
+func (sl *ZeroCopySkiplist[T, K, C]) materialize() {
+	small := sl.small
+	sl.flat, sl.small = false, nil
+	for _, e := range small {
+		sl.linkTail(e.item, e.key, e.context, 0)
+	}
+	sl.rebalance()
+}
@@ -0,0 +1,194 @@
+package zerocopyskiplist
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestSmallListThreshold(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt, WithSmallListThreshold(8))
+	items := createTestItems(100)
+	for _, item := range items[:8] {
+		skiplist.Insert(item, TestContext{})
+	}
+	if !skiplist.flat || len(skiplist.small) != 8 || skiplist.header.forward[0] != nil {
+		t.Fatal("A small list should hold its entries in the slice, with no nodes")
+	}
+	if ptr, _ := skiplist.Find(5); ptr == nil || ptr.Item() != items[4] || !ptr.Detached() {
+		t.Error("Find in a small list should return a detached copy")
+	}
+	if skiplist.Insert(&TestItem{ID: 5}, TestContext{AccessCount: 5}) || skiplist.Length() != 8 {
+		t.Error("Replacing an entry should not add one")
+	}
+	skiplist.Insert(items[4], TestContext{})
+	if !skiplist.UpdateContext(6, TestContext{AccessCount: 6}) || !skiplist.Delete(7) || skiplist.Delete(7) {
+		t.Error("Updates and deletes should reach the slice")
+	}
+	if entry, ok := skiplist.Get(6); !ok || entry.Context.AccessCount != 6 {
+		t.Error("Get in a small list failed")
+	}
+	skiplist.Insert(items[6], TestContext{})
+	want := 1
+	skiplist.ForEach(func(ptr *ItemPtr[TestItem, int, TestContext]) bool {
+		if ptr.Key() != want {
+			t.Errorf("ForEach yielded key %d, want %d", ptr.Key(), want)
+		}
+		want++
+		return true
+	})
+	if want != 9 || !skiplist.flat {
+		t.Error("ForEach should walk the slice without building nodes")
+	}
+	if copied := skiplist.Copy(); !copied.flat || copied.Length() != 8 || !skiplist.flat {
+		t.Error("A copy of a small list should be small too")
+	}
+	if first, last := skiplist.First(), skiplist.Last(); first.Key() != 1 || last.Key() != 8 || !first.Detached() {
+		t.Error("First and Last of a small list should be detached copies of its ends")
+	}
+	if lo, hi, ok := skiplist.Bounds(); !ok || lo != 1 || hi != 8 {
+		t.Errorf("Bounds of a small list = %d, %d, %v", lo, hi, ok)
+	}
+	if stats := skiplist.Stats(); stats.Length != 8 || stats.TotalItemBytes != skiplist.TotalItemBytes() {
+		t.Errorf("Stats of a small list: %+v", stats)
+	}
+	if err := skiplist.Validate(); err != nil || !skiplist.flat {
+		t.Errorf("Reads should leave a small list in the slice: %v", err)
+	}
+
+	// The ninth entry builds the nodes
+	skiplist.Insert(items[8], TestContext{})
+	if skiplist.flat || skiplist.small != nil || skiplist.level == 0 {
+		t.Error("Growing past the threshold should build balanced nodes")
+	}
+	for _, item := range items[9:] {
+		skiplist.Insert(item, TestContext{})
+	}
+	for _, item := range items {
+		if ptr, _ := skiplist.Find(item.ID); ptr == nil || ptr.Item() != item {
+			t.Fatalf("Find(%d) failed after leaving the slice", item.ID)
+		}
+	}
+	if err := skiplist.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+	if stats := skiplist.Stats(); stats.Length != 100 || stats.TotalItemBytes != int64(100*getTestItemSize(items[0])) {
+		t.Errorf("Stats = %d entries of %d bytes after leaving the slice", stats.Length, stats.TotalItemBytes)
+	}
+
+	for _, item := range items[2:] {
+		skiplist.Delete(item.ID)
+	}
+	if skiplist.flat {
+		t.Error("Shrinking should not return the list to the slice")
+	}
+	skiplist.Clear()
+	if !skiplist.flat {
+		t.Error("Clear should return the list to the slice")
+	}
+}
+
+func TestSmallListBuildsNodes(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt, WithSmallListThreshold(8))
+	for _, item := range createTestItems(4) {
+		skiplist.Insert(item, TestContext{AccessCount: item.ID})
+	}
+	if !skiplist.flat {
+		t.Fatal("Expected the list in the slice")
+	}
+
+	// Operations that need nodes build them first
+	if c := skiplist.SeekGE(1); !c.Valid() || c.Key() != 1 || !c.Next() || skiplist.flat {
+		t.Fatal("SeekGE should build the nodes of a small list")
+	}
+	if err := skiplist.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+	nodes := 0
+	for _, n := range skiplist.Stats().LevelCounts {
+		nodes += n
+	}
+	if nodes != 4 {
+		t.Errorf("Expected 4 nodes after building them, got %d", nodes)
+	}
+}
+
+func TestSmallListClearKeepsLimits(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt, WithSmallListThreshold(8))
+	skiplist.SetContextQuota(TestContext{}, 2, 0, QuotaReject)
+	skiplist.SetWeigher(func(*TestItem, TestContext) int64 { return 1 })
+	skiplist.Clear()
+	if skiplist.flat {
+		t.Fatal("Clear should not return a list with quotas to the slice")
+	}
+
+	inserted := 0
+	for _, item := range createTestItems(5) {
+		if skiplist.Insert(item, TestContext{}) {
+			inserted++
+		}
+	}
+	if inserted != 2 || skiplist.Length() != 2 {
+		t.Errorf("A quota of 2 admitted %d entries after Clear", inserted)
+	}
+	skiplist.Delete(1)
+	if entries, _, _ := skiplist.ContextUsage(TestContext{}); entries != 1 || skiplist.TotalWeight() != 1 {
+		t.Errorf("Usage after Delete = %d entries of weight %d, want 1 of 1", entries, skiplist.TotalWeight())
+	}
+}
+
+func TestSmallListConcurrent(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt, WithSmallListThreshold(64))
+	items := createTestItems(200)
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(items); i += 4 {
+				skiplist.Insert(items[i], TestContext{})
+				skiplist.Find(items[i/2].ID)
+				if i%16 == 0 {
+					skiplist.Bounds()
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	if skiplist.Length() != len(items) {
+		t.Errorf("Length = %d, want %d", skiplist.Length(), len(items))
+	}
+	if err := skiplist.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+// BenchmarkSmallLists builds many tiny lists, the per-session workload
+// WithSmallListThreshold targets, and reports the heap each one retains
+func BenchmarkSmallLists(b *testing.B) {
+	items := createTestItems(8)
+	for _, threshold := range []int{0, 16} {
+		b.Run(fmt.Sprintf("threshold=%d", threshold), func(b *testing.B) {
+			lists := make([]*ZeroCopySkiplist[TestItem, int, TestContext], 1000)
+			var retained uint64
+			for b.Loop() {
+				var before, after runtime.MemStats
+				clear(lists)
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+				for i := range lists {
+					lists[i] = MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt, WithSmallListThreshold(threshold))
+					for _, item := range items {
+						lists[i].Insert(item, TestContext{})
+					}
+				}
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				retained += after.HeapAlloc - before.HeapAlloc
+			}
+			b.ReportMetric(float64(retained)/float64(b.N*len(lists)), "B/list")
+			runtime.KeepAlive(lists)
+		})
+	}
+}
@@ -0,0 +1,273 @@
+// snapshot.go - Versioned snapshots of a skiplist through an ItemCodec
+
+package zerocopyskiplist
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"slices"
+)
+
+// ErrSnapshotCorrupt is returned when a snapshot is truncated or fails its checksum
+var ErrSnapshotCorrupt = errors.New("snapshot is corrupt")
+
+// persistMagic starts every persisted header
+var persistMagic = [4]byte{'Z', 'C', 'S', 'L'}
+
+// persistFormat is the version of the container layout, independent of the
+// application's schema version. Format 2 added the collation; format 1 data
+// is still read, as written under no collation.
+const persistFormat = 2
+
+// Persisted data kinds
+const (
+	persistSnapshot = 1
+	persistDelta    = 2 // BackupAgent deltas
+	persistIndex    = 3 // SaveIndex topologies
+)
+
+// persistHeaderSize is the encoded size of the fixed part of persistHeader
+const persistHeaderSize = 20
+
+// persistHeader starts every persisted file or stream, little-endian:
+// magic [4]byte, format uint16, kind uint16, schema uint32, count uint64,
+// then from format 2 the collation as a uint16 length and its bytes
+type persistHeader struct {
+	format    uint16
+	kind      uint16
+	schema    uint32
+	count     uint64
+	collation string
+}
+
+// crcTable is the CRC-32C table used for persisted checksums
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// appendTo appends the encoded header to dst
+func (h persistHeader) appendTo(dst []byte) []byte {
+	dst = append(dst, persistMagic[:]...)
+	dst = binary.LittleEndian.AppendUint16(dst, persistFormat)
+	dst = binary.LittleEndian.AppendUint16(dst, h.kind)
+	dst = binary.LittleEndian.AppendUint32(dst, h.schema)
+	dst = binary.LittleEndian.AppendUint64(dst, h.count)
+	dst = binary.LittleEndian.AppendUint16(dst, uint16(len(h.collation)))
+	return append(dst, h.collation...)
+}
+
+// size returns the encoded size of the header as parsed
+func (h persistHeader) size() int {
+	if h.format < 2 {
+		return persistHeaderSize
+	}
+	return persistHeaderSize + 2 + len(h.collation)
+}
+
+// parsePersistHeader decodes a header of the given kind from the start of buf
+func parsePersistHeader(buf []byte, kind uint16) (persistHeader, error) {
+	if len(buf) < persistHeaderSize || [4]byte(buf[:4]) != persistMagic {
+		return persistHeader{}, fmt.Errorf("%w: bad magic", ErrSnapshotCorrupt)
+	}
+	h := persistHeader{
+		format: binary.LittleEndian.Uint16(buf[4:]),
+		kind:   binary.LittleEndian.Uint16(buf[6:]),
+		schema: binary.LittleEndian.Uint32(buf[8:]),
+		count:  binary.LittleEndian.Uint64(buf[12:]),
+	}
+	if h.format < 1 || h.format > persistFormat {
+		return persistHeader{}, fmt.Errorf("unsupported persisted format %d", h.format)
+	}
+	if h.kind != kind {
+		return persistHeader{}, fmt.Errorf("persisted data is of kind %d, expected %d", h.kind, kind)
+	}
+	if h.format >= 2 {
+		rest := buf[persistHeaderSize:]
+		if len(rest) < 2 || len(rest)-2 < int(binary.LittleEndian.Uint16(rest)) {
+			return persistHeader{}, fmt.Errorf("%w: truncated header", ErrSnapshotCorrupt)
+		}
+		h.collation = string(rest[2 : 2+binary.LittleEndian.Uint16(rest)])
+	}
+	return h, nil
+}
+
+// readPersistHeader reads a header of the given kind from r
+func readPersistHeader(r io.Reader, kind uint16) (persistHeader, error) {
+	buf := make([]byte, persistHeaderSize, persistHeaderSize+2)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return persistHeader{}, fmt.Errorf("%w: reading header: %v", ErrSnapshotCorrupt, err)
+	}
+	if binary.LittleEndian.Uint16(buf[4:]) < 2 {
+		return parsePersistHeader(buf, kind)
+	}
+
+	buf = buf[:persistHeaderSize+2]
+	if _, err := io.ReadFull(r, buf[persistHeaderSize:]); err != nil {
+		return persistHeader{}, fmt.Errorf("%w: reading header: %v", ErrSnapshotCorrupt, err)
+	}
+	buf = append(buf, make([]byte, binary.LittleEndian.Uint16(buf[persistHeaderSize:]))...)
+	if _, err := io.ReadFull(r, buf[persistHeaderSize+2:]); err != nil {
+		return persistHeader{}, fmt.Errorf("%w: reading header: %v", ErrSnapshotCorrupt, err)
+	}
+	return parsePersistHeader(buf, kind)
+}
+
+// readChunkSize caps how far readSized grows its buffer ahead of the data
+const readChunkSize = 64 << 10
+
+// readSized reads a record of size bytes from r into buf, reallocating it if
+// it is too small. A size from an unverified length prefix is not trusted for
+// the allocation: beyond readChunkSize the buffer grows as the data arrives,
+// so a corrupt prefix fails at the end of the input instead of allocating up
+// to 4 GiB first. A record cut short returns io.ErrUnexpectedEOF.
+func readSized(r io.Reader, buf []byte, size uint32) ([]byte, error) {
+	if uint64(size) <= uint64(max(cap(buf), readChunkSize)) {
+		if cap(buf) < int(size) {
+			buf = make([]byte, size)
+		}
+		buf = buf[:size]
+		_, err := io.ReadFull(r, buf)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return buf, err
+	}
+
+	buf = buf[:0]
+	for remaining := int(size); remaining > 0; {
+		chunk := min(remaining, readChunkSize)
+		buf = slices.Grow(buf, chunk)
+		n, err := io.ReadFull(r, buf[len(buf):len(buf)+chunk])
+		buf = buf[:len(buf)+n]
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return buf, err
+		}
+		remaining -= n
+	}
+	return buf, nil
+}
+
+// SaveSnapshot writes every item and context to w in key order, encoded with
+// codec behind a header recording codec.SchemaVersion(). Each record is a
+// little-endian uint32 length followed by the encoding, and the snapshot ends
+// with a CRC-32C of everything before it.
+//
+// The read lock is held for the whole save so the snapshot is consistent;
+// writers block until it completes, so w should be buffered.
+func (sl *ZeroCopySkiplist[T, K, C]) SaveSnapshot(w io.Writer, codec ItemCodec[T, C]) error {
+	sl.rlock()
+	defer sl.runlock()
+
+	crc := crc32.New(crcTable)
+	out := io.MultiWriter(w, crc)
+
+	header := persistHeader{
+		kind:      persistSnapshot,
+		schema:    uint32(codec.SchemaVersion()),
+		count:     uint64(sl.length),
+		collation: sl.opts.collation,
+	}
+	buf := header.appendTo(make([]byte, 0, 256))
+	if _, err := out.Write(buf); err != nil {
+		return err
+	}
+
+	for current := sl.header.forward[0]; current != nil; current = current.forward[0] {
+		var err error
+		buf, err = appendRecord(buf[:0], codec, current.item, current.context)
+		if err != nil {
+			return fmt.Errorf("encoding key %v: %w", current.key, err)
+		}
+		if _, err := out.Write(buf); err != nil {
+			return err
+		}
+	}
+
+	_, err := w.Write(binary.LittleEndian.AppendUint32(buf[:0], crc.Sum32()))
+	return err
+}
+
+// LoadSnapshot reads a snapshot written by SaveSnapshot and inserts its items,
+// replacing any existing items with the same keys. Records written under a
+// different schema version are passed to the codec's Migrate method, and fail
+// with a *SchemaVersionError if the codec is not a Migrator.
+//
+// The whole snapshot is decoded and its checksum verified before the list is
+// modified, so a corrupt or unreadable snapshot leaves the list unchanged.
+// Records refused by a context quota or the admission filter are skipped and
+// the rest loaded, and the error returned wraps ErrRefused.
+func (sl *ZeroCopySkiplist[T, K, C]) LoadSnapshot(r io.Reader, codec ItemCodec[T, C]) error {
+	crc := crc32.New(crcTable)
+	in := io.TeeReader(bufio.NewReader(r), crc)
+
+	header, err := readPersistHeader(in, persistSnapshot)
+	if err != nil {
+		return err
+	}
+	if err := sl.checkCollation(header.collation); err != nil {
+		return err
+	}
+
+	buf := make([]byte, 256)
+
+	type entry struct {
+		item    *T
+		context C
+	}
+	var entries []entry
+	for i := uint64(0); i < header.count; i++ {
+		if _, err := io.ReadFull(in, buf[:4]); err != nil {
+			return fmt.Errorf("%w: reading record %d: %v", ErrSnapshotCorrupt, i, err)
+		}
+		if buf, err = readSized(in, buf, binary.LittleEndian.Uint32(buf)); err != nil {
+			return fmt.Errorf("%w: reading record %d: %v", ErrSnapshotCorrupt, i, err)
+		}
+
+		item, context, err := DecodeVersion(codec, int(header.schema), buf)
+		if err != nil {
+			return fmt.Errorf("decoding record %d: %w", i, err)
+		}
+		entries = append(entries, entry{item, context})
+	}
+
+	sum := crc.Sum32()
+	if _, err := io.ReadFull(in, buf[:4]); err != nil {
+		return fmt.Errorf("%w: reading checksum: %v", ErrSnapshotCorrupt, err)
+	}
+	if binary.LittleEndian.Uint32(buf) != sum {
+		return fmt.Errorf("%w: checksum mismatch", ErrSnapshotCorrupt)
+	}
+
+	var frozen bool
+	refused := 0
+	hook, totalBytes, length := func() (func(int64, int), int64, int) {
+		sl.lock()
+		defer sl.unlock()
+		if sl.frozen {
+			frozen = true
+			return nil, 0, 0
+		}
+		for _, e := range entries {
+			if _, r := sl.tryInsert(e.item, e.context); r {
+				refused++
+			}
+		}
+		return sl.checkPressure()
+	}()
+
+	if hook != nil {
+		hook(totalBytes, length)
+	}
+	if frozen {
+		return ErrFrozen
+	}
+	if refused > 0 {
+		return fmt.Errorf("%w: %d of %d records", ErrRefused, refused, len(entries))
+	}
+	return nil
+}
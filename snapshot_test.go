@@ -0,0 +1,215 @@
+package zerocopyskiplist
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"testing"
+)
+
+// testItemCodecV1 encodes ID and Value only
+type testItemCodecV1 struct{}
+
+func (testItemCodecV1) SchemaVersion() int { return 1 }
+
+func (testItemCodecV1) Encode(dst []byte, item *TestItem, context TestContext) ([]byte, error) {
+	dst = binary.LittleEndian.AppendUint64(dst, uint64(item.ID))
+	return append(dst, item.Value...), nil
+}
+
+func (testItemCodecV1) Decode(raw []byte) (*TestItem, TestContext, error) {
+	if len(raw) < 8 {
+		return nil, TestContext{}, fmt.Errorf("short v1 record of %d bytes", len(raw))
+	}
+	return &TestItem{ID: int(binary.LittleEndian.Uint64(raw)), Value: string(raw[8:])}, TestContext{}, nil
+}
+
+// testItemCodecV2 adds the context's AccessCount and migrates v1 records
+type testItemCodecV2 struct{}
+
+func (testItemCodecV2) SchemaVersion() int { return 2 }
+
+func (testItemCodecV2) Encode(dst []byte, item *TestItem, context TestContext) ([]byte, error) {
+	dst = binary.LittleEndian.AppendUint64(dst, uint64(item.ID))
+	dst = binary.LittleEndian.AppendUint32(dst, uint32(context.AccessCount))
+	return append(dst, item.Value...), nil
+}
+
+func (testItemCodecV2) Decode(raw []byte) (*TestItem, TestContext, error) {
+	if len(raw) < 12 {
+		return nil, TestContext{}, fmt.Errorf("short v2 record of %d bytes", len(raw))
+	}
+	item := &TestItem{ID: int(binary.LittleEndian.Uint64(raw)), Value: string(raw[12:])}
+	return item, TestContext{AccessCount: int(binary.LittleEndian.Uint32(raw[8:]))}, nil
+}
+
+func (c testItemCodecV2) Migrate(fromVersion int, raw []byte) (*TestItem, TestContext, error) {
+	if fromVersion != 1 {
+		return nil, TestContext{}, fmt.Errorf("cannot migrate from version %d", fromVersion)
+	}
+	item, _, err := testItemCodecV1{}.Decode(raw)
+	return item, TestContext{AccessCount: -1}, err
+}
+
+func newSnapshotTestList() *ZeroCopySkiplist[TestItem, int, TestContext] {
+	return MakeZeroCopySkiplist[TestItem, int, TestContext](
+		8,
+		getKeyFromTestItem,
+		getTestItemSize,
+		compareInt,
+	)
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	skiplist := newSnapshotTestList()
+	for i, item := range createTestItems(500) {
+		skiplist.Insert(item, TestContext{AccessCount: i})
+	}
+
+	var buf bytes.Buffer
+	if err := skiplist.SaveSnapshot(&buf, testItemCodecV2{}); err != nil {
+		t.Fatalf("SaveSnapshot: %v", err)
+	}
+
+	loaded := newSnapshotTestList()
+	if err := loaded.LoadSnapshot(&buf, testItemCodecV2{}); err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	if loaded.Length() != 500 {
+		t.Fatalf("Expected 500 loaded items, got %d", loaded.Length())
+	}
+	for i := 1; i <= 500; i++ {
+		found, ctx := loaded.Find(i)
+		if found == nil || found.Item().Value != fmt.Sprintf("value_%d", i) || ctx.AccessCount != i-1 {
+			t.Errorf("Wrong entry for key %d after load", i)
+		}
+	}
+	if err := loaded.Validate(); err != nil {
+		t.Errorf("Validate after load: %v", err)
+	}
+}
+
+func TestSnapshotLoadRefused(t *testing.T) {
+	skiplist := newSnapshotTestList()
+	for _, item := range createTestItems(5) {
+		skiplist.Insert(item, TestContext{})
+	}
+	var buf bytes.Buffer
+	if err := skiplist.SaveSnapshot(&buf, testItemCodecV2{}); err != nil {
+		t.Fatalf("SaveSnapshot: %v", err)
+	}
+
+	loaded := newSnapshotTestList()
+	loaded.SetContextQuota(TestContext{}, 3, 0, QuotaReject)
+	if err := loaded.LoadSnapshot(&buf, testItemCodecV2{}); !errors.Is(err, ErrRefused) {
+		t.Fatalf("Expected ErrRefused, got %v", err)
+	}
+	if loaded.Length() != 3 || loaded.Stats().Refused != 2 {
+		t.Errorf("Expected 3 loaded and 2 refused, got %d and %d", loaded.Length(), loaded.Stats().Refused)
+	}
+}
+
+func TestSnapshotMigration(t *testing.T) {
+	skiplist := newSnapshotTestList()
+	for _, item := range createTestItems(20) {
+		skiplist.Insert(item, TestContext{AccessCount: 5})
+	}
+
+	var buf bytes.Buffer
+	if err := skiplist.SaveSnapshot(&buf, testItemCodecV1{}); err != nil {
+		t.Fatalf("SaveSnapshot: %v", err)
+	}
+	v1 := buf.Bytes()
+
+	// A codec without Migrate cannot read another version
+	loaded := newSnapshotTestList()
+	err := loaded.LoadSnapshot(bytes.NewReader(v1), struct {
+		ItemCodec[TestItem, TestContext]
+	}{testItemCodecV2{}})
+	var versionErr *SchemaVersionError
+	if !errors.As(err, &versionErr) || versionErr.Persisted != 1 || versionErr.Current != 2 {
+		t.Fatalf("Expected SchemaVersionError 1 -> 2, got %v", err)
+	}
+	if !loaded.IsEmpty() {
+		t.Error("Failed load should leave the list unchanged")
+	}
+
+	if err := loaded.LoadSnapshot(bytes.NewReader(v1), testItemCodecV2{}); err != nil {
+		t.Fatalf("LoadSnapshot with migration: %v", err)
+	}
+	found, ctx := loaded.Find(7)
+	if loaded.Length() != 20 || found == nil || found.Item().Value != "value_7" || ctx.AccessCount != -1 {
+		t.Errorf("Migrated snapshot has wrong contents")
+	}
+}
+
+func TestSnapshotCorruption(t *testing.T) {
+	skiplist := newSnapshotTestList()
+	for _, item := range createTestItems(20) {
+		skiplist.Insert(item, TestContext{})
+	}
+
+	var buf bytes.Buffer
+	if err := skiplist.SaveSnapshot(&buf, testItemCodecV2{}); err != nil {
+		t.Fatalf("SaveSnapshot: %v", err)
+	}
+	good := buf.Bytes()
+
+	flipped := bytes.Clone(good)
+	flipped[len(flipped)/2] ^= 0x40
+	for name, data := range map[string][]byte{
+		"flipped":   flipped,
+		"truncated": good[:len(good)-3],
+		"empty":     nil,
+	} {
+		loaded := newSnapshotTestList()
+		if err := loaded.LoadSnapshot(bytes.NewReader(data), testItemCodecV2{}); !errors.Is(err, ErrSnapshotCorrupt) {
+			t.Errorf("%s: expected ErrSnapshotCorrupt, got %v", name, err)
+		}
+		if !loaded.IsEmpty() {
+			t.Errorf("%s: failed load should leave the list unchanged", name)
+		}
+	}
+}
+
+func TestSnapshotCorruptLength(t *testing.T) {
+	var empty, buf bytes.Buffer
+	if err := newSnapshotTestList().SaveSnapshot(&empty, testItemCodecV2{}); err != nil {
+		t.Fatalf("SaveSnapshot: %v", err)
+	}
+	skiplist := newSnapshotTestList()
+	for _, item := range createTestItems(20) {
+		skiplist.Insert(item, TestContext{})
+	}
+	if err := skiplist.SaveSnapshot(&buf, testItemCodecV2{}); err != nil {
+		t.Fatalf("SaveSnapshot: %v", err)
+	}
+
+	// The first record's length prefix follows the header, which is all of an
+	// empty snapshot but its checksum
+	data := buf.Bytes()
+	binary.LittleEndian.PutUint32(data[empty.Len()-4:], 0xfffffff0)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	err := newSnapshotTestList().LoadSnapshot(bytes.NewReader(data), testItemCodecV2{})
+	runtime.ReadMemStats(&after)
+	if !errors.Is(err, ErrSnapshotCorrupt) {
+		t.Errorf("Expected ErrSnapshotCorrupt, got %v", err)
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Errorf("A corrupt length should not be allocated up front, allocated %d bytes", allocated)
+	}
+
+	// Records past the chunk size still read whole
+	record := bytes.Repeat([]byte{7}, 3*readChunkSize+5)
+	got, err := readSized(bytes.NewReader(record), nil, uint32(len(record)))
+	if err != nil || !bytes.Equal(got, record) {
+		t.Errorf("readSized of %d bytes: got %d bytes, %v", len(record), len(got), err)
+	}
+	if _, err := readSized(bytes.NewReader(record), nil, uint32(len(record)+1)); err != io.ErrUnexpectedEOF {
+		t.Errorf("A short record should fail with io.ErrUnexpectedEOF, got %v", err)
+	}
+}
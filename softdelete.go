@@ -0,0 +1,111 @@
+// softdelete.go - Deletes that can be undone for a grace period
+
+package zerocopyskiplist
+
+import (
+	"context"
+	"time"
+)
+
+// tombstone is a soft-deleted entry awaiting Restore or its sweep
+type tombstone[T any, C comparable] struct {
+	item    *T
+	context C
+	expires time.Time
+}
+
+// SoftDelete removes the entry for key from the list, so Find, iteration and
+// flushes no longer see it, but keeps its item and context for ttl so Restore
+// can bring it back. It returns false if the key is not in the list. Expired
+// entries are dropped by SweepSoftDeleted; until then they hold their items
+// in memory. Inserting the key again discards its soft-deleted entry.
+func (sl *ZeroCopySkiplist[T, K, C]) SoftDelete(key K, ttl time.Duration) bool {
+	key = sl.normalize(key)
+	var found bool
+	hook, totalBytes, entries := func() (func(int64, int), int64, int) {
+		sl.lock()
+		defer sl.unlock()
+		if sl.frozen {
+			return nil, 0, 0
+		}
+
+		update := make([]*ItemPtr[T, K, C], sl.maxLevel+1)
+		node := sl.descend(key, -1, update).forward[0]
+		found = node != nil && sl.cmpKey(node.key, key) == 0
+		if found {
+			if sl.graveyard == nil {
+				sl.graveyard = make(map[K]tombstone[T, C])
+			}
+			// The item stays referenced by the tombstone, so a lease on the
+			// entry does not need to defer the unlink
+			sl.graveyard[node.key] = tombstone[T, C]{item: node.item, context: node.context, expires: sl.Clock().Now().Add(ttl)}
+			sl.unlink(node, update)
+		}
+		return sl.checkPressure()
+	}()
+
+	if hook != nil {
+		hook(totalBytes, entries)
+	}
+	return found
+}
+
+// Restore reinserts the entry soft-deleted for key and returns true, or false
+// if there is none, its grace period has passed or a quota or the admission
+// filter refuses it. A refused entry stays soft-deleted until it expires, so
+// Restore can be tried again once there is room.
+func (sl *ZeroCopySkiplist[T, K, C]) Restore(key K) bool {
+	key = sl.normalize(key)
+	var restored bool
+	hook, totalBytes, entries := func() (func(int64, int), int64, int) {
+		sl.lock()
+		defer sl.unlock()
+		stone, ok := sl.graveyard[key]
+		restored = ok && !sl.frozen && sl.Clock().Now().Before(stone.expires)
+		if restored {
+			// The key is not in the list, so insert only fails if it refuses
+			// the entry; inserting drops the tombstone
+			restored = sl.insert(stone.item, stone.context)
+		}
+		return sl.checkPressure()
+	}()
+
+	if hook != nil {
+		hook(totalBytes, entries)
+	}
+	return restored
+}
+
+// SweepSoftDeleted drops the soft-deleted entries whose grace period has
+// passed and returns how many were dropped
+func (sl *ZeroCopySkiplist[T, K, C]) SweepSoftDeleted() int {
+	sl.lock()
+	defer sl.unlock()
+
+	now := sl.Clock().Now()
+	swept := 0
+	for key, stone := range sl.graveyard {
+		if !now.Before(stone.expires) {
+			delete(sl.graveyard, key)
+			swept++
+		}
+	}
+	return swept
+}
+
+// SoftDeleteSweeper returns a background task for Go that calls
+// SweepSoftDeleted every interval until the list is closed
+func (sl *ZeroCopySkiplist[T, K, C]) SoftDeleteSweeper(interval time.Duration) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		ticker := sl.Clock().NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C():
+				sl.SweepSoftDeleted()
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package zerocopyskiplist
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSoftDeleteRestore(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt)
+	items := createTestItems(5)
+	for _, item := range items {
+		skiplist.Insert(item, TestContext{AccessCount: item.ID})
+	}
+
+	if skiplist.SoftDelete(42, time.Hour) {
+		t.Error("SoftDelete of a missing key should return false")
+	}
+	if !skiplist.SoftDelete(3, time.Hour) || !skiplist.SoftDelete(4, -time.Second) {
+		t.Fatal("SoftDelete of present keys should return true")
+	}
+	if skiplist.FindItem(3) != nil || skiplist.Length() != 3 || len(skiplist.ToIovecSlice(TestContext{})) != 3 {
+		t.Error("Soft-deleted entries should be hidden from lookups and scans")
+	}
+	if stats := skiplist.Stats(); stats.SoftDeleted != 2 {
+		t.Errorf("SoftDeleted = %d, want 2", stats.SoftDeleted)
+	}
+
+	if skiplist.Restore(4) {
+		t.Error("Restore after the grace period should fail")
+	}
+	if !skiplist.Restore(3) || skiplist.Restore(3) {
+		t.Error("Restore within the grace period should succeed once")
+	}
+	if item, context := skiplist.Find(3); item == nil || item.Item() != items[2] || context.AccessCount != 3 {
+		t.Error("Restore should bring back the original item and context")
+	}
+
+	if swept := skiplist.SweepSoftDeleted(); swept != 1 {
+		t.Errorf("Swept %d entries, want 1", swept)
+	}
+
+	// Inserting the key again discards the soft-deleted entry
+	skiplist.SoftDelete(5, time.Hour)
+	skiplist.Insert(&TestItem{ID: 5}, TestContext{})
+	skiplist.Delete(5)
+	if skiplist.Restore(5) || skiplist.Stats().SoftDeleted != 0 {
+		t.Error("A reinserted key should not be restorable")
+	}
+	if err := skiplist.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+func TestSoftDeleteRestoreRefused(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt)
+	full := TestContext{AccessCount: 1}
+	skiplist.SetContextQuota(full, 1, 0, QuotaReject)
+	items := createTestItems(2)
+	skiplist.Insert(items[0], full)
+	skiplist.SoftDelete(1, time.Hour)
+	skiplist.Insert(items[1], full)
+
+	if skiplist.Restore(1) {
+		t.Error("Restore refused by the quota should return false")
+	}
+	if skiplist.Stats().SoftDeleted != 1 || skiplist.Stats().Refused != 1 {
+		t.Error("A refused restore should keep the entry soft-deleted and count the refusal")
+	}
+	skiplist.Delete(2)
+	if !skiplist.Restore(1) || skiplist.FindItem(1) == nil {
+		t.Error("Restore should succeed once the quota has room")
+	}
+}
+
+func TestSoftDeleteSweeper(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt)
+	for _, item := range createTestItems(3) {
+		skiplist.Insert(item, TestContext{})
+	}
+	skiplist.SoftDelete(1, time.Millisecond)
+	if err := skiplist.Go(skiplist.SoftDeleteSweeper(time.Millisecond)); err != nil {
+		t.Fatalf("Go: %v", err)
+	}
+	defer skiplist.Close(t.Context())
+
+	deadline := time.Now().Add(5 * time.Second)
+	for skiplist.Stats().SoftDeleted != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("The sweeper should drop the expired entry")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
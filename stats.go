@@ -0,0 +1,206 @@
+// stats.go - Structural statistics and lifecycle accounting
+
+package zerocopyskiplist
+
+import (
+	"runtime"
+	"sort"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// Stats is a point-in-time summary of a skiplist
+type Stats struct {
+	Length         int
+	Level          int
+	MaxLevel       int
+	TotalItemBytes int64
+	IovMax         int   // iovecs per chunk from IovecChunks
+	LevelCounts    []int // LevelCounts[i] is the number of nodes of level i
+
+	// Link memory estimates, see LinkBytes and UnusedLevelBytes. They count
+	// the forward pointers, backlinks and inline keys, not the nodes' fixed
+	// fields or the items.
+	LevelBytes []int64 // LevelBytes[i] is the link memory of the nodes of level i
+	HeadBytes  int64   // links sized for MaxLevel: the head node's and the tail pointers
+
+	NodesAllocated uint64 // nodes created by inserts over the list's lifetime
+	NodesReleased  uint64 // nodes unlinked by deletes and shedding
+	NodesRecycled  uint64 // node allocations served from the WithNodePool pool
+
+	PathCacheHits   uint64 // searches served by WithPathCache
+	PathCacheMisses uint64 // searches that descended and filled the path cache
+
+	WriteLocks        uint64        // write lock acquisitions, only WithContentionTracking
+	WriteLockWaits    uint64        // acquisitions that found the lock held
+	WriteLockWaitTime time.Duration // total time spent waiting in those
+
+	Latency []LatencySnapshot // indexed by Operation, only WithLatencyHistograms
+
+	SlowLockHolds  uint64 // write locks reported by WithSlowLockWatchdog
+	PinnedEntries  int    // entries protected from eviction by Pin
+	LeasedEntries  int    // entries held by unreleased leases
+	DeferredWrites int    // deletes and replacements waiting for a lease release
+	SoftDeleted    int    // entries removed by SoftDelete and not yet restored or swept
+	Misuses        uint64 // misuse detected over the list's lifetime, see WithMisusePolicy
+	Refused        uint64 // inserts refused by a quota or admission filter over the list's lifetime
+
+	// Write amplification inputs, see WriteAmplification. Inserts,
+	// replacements and context updates each change their item's bytes;
+	// deletes change none. Flushes count what CallbackToEncoded, an
+	// IovecWriter or FlushExactlyOnce wrote.
+	BytesChanged   uint64 // item bytes written by mutations over the list's lifetime
+	BytesFlushed   uint64 // bytes written by flushes over the list's lifetime
+	UnflushedBytes int64  // item bytes changed since the last flush started
+
+	// Lifecycle counters, only maintained WithLeakTracking. Collection is
+	// observed through runtime cleanups, so counts lag until the garbage
+	// collector has run.
+	LeakTracking          bool
+	NodesCollected        uint64 // released nodes reclaimed by the garbage collector
+	CursorsOpened         uint64
+	CursorsCollected      uint64
+	IovecBatches          uint64 // slices returned by CallbackToIovecSlice and its wrappers
+	IovecBatchesCollected uint64
+}
+
+// RetainedNodes returns the number of released nodes that have not been
+// garbage collected, i.e. nodes still referenced by an ItemPtr, a Cursor or a
+// snapshot held somewhere. A deleted node keeps its forward links, so holding
+// one also retains any successors deleted after it. After runtime.GC() a
+// value that keeps growing indicates a leak. Only meaningful WithLeakTracking and without WithNodePool,
+// whose recycled nodes are never collected.
+func (s Stats) RetainedNodes() uint64 {
+	return s.NodesReleased - s.NodesCollected
+}
+
+// OpenCursors returns the number of cursors not yet garbage collected
+func (s Stats) OpenCursors() uint64 {
+	return s.CursorsOpened - s.CursorsCollected
+}
+
+// RetainedIovecBatches returns the number of iovec slices not yet garbage collected
+func (s Stats) RetainedIovecBatches() uint64 {
+	return s.IovecBatches - s.IovecBatchesCollected
+}
+
+// Stats returns current statistics for the skiplist
+func (sl *ZeroCopySkiplist[T, K, C]) Stats() Stats {
+	sl.rlockFlat()
+	defer sl.runlock()
+
+	stats := Stats{
+		Length:         sl.length,
+		Level:          sl.level,
+		MaxLevel:       sl.maxLevel,
+		TotalItemBytes: sl.totalBytes,
+		IovMax:         sl.iovMax(),
+		LevelCounts:    append([]int(nil), sl.levelCounts[:sl.level+1]...),
+		LevelBytes:     make([]int64, sl.level+1),
+		HeadBytes:      int64(sl.maxLevel+1) * (sl.linkBytes(0) + int64(unsafe.Sizeof(sl.header))),
+		NodesAllocated: sl.nodesAllocated,
+		NodesReleased:  sl.nodesReleased,
+		NodesRecycled:  sl.nodesRecycled,
+		PinnedEntries:  len(sl.pins),
+		LeasedEntries:  len(sl.leases),
+		DeferredWrites: len(sl.deferred),
+		SoftDeleted:    len(sl.graveyard),
+		BytesChanged:   sl.amp.changed.Load(),
+		BytesFlushed:   sl.amp.flushed.Load(),
+		UnflushedBytes: sl.amp.unflushed.Load(),
+		Misuses:        sl.misuses.Load(),
+		Refused:        sl.refusals,
+	}
+	for level, count := range stats.LevelCounts {
+		stats.LevelBytes[level] = int64(count) * sl.linkBytes(level)
+	}
+	if sl.watchdog != nil {
+		stats.SlowLockHolds = sl.watchdog.stalls.Load()
+	}
+	if sl.latency != nil {
+		stats.Latency = make([]LatencySnapshot, numOperations)
+		for op := range sl.latency {
+			stats.Latency[op] = sl.latency[op].snapshot()
+		}
+	}
+	if sl.contention != nil {
+		stats.WriteLocks = sl.contention.acquisitions
+		stats.WriteLockWaits = sl.contention.waits
+		stats.WriteLockWaitTime = sl.contention.waitTime
+	}
+	if sl.paths != nil {
+		stats.PathCacheHits = sl.paths.hits.Load()
+		stats.PathCacheMisses = sl.paths.misses.Load()
+	}
+	if sl.leaks != nil {
+		stats.LeakTracking = true
+		stats.NodesCollected = sl.leaks.nodesCollected.Load()
+		stats.CursorsOpened = sl.leaks.cursorsOpened.Load()
+		stats.CursorsCollected = sl.leaks.cursorsCollected.Load()
+		stats.IovecBatches = sl.leaks.iovecBatches.Load()
+		stats.IovecBatchesCollected = sl.leaks.iovecBatchesCollected.Load()
+	}
+	return stats
+}
+
+// linkBytes estimates the memory of the link slices of a node of level: its
+// forward pointers, and its backlinks and inline keys when the list keeps them
+func (sl *ZeroCopySkiplist[T, K, C]) linkBytes(level int) int64 {
+	ptr := int64(unsafe.Sizeof(sl.header))
+	bytes := int64(level+1) * ptr
+	if sl.opts.levelBacklinks {
+		bytes += int64(level) * ptr
+	}
+	if sl.inlineKeys {
+		var key K
+		bytes += int64(level+1) * int64(unsafe.Sizeof(key))
+	}
+	return bytes
+}
+
+// SizeHistogram counts items by size. buckets holds ascending upper bounds:
+// counts[i] is the number of items whose size is at most buckets[i] and above
+// buckets[i-1], and the extra counts[len(buckets)] holds items larger than the
+// last bound. Sizes come from the list's getItemSize function. The list is
+// scanned in batches as for ForEach, so the counts are not an atomic snapshot
+// of a list being written concurrently.
+func (sl *ZeroCopySkiplist[T, K, C]) SizeHistogram(buckets []int) []int {
+	counts := make([]int, len(buckets)+1)
+	sl.ForEach(func(ip *ItemPtr[T, K, C]) bool {
+		counts[sort.SearchInts(buckets, sl.getItemSize(ip.item))]++
+		return true
+	})
+	return counts
+}
+
+// leakCounters are updated from runtime cleanups, which run on their own goroutine
+type leakCounters struct {
+	nodesCollected        atomic.Uint64
+	cursorsOpened         atomic.Uint64
+	cursorsCollected      atomic.Uint64
+	iovecBatches          atomic.Uint64
+	iovecBatchesCollected atomic.Uint64
+}
+
+// trackNode counts a released node's eventual collection
+func trackNode[T any, K comparable, C comparable](lc *leakCounters, node *ItemPtr[T, K, C]) {
+	runtime.AddCleanup(node, func(lc *leakCounters) { lc.nodesCollected.Add(1) }, lc)
+}
+
+// trackCursor counts an opened cursor and its eventual collection
+func trackCursor[T any, K comparable, C comparable](lc *leakCounters, c *Cursor[T, K, C]) {
+	lc.cursorsOpened.Add(1)
+	runtime.AddCleanup(c, func(lc *leakCounters) { lc.cursorsCollected.Add(1) }, lc)
+}
+
+// trackIovecs counts a returned iovec slice and its eventual collection
+func (lc *leakCounters) trackIovecs(iovecs []syscall.Iovec) {
+	lc.iovecBatches.Add(1)
+	if cap(iovecs) == 0 {
+		lc.iovecBatchesCollected.Add(1)
+		return
+	}
+	runtime.AddCleanup(&iovecs[:1][0], func(lc *leakCounters) { lc.iovecBatchesCollected.Add(1) }, lc)
+}
@@ -0,0 +1,140 @@
+package zerocopyskiplist
+
+import (
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+// collectedStats runs the garbage collector until done reports true for the
+// list's stats or a deadline passes, since cleanups run asynchronously
+func collectedStats[T any, K comparable, C comparable](sl *ZeroCopySkiplist[T, K, C], done func(Stats) bool) Stats {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		runtime.GC()
+		stats := sl.Stats()
+		if done(stats) || time.Now().After(deadline) {
+			return stats
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestStatsCounters(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		8,
+		getKeyFromTestItem,
+		getTestItemSize,
+		compareInt,
+		WithNodePool(),
+	)
+
+	items := createTestItems(100)
+	for _, item := range items {
+		skiplist.Insert(item, TestContext{})
+	}
+	for i := 0; i < 40; i++ {
+		skiplist.Delete(items[i].ID)
+	}
+	for i := 0; i < 40; i++ {
+		skiplist.Insert(items[i], TestContext{})
+	}
+
+	stats := skiplist.Stats()
+	if stats.Length != 100 || stats.MaxLevel != 8 || stats.TotalItemBytes != skiplist.TotalItemBytes() {
+		t.Errorf("Unexpected structural stats %+v", stats)
+	}
+	if stats.NodesAllocated != 140 || stats.NodesReleased != 40 {
+		t.Errorf("Expected 140 allocated and 40 released nodes, got %d and %d", stats.NodesAllocated, stats.NodesReleased)
+	}
+	if stats.NodesRecycled > 40 {
+		t.Errorf("At most 40 nodes can be recycled, got %d", stats.NodesRecycled)
+	}
+	if stats.LeakTracking || stats.CursorsOpened != 0 {
+		t.Error("Lifecycle counters should not be maintained without WithLeakTracking")
+	}
+}
+
+func TestLeakTrackingNodes(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		8,
+		getKeyFromTestItem,
+		getTestItemSize,
+		compareInt,
+		WithLeakTracking(),
+	)
+
+	for _, item := range createTestItems(100) {
+		skiplist.Insert(item, TestContext{})
+	}
+
+	// Hold on to one deleted node, drop the rest. The last node is chosen
+	// because a deleted node keeps its old forward links, which would also
+	// retain successors deleted after it.
+	retained := skiplist.FindItem(100)
+	for i := 1; i <= 100; i++ {
+		skiplist.Delete(i)
+	}
+
+	stats := collectedStats(skiplist, func(s Stats) bool { return s.RetainedNodes() <= 1 })
+	if stats.NodesReleased != 100 || stats.RetainedNodes() != 1 {
+		t.Errorf("Expected 1 retained node of 100 released, got %d of %d", stats.RetainedNodes(), stats.NodesReleased)
+	}
+
+	runtime.KeepAlive(retained)
+	retained = nil
+	stats = collectedStats(skiplist, func(s Stats) bool { return s.RetainedNodes() == 0 })
+	if stats.RetainedNodes() != 0 {
+		t.Errorf("Expected no retained nodes once released, got %d", stats.RetainedNodes())
+	}
+}
+
+func TestLeakTrackingCursorsAndIovecs(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		8,
+		getKeyFromTestItem,
+		getTestItemSize,
+		compareInt,
+		WithLeakTracking(),
+	)
+
+	for _, item := range createTestItems(10) {
+		skiplist.Insert(item, TestContext{})
+	}
+
+	cursor := skiplist.SeekGE(3)
+	skiplist.SeekLE(7)
+	iovecs := skiplist.ToIovecSlice(TestContext{})
+	skiplist.ToIovecSlice(TestContext{})
+
+	stats := collectedStats(skiplist, func(s Stats) bool {
+		return s.OpenCursors() <= 1 && s.RetainedIovecBatches() <= 1
+	})
+	if stats.CursorsOpened != 2 || stats.OpenCursors() != 1 {
+		t.Errorf("Expected 1 of 2 cursors open, got %d of %d", stats.OpenCursors(), stats.CursorsOpened)
+	}
+	if stats.IovecBatches != 2 || stats.RetainedIovecBatches() != 1 {
+		t.Errorf("Expected 1 of 2 iovec batches retained, got %d of %d", stats.RetainedIovecBatches(), stats.IovecBatches)
+	}
+
+	runtime.KeepAlive(cursor)
+	runtime.KeepAlive(iovecs)
+}
+
+func TestSizeHistogram(t *testing.T) {
+	sl := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, func(item *TestItem) int { return len(item.Data) }, compareInt)
+	sizes := []int{0, 10, 11, 64, 64, 65, 4096, 4097, 100000}
+	for i, size := range sizes {
+		sl.Insert(&TestItem{ID: i, Data: make([]byte, size)}, TestContext{})
+	}
+
+	got := sl.SizeHistogram([]int{10, 64, 4096})
+	want := []int{2, 3, 2, 2}
+	if !slices.Equal(got, want) {
+		t.Errorf("SizeHistogram = %v, want %v", got, want)
+	}
+	if got := sl.SizeHistogram(nil); !slices.Equal(got, []int{len(sizes)}) {
+		t.Errorf("SizeHistogram without buckets = %v, want [%d]", got, len(sizes))
+	}
+}
@@ -0,0 +1,64 @@
+// stream.go - Range results delivered in bounded batches
+
+package zerocopyskiplist
+
+import "context"
+
+// StreamRange delivers the entries with min <= key <= max in ascending key
+// order on the returned channel, in batches of up to batchSize entries (the
+// scan batch size when batchSize is not positive), and closes it after the
+// last batch or once ctx is done. The read lock is taken afresh for each
+// batch and released before the batch is sent, so a huge range never holds
+// the lock for long and at most two batches exist at a time: the one the
+// receiver holds and the one waiting to be sent. Each batch is a new slice
+// owned by the receiver.
+//
+// Every batch is consistent on its own; entries written between batches are
+// seen by later ones if they sort after the last key sent. A receiver that
+// stops early must cancel ctx, or the goroutine filling the channel blocks
+// until it does.
+func (sl *ZeroCopySkiplist[T, K, C]) StreamRange(ctx context.Context, min, max K, batchSize int) <-chan []Entry[T, K, C] {
+	if batchSize <= 0 {
+		batchSize = callbackBatchSize
+	}
+	min, max = sl.normalize(min), sl.normalize(max)
+	out := make(chan []Entry[T, K, C])
+	go func() {
+		defer close(out)
+		from, inclusive := min, true
+		for {
+			batch := sl.snapshotEntries(make([]Entry[T, K, C], 0, batchSize), from, inclusive, max)
+			if len(batch) == 0 {
+				return
+			}
+			select {
+			case out <- batch:
+			case <-ctx.Done():
+				return
+			}
+			if len(batch) < batchSize {
+				return
+			}
+			from, inclusive = batch[len(batch)-1].Key, false
+		}
+	}()
+	return out
+}
+
+// snapshotEntries appends up to cap(dst) entries to dst, starting at the
+// first key >= from (> from when not inclusive) and stopping after max
+func (sl *ZeroCopySkiplist[T, K, C]) snapshotEntries(dst []Entry[T, K, C], from K, inclusive bool, max K) []Entry[T, K, C] {
+	sl.rlock()
+	defer sl.runlock()
+
+	touched := 0
+	for current := sl.seek(from, inclusive); current != nil && len(dst) < cap(dst); current = current.forward[0] {
+		if sl.cmpKey(current.key, max) > 0 {
+			break
+		}
+		touched += prefetch(current, 1)
+		dst = append(dst, current.Entry())
+	}
+	prefetchSink.Add(int64(touched))
+	return dst
+}
@@ -0,0 +1,53 @@
+package zerocopyskiplist
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStreamRange(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	for _, item := range createTestItems(1000) {
+		skiplist.Insert(item, TestContext{AccessCount: item.ID})
+	}
+
+	// Full batches, then the remainder, in key order
+	next := 100
+	batches := 0
+	for batch := range skiplist.StreamRange(context.Background(), 100, 349, 64) {
+		if len(batch) > 64 || cap(batch) != 64 {
+			t.Fatalf("Batch of %d entries with capacity %d", len(batch), cap(batch))
+		}
+		for _, entry := range batch {
+			if entry.Key != next || entry.Context.AccessCount != next {
+				t.Fatalf("Streamed %d, want %d", entry.Key, next)
+			}
+			next++
+		}
+		batches++
+
+		// Writes between batches are seen when they sort after the last key sent
+		if batches == 1 {
+			skiplist.Delete(200)
+		}
+		if next == 200 {
+			next++
+		}
+	}
+	if next != 350 || batches != 4 {
+		t.Errorf("Stream ended at %d after %d batches", next, batches)
+	}
+
+	// An empty range closes at once
+	if _, ok := <-skiplist.StreamRange(context.Background(), 2000, 3000, 10); ok {
+		t.Error("An empty range should deliver no batches")
+	}
+
+	// Cancelling releases the producer
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := skiplist.StreamRange(ctx, 1, 1000, 10)
+	<-stream
+	cancel()
+	for range stream {
+	}
+}
@@ -0,0 +1,70 @@
+// swap.go - Exchanging the contents of two lists for build-aside rebuilds
+
+package zerocopyskiplist
+
+// SwapContents exchanges the entries of sl and other as a single step: both
+// write locks are held while the nodes, length, levels and per-entry state
+// (pins and soft-deleted entries) change hands, so every reader of either
+// list sees the old contents or the new, never a mix. Build a replacement
+// aside and swap it in, rather than reassigning a variable that other
+// goroutines may still hold the old pointer from. Configuration stays with
+// each list: comparator, options, quotas and hooks, so both lists must order
+// keys the same way. Quota usage and context stats are recounted for the new
+// contents, both lists' Generation changes, and a pressure hook fires if a
+// list crosses its threshold. Cursors follow the entries they are parked on
+// into the other list. It reports whether the contents were swapped; nothing
+// changes if the lists differ in maximum level, WithInlineKeys or
+// WithLevelBacklinks, or if
+// either is frozen, has outstanding leases or was built WithFlushExactlyOnce.
+func (sl *ZeroCopySkiplist[T, K, C]) SwapContents(other *ZeroCopySkiplist[T, K, C]) bool {
+	if other == sl {
+		return true
+	}
+	type pressure struct {
+		hook       func(int64, int)
+		totalBytes int64
+		entries    int
+	}
+	var fired [2]pressure
+	var swapped bool
+	func() {
+		ordered := lockOrdered([]*ZeroCopySkiplist[T, K, C]{sl, other})
+		for _, l := range ordered {
+			l.lock()
+		}
+		defer func() {
+			for _, l := range ordered {
+				l.unlock()
+			}
+		}()
+
+		// Nodes are sized for their list, so only lists built alike can trade them
+		swapped = sl.maxLevel == other.maxLevel && sl.inlineKeys == other.inlineKeys &&
+			sl.opts.levelBacklinks == other.opts.levelBacklinks &&
+			!sl.frozen && !other.frozen && len(sl.leases) == 0 && len(other.leases) == 0 &&
+			!sl.opts.flushExactlyOnce && !other.opts.flushExactlyOnce
+		if swapped {
+			sl.header, other.header = other.header, sl.header
+			sl.tails, other.tails = other.tails, sl.tails
+			sl.levelCounts, other.levelCounts = other.levelCounts, sl.levelCounts
+			sl.pins, other.pins = other.pins, sl.pins
+			sl.graveyard, other.graveyard = other.graveyard, sl.graveyard
+
+			// Cached paths and stamped batches of either list are now stale
+			generation := max(sl.generation, other.generation) + 1
+			version := max(sl.version, other.version) + 1
+			for i, l := range []*ZeroCopySkiplist[T, K, C]{sl, other} {
+				l.generation, l.version = generation, version
+				l.recount()
+				fired[i].hook, fired[i].totalBytes, fired[i].entries = l.checkPressure()
+			}
+		}
+	}()
+
+	for _, p := range fired {
+		if p.hook != nil {
+			p.hook(p.totalBytes, p.entries)
+		}
+	}
+	return swapped
+}
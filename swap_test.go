@@ -0,0 +1,103 @@
+package zerocopyskiplist
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSwapContents(t *testing.T) {
+	live := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	rebuilt := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	for _, item := range createTestItems(50) {
+		live.Insert(item, TestContext{})
+	}
+	for _, item := range createTestItems(120)[20:] {
+		rebuilt.Insert(item, TestContext{AccessCount: 1})
+	}
+	live.Pin(10)
+	generation := live.Generation()
+
+	if !live.SwapContents(rebuilt) {
+		t.Fatal("SwapContents of two alike lists should succeed")
+	}
+	if live.Length() != 100 || rebuilt.Length() != 50 {
+		t.Errorf("Lengths after swap = %d, %d; want 100, 50", live.Length(), rebuilt.Length())
+	}
+	if _, ctx := live.Find(21); ctx.AccessCount != 1 {
+		t.Error("Live list should hold the rebuilt entries")
+	}
+	if ptr, _ := live.Find(5); ptr != nil {
+		t.Error("Live list still finds an entry only in the old contents")
+	}
+	if live.Pinned(10) || !rebuilt.Pinned(10) {
+		t.Error("Pins should move with the entries")
+	}
+	if live.Generation() == generation {
+		t.Error("SwapContents should change the Generation")
+	}
+	for _, l := range []*ZeroCopySkiplist[TestItem, int, TestContext]{live, rebuilt} {
+		if err := l.Validate(); err != nil {
+			t.Errorf("Validate after swap: %v", err)
+		}
+	}
+	if !live.SwapContents(live) || live.Length() != 100 {
+		t.Error("Swapping a list with itself should leave it unchanged")
+	}
+
+	small := MakeZeroCopySkiplist[TestItem, int, TestContext](4, getKeyFromTestItem, getTestItemSize, compareInt)
+	if live.SwapContents(small) || live.Length() != 100 {
+		t.Error("Lists with different maximum levels should not swap")
+	}
+	rebuilt.Freeze()
+	if live.SwapContents(rebuilt) || live.Length() != 100 {
+		t.Error("A frozen list should not swap")
+	}
+}
+
+func TestSwapContentsLevelBacklinks(t *testing.T) {
+	linked := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt, WithLevelBacklinks())
+	plain := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	for _, item := range createTestItems(100) {
+		plain.Insert(item, TestContext{})
+	}
+
+	if linked.SwapContents(plain) || plain.SwapContents(linked) {
+		t.Error("Lists differing in WithLevelBacklinks should not swap")
+	}
+	for _, l := range []*ZeroCopySkiplist[TestItem, int, TestContext]{linked, plain} {
+		if err := l.Validate(); err != nil {
+			t.Errorf("Validate after refused swap: %v", err)
+		}
+	}
+	if linked.Length() != 0 || plain.Length() != 100 {
+		t.Error("A refused swap should leave both lists unchanged")
+	}
+}
+
+func TestSwapContentsConcurrent(t *testing.T) {
+	a := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	b := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	for _, item := range createTestItems(100) {
+		a.Insert(item, TestContext{})
+	}
+
+	// Readers see one list full and the other empty, never a mix
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 1000 {
+			a.SwapContents(b)
+		}
+	}()
+	for range 1000 {
+		if n := a.Length(); n != 0 && n != 100 {
+			t.Fatalf("Length during swaps = %d, want 0 or 100", n)
+		}
+		snapshots := SnapshotTogether(a, b)
+		if total := snapshots[0].Length() + snapshots[1].Length(); total != 100 {
+			t.Fatalf("Snapshot holds %d entries, want 100", total)
+		}
+	}
+	wg.Wait()
+}
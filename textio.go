@@ -0,0 +1,165 @@
+// textio.go - NDJSON and CSV export and import for operational tooling
+
+package zerocopyskiplist
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// importChunkSize is the number of decoded items passed to each BulkLoad call
+const importChunkSize = 1024
+
+// ExportNDJSON writes every item to w in key order, one line per item as
+// produced by marshalItem, e.g. json.Marshal of a view of the item and
+// context. marshalItem must not produce newlines.
+func (sl *ZeroCopySkiplist[T, K, C]) ExportNDJSON(w io.Writer, marshalItem func(item *T, context C) ([]byte, error)) error {
+	out := bufio.NewWriter(w)
+	var err error
+	sl.ForEach(func(ip *ItemPtr[T, K, C]) bool {
+		var line []byte
+		if line, err = marshalItem(ip.item, ip.context); err != nil {
+			err = fmt.Errorf("marshalling key %v: %w", ip.key, err)
+			return false
+		}
+		if bytes.IndexByte(line, '\n') >= 0 {
+			err = fmt.Errorf("marshalling key %v: output contains a newline", ip.key)
+			return false
+		}
+		out.Write(line)
+		err = out.WriteByte('\n')
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+// ImportNDJSON reads lines from r, decodes each non-blank line with unmarshal
+// and loads the results with BulkLoad, returning the number of new keys. A
+// dump written by ExportNDJSON is in key order, so importing it into an empty
+// list appends without searching. Items decoded before an error are loaded.
+func (sl *ZeroCopySkiplist[T, K, C]) ImportNDJSON(r io.Reader, unmarshal func(line []byte) (*T, C, error)) (int, error) {
+	in := bufio.NewReader(r)
+	chunk := newImportChunk(sl)
+	for lineNo := 1; ; lineNo++ {
+		line, readErr := in.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			added, _ := chunk.flush()
+			return added, readErr
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			item, context, err := unmarshal(line)
+			if err != nil {
+				added, _ := chunk.flush()
+				return added, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			if err := chunk.add(item, context); err != nil {
+				return chunk.added, err
+			}
+		}
+		if readErr == io.EOF {
+			return chunk.flush()
+		}
+	}
+}
+
+// ExportCSV writes every item to w in key order as a CSV record produced by
+// record, preceded by header when it is not nil
+func (sl *ZeroCopySkiplist[T, K, C]) ExportCSV(w io.Writer, header []string, record func(item *T, context C) []string) error {
+	out := csv.NewWriter(w)
+	if header != nil {
+		if err := out.Write(header); err != nil {
+			return err
+		}
+	}
+	var err error
+	sl.ForEach(func(ip *ItemPtr[T, K, C]) bool {
+		err = out.Write(record(ip.item, ip.context))
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// ImportCSV reads CSV records from r, skipping the first when skipHeader is
+// true, decodes each with parse and loads the results with BulkLoad,
+// returning the number of new keys. Items decoded before an error are loaded.
+func (sl *ZeroCopySkiplist[T, K, C]) ImportCSV(r io.Reader, skipHeader bool, parse func(record []string) (*T, C, error)) (int, error) {
+	in := csv.NewReader(r)
+	in.ReuseRecord = true
+	chunk := newImportChunk(sl)
+	if skipHeader {
+		if _, err := in.Read(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return 0, nil
+			}
+			return 0, err
+		}
+	}
+	for {
+		record, err := in.Read()
+		if errors.Is(err, io.EOF) {
+			return chunk.flush()
+		}
+		if err != nil {
+			added, _ := chunk.flush()
+			return added, err
+		}
+		item, context, err := parse(record)
+		if err != nil {
+			line, _ := in.FieldPos(0)
+			added, _ := chunk.flush()
+			return added, fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := chunk.add(item, context); err != nil {
+			return chunk.added, err
+		}
+	}
+}
+
+// importChunk batches decoded items for BulkLoad
+type importChunk[T any, K comparable, C comparable] struct {
+	sl       *ZeroCopySkiplist[T, K, C]
+	items    []*T
+	contexts []C
+	added    int
+}
+
+// newImportChunk returns an empty chunk loading into sl
+func newImportChunk[T any, K comparable, C comparable](sl *ZeroCopySkiplist[T, K, C]) *importChunk[T, K, C] {
+	return &importChunk[T, K, C]{
+		sl:       sl,
+		items:    make([]*T, 0, importChunkSize),
+		contexts: make([]C, 0, importChunkSize),
+	}
+}
+
+// add queues an item, loading the chunk once it is full
+func (c *importChunk[T, K, C]) add(item *T, context C) error {
+	c.items = append(c.items, item)
+	c.contexts = append(c.contexts, context)
+	if len(c.items) < importChunkSize {
+		return nil
+	}
+	_, err := c.flush()
+	return err
+}
+
+// flush loads the queued items and returns the total number of new keys so far
+func (c *importChunk[T, K, C]) flush() (int, error) {
+	added, err := c.sl.BulkLoad(c.items, c.contexts)
+	c.added += added
+	clear(c.items)
+	c.items = c.items[:0]
+	c.contexts = c.contexts[:0]
+	return c.added, err
+}
@@ -0,0 +1,97 @@
+package zerocopyskiplist
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testItemJSON is the NDJSON view of a TestItem and its context
+type testItemJSON struct {
+	ID          int    `json:"id"`
+	Value       string `json:"value"`
+	AccessCount int    `json:"access_count"`
+}
+
+func TestNDJSONRoundTrip(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	skiplist.BulkLoad(createTestItems(2500), createTestContexts(2500))
+
+	var buf bytes.Buffer
+	err := skiplist.ExportNDJSON(&buf, func(item *TestItem, ctx TestContext) ([]byte, error) {
+		return json.Marshal(testItemJSON{ID: item.ID, Value: item.Value, AccessCount: ctx.AccessCount})
+	})
+	if err != nil {
+		t.Fatalf("ExportNDJSON: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2500 || lines[0] != `{"id":1,"value":"value_1","access_count":0}` {
+		t.Fatalf("Unexpected NDJSON output: %d lines, first %q", len(lines), lines[0])
+	}
+
+	loaded := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	added, err := loaded.ImportNDJSON(&buf, func(line []byte) (*TestItem, TestContext, error) {
+		var v testItemJSON
+		err := json.Unmarshal(line, &v)
+		return &TestItem{ID: v.ID, Value: v.Value}, TestContext{AccessCount: v.AccessCount}, err
+	})
+	if err != nil || added != 2500 {
+		t.Fatalf("ImportNDJSON added %d: %v", added, err)
+	}
+	if found, ctx := loaded.Find(1234); found == nil || found.Item().Value != "value_1234" || ctx.AccessCount != 1233 {
+		t.Error("Imported entry has wrong contents")
+	}
+	if err := loaded.Validate(); err != nil {
+		t.Errorf("Validate after import: %v", err)
+	}
+
+	// A bad line reports its number and keeps the lines before it
+	partial := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	added, err = partial.ImportNDJSON(strings.NewReader("{\"id\":1}\n\n{\"id\":2}\nnot json\n"), func(line []byte) (*TestItem, TestContext, error) {
+		var v testItemJSON
+		err := json.Unmarshal(line, &v)
+		return &TestItem{ID: v.ID}, TestContext{}, err
+	})
+	if err == nil || !strings.Contains(err.Error(), "line 4") || added != 2 || partial.Length() != 2 {
+		t.Errorf("Expected an error on line 4 after 2 items, got %d items: %v", added, err)
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	skiplist.BulkLoad(createTestItems(100), nil)
+	skiplist.Insert(&TestItem{ID: 101, Value: "quoted, \"value\""}, TestContext{})
+
+	var buf bytes.Buffer
+	err := skiplist.ExportCSV(&buf, []string{"id", "value"}, func(item *TestItem, _ TestContext) []string {
+		return []string{strconv.Itoa(item.ID), item.Value}
+	})
+	if err != nil {
+		t.Fatalf("ExportCSV: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "id,value\n1,value_1\n") {
+		t.Errorf("Unexpected CSV output %q", buf.String()[:30])
+	}
+
+	loaded := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	added, err := loaded.ImportCSV(&buf, true, func(record []string) (*TestItem, TestContext, error) {
+		id, err := strconv.Atoi(record[0])
+		return &TestItem{ID: id, Value: record[1]}, TestContext{}, err
+	})
+	if err != nil || added != 101 {
+		t.Fatalf("ImportCSV added %d: %v", added, err)
+	}
+	if found := loaded.FindItem(101); found == nil || found.Item().Value != "quoted, \"value\"" {
+		t.Error("Quoted CSV field did not round trip")
+	}
+
+	_, err = loaded.ImportCSV(strings.NewReader("id,value\nx,y\n"), true, func(record []string) (*TestItem, TestContext, error) {
+		id, err := strconv.Atoi(record[0])
+		return &TestItem{ID: id}, TestContext{}, err
+	})
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected a parse error on line 2, got %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+// transfer.go - Resumable snapshot transfer over a network connection
+
+package zerocopyskiplist
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"sync/atomic"
+)
+
+// transferChunkSize is the payload size of each snapshot transfer frame
+const transferChunkSize = 64 << 10
+
+// Transfer protocol magics for requests and responses
+var (
+	transferRequestMagic  = [4]byte{'Z', 'C', 'S', 'R'}
+	transferResponseMagic = [4]byte{'Z', 'C', 'S', 'S'}
+)
+
+// ErrTransferCorrupt is returned when a transfer frame fails its checksum or
+// the peer does not speak the transfer protocol
+var ErrTransferCorrupt = errors.New("snapshot transfer is corrupt")
+
+// transferIDs numbers captured snapshots so a resuming client can tell
+// whether the server still holds the snapshot it was fetching
+var transferIDs atomic.Uint64
+
+// SnapshotSource is a captured snapshot that can be served to any number of
+// clients, each of which may disconnect and resume where it left off
+type SnapshotSource struct {
+	id   uint64
+	data []byte
+}
+
+// CaptureSnapshot encodes a snapshot of the list with codec for serving. The
+// snapshot is held in memory so every client receives the same bytes, which
+// is what makes resuming from an offset possible.
+func (sl *ZeroCopySkiplist[T, K, C]) CaptureSnapshot(codec ItemCodec[T, C]) (*SnapshotSource, error) {
+	var buf bytes.Buffer
+	if err := sl.SaveSnapshot(&buf, codec); err != nil {
+		return nil, err
+	}
+	return &SnapshotSource{id: transferIDs.Add(1), data: buf.Bytes()}, nil
+}
+
+// Size returns the encoded size of the snapshot
+func (s *SnapshotSource) Size() int64 {
+	return int64(len(s.data))
+}
+
+// ServeSnapshot answers one FetchSnapshot request on conn. A client resuming
+// this snapshot is sent the rest of it from its offset; any other client is
+// sent the whole snapshot. The snapshot follows a response header as frames
+// of a little-endian uint32 length and CRC-32C and up to 64KiB of payload,
+// ending with an empty frame. Header and payload of each frame are written
+// together with writev when conn is a network connection.
+func (s *SnapshotSource) ServeSnapshot(conn io.ReadWriter) error {
+	var req [20]byte
+	if _, err := io.ReadFull(conn, req[:]); err != nil {
+		return err
+	}
+	if [4]byte(req[:4]) != transferRequestMagic {
+		return fmt.Errorf("%w: bad request", ErrTransferCorrupt)
+	}
+	offset := binary.LittleEndian.Uint64(req[12:])
+	if binary.LittleEndian.Uint64(req[4:]) != s.id || offset > uint64(len(s.data)) {
+		offset = 0
+	}
+
+	resp := append([]byte(nil), transferResponseMagic[:]...)
+	resp = binary.LittleEndian.AppendUint64(resp, s.id)
+	resp = binary.LittleEndian.AppendUint64(resp, uint64(len(s.data)))
+	resp = binary.LittleEndian.AppendUint64(resp, offset)
+	if _, err := conn.Write(resp); err != nil {
+		return err
+	}
+
+	var frame [8]byte
+	for rest := s.data[offset:]; ; {
+		chunk := rest[:min(len(rest), transferChunkSize)]
+		rest = rest[len(chunk):]
+		binary.LittleEndian.PutUint32(frame[:], uint32(len(chunk)))
+		binary.LittleEndian.PutUint32(frame[4:], crc32.Checksum(chunk, crcTable))
+		if len(chunk) == 0 {
+			_, err := conn.Write(frame[:])
+			return err
+		}
+		buffers := net.Buffers{frame[:], chunk}
+		if _, err := buffers.WriteTo(conn); err != nil {
+			return err
+		}
+	}
+}
+
+// SnapshotFetch accumulates a snapshot received by FetchSnapshot. Its zero
+// value starts a new fetch; after an interrupted fetch, passing the same
+// SnapshotFetch to FetchSnapshot on a new connection resumes it.
+type SnapshotFetch struct {
+	id   uint64
+	size uint64
+	data []byte
+	done bool
+}
+
+// Done returns true once the whole snapshot has been received
+func (f *SnapshotFetch) Done() bool {
+	return f.done
+}
+
+// Received returns the number of bytes received so far and the snapshot size
+func (f *SnapshotFetch) Received() (int64, int64) {
+	return int64(len(f.data)), int64(f.size)
+}
+
+// Reader returns a reader over the received snapshot, for LoadSnapshot
+func (f *SnapshotFetch) Reader() io.Reader {
+	return bytes.NewReader(f.data)
+}
+
+// FetchSnapshot requests a snapshot from a peer running ServeSnapshot and
+// receives it into f, resuming from the bytes already in f if the peer still
+// serves the same snapshot and starting over otherwise. Frames are verified as
+// they arrive; on any error f keeps every verified byte, so the fetch can be
+// retried on a new connection.
+func FetchSnapshot(conn io.ReadWriter, f *SnapshotFetch) error {
+	if f.done {
+		return nil
+	}
+
+	req := append([]byte(nil), transferRequestMagic[:]...)
+	req = binary.LittleEndian.AppendUint64(req, f.id)
+	req = binary.LittleEndian.AppendUint64(req, uint64(len(f.data)))
+	if _, err := conn.Write(req); err != nil {
+		return err
+	}
+
+	var resp [28]byte
+	if _, err := io.ReadFull(conn, resp[:]); err != nil {
+		return err
+	}
+	if [4]byte(resp[:4]) != transferResponseMagic {
+		return fmt.Errorf("%w: bad response", ErrTransferCorrupt)
+	}
+	id := binary.LittleEndian.Uint64(resp[4:])
+	size := binary.LittleEndian.Uint64(resp[12:])
+	offset := binary.LittleEndian.Uint64(resp[20:])
+	if id != f.id || offset != uint64(len(f.data)) {
+		if offset != 0 {
+			return fmt.Errorf("%w: resumed at offset %d, expected 0", ErrTransferCorrupt, offset)
+		}
+		f.id, f.data = id, f.data[:0]
+	}
+	f.size = size
+
+	var frame [8]byte
+	chunk := make([]byte, transferChunkSize)
+	for {
+		if _, err := io.ReadFull(conn, frame[:]); err != nil {
+			return err
+		}
+		length := binary.LittleEndian.Uint32(frame[:])
+		if length > transferChunkSize || uint64(len(f.data))+uint64(length) > f.size {
+			return fmt.Errorf("%w: frame of %d bytes", ErrTransferCorrupt, length)
+		}
+		if _, err := io.ReadFull(conn, chunk[:length]); err != nil {
+			return err
+		}
+		if crc32.Checksum(chunk[:length], crcTable) != binary.LittleEndian.Uint32(frame[4:]) {
+			return fmt.Errorf("%w: frame checksum mismatch at offset %d", ErrTransferCorrupt, len(f.data))
+		}
+		if length == 0 {
+			if uint64(len(f.data)) != f.size {
+				return fmt.Errorf("%w: ended at %d of %d bytes", ErrTransferCorrupt, len(f.data), f.size)
+			}
+			f.done = true
+			return nil
+		}
+		f.data = append(f.data, chunk[:length]...)
+	}
+}
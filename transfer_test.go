@@ -0,0 +1,148 @@
+package zerocopyskiplist
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+)
+
+// cutConn fails reads once limit bytes have been read
+type cutConn struct {
+	net.Conn
+	limit int
+	read  int
+}
+
+func (c *cutConn) Read(p []byte) (int, error) {
+	if c.read >= c.limit {
+		return 0, io.ErrUnexpectedEOF
+	}
+	p = p[:min(len(p), c.limit-c.read)]
+	n, err := c.Conn.Read(p)
+	c.read += n
+	return n, err
+}
+
+// serveOnce runs ServeSnapshot on the server end of a new pipe
+func serveOnce(source *SnapshotSource) (net.Conn, chan error) {
+	client, server := net.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- source.ServeSnapshot(server)
+		server.Close()
+	}()
+	return client, done
+}
+
+func TestSnapshotTransferResume(t *testing.T) {
+	skiplist := newSnapshotTestList()
+	for i, item := range createTestItems(20000) {
+		skiplist.Insert(item, TestContext{AccessCount: i})
+	}
+	source, err := skiplist.CaptureSnapshot(testItemCodecV2{})
+	if err != nil {
+		t.Fatalf("CaptureSnapshot: %v", err)
+	}
+	if source.Size() < 3*transferChunkSize {
+		t.Fatalf("Snapshot of %d bytes is too small to span several frames", source.Size())
+	}
+
+	// The first connection drops part way through
+	var fetch SnapshotFetch
+	conn, _ := serveOnce(source)
+	err = FetchSnapshot(&cutConn{Conn: conn, limit: transferChunkSize + 1000}, &fetch)
+	conn.Close()
+	if err == nil || fetch.Done() {
+		t.Fatal("Expected the cut connection to interrupt the fetch")
+	}
+	received, size := fetch.Received()
+	if received != transferChunkSize || size != source.Size() {
+		t.Errorf("Expected one verified frame of %d bytes, got %d of %d", transferChunkSize, received, size)
+	}
+
+	// The second connection resumes, reading only the remainder
+	conn, served := serveOnce(source)
+	counted := &cutConn{Conn: conn, limit: int(source.Size())}
+	if err := FetchSnapshot(counted, &fetch); err != nil || !fetch.Done() {
+		t.Fatalf("Resumed FetchSnapshot: %v", err)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("ServeSnapshot: %v", err)
+	}
+	remaining := int(source.Size() - received)
+	if framing := 28 + 8*(remaining/transferChunkSize+2); counted.read > remaining+framing {
+		t.Errorf("Resume read %d bytes, more than the %d remaining and their framing", counted.read, remaining)
+	}
+
+	loaded := newSnapshotTestList()
+	if err := loaded.LoadSnapshot(fetch.Reader(), testItemCodecV2{}); err != nil {
+		t.Fatalf("LoadSnapshot: %v", err)
+	}
+	if loaded.Length() != 20000 {
+		t.Errorf("Expected 20000 items, got %d", loaded.Length())
+	}
+}
+
+func TestSnapshotTransferRestart(t *testing.T) {
+	skiplist := newSnapshotTestList()
+	for _, item := range createTestItems(10000) {
+		skiplist.Insert(item, TestContext{})
+	}
+	old, _ := skiplist.CaptureSnapshot(testItemCodecV2{})
+
+	var fetch SnapshotFetch
+	conn, _ := serveOnce(old)
+	FetchSnapshot(&cutConn{Conn: conn, limit: 5000}, &fetch)
+	conn.Close()
+
+	// The server has since captured a newer snapshot, so the fetch starts over
+	skiplist.Delete(1)
+	current, _ := skiplist.CaptureSnapshot(testItemCodecV2{})
+	conn, _ = serveOnce(current)
+	if err := FetchSnapshot(conn, &fetch); err != nil {
+		t.Fatalf("FetchSnapshot: %v", err)
+	}
+	loaded := newSnapshotTestList()
+	if err := loaded.LoadSnapshot(fetch.Reader(), testItemCodecV2{}); err != nil || loaded.Length() != 9999 {
+		t.Errorf("Expected the new snapshot of 9999 items, got %d: %v", loaded.Length(), err)
+	}
+}
+
+func TestSnapshotTransferCorruptFrame(t *testing.T) {
+	skiplist := newSnapshotTestList()
+	skiplist.Insert(&TestItem{ID: 1, Value: "x"}, TestContext{})
+	source, _ := skiplist.CaptureSnapshot(testItemCodecV2{})
+
+	client, server := net.Pipe()
+	go func() {
+		// Serve a response whose frame payload is corrupted in flight
+		tampered := &tamperConn{Conn: server, at: 28 + 8}
+		source.ServeSnapshot(tampered)
+		server.Close()
+	}()
+
+	var fetch SnapshotFetch
+	if err := FetchSnapshot(client, &fetch); !errors.Is(err, ErrTransferCorrupt) {
+		t.Errorf("Expected ErrTransferCorrupt, got %v", err)
+	}
+	if received, _ := fetch.Received(); received != 0 {
+		t.Errorf("A corrupt frame should not be kept, have %d bytes", received)
+	}
+}
+
+// tamperConn flips a bit of the byte written at offset at
+type tamperConn struct {
+	net.Conn
+	at      int
+	written int
+}
+
+func (c *tamperConn) Write(p []byte) (int, error) {
+	if c.at >= c.written && c.at < c.written+len(p) {
+		p = append([]byte(nil), p...)
+		p[c.at-c.written] ^= 1
+	}
+	c.written += len(p)
+	return c.Conn.Write(p)
+}
@@ -0,0 +1,94 @@
+// validate.go - Structural invariant checking for the zero copy skiplist
+
+package zerocopyskiplist
+
+import "fmt"
+
+// Validate checks the structural invariants of the skiplist and returns an
+// error describing the first violation found, or nil if the list is sound.
+//
+// The checked invariants are: keys strictly increase along every level, every
+// node on level i has at least i+1 forward pointers, the level 0 chain holds
+// exactly Length() nodes, backward links mirror the forward links (the first
+// node has a nil Prev()), the level tails point at the last node of each
+// level, inline key caches match their successors, and the list level is the
+// highest non-empty level. A list in small list storage has its slice checked
+// instead: keys strictly increase and there are exactly Length() entries.
+func (sl *ZeroCopySkiplist[T, K, C]) Validate() error {
+	sl.rlockFlat()
+	defer sl.runlock()
+	return sl.validate()
+}
+
+// validate performs the checks for Validate; the caller must hold the lock
+func (sl *ZeroCopySkiplist[T, K, C]) validate() error {
+	if sl.flat {
+		return sl.validateSmall()
+	}
+	for i := sl.level + 1; i <= sl.maxLevel; i++ {
+		if sl.header.forward[i] != nil {
+			return fmt.Errorf("level %d is populated above list level %d", i, sl.level)
+		}
+		if sl.tails[i] != nil {
+			return fmt.Errorf("level %d has a tail above list level %d", i, sl.level)
+		}
+	}
+	if sl.level > 0 && sl.header.forward[sl.level] == nil {
+		return fmt.Errorf("list level %d is empty", sl.level)
+	}
+
+	for i := 0; i <= sl.level; i++ {
+		count := 0
+		var prev *ItemPtr[T, K, C]
+		for current := sl.header.forward[i]; current != nil; current = current.forward[i] {
+			if current.level < i || len(current.forward) != current.level+1 {
+				return fmt.Errorf("key %v on level %d has level %d with %d forward pointers", current.key, i, current.level, len(current.forward))
+			}
+			if prev != nil && sl.cmpKey(prev.key, current.key) >= 0 {
+				return fmt.Errorf("key %v follows key %v on level %d", current.key, prev.key, i)
+			}
+			if sl.inlineKeys {
+				owner := prev
+				if owner == nil {
+					owner = sl.header
+				}
+				if owner.fkeys[i] != current.key {
+					return fmt.Errorf("key %v has a stale inline key on level %d", current.key, i)
+				}
+			}
+			if (i == 0 || sl.opts.levelBacklinks) && current.prevAt(i) != prev {
+				return fmt.Errorf("key %v has a broken backward link on level %d", current.key, i)
+			}
+			prev = current
+			count++
+			if count > sl.length {
+				return fmt.Errorf("level %d holds more nodes than the list length %d", i, sl.length)
+			}
+		}
+		if sl.tails[i] != prev {
+			return fmt.Errorf("tail of level %d does not point at its last node", i)
+		}
+		if i == 0 && count != sl.length {
+			return fmt.Errorf("level 0 holds %d nodes but the list length is %d", count, sl.length)
+		}
+	}
+
+	return nil
+}
+
+// validateSmall performs validate on a list in small list storage; the
+// caller must hold the lock
+func (sl *ZeroCopySkiplist[T, K, C]) validateSmall() error {
+	if sl.header.forward[0] != nil || sl.tails[0] != nil {
+		return fmt.Errorf("list in small list storage has nodes")
+	}
+	if len(sl.small) != sl.length {
+		return fmt.Errorf("small list storage holds %d entries but the list length is %d", len(sl.small), sl.length)
+	}
+	for i := 1; i < len(sl.small); i++ {
+		if sl.cmpKey(sl.small[i-1].key, sl.small[i].key) >= 0 {
+			return fmt.Errorf("key %v follows key %v in small list storage", sl.small[i].key, sl.small[i-1].key)
+		}
+	}
+	return nil
+}
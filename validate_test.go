@@ -0,0 +1,141 @@
+package zerocopyskiplist
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestValidateBackwardLinks(t *testing.T) {
+	for _, backlinks := range []bool{false, true} {
+		var opts []Option
+		if backlinks {
+			opts = append(opts, WithLevelBacklinks())
+		}
+		skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+			8,
+			getKeyFromTestItem,
+			getTestItemSize,
+			compareInt,
+			opts...,
+		)
+
+		items := createTestItems(200)
+		rng := rand.New(rand.NewSource(42))
+		for _, i := range rng.Perm(len(items)) {
+			skiplist.Insert(items[i], TestContext{})
+			if err := skiplist.Validate(); err != nil {
+				t.Fatalf("backlinks=%v: Validate after insert of %d: %v", backlinks, items[i].ID, err)
+			}
+		}
+
+		// Delete at the boundaries first, then at random
+		for _, key := range []int{1, 200, 2, 199} {
+			if !skiplist.Delete(key) {
+				t.Fatalf("backlinks=%v: Delete(%d) should succeed", backlinks, key)
+			}
+			if err := skiplist.Validate(); err != nil {
+				t.Fatalf("backlinks=%v: Validate after boundary delete of %d: %v", backlinks, key, err)
+			}
+		}
+		if first := skiplist.First(); first.Key() != 3 || first.Prev() != nil {
+			t.Errorf("backlinks=%v: First() should be key 3 with nil Prev()", backlinks)
+		}
+		if last := skiplist.Last(); last.Key() != 198 || last.Next() != nil {
+			t.Errorf("backlinks=%v: Last() should be key 198 with nil Next()", backlinks)
+		}
+
+		for _, i := range rng.Perm(len(items)) {
+			skiplist.Delete(items[i].ID)
+			if err := skiplist.Validate(); err != nil {
+				t.Fatalf("backlinks=%v: Validate after delete of %d: %v", backlinks, items[i].ID, err)
+			}
+		}
+		if skiplist.Last() != nil || skiplist.First() != nil {
+			t.Errorf("backlinks=%v: emptied skiplist should have no First() or Last()", backlinks)
+		}
+	}
+}
+
+func TestValidateDetectsCorruption(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		8,
+		getKeyFromTestItem,
+		getTestItemSize,
+		compareInt,
+	)
+
+	for _, item := range createTestItems(10) {
+		skiplist.Insert(item, TestContext{})
+	}
+	if err := skiplist.Validate(); err != nil {
+		t.Fatalf("Validate should pass on a healthy list: %v", err)
+	}
+
+	third := skiplist.FindItem(3)
+	third.backward = skiplist.FindItem(1)
+	if err := skiplist.Validate(); err == nil {
+		t.Error("Validate should detect a broken backward link")
+	}
+	third.backward = skiplist.FindItem(2)
+
+	skiplist.length++
+	if err := skiplist.Validate(); err == nil {
+		t.Error("Validate should detect a length mismatch")
+	}
+	skiplist.length--
+
+	third.key = 100
+	if err := skiplist.Validate(); err == nil {
+		t.Error("Validate should detect out of order keys")
+	}
+}
+
+func TestSeekLast(t *testing.T) {
+	for _, backlinks := range []bool{false, true} {
+		var opts []Option
+		if backlinks {
+			opts = append(opts, WithLevelBacklinks())
+		}
+		skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+			8,
+			getKeyFromTestItem,
+			getTestItemSize,
+			compareInt,
+			opts...,
+		)
+
+		// Even keys 2..100
+		for i := 1; i <= 50; i++ {
+			skiplist.Insert(&TestItem{ID: i * 2}, TestContext{})
+		}
+
+		for key := 0; key <= 102; key++ {
+			inclusive := skiplist.seekLast(key, true)
+			exclusive := skiplist.seekLast(key, false)
+
+			expectedInclusive := key - key%2
+			if expectedInclusive > 100 {
+				expectedInclusive = 100
+			}
+			expectedExclusive := expectedInclusive
+			if expectedExclusive == key && key > 0 {
+				expectedExclusive -= 2
+			}
+
+			if got := keyOrZero(inclusive); got != expectedInclusive {
+				t.Errorf("backlinks=%v: seekLast(%d, true) = %d, want %d", backlinks, key, got, expectedInclusive)
+			}
+			if got := keyOrZero(exclusive); got != expectedExclusive {
+				t.Errorf("backlinks=%v: seekLast(%d, false) = %d, want %d", backlinks, key, got, expectedExclusive)
+			}
+		}
+	}
+}
+
+// keyOrZero returns the node key, or 0 for a nil node
+func keyOrZero(node *ItemPtr[TestItem, int, TestContext]) int {
+	if node == nil {
+		return 0
+	}
+	return node.Key()
+}
@@ -0,0 +1,62 @@
+package zerocopyskiplist
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestVerifyIovecs(t *testing.T) {
+	sl := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	for _, item := range createTestItems(2000) {
+		sl.Insert(item, TestContext{})
+	}
+	iovecs := sl.ToIovecSlice(TestContext{})
+
+	f, err := os.Create(filepath.Join(t.TempDir(), "segment"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// Write the segment after a header, as a flush into an existing file would
+	header := []byte("header")
+	if _, err := f.Write(header); err != nil {
+		t.Fatal(err)
+	}
+	w := sl.NewIovecWriter(f.Fd(), iovecs, nil)
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	offset := int64(len(header))
+	if err := VerifyIovecs(f.Fd(), offset, iovecs); err != nil {
+		t.Fatalf("VerifyIovecs of an intact segment: %v", err)
+	}
+
+	// A flipped byte is reported at its offset
+	bad := offset + w.Written() - 100
+	if _, err := f.WriteAt([]byte{0xff}, bad); err != nil {
+		t.Fatal(err)
+	}
+	err = VerifyIovecs(f.Fd(), offset, iovecs)
+	if !errors.Is(err, ErrVerifyMismatch) || !strings.Contains(err.Error(), "offset "+strconv.FormatInt(bad, 10)) {
+		t.Errorf("Expected a mismatch at offset %d, got %v", bad, err)
+	}
+
+	// So is a truncated segment
+	if err := f.Truncate(offset + 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyIovecs(f.Fd(), offset, iovecs); !errors.Is(err, ErrVerifyMismatch) {
+		t.Errorf("Expected a mismatch for a truncated segment, got %v", err)
+	}
+
+	// Descriptors that cannot be read back fail with their errno
+	if err := VerifyIovecs(^uintptr(0)>>1, 0, iovecs); !errors.Is(err, syscall.EBADF) {
+		t.Errorf("Expected EBADF, got %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+// watchdog.go - Logging the goroutine stacks of long write lock holds
+
+package zerocopyskiplist
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// maxStackDump bounds the goroutine dump captured for one slow lock hold
+const maxStackDump = 8 << 20
+
+// lockWatchdog notices write locks held past a threshold. lock and unlock
+// stamp each acquisition; a background task polls the stamp so the holder's
+// stack is captured while it is still stuck.
+type lockWatchdog struct {
+	threshold   time.Duration
+	logger      *slog.Logger
+	acquisition atomic.Uint64 // write lock acquisitions so far
+	heldSince   atomic.Int64  // UnixNano of the current acquisition, 0 when free
+	stalls      atomic.Uint64 // holds reported
+}
+
+// WithSlowLockWatchdog watches for write locks held longer than threshold,
+// such as an Insert stalled behind a slow pressure hook or a page fault
+// storm, and logs each one once to logger (slog.Default() when nil) at warn
+// level with the stacks of every goroutine, captured while the lock is still
+// held so the holder's stack shows where it is stuck. The watchdog runs as a
+// background task, started with the list and stopped by Close; copies of the
+// list are not watched. Each write lock costs a clock read and two atomic
+// stores; Stats().SlowLockHolds counts the reports.
+func WithSlowLockWatchdog(threshold time.Duration, logger *slog.Logger) Option {
+	return func(o *options) {
+		o.slowLockThreshold = threshold
+		o.slowLockLogger = logger
+	}
+}
+
+// newLockWatchdog returns a watchdog for the options, nil if none is wanted
+func newLockWatchdog(opts options) *lockWatchdog {
+	if opts.slowLockThreshold <= 0 {
+		return nil
+	}
+	logger := opts.slowLockLogger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &lockWatchdog{threshold: opts.slowLockThreshold, logger: logger}
+}
+
+// acquired stamps a new write lock acquisition
+func (w *lockWatchdog) acquired() {
+	w.acquisition.Add(1)
+	w.heldSince.Store(time.Now().UnixNano())
+}
+
+// released clears the stamp before the write lock is released
+func (w *lockWatchdog) released() {
+	w.heldSince.Store(0)
+}
+
+// run polls for slow holds until ctx is done
+func (w *lockWatchdog) run(ctx context.Context) {
+	ticker := time.NewTicker(max(w.threshold/4, time.Millisecond))
+	defer ticker.Stop()
+
+	var reported uint64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		acquisition := w.acquisition.Load()
+		since := w.heldSince.Load()
+		if since == 0 || acquisition == reported || acquisition != w.acquisition.Load() {
+			continue
+		}
+		held := time.Since(time.Unix(0, since))
+		if held < w.threshold {
+			continue
+		}
+		reported = acquisition
+		w.stalls.Add(1)
+		w.logger.Warn("skiplist write lock held too long",
+			"held", held,
+			"threshold", w.threshold,
+			"stacks", string(goroutineStacks()))
+	}
+}
+
+// goroutineStacks returns the stacks of all goroutines, truncated at maxStackDump
+func goroutineStacks() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= maxStackDump {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// unlock releases the write lock taken by lock
+func (sl *ZeroCopySkiplist[T, K, C]) unlock() {
+	if sl.watchdog != nil {
+		sl.watchdog.released()
+	}
+	if paranoidBuild {
+		sl.holders.released(0, true)
+	}
+	sl.rw.Unlock()
+}
@@ -0,0 +1,66 @@
+package zerocopyskiplist
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for a logger and a test to share
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestSlowLockWatchdog(t *testing.T) {
+	var out syncBuffer
+	logger := slog.New(slog.NewTextHandler(&out, nil))
+	sl := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt,
+		WithSlowLockWatchdog(10*time.Millisecond, logger))
+
+	// Stall with the write lock held, as a slow Insert would
+	stallWhileLocked := func() {
+		sl.lock()
+		defer sl.unlock()
+		time.Sleep(60 * time.Millisecond)
+	}
+	stallWhileLocked()
+
+	for _, item := range createTestItems(100) {
+		sl.Insert(item, TestContext{})
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	if holds := sl.Stats().SlowLockHolds; holds != 1 {
+		t.Errorf("Expected one slow hold reported, got %d", holds)
+	}
+	log := out.String()
+	if !strings.Contains(log, "skiplist write lock held too long") || !strings.Contains(log, "TestSlowLockWatchdog") {
+		t.Errorf("Expected a warning with the holder's stack, got:\n%.500s", log)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := sl.Close(ctx); err != nil {
+		t.Errorf("Close should stop the watchdog: %v", err)
+	}
+	if copied := sl.Copy(); copied.watchdog != nil {
+		t.Error("Copies should not be watched")
+	}
+}
@@ -0,0 +1,81 @@
+// weight.go - Weighted entries and weight-bounded eviction
+
+package zerocopyskiplist
+
+// SetWeigher weighs each entry with weight instead of its item size, for
+// lists whose items cost very different amounts to keep, such as cached
+// responses whose value is not their size. The total weight is maintained
+// incrementally on every insert, replacement, context update and delete, and
+// drives SetWeightLimit and TrimToWeight. weight must return the same value
+// for the same item and context. Setting a weigher walks the list once to
+// total the existing entries; nil goes back to weighing by item size.
+func (sl *ZeroCopySkiplist[T, K, C]) SetWeigher(weight func(item *T, context C) int64) {
+	sl.lock()
+	defer sl.unlock()
+	sl.weigher = weight
+	sl.totalWeight = 0
+	for node := sl.header.forward[0]; node != nil; node = node.forward[0] {
+		sl.chargeWeight(node.item, node.context, 1)
+	}
+	sl.enforceWeightLimit()
+}
+
+// TotalWeight returns the total weight of the entries, TotalItemBytes when no
+// weigher is set
+func (sl *ZeroCopySkiplist[T, K, C]) TotalWeight() int64 {
+	sl.rlock()
+	defer sl.runlock()
+	return sl.weight()
+}
+
+// SetWeightLimit bounds the list's total weight: whenever a mutation takes it
+// above max, entries are evicted from the head of the list, as ShedOldest
+// does, until it is back within max. Pinned and leased entries are never
+// evicted, so the limit can be exceeded while they hold it up. A max <= 0
+// removes the limit.
+func (sl *ZeroCopySkiplist[T, K, C]) SetWeightLimit(max int64) {
+	sl.lock()
+	defer sl.unlock()
+	sl.weightLimit = max
+	sl.enforceWeightLimit()
+}
+
+// TrimToWeight evicts entries from the head of the list until its total
+// weight is at most target or no evictable entries remain, returning the
+// weight freed and the number of entries evicted. Pinned and leased entries
+// are skipped.
+func (sl *ZeroCopySkiplist[T, K, C]) TrimToWeight(target int64) (int64, int) {
+	sl.lock()
+	defer sl.unlock()
+	if sl.frozen {
+		return 0, 0
+	}
+	before := sl.weight()
+	_, count := sl.shed(func(int64) bool { return sl.weight() <= target })
+	sl.checkPressure()
+	return before - sl.weight(), count
+}
+
+// weight returns the total weight; the caller must hold the lock
+func (sl *ZeroCopySkiplist[T, K, C]) weight() int64 {
+	if sl.weigher == nil {
+		return sl.totalBytes
+	}
+	return sl.totalWeight
+}
+
+// chargeWeight adds (sign 1) or removes (sign -1) the weight of an entry when
+// a weigher is set; the caller must hold the write lock
+func (sl *ZeroCopySkiplist[T, K, C]) chargeWeight(item *T, context C, sign int) {
+	if sl.weigher != nil {
+		sl.totalWeight += int64(sign) * sl.weigher(item, context)
+	}
+}
+
+// enforceWeightLimit evicts from the head while the list is over its weight
+// limit; the caller must hold the write lock
+func (sl *ZeroCopySkiplist[T, K, C]) enforceWeightLimit() {
+	if sl.weightLimit > 0 && !sl.frozen && sl.weight() > sl.weightLimit {
+		sl.shed(func(int64) bool { return sl.weight() <= sl.weightLimit })
+	}
+}
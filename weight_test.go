@@ -0,0 +1,93 @@
+package zerocopyskiplist
+
+import "testing"
+
+func TestWeigher(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	for _, item := range createTestItems(10) {
+		skiplist.Insert(item, TestContext{AccessCount: 1})
+	}
+	if skiplist.TotalWeight() != skiplist.TotalItemBytes() {
+		t.Errorf("TotalWeight without a weigher = %d, want TotalItemBytes %d", skiplist.TotalWeight(), skiplist.TotalItemBytes())
+	}
+
+	// Weight by ID times the context's access count
+	skiplist.SetWeigher(func(item *TestItem, c TestContext) int64 { return int64(item.ID * c.AccessCount) })
+	if got := skiplist.TotalWeight(); got != 55 {
+		t.Fatalf("TotalWeight after SetWeigher = %d, want 55", got)
+	}
+	skiplist.UpdateContext(10, TestContext{AccessCount: 3})
+	skiplist.Delete(1)
+	replacement := &TestItem{ID: 2, Data: []byte("replacement")}
+	skiplist.Insert(replacement, TestContext{AccessCount: 2})
+	if got := skiplist.TotalWeight(); got != 55+20-1+2 {
+		t.Errorf("TotalWeight after updates = %d, want %d", got, 55+20-1+2)
+	}
+	if _, err := skiplist.RepairFrom(2); err != nil {
+		t.Fatalf("RepairFrom: %v", err)
+	}
+	if got := skiplist.TotalWeight(); got != 76 {
+		t.Errorf("TotalWeight after Repair = %d, want 76", got)
+	}
+	skiplist.Clear()
+	if skiplist.TotalWeight() != 0 {
+		t.Errorf("TotalWeight after Clear = %d", skiplist.TotalWeight())
+	}
+}
+
+func TestTrimToWeight(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	for _, item := range createTestItems(10) {
+		skiplist.Insert(item, TestContext{})
+	}
+	skiplist.SetWeigher(func(item *TestItem, _ TestContext) int64 { return int64(item.ID) })
+	skiplist.Pin(1)
+
+	// 55 - 2 - 3 - 4 - 5 = 41 is the first total at or below 45
+	freed, count := skiplist.TrimToWeight(45)
+	if freed != 14 || count != 4 {
+		t.Errorf("TrimToWeight = %d, %d; want 14, 4", freed, count)
+	}
+	if !contains(skiplist, 1) || contains(skiplist, 5) || !contains(skiplist, 6) {
+		t.Error("TrimToWeight should skip the pinned head and evict 2 to 5")
+	}
+	if freed, count := skiplist.TrimToWeight(0); freed != 40 || count != 5 || skiplist.TotalWeight() != 1 {
+		t.Errorf("TrimToWeight(0) = %d, %d leaving %d; want the pinned entry kept", freed, count, skiplist.TotalWeight())
+	}
+}
+
+func TestWeightLimit(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	skiplist.SetWeigher(func(item *TestItem, c TestContext) int64 { return int64(c.AccessCount) })
+	skiplist.SetWeightLimit(10)
+	for _, item := range createTestItems(8) {
+		skiplist.Insert(item, TestContext{AccessCount: 2})
+	}
+	if skiplist.TotalWeight() != 10 || skiplist.Length() != 5 || contains(skiplist, 3) || !contains(skiplist, 4) {
+		t.Errorf("Inserts past the limit left weight %d over %d entries; want the oldest evicted down to 10", skiplist.TotalWeight(), skiplist.Length())
+	}
+
+	// A context update that adds weight evicts too
+	skiplist.UpdateContext(8, TestContext{AccessCount: 6})
+	if skiplist.TotalWeight() > 10 || !contains(skiplist, 8) || contains(skiplist, 5) {
+		t.Errorf("UpdateContext past the limit left weight %d", skiplist.TotalWeight())
+	}
+
+	// Lowering the limit evicts immediately; removing it stops eviction
+	skiplist.SetWeightLimit(6)
+	if skiplist.TotalWeight() != 6 || skiplist.Length() != 1 {
+		t.Errorf("SetWeightLimit(6) left weight %d over %d entries", skiplist.TotalWeight(), skiplist.Length())
+	}
+	skiplist.SetWeightLimit(0)
+	for _, item := range createTestItems(8) {
+		skiplist.Insert(item, TestContext{AccessCount: 2})
+	}
+	if skiplist.Length() != 8 {
+		t.Errorf("Length without a limit = %d, want 8", skiplist.Length())
+	}
+}
+
+func contains(skiplist *ZeroCopySkiplist[TestItem, int, TestContext], key int) bool {
+	_, ok := skiplist.Get(key)
+	return ok
+}
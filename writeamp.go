@@ -0,0 +1,49 @@
+// writeamp.go - Write amplification accounting for flushes
+
+package zerocopyskiplist
+
+import "sync/atomic"
+
+// writeAmp counts the inputs to the write amplification ratio. Flushes run
+// without the list lock, so the counters are atomic.
+type writeAmp struct {
+	changed   atomic.Uint64 // item bytes written into the list by mutations
+	flushed   atomic.Uint64 // bytes written out by flushes
+	unflushed atomic.Int64  // changed since the last flush started
+}
+
+// countChange adds a write of size item bytes to the logical bytes changed;
+// the caller must hold the write lock
+func (sl *ZeroCopySkiplist[T, K, C]) countChange(size int) {
+	sl.amp.changed.Add(uint64(size))
+	sl.amp.unflushed.Add(int64(size))
+}
+
+// startFlush marks the start of a flush, returning the bytes changed since
+// the previous one
+func (sl *ZeroCopySkiplist[T, K, C]) startFlush() int64 {
+	return sl.amp.unflushed.Swap(0)
+}
+
+// WriteAmplification returns the bytes flushed per logical byte changed over
+// the list's lifetime, 0 before anything has changed. A ratio near 1 means
+// flushes write little beyond the changes; a full flush after a small change
+// drives it up, which is the cue to checkpoint less often or track dirty
+// entries more finely.
+func (s Stats) WriteAmplification() float64 {
+	if s.BytesChanged == 0 {
+		return 0
+	}
+	return float64(s.BytesFlushed) / float64(s.BytesChanged)
+}
+
+// WriteAmplificationSince returns the write amplification over the interval
+// from prev, an earlier Stats of the same list, to s, 0 if nothing changed
+// in between
+func (s Stats) WriteAmplificationSince(prev Stats) float64 {
+	changed := s.BytesChanged - prev.BytesChanged
+	if changed == 0 {
+		return 0
+	}
+	return float64(s.BytesFlushed-prev.BytesFlushed) / float64(changed)
+}
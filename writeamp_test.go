@@ -0,0 +1,99 @@
+package zerocopyskiplist
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteAmplification(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	itemSize := uint64(getTestItemSize(&TestItem{}))
+	for _, item := range createTestItems(10) {
+		skiplist.Insert(item, TestContext{})
+	}
+	skiplist.UpdateContext(1, TestContext{AccessCount: 1})
+	skiplist.Delete(2)
+	stats := skiplist.Stats()
+	if stats.BytesChanged != 11*itemSize || stats.UnflushedBytes != int64(11*itemSize) || stats.WriteAmplification() != 0 {
+		t.Fatalf("After writes: changed %d, unflushed %d, amplification %v", stats.BytesChanged, stats.UnflushedBytes, stats.WriteAmplification())
+	}
+
+	// A full iovec flush writes the 9 live items for 11 changed
+	f, err := os.Create(filepath.Join(t.TempDir(), "flush"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := skiplist.NewIovecWriter(f.Fd(), skiplist.CallbackToIovecSlice(func(*ItemPtr[TestItem, int, TestContext]) bool { return true }), nil).Flush(); err != nil {
+		t.Fatal(err)
+	}
+	prev := skiplist.Stats()
+	if prev.BytesFlushed != 9*itemSize || prev.UnflushedBytes != 0 || prev.WriteAmplification() != 9.0/11 {
+		t.Errorf("After a flush: flushed %d, unflushed %d, amplification %v", prev.BytesFlushed, prev.UnflushedBytes, prev.WriteAmplification())
+	}
+
+	// Rewriting everything for one changed item amplifies by the list length
+	skiplist.Insert(&TestItem{ID: 3}, TestContext{})
+	var buf bytes.Buffer
+	report, err := skiplist.ToEncoded(&buf, testItemCodecV2{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats = skiplist.Stats()
+	if stats.BytesFlushed-prev.BytesFlushed != uint64(report.Bytes) {
+		t.Errorf("Encoded flush counted %d bytes, report says %d", stats.BytesFlushed-prev.BytesFlushed, report.Bytes)
+	}
+	if got, want := stats.WriteAmplificationSince(prev), float64(report.Bytes)/float64(itemSize); got != want {
+		t.Errorf("WriteAmplificationSince = %v, want %v", got, want)
+	}
+	if stats.WriteAmplificationSince(stats) != 0 {
+		t.Error("An empty interval should report 0")
+	}
+}
+
+// failAfterWriter accepts n bytes, then fails every write
+type failAfterWriter struct {
+	n int
+}
+
+func (w *failAfterWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		n := w.n
+		w.n = 0
+		return n, errors.New("disk full")
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestGroupedFlushWriteAmplification(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	for _, item := range createTestItems(10) {
+		skiplist.Insert(item, TestContext{AccessCount: item.ID % 2})
+	}
+	all := func(*ItemPtr[TestItem, int, TestContext]) bool { return true }
+	prev := skiplist.Stats()
+
+	// A failed flush counts what it wrote and leaves the changes unflushed
+	_, err := skiplist.CallbackToGroupedEncoded(&failAfterWriter{n: 10}, testItemCodecV2{}, all, nil)
+	if err == nil {
+		t.Fatal("Expected the write to fail")
+	}
+	stats := skiplist.Stats()
+	if stats.BytesFlushed-prev.BytesFlushed != 10 || stats.UnflushedBytes != prev.UnflushedBytes {
+		t.Errorf("After a failed flush: flushed %d, unflushed %d, want 10 and %d", stats.BytesFlushed-prev.BytesFlushed, stats.UnflushedBytes, prev.UnflushedBytes)
+	}
+
+	var buf bytes.Buffer
+	report, err := skiplist.CallbackToGroupedEncoded(&buf, testItemCodecV2{}, all, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev, stats = stats, skiplist.Stats()
+	if stats.BytesFlushed-prev.BytesFlushed != uint64(report.Bytes) || stats.UnflushedBytes != 0 {
+		t.Errorf("After a grouped flush: flushed %d of %d, unflushed %d", stats.BytesFlushed-prev.BytesFlushed, report.Bytes, stats.UnflushedBytes)
+	}
+}
@@ -0,0 +1,179 @@
+// writev.go - Vectored writes that survive interrupts and full descriptors
+
+package zerocopyskiplist
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// ErrWouldBlock is returned by IovecWriter.Flush when a non-blocking
+// descriptor is still full once the retry policy gives up. The writer keeps
+// its position: call Flush again when the descriptor is writable.
+var ErrWouldBlock = errors.New("write would block")
+
+// RetryPolicy decides how a vectored write that failed with a transient error
+// (EINTR or EAGAIN) is retried. attempt counts consecutive failures from 1 and
+// resets whenever a write makes progress. It returns how long to wait before
+// retrying, or false to give up.
+type RetryPolicy func(attempt int, err error) (time.Duration, bool)
+
+// BackoffRetry returns a policy that retries EINTR immediately and EAGAIN
+// after a delay that doubles from initial up to max, giving up after attempts
+// consecutive failures.
+func BackoffRetry(attempts int, initial, max time.Duration) RetryPolicy {
+	return func(attempt int, err error) (time.Duration, bool) {
+		if attempt > attempts {
+			return 0, false
+		}
+		if errors.Is(err, syscall.EINTR) {
+			return 0, true
+		}
+		return min(initial<<(attempt-1), max), true
+	}
+}
+
+// NoRetry is a policy that retries nothing, so Flush on a non-blocking
+// descriptor returns ErrWouldBlock as soon as it is full. Interrupted writes
+// are still retried, since EINTR says nothing about the descriptor.
+func NoRetry(attempt int, err error) (time.Duration, bool) {
+	return 0, errors.Is(err, syscall.EINTR)
+}
+
+// defaultRetry is used when NewIovecWriter is given no policy
+var defaultRetry = BackoffRetry(8, 50*time.Microsecond, 10*time.Millisecond)
+
+// IovecWriter writes a sequence of iovecs to a file descriptor in chunks of
+// at most the list's iovec limit, resuming after short writes and retrying
+// transient errors under a RetryPolicy. It remembers how far it got, so a
+// flush interrupted by ErrWouldBlock or any other error can be resumed by
+// calling Flush again.
+type IovecWriter struct {
+	fd      uintptr
+	pending []syscall.Iovec // unwritten iovecs; the first may be partly written
+	iovMax  int
+	retry   RetryPolicy
+	written int64
+	flushed *atomic.Uint64 // the list's BytesFlushed
+	report  FlushReport[struct{}]
+}
+
+// NewIovecWriter returns a writer for iovecs, typically from ToIovecSlice or
+// CallbackToIovecSlice, to fd. A nil retry uses a bounded exponential backoff
+// of up to eight attempts. The iovecs are copied; the memory they point at
+// must stay valid until the writer is done.
+func (sl *ZeroCopySkiplist[T, K, C]) NewIovecWriter(fd uintptr, iovecs []syscall.Iovec, retry RetryPolicy) *IovecWriter {
+	sl.startFlush()
+	if retry == nil {
+		retry = defaultRetry
+	}
+	return &IovecWriter{
+		fd:      fd,
+		pending: append([]syscall.Iovec(nil), iovecs...),
+		iovMax:  sl.iovMax(),
+		retry:   retry,
+		flushed: &sl.amp.flushed,
+	}
+}
+
+// Flush writes the pending iovecs and returns nil once all of them have been
+// written. A failure the policy gives up on is returned wrapping the errno,
+// and wrapping ErrWouldBlock as well if the errno was EAGAIN.
+func (w *IovecWriter) Flush() error {
+	start := time.Now()
+	defer func() {
+		w.report.Duration += time.Since(start)
+	}()
+
+	attempt := 0
+	for w.skipEmpty() {
+		chunk := w.pending[:min(len(w.pending), w.iovMax)]
+		n, _, errno := syscall.Syscall(syscall.SYS_WRITEV, w.fd, uintptr(unsafe.Pointer(&chunk[0])), uintptr(len(chunk)))
+		if errno != 0 {
+			err := error(errno)
+			if errno != syscall.EINTR && errno != syscall.EAGAIN {
+				return fmt.Errorf("writev: %w", err)
+			}
+			attempt++
+			wait, ok := w.retry(attempt, err)
+			if !ok {
+				if errno == syscall.EAGAIN {
+					return fmt.Errorf("writev: %w: %w", ErrWouldBlock, err)
+				}
+				return fmt.Errorf("writev: %w", err)
+			}
+			time.Sleep(wait)
+			continue
+		}
+		if n == 0 {
+			return fmt.Errorf("writev: %w", io.ErrShortWrite)
+		}
+		attempt = 0
+		w.report.Chunks++
+		w.advance(int(n))
+	}
+	return nil
+}
+
+// skipEmpty drops leading zero length iovecs and returns true if any bytes remain
+func (w *IovecWriter) skipEmpty() bool {
+	for len(w.pending) > 0 && w.pending[0].Len == 0 {
+		w.pending = w.pending[1:]
+	}
+	return len(w.pending) > 0
+}
+
+// advance consumes n written bytes from the front of the pending iovecs
+func (w *IovecWriter) advance(n int) {
+	w.written += int64(n)
+	w.flushed.Add(uint64(n))
+	for n > 0 {
+		iov := &w.pending[0]
+		length := int(iov.Len)
+		if n < length {
+			iov.Base = (*byte)(unsafe.Add(unsafe.Pointer(iov.Base), n))
+			iov.SetLen(length - n)
+			return
+		}
+		n -= length
+		w.pending = w.pending[1:]
+		if length > 0 {
+			w.report.Entries++
+		}
+	}
+}
+
+// Written returns the number of bytes written so far
+func (w *IovecWriter) Written() int64 {
+	return w.written
+}
+
+// Report returns a FlushReport of the writes so far, across every call to
+// Flush: Entries counts the iovecs written in full, Chunks the writev calls
+// that wrote something and Duration the time spent in Flush. An iovec flush
+// has no keys and skips nothing, so the report's key type is struct{}.
+func (w *IovecWriter) Report() FlushReport[struct{}] {
+	report := w.report
+	report.Bytes = w.written
+	report.rate()
+	return report
+}
+
+// Remaining returns the number of bytes still to be written
+func (w *IovecWriter) Remaining() int64 {
+	var n int64
+	for _, iov := range w.pending {
+		n += int64(iov.Len)
+	}
+	return n
+}
+
+// Done returns true once every iovec has been written
+func (w *IovecWriter) Done() bool {
+	return !w.skipEmpty()
+}
@@ -0,0 +1,86 @@
+package zerocopyskiplist
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestIovecWriterNonBlocking(t *testing.T) {
+	var fds [2]int
+	if err := syscall.Pipe(fds[:]); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.SetNonblock(fds[1], true); err != nil {
+		t.Fatal(err)
+	}
+	r := os.NewFile(uintptr(fds[0]), "pipe-read")
+	defer r.Close()
+	defer syscall.Close(fds[1])
+
+	// Far more than a pipe buffer holds, in pieces that do not line up with
+	// the pipe's page sized writes, so writes end part way through an iovec
+	sl := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt, WithIovMax(7))
+	var want []byte
+	var iovecs []syscall.Iovec
+	for i := 0; i < 400; i++ {
+		data := bytes.Repeat([]byte{byte(i)}, 1000+i)
+		want = append(want, data...)
+		iovecs = append(iovecs, syscall.Iovec{Base: &data[0]})
+		iovecs[len(iovecs)-1].SetLen(len(data))
+		iovecs = append(iovecs, syscall.Iovec{})
+	}
+
+	w := sl.NewIovecWriter(uintptr(fds[1]), iovecs, NoRetry)
+	err := w.Flush()
+	if !errors.Is(err, ErrWouldBlock) || !errors.Is(err, syscall.EAGAIN) {
+		t.Fatalf("Flush to a full pipe = %v, want ErrWouldBlock", err)
+	}
+	if w.Done() || w.Written() == 0 || w.Written()+w.Remaining() != int64(len(want)) {
+		t.Fatalf("Written %d + remaining %d, want %d in total", w.Written(), w.Remaining(), len(want))
+	}
+
+	// Drain the pipe while resuming until everything is written
+	got := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(r)
+		got <- data
+	}()
+	for !w.Done() {
+		if err := w.Flush(); err != nil && !errors.Is(err, ErrWouldBlock) {
+			t.Fatal(err)
+		}
+	}
+	syscall.Close(fds[1])
+	if data := <-got; !bytes.Equal(data, want) {
+		t.Errorf("Pipe received %d bytes, want %d matching bytes", len(data), len(want))
+	}
+	if w.Remaining() != 0 || w.Flush() != nil {
+		t.Error("A finished writer should have nothing left to flush")
+	}
+	if report := w.Report(); report.Entries != 400 || report.Bytes != int64(len(want)) || report.Chunks == 0 || report.Duration == 0 {
+		t.Errorf("Report = %+v, want 400 entries of %d bytes", report, len(want))
+	}
+}
+
+func TestBackoffRetry(t *testing.T) {
+	policy := BackoffRetry(4, time.Millisecond, 5*time.Millisecond)
+	for attempt, want := range []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond, 5 * time.Millisecond} {
+		if wait, ok := policy(attempt+1, syscall.EAGAIN); !ok || wait != want {
+			t.Errorf("Attempt %d waits %v, %v; want %v", attempt+1, wait, ok, want)
+		}
+	}
+	if _, ok := policy(5, syscall.EAGAIN); ok {
+		t.Error("Policy should give up after four attempts")
+	}
+	if wait, ok := policy(1, syscall.EINTR); !ok || wait != 0 {
+		t.Error("EINTR should be retried immediately")
+	}
+	if _, ok := NoRetry(1, syscall.EAGAIN); ok {
+		t.Error("NoRetry should not retry EAGAIN")
+	}
+}
@@ -0,0 +1,95 @@
+// histogram.go - Log-linear latency histogram for the benchmark harness
+
+package zcslbench
+
+import (
+	"math/bits"
+	"time"
+)
+
+// subBuckets is the number of linear buckets per power of two, giving about
+// 12% worst-case relative error on reported percentiles
+const subBuckets = 8
+
+// Histogram records durations in log-linear buckets. It is not safe for
+// concurrent use; each worker records into its own and they are merged.
+type Histogram struct {
+	counts [64 * subBuckets]uint64
+	total  uint64
+	max    time.Duration
+}
+
+// Record adds one sample
+func (h *Histogram) Record(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	h.counts[bucketOf(uint64(d))]++
+	h.total++
+	if d > h.max {
+		h.max = d
+	}
+}
+
+// Merge adds every sample of other to h
+func (h *Histogram) Merge(other *Histogram) {
+	for i, count := range other.counts {
+		h.counts[i] += count
+	}
+	h.total += other.total
+	if other.max > h.max {
+		h.max = other.max
+	}
+}
+
+// Count returns the number of samples recorded
+func (h *Histogram) Count() uint64 {
+	return h.total
+}
+
+// Max returns the largest sample recorded
+func (h *Histogram) Max() time.Duration {
+	return h.max
+}
+
+// Percentile returns the upper bound of the bucket holding the p-th
+// percentile sample (0 < p <= 100), capped at Max
+func (h *Histogram) Percentile(p float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+
+	rank := uint64(p / 100 * float64(h.total))
+	if rank == 0 {
+		rank = 1
+	}
+	var seen uint64
+	for i, count := range h.counts {
+		seen += count
+		if seen >= rank {
+			return min(time.Duration(bucketUpper(i)), h.max)
+		}
+	}
+	return h.max
+}
+
+// bucketOf returns the bucket index for v
+func bucketOf(v uint64) int {
+	if v < subBuckets {
+		return int(v)
+	}
+	exp := bits.Len64(v) - 1 // v is in [2^exp, 2^(exp+1))
+	sub := (v >> (exp - 3)) & (subBuckets - 1)
+	return (exp-2)*subBuckets + int(sub)
+}
+
+// bucketUpper returns the largest value that falls into bucket i
+func bucketUpper(i int) uint64 {
+	if i < subBuckets {
+		return uint64(i)
+	}
+	exp := i/subBuckets + 2
+	sub := uint64(i % subBuckets)
+	lower := uint64(1)<<exp | sub<<(exp-3)
+	return lower + uint64(1)<<(exp-3) - 1
+}
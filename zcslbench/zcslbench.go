@@ -0,0 +1,308 @@
+// zcslbench.go - Concurrent stress and soak harness for the zero copy skiplist
+
+// Package zcslbench drives a zerocopyskiplist with a configurable mix of
+// operations from many goroutines, validating the structure periodically and
+// reporting per-operation latency percentiles. It is used by cmd/zcslbench and
+// can be embedded in tests that need to soak the list under contention.
+package zcslbench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/mattkeenan/zerocopyskiplist"
+)
+
+// Op identifies one kind of operation in a workload
+type Op int
+
+const (
+	OpRead Op = iota
+	OpInsert
+	OpDelete
+	OpScan
+	OpFlush
+	numOps
+)
+
+// opNames are the report labels of each Op
+var opNames = [numOps]string{"read", "insert", "delete", "scan", "flush"}
+
+// String returns the report label of the operation
+func (op Op) String() string {
+	if op < 0 || op >= numOps {
+		return fmt.Sprintf("op(%d)", int(op))
+	}
+	return opNames[op]
+}
+
+// Profile is the percentage mix of operations; the weights need not sum to 100
+type Profile struct {
+	Read   int
+	Insert int
+	Delete int
+	Scan   int
+	Flush  int
+}
+
+// Profiles are named workload mixes for common scenarios
+var Profiles = map[string]Profile{
+	"read-mostly": {Read: 90, Insert: 5, Delete: 5},
+	"balanced":    {Read: 50, Insert: 25, Delete: 20, Scan: 5},
+	"churn":       {Read: 10, Insert: 45, Delete: 45},
+	"flush-heavy": {Read: 40, Insert: 30, Delete: 10, Scan: 5, Flush: 15},
+}
+
+// Config describes one harness run
+type Config struct {
+	Profile       Profile
+	Goroutines    int           // concurrent workers, default 8
+	Duration      time.Duration // run length, default 10s
+	KeySpace      int           // keys are drawn from [0, KeySpace), default 1M
+	Prefill       int           // keys inserted before the timed run starts
+	ScanLength    int           // items visited per scan, default 100
+	MaxLevel      int           // skiplist max level, default 20
+	ValidateEvery time.Duration // structural validation interval, 0 disables
+	FlushTarget   *os.File      // flushes writev here when set, otherwise only build iovecs
+	Seed          int64
+	Options       []zerocopyskiplist.Option
+}
+
+// Record is the fixed-size, pointer-free item the harness indexes
+type Record struct {
+	Key     uint64
+	Version uint64
+	Payload [48]byte
+}
+
+// Report summarises a harness run
+type Report struct {
+	Elapsed     time.Duration
+	Latency     [numOps]Histogram
+	Validations int
+	FinalLength int
+	FlushBytes  int64
+}
+
+// ErrInvariant wraps a structural validation failure detected during a run
+var ErrInvariant = errors.New("skiplist invariant violated")
+
+// Run executes the workload described by cfg until its duration elapses, ctx
+// is cancelled, or validation fails. The report is returned in every case; the
+// error wraps ErrInvariant if validation failed.
+func Run(ctx context.Context, cfg Config) (*Report, error) {
+	cfg = withDefaults(cfg)
+	weights := [numOps]int{cfg.Profile.Read, cfg.Profile.Insert, cfg.Profile.Delete, cfg.Profile.Scan, cfg.Profile.Flush}
+	totalWeight := 0
+	for _, w := range weights {
+		if w < 0 {
+			return nil, fmt.Errorf("negative weight in profile %+v", cfg.Profile)
+		}
+		totalWeight += w
+	}
+	if totalWeight == 0 {
+		return nil, fmt.Errorf("profile %+v has no operations", cfg.Profile)
+	}
+
+	sl := zerocopyskiplist.MakeOrderedZeroCopySkiplist[Record, uint64, uint8](
+		cfg.MaxLevel,
+		func(r *Record) uint64 { return r.Key },
+		func(r *Record) int { return int(unsafe.Sizeof(*r)) },
+		cfg.Options...,
+	)
+
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	for i := 0; i < cfg.Prefill; i++ {
+		sl.Insert(&Record{Key: uint64(rng.Intn(cfg.KeySpace))}, 0)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	report := &Report{}
+	var validateErr error
+	var validators sync.WaitGroup
+	if cfg.ValidateEvery > 0 {
+		validators.Add(1)
+		go func() {
+			defer validators.Done()
+			ticker := time.NewTicker(cfg.ValidateEvery)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+				report.Validations++
+				if err := sl.Validate(); err != nil {
+					validateErr = fmt.Errorf("%w: %v", ErrInvariant, err)
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+
+	workers := make([]*worker, cfg.Goroutines)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := range workers {
+		workers[i] = &worker{
+			cfg:     &cfg,
+			sl:      sl,
+			rng:     rand.New(rand.NewSource(cfg.Seed + int64(i) + 1)),
+			weights: weights,
+			total:   totalWeight,
+		}
+		wg.Add(1)
+		go func(w *worker) {
+			defer wg.Done()
+			w.run(ctx)
+		}(workers[i])
+	}
+	wg.Wait()
+	report.Elapsed = time.Since(start)
+	cancel()
+	validators.Wait()
+
+	for _, w := range workers {
+		for op := range report.Latency {
+			report.Latency[op].Merge(&w.latency[op])
+		}
+		report.FlushBytes += w.flushBytes
+	}
+
+	if validateErr == nil {
+		if err := sl.Validate(); err != nil {
+			validateErr = fmt.Errorf("%w: %v", ErrInvariant, err)
+		}
+		report.Validations++
+	}
+	report.FinalLength = sl.Length()
+	return report, validateErr
+}
+
+// Format writes a human readable summary of the report to w
+func (r *Report) Format(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "elapsed %v, final length %d, validations %d, flushed %d bytes\n",
+		r.Elapsed.Round(time.Millisecond), r.FinalLength, r.Validations, r.FlushBytes)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%-8s %12s %12s %10s %10s %10s %10s\n", "op", "count", "ops/s", "p50", "p90", "p99", "max")
+	if err != nil {
+		return err
+	}
+	for op := Op(0); op < numOps; op++ {
+		h := &r.Latency[op]
+		if h.Count() == 0 {
+			continue
+		}
+		rate := float64(h.Count()) / r.Elapsed.Seconds()
+		_, err = fmt.Fprintf(w, "%-8s %12d %12.0f %10v %10v %10v %10v\n", op, h.Count(), rate,
+			h.Percentile(50), h.Percentile(90), h.Percentile(99), h.Max())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withDefaults fills in zero fields of cfg
+func withDefaults(cfg Config) Config {
+	if cfg.Goroutines <= 0 {
+		cfg.Goroutines = 8
+	}
+	if cfg.Duration <= 0 {
+		cfg.Duration = 10 * time.Second
+	}
+	if cfg.KeySpace <= 0 {
+		cfg.KeySpace = 1 << 20
+	}
+	if cfg.ScanLength <= 0 {
+		cfg.ScanLength = 100
+	}
+	if cfg.MaxLevel <= 0 {
+		cfg.MaxLevel = 20
+	}
+	return cfg
+}
+
+// worker runs operations from one goroutine
+type worker struct {
+	cfg        *Config
+	sl         *zerocopyskiplist.ZeroCopySkiplist[Record, uint64, uint8]
+	rng        *rand.Rand
+	weights    [numOps]int
+	total      int
+	latency    [numOps]Histogram
+	flushBytes int64
+	iovecs     []syscall.Iovec // reused by every flush
+}
+
+// run performs operations until ctx is done
+func (w *worker) run(ctx context.Context) {
+	for ctx.Err() == nil {
+		op := w.pick()
+		key := uint64(w.rng.Intn(w.cfg.KeySpace))
+		start := time.Now()
+		switch op {
+		case OpRead:
+			w.sl.Find(key)
+		case OpInsert:
+			w.sl.Insert(&Record{Key: key, Version: uint64(start.UnixNano())}, 1)
+		case OpDelete:
+			w.sl.Delete(key)
+		case OpScan:
+			c := w.sl.SeekGE(key)
+			for i := 0; i < w.cfg.ScanLength && c.Valid(); i++ {
+				c.Next()
+			}
+		case OpFlush:
+			w.flush()
+		}
+		w.latency[op].Record(time.Since(start))
+	}
+}
+
+// pick draws the next operation according to the profile weights
+func (w *worker) pick() Op {
+	n := w.rng.Intn(w.total)
+	for op, weight := range w.weights {
+		if n < weight {
+			return Op(op)
+		}
+		n -= weight
+	}
+	return OpRead
+}
+
+// flush builds iovecs for dirty records, writes them to the flush target if
+// there is one, and marks them clean
+func (w *worker) flush() {
+	w.iovecs = w.sl.AppendIovecs(w.iovecs[:0], func(item *zerocopyskiplist.ItemPtr[Record, uint64, uint8]) bool {
+		return item.Context() == 1
+	})
+	iovecs := w.iovecs
+	for _, iovec := range iovecs {
+		w.flushBytes += int64(iovec.Len)
+	}
+
+	if w.cfg.FlushTarget != nil {
+		w.sl.NewIovecWriter(w.cfg.FlushTarget.Fd(), iovecs, nil).Flush()
+	}
+
+	for _, iovec := range iovecs {
+		record := (*Record)(unsafe.Pointer(iovec.Base))
+		w.sl.UpdateContext(record.Key, 0)
+	}
+}
@@ -0,0 +1,86 @@
+package zcslbench
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattkeenan/zerocopyskiplist"
+)
+
+func TestRunProfiles(t *testing.T) {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("open %s: %v", os.DevNull, err)
+	}
+	defer devNull.Close()
+
+	for name, profile := range Profiles {
+		report, err := Run(context.Background(), Config{
+			Profile:       profile,
+			Goroutines:    4,
+			Duration:      100 * time.Millisecond,
+			KeySpace:      5000,
+			Prefill:       1000,
+			MaxLevel:      12,
+			ValidateEvery: 20 * time.Millisecond,
+			FlushTarget:   devNull,
+			Seed:          1,
+			Options:       []zerocopyskiplist.Option{zerocopyskiplist.WithNodePool()},
+		})
+		if err != nil {
+			t.Fatalf("%s: Run: %v", name, err)
+		}
+		if report.Validations == 0 {
+			t.Errorf("%s: expected at least one validation", name)
+		}
+		if report.Latency[OpRead].Count() == 0 {
+			t.Errorf("%s: expected reads to be recorded", name)
+		}
+		if profile.Flush > 0 && report.Latency[OpFlush].Count() == 0 {
+			t.Errorf("%s: expected flushes to be recorded", name)
+		}
+
+		var out bytes.Buffer
+		if err := report.Format(&out); err != nil {
+			t.Fatalf("%s: Format: %v", name, err)
+		}
+		if !strings.Contains(out.String(), "read") {
+			t.Errorf("%s: report should list reads:\n%s", name, out.String())
+		}
+	}
+}
+
+func TestRunRejectsEmptyProfile(t *testing.T) {
+	if _, err := Run(context.Background(), Config{Duration: time.Millisecond}); err == nil {
+		t.Error("Run should reject a profile with no operations")
+	}
+}
+
+func TestHistogramPercentiles(t *testing.T) {
+	var h Histogram
+	for i := 1; i <= 1000; i++ {
+		h.Record(time.Duration(i) * time.Microsecond)
+	}
+
+	if h.Count() != 1000 || h.Max() != time.Millisecond {
+		t.Fatalf("Expected 1000 samples with max 1ms, got %d with max %v", h.Count(), h.Max())
+	}
+	for _, p := range []float64{50, 90, 99} {
+		exact := time.Duration(p*10) * time.Microsecond
+		got := h.Percentile(p)
+		if got < exact || got > exact+exact/8 {
+			t.Errorf("p%v = %v, want within 12.5%% above %v", p, got, exact)
+		}
+	}
+
+	var merged Histogram
+	merged.Merge(&h)
+	merged.Merge(&h)
+	if merged.Count() != 2000 || merged.Percentile(50) != h.Percentile(50) {
+		t.Error("Merging a histogram with itself should double counts and keep percentiles")
+	}
+}
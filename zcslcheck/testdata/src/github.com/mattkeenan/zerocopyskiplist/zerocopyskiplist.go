@@ -0,0 +1,51 @@
+// Package zerocopyskiplist declares the part of the real package's API the
+// misuse testdata uses, for analysistest's GOPATH-style tree
+package zerocopyskiplist
+
+import "syscall"
+
+type ZeroCopySkiplist[T any, K comparable, C comparable] struct{}
+
+type ItemPtr[T any, K comparable, C comparable] struct{}
+
+type Entry[T any, K comparable, C comparable] struct {
+	Key     K
+	Item    *T
+	Context C
+}
+
+func MakeZeroCopySkiplist[T any, K comparable, C comparable](maxLevel int, getKeyFromItem func(*T) K, getItemSize func(*T) int, cmpKey func(K, K) int) *ZeroCopySkiplist[T, K, C] {
+	return &ZeroCopySkiplist[T, K, C]{}
+}
+
+func (sl *ZeroCopySkiplist[T, K, C]) Insert(item *T, context C) bool { return true }
+
+func (sl *ZeroCopySkiplist[T, K, C]) InsertChecked(item *T, context C) (bool, error) {
+	return true, nil
+}
+
+func (sl *ZeroCopySkiplist[T, K, C]) Find(key K) (*ItemPtr[T, K, C], C) {
+	var context C
+	return nil, context
+}
+
+func (sl *ZeroCopySkiplist[T, K, C]) UpdateContext(key K, context C) bool { return true }
+
+func (sl *ZeroCopySkiplist[T, K, C]) CallbackToIovecSlice(callback func(*ItemPtr[T, K, C]) bool) []syscall.Iovec {
+	return nil
+}
+
+func (sl *ZeroCopySkiplist[T, K, C]) AppendIovecs(dst []syscall.Iovec, filter func(*ItemPtr[T, K, C]) bool) []syscall.Iovec {
+	return dst
+}
+
+func (ip *ItemPtr[T, K, C]) Item() *T { return nil }
+
+func (ip *ItemPtr[T, K, C]) Key() K {
+	var key K
+	return key
+}
+
+func (ip *ItemPtr[T, K, C]) SetContext(context C) {}
+
+func (ip *ItemPtr[T, K, C]) Entry() Entry[T, K, C] { return Entry[T, K, C]{} }
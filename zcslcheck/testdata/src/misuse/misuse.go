@@ -0,0 +1,71 @@
+// Package misuse exercises the zcslcheck checks; each line that should be
+// reported ends with a want comment holding a pattern of the message, as
+// analysistest reads them
+package misuse
+
+import (
+	"cmp"
+
+	"github.com/mattkeenan/zerocopyskiplist"
+)
+
+type record struct {
+	id    int
+	count int
+	data  []byte
+}
+
+type list = zerocopyskiplist.ZeroCopySkiplist[record, int, int]
+
+func newList() *list {
+	return zerocopyskiplist.MakeZeroCopySkiplist[record, int, int](8,
+		func(r *record) int { return r.id },
+		func(r *record) int { return len(r.data) },
+		cmp.Compare[int])
+}
+
+func inserts(l *list) bool {
+	l.Insert(&record{id: 1}, 0) // want `result of Insert is ignored`
+	_ = l.Insert(&record{id: 2}, 0)
+	if !l.Insert(&record{id: 3}, 0) {
+		return false
+	}
+	inserted, _ := l.InsertChecked(&record{id: 4}, 0)
+	return inserted
+}
+
+func callbacks(l *list) {
+	l.CallbackToIovecSlice(func(p *zerocopyskiplist.ItemPtr[record, int, int]) bool {
+		p.SetContext(1)      // want `SetContext in a CallbackToIovecSlice callback`
+		p.Item().count++     // want `write to the item of a CallbackToIovecSlice callback`
+		p.Item().data[0] = 0 // want `write to the item of a CallbackToIovecSlice callback`
+		count := p.Item().count
+		l.UpdateContext(p.Key(), count)
+		return true
+	})
+	l.AppendIovecs(nil, func(p *zerocopyskiplist.ItemPtr[record, int, int]) bool {
+		r := p.Item()
+		return r.count > 0
+	})
+}
+
+func goroutines(l *list, done chan struct{}) {
+	p, _ := l.Find(1)
+	go func() {
+		_ = p.Key() // want `\*ItemPtr p captured by a goroutine`
+		_ = p.Item()
+		q, _ := l.Find(2)
+		_ = q.Key()
+		close(done)
+	}()
+	go use(p) // want `\*ItemPtr passed to a goroutine`
+	entry := p.Entry()
+	go func() {
+		_ = entry.Key
+	}()
+
+	ch := make(chan *zerocopyskiplist.ItemPtr[record, int, int], 1)
+	ch <- p // want `\*ItemPtr sent on a channel`
+}
+
+func use(*zerocopyskiplist.ItemPtr[record, int, int]) {}
@@ -0,0 +1,303 @@
+// zcslcheck.go - Static checks for misuse of the skiplist API
+
+// Package zcslcheck finds common misuse of the zerocopyskiplist API in
+// type-checked Go code, in the manner of a go vet pass:
+//
+//   - ignoredinsert: the bool result of Insert is discarded, although false
+//     means the key was already present and its item replaced, or that the
+//     list refused the item
+//   - callbackmutation: an iovec callback calls SetContext on the ItemPtr it
+//     is given, which changes a snapshot copy rather than the list, or writes
+//     to its item while iovecs over the item are being built
+//   - shareditemptr: an *ItemPtr is passed to or captured by a go statement,
+//     or sent on a channel, although it points at a live node that writers
+//     may unlink or, WithNodePool, reuse
+//   - iovecplatform: iovec APIs are called in a build for a platform other
+//     than Linux, where IOV_MAX may be as low as 16 so iovecs must be written
+//     through IovecChunks or an IovecWriter
+//
+// Analyzer runs the checks as a golang.org/x/tools/go/analysis pass, so they
+// run under go vet -vettool, gopls or a multichecker; a vet tool is
+//
+//	func main() { singlechecker.Main(zcslcheck.Analyzer) }
+//
+// CheckDir and the zcslcheck command load a package from source instead, and
+// Check takes any already type-checked package.
+package zcslcheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// PackagePath is the import path of the package whose use is checked
+const PackagePath = "github.com/mattkeenan/zerocopyskiplist"
+
+// Names of the checks, reported in Diagnostic.Check
+const (
+	CheckIgnoredInsert    = "ignoredinsert"
+	CheckCallbackMutation = "callbackmutation"
+	CheckSharedItemPtr    = "shareditemptr"
+	CheckIovecPlatform    = "iovecplatform"
+)
+
+// Analyzer reports misuse of the package in the pass's files for the
+// platform in build.Default, which follows GOOS, with each diagnostic's
+// Category naming the check that found it
+var Analyzer = &analysis.Analyzer{
+	Name: "zcslcheck",
+	Doc:  "report misuse of the zerocopyskiplist API\n\nChecks for ignored Insert results, mutation in iovec callbacks, *ItemPtr values shared with goroutines and iovec use that needs chunking off Linux.",
+	URL:  "https://pkg.go.dev/" + PackagePath + "/zcslcheck",
+	Run:  run,
+}
+
+// run is the Analyzer's Run function
+func run(pass *analysis.Pass) (any, error) {
+	for _, d := range Check(pass.Files, pass.TypesInfo, build.Default.GOOS) {
+		pass.Report(analysis.Diagnostic{Pos: d.Pos, Category: d.Check, Message: d.Message})
+	}
+	return nil, nil
+}
+
+// Diagnostic is a misuse found by Check
+type Diagnostic struct {
+	Pos     token.Pos
+	Check   string // name of the check that found it
+	Message string
+}
+
+// Check reports misuse of the package in files, which must have been type
+// checked into info with its Types, Defs, Uses and Selections maps filled, for
+// a build targeting goos. Diagnostics are returned in source order.
+func Check(files []*ast.File, info *types.Info, goos string) []Diagnostic {
+	c := &checker{info: info, goos: goos}
+	for _, file := range files {
+		ast.Inspect(file, c.visit)
+	}
+	sort.SliceStable(c.diags, func(i, j int) bool {
+		return c.diags[i].Pos < c.diags[j].Pos
+	})
+	return c.diags
+}
+
+// CheckDir parses and type-checks the package in dir, choosing its files for
+// ctxt, and checks it for ctxt.GOOS. Imports are type-checked from source.
+// Test files are not included.
+func CheckDir(ctxt *build.Context, dir string) (*token.FileSet, []Diagnostic, error) {
+	pkg, err := ctxt.ImportDir(dir, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range pkg.GoFiles {
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, file)
+	}
+
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check(pkg.ImportPath, fset, files, info); err != nil {
+		return nil, nil, fmt.Errorf("type checking %s: %w", dir, err)
+	}
+	return fset, Check(files, info, ctxt.GOOS), nil
+}
+
+// checker accumulates the diagnostics for one package
+type checker struct {
+	info  *types.Info
+	goos  string
+	diags []Diagnostic
+}
+
+// report records a diagnostic at pos
+func (c *checker) report(pos token.Pos, check, format string, args ...any) {
+	c.diags = append(c.diags, Diagnostic{Pos: pos, Check: check, Message: fmt.Sprintf(format, args...)})
+}
+
+// visit applies the checks to n
+func (c *checker) visit(n ast.Node) bool {
+	switch n := n.(type) {
+	case *ast.ExprStmt:
+		if call, ok := ast.Unparen(n.X).(*ast.CallExpr); ok {
+			if recv, name := c.method(call); recv == "ZeroCopySkiplist" && name == "Insert" {
+				c.report(call.Pos(), CheckIgnoredInsert, "result of Insert is ignored: false means the key was replaced or the item refused")
+			}
+		}
+	case *ast.CallExpr:
+		recv, name := c.method(n)
+		if recv != "ZeroCopySkiplist" || !strings.Contains(name, "Iovec") {
+			break
+		}
+		for _, arg := range n.Args {
+			if lit, ok := ast.Unparen(arg).(*ast.FuncLit); ok {
+				c.checkCallback(name, lit)
+			}
+		}
+		if c.goos != "linux" && name != "IovecChunks" && name != "NewIovecWriter" {
+			c.report(n.Pos(), CheckIovecPlatform, "%s builds iovecs for writev; on %s IOV_MAX may be as low as 16, so write them through IovecChunks or an IovecWriter", name, c.goos)
+		}
+	case *ast.GoStmt:
+		c.checkGo(n)
+	case *ast.SendStmt:
+		if c.isItemPtr(c.info.TypeOf(n.Value)) {
+			c.report(n.Value.Pos(), CheckSharedItemPtr, "*ItemPtr sent on a channel points at a live node that writers may unlink or reuse; send its Entry() or key instead")
+		}
+	}
+	return true
+}
+
+// checkCallback reports SetContext calls on, and writes to the item of, the
+// *ItemPtr parameters of a callback passed to the iovec API api
+func (c *checker) checkCallback(api string, lit *ast.FuncLit) {
+	params := make(map[types.Object]bool)
+	for _, field := range lit.Type.Params.List {
+		for _, name := range field.Names {
+			if obj := c.info.Defs[name]; obj != nil && c.isItemPtr(obj.Type()) {
+				params[obj] = true
+			}
+		}
+	}
+	if len(params) == 0 {
+		return
+	}
+
+	ast.Inspect(lit.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			if recv, name := c.method(n); recv == "ItemPtr" && name == "SetContext" && c.refersTo(n.Fun.(*ast.SelectorExpr).X, params) {
+				c.report(n.Pos(), CheckCallbackMutation, "SetContext in a %s callback changes a snapshot copy of the entry, not the list; use UpdateContext", api)
+			}
+		case *ast.AssignStmt:
+			if n.Tok == token.DEFINE {
+				break
+			}
+			for _, lhs := range n.Lhs {
+				if c.itemOf(lhs, params) {
+					c.report(lhs.Pos(), CheckCallbackMutation, "write to the item of a %s callback's ItemPtr while iovecs over it are being built", api)
+				}
+			}
+		case *ast.IncDecStmt:
+			if c.itemOf(n.X, params) {
+				c.report(n.X.Pos(), CheckCallbackMutation, "write to the item of a %s callback's ItemPtr while iovecs over it are being built", api)
+			}
+		}
+		return true
+	})
+}
+
+// itemOf reports whether expr denotes the item, or part of the item, returned
+// by Item() on one of params
+func (c *checker) itemOf(expr ast.Expr, params map[types.Object]bool) bool {
+	for {
+		switch e := expr.(type) {
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.SelectorExpr:
+			expr = e.X
+		case *ast.CallExpr:
+			recv, name := c.method(e)
+			return recv == "ItemPtr" && name == "Item" && c.refersTo(e.Fun.(*ast.SelectorExpr).X, params)
+		default:
+			return false
+		}
+	}
+}
+
+// checkGo reports *ItemPtr values passed to or captured by a go statement
+func (c *checker) checkGo(stmt *ast.GoStmt) {
+	for _, arg := range stmt.Call.Args {
+		if c.isItemPtr(c.info.TypeOf(arg)) {
+			c.report(arg.Pos(), CheckSharedItemPtr, "*ItemPtr passed to a goroutine points at a live node that writers may unlink or reuse; pass its Entry() or key instead")
+		}
+	}
+
+	lit, ok := ast.Unparen(stmt.Call.Fun).(*ast.FuncLit)
+	if !ok {
+		return
+	}
+	seen := make(map[types.Object]bool)
+	ast.Inspect(lit.Body, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		v, ok := c.info.Uses[id].(*types.Var)
+		if !ok || v.IsField() || seen[v] || !c.isItemPtr(v.Type()) || (v.Pos() >= lit.Pos() && v.Pos() < lit.End()) {
+			return true
+		}
+		seen[v] = true
+		c.report(id.Pos(), CheckSharedItemPtr, "*ItemPtr %s captured by a goroutine points at a live node that writers may unlink or reuse; capture its Entry() or key instead", id.Name)
+		return true
+	})
+}
+
+// method returns the receiver type name and method name of a call to a method
+// of the package, or empty strings
+func (c *checker) method(call *ast.CallExpr) (recv, name string) {
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return "", ""
+	}
+	s := c.info.Selections[sel]
+	if s == nil || s.Kind() != types.MethodVal {
+		return "", ""
+	}
+	sig, ok := s.Obj().Type().(*types.Signature)
+	if !ok || sig.Recv() == nil {
+		return "", ""
+	}
+	return named(sig.Recv().Type()), s.Obj().Name()
+}
+
+// refersTo reports whether expr is an identifier for one of objs
+func (c *checker) refersTo(expr ast.Expr, objs map[types.Object]bool) bool {
+	id, ok := ast.Unparen(expr).(*ast.Ident)
+	return ok && objs[c.info.Uses[id]]
+}
+
+// isItemPtr reports whether t is a pointer to the package's ItemPtr
+func (c *checker) isItemPtr(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	_, ok := types.Unalias(t).(*types.Pointer)
+	return ok && named(t) == "ItemPtr"
+}
+
+// named returns the name of t, or of the type t points to, if it is declared
+// in the package, and an empty string otherwise
+func named(t types.Type) string {
+	t = types.Unalias(t)
+	if p, ok := t.(*types.Pointer); ok {
+		t = types.Unalias(p.Elem())
+	}
+	n, ok := t.(*types.Named)
+	if !ok || n.Obj().Pkg() == nil || n.Obj().Pkg().Path() != PackagePath {
+		return ""
+	}
+	return n.Obj().Name()
+}
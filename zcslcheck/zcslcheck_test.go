@@ -0,0 +1,89 @@
+package zcslcheck
+
+import (
+	"go/build"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+// misuseFile is the testdata file whose lines say which message should be
+// reported on them with a trailing "// want `pattern`" comment
+const misuseFile = "testdata/src/misuse/misuse.go"
+
+// wants returns the message pattern expected on each line of misuseFile
+func wants(t *testing.T) map[int]*regexp.Regexp {
+	t.Helper()
+	data, err := os.ReadFile(misuseFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := make(map[int]*regexp.Regexp)
+	for i, line := range strings.Split(string(data), "\n") {
+		if _, pattern, ok := strings.Cut(line, "// want "); ok {
+			pattern, err := strconv.Unquote(pattern)
+			if err != nil {
+				t.Fatalf("Line %d: %v", i+1, err)
+			}
+			want[i+1] = regexp.MustCompile(pattern)
+		}
+	}
+	return want
+}
+
+// check runs CheckDir over the testdata package for goos, type checking it
+// against the real package, and returns the diagnostics on each line
+func check(t *testing.T, goos string) map[int][]Diagnostic {
+	t.Helper()
+	ctxt := build.Default
+	ctxt.GOOS = goos
+	fset, diags, err := CheckDir(&ctxt, "testdata/src/misuse")
+	if err != nil {
+		t.Fatalf("CheckDir: %v", err)
+	}
+	got := make(map[int][]Diagnostic)
+	for _, d := range diags {
+		pos := fset.Position(d.Pos)
+		if !strings.HasSuffix(pos.Filename, misuseFile) {
+			t.Errorf("Diagnostic in %s", pos.Filename)
+		}
+		got[pos.Line] = append(got[pos.Line], d)
+	}
+	return got
+}
+
+func TestCheck(t *testing.T) {
+	want, got := wants(t), check(t, "linux")
+	for line, diags := range got {
+		if len(diags) != 1 || want[line] == nil || !want[line].MatchString(diags[0].Message) {
+			t.Errorf("Line %d reported %v, want %v", line, diags, want[line])
+		}
+	}
+	for line, pattern := range want {
+		if len(got[line]) == 0 {
+			t.Errorf("Line %d not reported, want %s", line, pattern)
+		}
+	}
+}
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "misuse")
+}
+
+func TestCheckIovecPlatform(t *testing.T) {
+	var iovec []int
+	for line, diags := range check(t, "darwin") {
+		if slices.ContainsFunc(diags, func(d Diagnostic) bool { return d.Check == CheckIovecPlatform }) {
+			iovec = append(iovec, line)
+		}
+	}
+	// CallbackToIovecSlice and AppendIovecs
+	if len(iovec) != 2 {
+		t.Errorf("Iovec calls reported on lines %v, want 2", iovec)
+	}
+}
@@ -0,0 +1,242 @@
+// zcslkey.go - Order-preserving encodings for composite keys
+
+// Package zcslkey encodes composite keys (several fields, such as a tenant
+// ID and a name, or a timestamp and a sequence number) as strings whose
+// bytewise order is the order of the fields compared one after another. The
+// strings are ordinary comparable keys: use them with
+// MakeOrderedZeroCopySkiplist, whose natural string order is bytewise, or
+// pass Compare to MakeZeroCopySkiplist.
+//
+// Each field is appended with the Append function for its type, or its Desc
+// variant to sort that field in descending order, and read back in the same
+// sequence with a Reader:
+//
+//	key := string(zcslkey.AppendString(zcslkey.AppendUint64(nil, tenant), name))
+//
+//	r := zcslkey.NewReader(key)
+//	tenant, _ := r.Uint64()
+//	name, _ := r.String()
+//
+// Integers are big-endian with the sign bit flipped, times are their Unix
+// nanoseconds, and strings are escaped and terminated so that a shorter
+// string sorts before every longer string it prefixes and a key built from
+// leading fields is a prefix of every key that shares them, which suits
+// ScanPrefix with HasPrefix.
+package zcslkey
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrMalformed is returned by Reader methods when the key does not hold the
+// requested field at the current position
+var ErrMalformed = errors.New("malformed key")
+
+// String encoding: 0x00 bytes are escaped as 0x00 0xff and the string ends
+// with 0x00 0x01, which sorts below any escaped or ordinary byte
+const (
+	escape     = 0x00
+	escaped00  = 0xff
+	terminator = 0x01
+)
+
+// Compare orders encoded keys; it is the bytewise order the encodings preserve
+func Compare(a, b string) int {
+	return strings.Compare(a, b)
+}
+
+// HasPrefix reports whether key starts with prefix, for ScanPrefix over the
+// keys that share their leading fields
+func HasPrefix(key, prefix string) bool {
+	return strings.HasPrefix(key, prefix)
+}
+
+// Reverse returns a comparator ordering keys in the opposite order to cmp,
+// for lists that should iterate from the largest key
+func Reverse[K any](cmp func(a, b K) int) func(a, b K) int {
+	return func(a, b K) int {
+		return cmp(b, a)
+	}
+}
+
+// AppendUint64 appends v in 8 big-endian bytes
+func AppendUint64(dst []byte, v uint64) []byte {
+	return binary.BigEndian.AppendUint64(dst, v)
+}
+
+// AppendUint64Desc appends v so that larger values sort first
+func AppendUint64Desc(dst []byte, v uint64) []byte {
+	return AppendUint64(dst, ^v)
+}
+
+// AppendUint32 appends v in 4 big-endian bytes
+func AppendUint32(dst []byte, v uint32) []byte {
+	return binary.BigEndian.AppendUint32(dst, v)
+}
+
+// AppendUint32Desc appends v so that larger values sort first
+func AppendUint32Desc(dst []byte, v uint32) []byte {
+	return AppendUint32(dst, ^v)
+}
+
+// AppendInt64 appends v in 8 big-endian bytes with the sign bit flipped, so
+// negative values sort before positive ones
+func AppendInt64(dst []byte, v int64) []byte {
+	return AppendUint64(dst, uint64(v)^(1<<63))
+}
+
+// AppendInt64Desc appends v so that larger values sort first
+func AppendInt64Desc(dst []byte, v int64) []byte {
+	return AppendUint64Desc(dst, uint64(v)^(1<<63))
+}
+
+// AppendTime appends t as its Unix nanoseconds; the location is not kept
+func AppendTime(dst []byte, t time.Time) []byte {
+	return AppendInt64(dst, t.UnixNano())
+}
+
+// AppendTimeDesc appends t so that later times sort first
+func AppendTimeDesc(dst []byte, t time.Time) []byte {
+	return AppendInt64Desc(dst, t.UnixNano())
+}
+
+// AppendString appends s escaped and terminated
+func AppendString(dst []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		if s[i] == escape {
+			dst = append(dst, escape, escaped00)
+		} else {
+			dst = append(dst, s[i])
+		}
+	}
+	return append(dst, escape, terminator)
+}
+
+// AppendStringDesc appends s so that strings sort in descending order, a
+// string sorting after every longer string it prefixes
+func AppendStringDesc(dst []byte, s string) []byte {
+	start := len(dst)
+	dst = AppendString(dst, s)
+	for i := start; i < len(dst); i++ {
+		dst[i] = ^dst[i]
+	}
+	return dst
+}
+
+// Reader reads the fields of an encoded key in the order they were appended
+type Reader struct {
+	rest string
+}
+
+// NewReader returns a Reader positioned at the first field of key
+func NewReader(key string) *Reader {
+	return &Reader{rest: key}
+}
+
+// Rest returns the part of the key not yet read
+func (r *Reader) Rest() string {
+	return r.rest
+}
+
+// fixed consumes n bytes, or fails if fewer remain
+func (r *Reader) fixed(n int) (string, error) {
+	if len(r.rest) < n {
+		return "", ErrMalformed
+	}
+	field := r.rest[:n]
+	r.rest = r.rest[n:]
+	return field, nil
+}
+
+// Uint64 reads a field written by AppendUint64
+func (r *Reader) Uint64() (uint64, error) {
+	field, err := r.fixed(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64([]byte(field)), nil
+}
+
+// Uint64Desc reads a field written by AppendUint64Desc
+func (r *Reader) Uint64Desc() (uint64, error) {
+	v, err := r.Uint64()
+	return ^v, err
+}
+
+// Uint32 reads a field written by AppendUint32
+func (r *Reader) Uint32() (uint32, error) {
+	field, err := r.fixed(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32([]byte(field)), nil
+}
+
+// Uint32Desc reads a field written by AppendUint32Desc
+func (r *Reader) Uint32Desc() (uint32, error) {
+	v, err := r.Uint32()
+	return ^v, err
+}
+
+// Int64 reads a field written by AppendInt64
+func (r *Reader) Int64() (int64, error) {
+	v, err := r.Uint64()
+	return int64(v ^ (1 << 63)), err
+}
+
+// Int64Desc reads a field written by AppendInt64Desc
+func (r *Reader) Int64Desc() (int64, error) {
+	v, err := r.Uint64Desc()
+	return int64(v ^ (1 << 63)), err
+}
+
+// Time reads a field written by AppendTime, in the local location
+func (r *Reader) Time() (time.Time, error) {
+	ns, err := r.Int64()
+	return time.Unix(0, ns), err
+}
+
+// TimeDesc reads a field written by AppendTimeDesc, in the local location
+func (r *Reader) TimeDesc() (time.Time, error) {
+	ns, err := r.Int64Desc()
+	return time.Unix(0, ns), err
+}
+
+// String reads a field written by AppendString
+func (r *Reader) String() (string, error) {
+	return r.str(0)
+}
+
+// StringDesc reads a field written by AppendStringDesc
+func (r *Reader) StringDesc() (string, error) {
+	return r.str(0xff)
+}
+
+// str reads an escaped, terminated string whose bytes were xored with mask
+func (r *Reader) str(mask byte) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(r.rest); i++ {
+		c := r.rest[i] ^ mask
+		if c != escape {
+			b.WriteByte(c)
+			continue
+		}
+		if i+1 == len(r.rest) {
+			break
+		}
+		i++
+		switch r.rest[i] ^ mask {
+		case terminator:
+			r.rest = r.rest[i+1:]
+			return b.String(), nil
+		case escaped00:
+			b.WriteByte(escape)
+		default:
+			return "", ErrMalformed
+		}
+	}
+	return "", ErrMalformed
+}
@@ -0,0 +1,168 @@
+package zcslkey
+
+import (
+	"cmp"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattkeenan/zerocopyskiplist"
+)
+
+// tuple is a composite key with a field of every supported type
+type tuple struct {
+	u   uint64
+	i   int64
+	s   string
+	ds  string // sorted descending
+	du  uint32 // sorted descending
+	at  time.Time
+	dat time.Time // sorted descending
+}
+
+func (t tuple) encode() string {
+	b := AppendUint64(nil, t.u)
+	b = AppendInt64(b, t.i)
+	b = AppendString(b, t.s)
+	b = AppendStringDesc(b, t.ds)
+	b = AppendUint32Desc(b, t.du)
+	b = AppendTime(b, t.at)
+	b = AppendTimeDesc(b, t.dat)
+	return string(b)
+}
+
+func compareTuples(a, b tuple) int {
+	return cmp.Or(
+		cmp.Compare(a.u, b.u),
+		cmp.Compare(a.i, b.i),
+		strings.Compare(a.s, b.s),
+		strings.Compare(b.ds, a.ds),
+		cmp.Compare(b.du, a.du),
+		a.at.Compare(b.at),
+		b.dat.Compare(a.dat),
+	)
+}
+
+// randomTuple draws fields from small ranges so ties in leading fields are common
+func randomTuple(rng *rand.Rand) tuple {
+	str := func() string {
+		alphabet := []byte{0x00, 0x01, 'a', 'b', 0xfe, 0xff}
+		b := make([]byte, rng.Intn(4))
+		for i := range b {
+			b[i] = alphabet[rng.Intn(len(alphabet))]
+		}
+		return string(b)
+	}
+	return tuple{
+		u:   uint64(rng.Intn(3)) << 62,
+		i:   int64(rng.Intn(5) - 2),
+		s:   str(),
+		ds:  str(),
+		du:  uint32(rng.Intn(3)),
+		at:  time.Unix(0, int64(rng.Intn(3)-1)),
+		dat: time.Unix(0, int64(rng.Intn(3)-1)),
+	}
+}
+
+func TestOrderPreserved(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for n := 0; n < 20000; n++ {
+		a, b := randomTuple(rng), randomTuple(rng)
+		if got, want := Compare(a.encode(), b.encode()), compareTuples(a, b); got != want {
+			t.Fatalf("Compare(%+v, %+v) = %d, want %d", a, b, got, want)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	for n := 0; n < 1000; n++ {
+		want := randomTuple(rng)
+		r := NewReader(want.encode())
+		var got tuple
+		var errs [7]error
+		got.u, errs[0] = r.Uint64()
+		got.i, errs[1] = r.Int64()
+		got.s, errs[2] = r.String()
+		got.ds, errs[3] = r.StringDesc()
+		got.du, errs[4] = r.Uint32Desc()
+		got.at, errs[5] = r.Time()
+		got.dat, errs[6] = r.TimeDesc()
+		for _, err := range errs {
+			if err != nil {
+				t.Fatalf("Decoding %+v: %v", want, err)
+			}
+		}
+		if compareTuples(got, want) != 0 || got.s != want.s || got.ds != want.ds || r.Rest() != "" {
+			t.Fatalf("Decoded %+v, want %+v", got, want)
+		}
+	}
+
+	for _, key := range []string{"", "abc", "a\x00", "a\x00\x02"} {
+		if _, err := NewReader(key).String(); err != ErrMalformed {
+			t.Errorf("String() of %q = %v, want ErrMalformed", key, err)
+		}
+	}
+	if _, err := NewReader("short").Uint64(); err != ErrMalformed {
+		t.Errorf("Uint64() of a short key = %v, want ErrMalformed", err)
+	}
+}
+
+func TestScanPrefixByTenant(t *testing.T) {
+	sl := zerocopyskiplist.MakeOrderedZeroCopySkiplist[string, string, int](8, func(k *string) string { return *k }, func(k *string) int { return len(*k) })
+	for tenant := uint64(1); tenant <= 3; tenant++ {
+		for _, name := range []string{"", "alice", "bob"} {
+			key := string(AppendString(AppendUint64(nil, tenant), name))
+			sl.Insert(&key, 0)
+		}
+	}
+
+	var names []string
+	sl.ScanPrefix(string(AppendUint64(nil, 2)), HasPrefix, func(ip *zerocopyskiplist.ItemPtr[string, string, int]) bool {
+		r := NewReader(ip.Key())
+		if tenant, _ := r.Uint64(); tenant != 2 {
+			t.Errorf("Scanned tenant %d", tenant)
+		}
+		name, _ := r.String()
+		names = append(names, name)
+		return true
+	})
+	if !slices.Equal(names, []string{"", "alice", "bob"}) {
+		t.Errorf("Tenant 2 names = %v", names)
+	}
+
+	desc := Reverse(strings.Compare)
+	if desc("a", "b") != 1 || desc("b", "a") != -1 || desc("a", "a") != 0 {
+		t.Error("Reverse should invert the comparator")
+	}
+}
+
+// event is stored several times under one logical key, its user
+type event struct {
+	user  string
+	seq   uint64
+	score int64
+}
+
+func TestDuplicatesByTieBreak(t *testing.T) {
+	// Events of a user are kept in descending score order, ties broken by
+	// insert sequence, under a key of (user, score descending, sequence)
+	sl := zerocopyskiplist.MakeOrderedZeroCopySkiplist[event, string, int](8, func(e *event) string {
+		return string(AppendUint64(AppendInt64Desc(AppendString(nil, e.user), e.score), e.seq))
+	}, func(*event) int { return 1 })
+	events := []event{{"bob", 1, 5}, {"alice", 2, 3}, {"bob", 3, 9}, {"bob", 4, 5}, {"carol", 5, 1}, {"bob", 6, -1}}
+	for i := range events {
+		sl.Insert(&events[i], 0)
+	}
+
+	var seqs []uint64
+	sl.ScanPrefix(string(AppendString(nil, "bob")), HasPrefix, func(ip *zerocopyskiplist.ItemPtr[event, string, int]) bool {
+		seqs = append(seqs, ip.Item().seq)
+		return true
+	})
+	if !slices.Equal(seqs, []uint64{3, 1, 4, 6}) {
+		t.Errorf("bob's events in order %v, want [3 1 4 6]", seqs)
+	}
+}
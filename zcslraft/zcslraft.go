@@ -0,0 +1,157 @@
+// zcslraft.go - Replicated state machine adapter for raft consensus libraries
+
+// Package zcslraft lets a zerocopyskiplist serve as the replicated state
+// machine of a raft cluster, such as hashicorp/raft. Mutations are encoded as
+// commands with EncodeInsert and EncodeDelete and proposed through raft; every
+// node applies them to its list with Apply. Snapshots use the list's versioned
+// snapshot format, so a schema change is handled by the codec's Migrate.
+//
+// The package has no raft dependency, so the raft library's types appear in a
+// few lines of shim; for hashicorp/raft:
+//
+//	type raftFSM struct{ *zcslraft.FSM[Entry, string, Meta] }
+//
+//	func (f raftFSM) Apply(l *raft.Log) interface{} { return f.FSM.Apply(l.Data) }
+//
+//	func (f raftFSM) Snapshot() (raft.FSMSnapshot, error) {
+//		s, err := f.FSM.Snapshot()
+//		return raftSnapshot{s}, err
+//	}
+//
+//	type raftSnapshot struct{ *zcslraft.Snapshot[Entry, string, Meta] }
+//
+//	func (s raftSnapshot) Persist(sink raft.SnapshotSink) error { return s.Snapshot.Persist(sink) }
+//
+// Restore already has the signature raft expects.
+package zcslraft
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mattkeenan/zerocopyskiplist"
+)
+
+// Command operations
+const (
+	opInsert = 1
+	opDelete = 2
+)
+
+// ErrBadCommand is returned by Apply for data that is not an encoded command
+var ErrBadCommand = errors.New("malformed command")
+
+// FSM applies replicated commands to a skiplist
+type FSM[T any, K comparable, C comparable] struct {
+	list  *zerocopyskiplist.ZeroCopySkiplist[T, K, C]
+	codec zerocopyskiplist.ItemCodec[T, C]
+	keys  zerocopyskiplist.KeyCodec[K]
+}
+
+// New returns an FSM applying commands to list, encoding items with codec and
+// keys with keys. The list must only be modified through Apply and Restore,
+// though it may be read directly at any time.
+func New[T any, K comparable, C comparable](
+	list *zerocopyskiplist.ZeroCopySkiplist[T, K, C],
+	codec zerocopyskiplist.ItemCodec[T, C],
+	keys zerocopyskiplist.KeyCodec[K],
+) *FSM[T, K, C] {
+	return &FSM[T, K, C]{list: list, codec: codec, keys: keys}
+}
+
+// List returns the skiplist the FSM applies commands to
+func (f *FSM[T, K, C]) List() *zerocopyskiplist.ZeroCopySkiplist[T, K, C] {
+	return f.list
+}
+
+// EncodeInsert returns a command inserting or replacing item with context.
+// Commands record the codec's schema version, so log entries written before a
+// schema change are migrated when they are replayed.
+func (f *FSM[T, K, C]) EncodeInsert(item *T, context C) ([]byte, error) {
+	cmd := binary.AppendUvarint([]byte{opInsert}, uint64(f.codec.SchemaVersion()))
+	return f.codec.Encode(cmd, item, context)
+}
+
+// EncodeDelete returns a command deleting key
+func (f *FSM[T, K, C]) EncodeDelete(key K) []byte {
+	return f.keys.AppendKey([]byte{opDelete}, key)
+}
+
+// Apply applies a command created by EncodeInsert or EncodeDelete and returns
+// true if an item was added or deleted, false if an existing item was replaced
+// or the key to delete was absent, or an error if the command is malformed.
+func (f *FSM[T, K, C]) Apply(data []byte) any {
+	if len(data) == 0 {
+		return ErrBadCommand
+	}
+	switch data[0] {
+	case opInsert:
+		version, n := binary.Uvarint(data[1:])
+		if n <= 0 {
+			return ErrBadCommand
+		}
+		item, context, err := zerocopyskiplist.DecodeVersion(f.codec, int(version), data[1+n:])
+		if err != nil {
+			return fmt.Errorf("decoding insert: %w", err)
+		}
+		return f.list.Insert(item, context)
+	case opDelete:
+		key, err := f.keys.DecodeKey(data[1:])
+		if err != nil {
+			return fmt.Errorf("decoding delete: %w", err)
+		}
+		return f.list.Delete(key)
+	}
+	return fmt.Errorf("%w: unknown operation %d", ErrBadCommand, data[0])
+}
+
+// Snapshot captures the list for persisting. The capture is a structural copy
+// sharing the items, so it is cheap and Apply may continue while the snapshot
+// is persisted; items must therefore not be modified in place.
+func (f *FSM[T, K, C]) Snapshot() (*Snapshot[T, K, C], error) {
+	return &Snapshot[T, K, C]{list: f.list.Copy(), codec: f.codec}, nil
+}
+
+// Restore replaces the list's contents with a snapshot written by Persist.
+// The list is cleared first, so it is left empty if the snapshot is unreadable.
+func (f *FSM[T, K, C]) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+	f.list.Clear()
+	return f.list.LoadSnapshot(rc, f.codec)
+}
+
+// SnapshotSink receives a persisted snapshot; hashicorp/raft's SnapshotSink
+// satisfies it
+type SnapshotSink interface {
+	io.WriteCloser
+	Cancel() error
+}
+
+// Snapshot is a point-in-time capture of an FSM's list
+type Snapshot[T any, K comparable, C comparable] struct {
+	list  *zerocopyskiplist.ZeroCopySkiplist[T, K, C]
+	codec zerocopyskiplist.ItemCodec[T, C]
+}
+
+// Persist writes the snapshot to sink, closing it on success and cancelling
+// it on failure
+func (s *Snapshot[T, K, C]) Persist(sink SnapshotSink) error {
+	out := bufio.NewWriter(sink)
+	err := s.list.SaveSnapshot(out, s.codec)
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		sink.Cancel()
+		return err
+	}
+	return sink.Close()
+}
+
+// Release drops the captured list
+func (s *Snapshot[T, K, C]) Release() {
+	s.list = nil
+}
@@ -0,0 +1,136 @@
+package zcslraft
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/mattkeenan/zerocopyskiplist"
+)
+
+// Entry is a replicated key/value pair
+type Entry struct {
+	Key   uint64
+	Value uint64
+}
+
+// Meta is the replicated context
+type Meta struct {
+	Term uint32
+}
+
+// uint64Keys encodes keys as 8 byte little-endian integers
+type uint64Keys struct{}
+
+func (uint64Keys) AppendKey(dst []byte, key uint64) []byte {
+	return binary.LittleEndian.AppendUint64(dst, key)
+}
+
+func (uint64Keys) DecodeKey(raw []byte) (uint64, error) {
+	if len(raw) != 8 {
+		return 0, fmt.Errorf("key of %d bytes", len(raw))
+	}
+	return binary.LittleEndian.Uint64(raw), nil
+}
+
+// testSink collects a persisted snapshot
+type testSink struct {
+	bytes.Buffer
+	closed, cancelled bool
+}
+
+func (s *testSink) Close() error  { s.closed = true; return nil }
+func (s *testSink) Cancel() error { s.cancelled = true; return nil }
+
+func newFSM(t *testing.T) *FSM[Entry, uint64, Meta] {
+	codec, err := zerocopyskiplist.NewLittleEndianCodec[Entry, Meta](1)
+	if err != nil {
+		t.Fatalf("NewLittleEndianCodec: %v", err)
+	}
+	list := zerocopyskiplist.MakeOrderedZeroCopySkiplist[Entry, uint64, Meta](
+		12,
+		func(e *Entry) uint64 { return e.Key },
+		func(e *Entry) int { return 16 },
+	)
+	return New(list, codec, uint64Keys{})
+}
+
+func TestApplyReplicates(t *testing.T) {
+	leader, follower := newFSM(t), newFSM(t)
+
+	// The leader encodes commands; every node applies the same log
+	var log [][]byte
+	for i := uint64(1); i <= 100; i++ {
+		cmd, err := leader.EncodeInsert(&Entry{Key: i, Value: i * i}, Meta{Term: 1})
+		if err != nil {
+			t.Fatalf("EncodeInsert: %v", err)
+		}
+		log = append(log, cmd)
+	}
+	for i := uint64(1); i <= 100; i += 3 {
+		log = append(log, leader.EncodeDelete(i))
+	}
+	for _, node := range []*FSM[Entry, uint64, Meta]{leader, follower} {
+		for _, cmd := range log {
+			if result := node.Apply(cmd); result != true {
+				t.Fatalf("Apply returned %v", result)
+			}
+		}
+	}
+
+	if leader.List().Length() != 66 || follower.List().Length() != 66 {
+		t.Errorf("Expected 66 items on both nodes, got %d and %d", leader.List().Length(), follower.List().Length())
+	}
+	found, meta := follower.List().Find(50)
+	if found == nil || found.Item().Value != 2500 || meta.Term != 1 {
+		t.Error("Follower has the wrong entry for key 50")
+	}
+	if result := follower.Apply(follower.EncodeDelete(1)); result != false {
+		t.Errorf("Deleting an absent key should return false, got %v", result)
+	}
+
+	for _, bad := range [][]byte{nil, {9}, {opDelete, 1, 2}} {
+		if err, ok := follower.Apply(bad).(error); !ok {
+			t.Errorf("Expected an error applying %v", bad)
+		} else if len(bad) < 2 && !errors.Is(err, ErrBadCommand) {
+			t.Errorf("Expected ErrBadCommand applying %v, got %v", bad, err)
+		}
+	}
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	source := newFSM(t)
+	for i := uint64(1); i <= 500; i++ {
+		cmd, _ := source.EncodeInsert(&Entry{Key: i, Value: i}, Meta{Term: 2})
+		source.Apply(cmd)
+	}
+
+	snapshot, err := source.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	// Mutations after the capture are not part of the snapshot
+	source.Apply(source.EncodeDelete(1))
+
+	sink := &testSink{}
+	if err := snapshot.Persist(sink); err != nil || !sink.closed || sink.cancelled {
+		t.Fatalf("Persist: %v (closed %v, cancelled %v)", err, sink.closed, sink.cancelled)
+	}
+	snapshot.Release()
+
+	target := newFSM(t)
+	cmd, _ := target.EncodeInsert(&Entry{Key: 9999}, Meta{})
+	target.Apply(cmd)
+	if err := target.Restore(io.NopCloser(&sink.Buffer)); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if target.List().Length() != 500 || target.List().FindItem(9999) != nil || target.List().FindItem(1) == nil {
+		t.Error("Restore should replace the list with the snapshot contents")
+	}
+	if err := target.List().Validate(); err != nil {
+		t.Errorf("Validate after restore: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+// expiry.go - Index of keys ordered by expiry time
+
+package zcslserver
+
+import (
+	"cmp"
+	"strings"
+	"time"
+
+	"github.com/mattkeenan/zerocopyskiplist"
+)
+
+// expiry is an entry of the expiry index: key expires at At
+type expiry struct {
+	At  int64 // unix nanoseconds
+	Key string
+}
+
+// expiryIndex orders keys with a TTL by expiry time, then key. Entries are
+// added by SET and removed by the sweep once due, so a key that was deleted
+// or overwritten leaves a stale entry until its old expiry passes; the sweep
+// checks the key's current expiry before deleting anything.
+type expiryIndex = zerocopyskiplist.ZeroCopySkiplist[expiry, expiry, struct{}]
+
+// newExpiryIndex returns an empty expiry index
+func newExpiryIndex() *expiryIndex {
+	return zerocopyskiplist.MakeZeroCopySkiplist[expiry, expiry, struct{}](
+		24,
+		func(e *expiry) expiry { return *e },
+		func(e *expiry) int { return 8 + len(e.Key) },
+		func(a, b expiry) int {
+			if c := cmp.Compare(a.At, b.At); c != 0 {
+				return c
+			}
+			return strings.Compare(a.Key, b.Key)
+		},
+	)
+}
+
+// indexExpiry adds key to the expiry index if meta carries an expiry
+func (s *Server) indexExpiry(key string, meta Meta) {
+	if meta.ExpiresAt != 0 {
+		s.expiries.Insert(&expiry{At: meta.ExpiresAt, Key: key}, struct{}{})
+	}
+}
+
+// NextExpiry returns the earliest expiry time in the index, false if no key
+// has a TTL, so a scheduler can call SweepExpired exactly when it is due. It
+// may be earlier than any live key's expiry when keys with a TTL have since
+// been deleted or overwritten, but never later.
+func (s *Server) NextExpiry() (time.Time, bool) {
+	first := s.expiries.First()
+	if first == nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, first.Key().At), true
+}
+
+// SweepExpired deletes every expired key and returns how many were removed.
+// It pops due entries from the expiry index, so its cost depends on the
+// number of expired keys rather than the size of the list. Expired keys are
+// also removed lazily when accessed.
+func (s *Server) SweepExpired() int {
+	now := s.now().UnixNano()
+	var due []expiry
+	for c := s.expiries.SeekGE(expiry{}); c.Valid() && c.Key().At <= now; c.Next() {
+		due = append(due, c.Key())
+	}
+
+	removed := 0
+	for _, e := range due {
+		if s.deleteExpired(e.Key) {
+			removed++
+		}
+		// A pinned key is still due; keep its entry for the next sweep
+		if entry, ok := s.list.Get(e.Key); !ok || entry.Context.ExpiresAt != e.At {
+			s.expiries.Delete(e)
+		}
+	}
+	return removed
+}
@@ -0,0 +1,112 @@
+// resp.go - Minimal RESP2 protocol reader and writer
+
+package zcslserver
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// maxBulkLen bounds the size of a single bulk string a client may send
+const maxBulkLen = 64 << 20
+
+// errProtocol is returned for malformed client input; the connection is closed
+var errProtocol = errors.New("protocol error")
+
+// readCommand reads one command, either a RESP array of bulk strings or an
+// inline command of space separated words, and returns its arguments
+func readCommand(r *bufio.Reader) ([][]byte, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		return bytes.Fields(line), nil
+	}
+
+	n, err := strconv.Atoi(string(line[1:]))
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("%w: bad array length %q", errProtocol, line)
+	}
+	args := make([][]byte, n)
+	for i := range args {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, fmt.Errorf("%w: expected bulk string, got %q", errProtocol, line)
+		}
+		size, err := strconv.Atoi(string(line[1:]))
+		if err != nil || size < 0 || size > maxBulkLen {
+			return nil, fmt.Errorf("%w: bad bulk length %q", errProtocol, line)
+		}
+		args[i] = make([]byte, size+2)
+		if _, err := io.ReadFull(r, args[i]); err != nil {
+			return nil, err
+		}
+		if !bytes.HasSuffix(args[i], []byte("\r\n")) {
+			return nil, fmt.Errorf("%w: bulk string not terminated by CRLF", errProtocol)
+		}
+		args[i] = args[i][:size]
+	}
+	return args, nil
+}
+
+// readLine reads a CRLF or LF terminated line without its terminator
+func readLine(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		return nil, fmt.Errorf("%w: line too long", errProtocol)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(line[:len(line)-1], []byte("\r")), nil
+}
+
+// respWriter writes RESP2 replies
+type respWriter struct {
+	*bufio.Writer
+}
+
+func (w respWriter) simple(s string) {
+	w.WriteByte('+')
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+func (w respWriter) error(s string) {
+	w.WriteString("-ERR ")
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+func (w respWriter) integer(n int64) {
+	w.WriteByte(':')
+	w.WriteString(strconv.FormatInt(n, 10))
+	w.WriteString("\r\n")
+}
+
+// bulk writes b as a bulk string, or the null bulk string when b is nil
+func (w respWriter) bulk(b []byte) {
+	if b == nil {
+		w.WriteString("$-1\r\n")
+		return
+	}
+	w.WriteByte('$')
+	w.WriteString(strconv.Itoa(len(b)))
+	w.WriteString("\r\n")
+	w.Write(b)
+	w.WriteString("\r\n")
+}
+
+func (w respWriter) array(n int) {
+	w.WriteByte('*')
+	w.WriteString(strconv.Itoa(n))
+	w.WriteString("\r\n")
+}
@@ -0,0 +1,487 @@
+// zcslserver.go - RESP key/value server backed by a zero copy skiplist
+
+// Package zcslserver serves a Redis-compatible subset of commands (GET, SET
+// with expiry, DEL, EXISTS, TTL, DBSIZE, SCAN, RANGE, SAVE) over RESP from a
+// single skiplist. It exercises the codec, snapshot and expiry paths end to
+// end, and gives redis-benchmark and redis-cli a realistic target. It is used
+// by cmd/zcsl-server.
+package zcslserver
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattkeenan/zerocopyskiplist"
+)
+
+// Record is a stored key/value pair. Records are never modified once inserted;
+// SET replaces the record.
+type Record struct {
+	Key   string
+	Value []byte
+}
+
+// Meta is the context stored with each record
+type Meta struct {
+	ExpiresAt int64 // unix nanoseconds, 0 if the key never expires
+}
+
+// SchemaVersion is the snapshot schema version written by Codec
+const SchemaVersion = 1
+
+// scanDefaultCount is the number of keys SCAN returns when COUNT is not given
+const scanDefaultCount = 10
+
+// List is the skiplist type served
+type List = zerocopyskiplist.ZeroCopySkiplist[Record, string, Meta]
+
+// Server serves RESP connections from a skiplist
+type Server struct {
+	list         *List
+	expiries     *expiryIndex
+	codec        zerocopyskiplist.ItemCodec[Record, Meta]
+	snapshotPath string
+	now          func() time.Time
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+}
+
+// New returns a server whose list is built with opts. When snapshotPath is not
+// empty, an existing snapshot there is loaded and SAVE writes to it.
+func New(snapshotPath string, opts ...zerocopyskiplist.Option) (*Server, error) {
+	codec, err := Codec()
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		list: zerocopyskiplist.MakeOrderedZeroCopySkiplist[Record, string, Meta](
+			24,
+			func(r *Record) string { return r.Key },
+			func(r *Record) int { return len(r.Key) + len(r.Value) },
+			opts...,
+		),
+		expiries:     newExpiryIndex(),
+		codec:        codec,
+		snapshotPath: snapshotPath,
+		listeners:    make(map[net.Listener]struct{}),
+		conns:        make(map[net.Conn]struct{}),
+	}
+	s.now = s.list.Clock().Now
+
+	if snapshotPath != "" {
+		f, err := os.Open(snapshotPath)
+		if err == nil {
+			err = s.list.LoadSnapshot(f, codec)
+			f.Close()
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("loading snapshot %s: %w", snapshotPath, err)
+		}
+		s.list.ForEach(func(ip *zerocopyskiplist.ItemPtr[Record, string, Meta]) bool {
+			s.indexExpiry(ip.Key(), ip.Context())
+			return true
+		})
+	}
+	return s, nil
+}
+
+// Codec returns the codec used for snapshots: the key and value as a uvarint
+// length prefixed key followed by the value, after the fixed size Meta
+func Codec() (*zerocopyskiplist.MessageCodec[Record, Meta], error) {
+	return zerocopyskiplist.NewMessageCodec[Record, Meta](SchemaVersion,
+		func(dst []byte, r *Record) ([]byte, error) {
+			dst = binary.AppendUvarint(dst, uint64(len(r.Key)))
+			dst = append(dst, r.Key...)
+			return append(dst, r.Value...), nil
+		},
+		func(raw []byte, r *Record) error {
+			size, n := binary.Uvarint(raw)
+			if n <= 0 || uint64(len(raw)-n) < size {
+				return errors.New("bad record key length")
+			}
+			r.Key = string(raw[n : n+int(size)])
+			r.Value = append([]byte(nil), raw[n+int(size):]...)
+			return nil
+		})
+}
+
+// List returns the skiplist being served
+func (s *Server) List() *List {
+	return s.list
+}
+
+// Serve accepts connections on l and serves each in its own goroutine until l
+// is closed or Close is called
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return net.ErrClosed
+	}
+	s.listeners[l] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.listeners, l)
+		s.mu.Unlock()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return net.ErrClosed
+			}
+			return err
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		go func() {
+			s.ServeConn(conn)
+			conn.Close()
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// Close stops every listener passed to Serve and closes open connections
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for l := range s.listeners {
+		l.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	return nil
+}
+
+// ServeConn reads commands from conn and writes their replies until the
+// client disconnects, sends QUIT or sends malformed input
+func (s *Server) ServeConn(conn io.ReadWriter) error {
+	r := bufio.NewReader(conn)
+	w := respWriter{bufio.NewWriter(conn)}
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			if errors.Is(err, errProtocol) {
+				w.error(err.Error())
+				w.Flush()
+			}
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		quit := s.execute(w, args)
+		// Flush once the client has no further pipelined commands waiting
+		if quit || r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		}
+		if quit {
+			return nil
+		}
+	}
+}
+
+// execute runs one command and writes its reply, returning true for QUIT
+func (s *Server) execute(w respWriter, args [][]byte) bool {
+	name := strings.ToUpper(string(args[0]))
+	args = args[1:]
+	switch name {
+	case "PING":
+		if len(args) > 0 {
+			w.bulk(args[0])
+		} else {
+			w.simple("PONG")
+		}
+	case "QUIT":
+		w.simple("OK")
+		return true
+	case "GET":
+		if !arity(w, name, args, 1, 1) {
+			break
+		}
+		if record, _, ok := s.get(string(args[0])); ok {
+			w.bulk(record.Value)
+		} else {
+			w.bulk(nil)
+		}
+	case "SET":
+		s.set(w, args)
+	case "DEL":
+		if !arity(w, name, args, 1, -1) {
+			break
+		}
+		deleted := 0
+		for _, key := range args {
+			if _, _, ok := s.get(string(key)); ok && s.list.Delete(string(key)) {
+				deleted++
+			}
+		}
+		w.integer(int64(deleted))
+	case "EXISTS":
+		if !arity(w, name, args, 1, -1) {
+			break
+		}
+		exists := 0
+		for _, key := range args {
+			if _, _, ok := s.get(string(key)); ok {
+				exists++
+			}
+		}
+		w.integer(int64(exists))
+	case "TTL":
+		if !arity(w, name, args, 1, 1) {
+			break
+		}
+		_, meta, ok := s.get(string(args[0]))
+		switch {
+		case !ok:
+			w.integer(-2)
+		case meta.ExpiresAt == 0:
+			w.integer(-1)
+		default:
+			remaining := time.Duration(meta.ExpiresAt - s.now().UnixNano())
+			w.integer(int64((remaining + time.Second - 1) / time.Second))
+		}
+	case "DBSIZE":
+		w.integer(int64(s.list.Length()))
+	case "SCAN":
+		s.scan(w, args)
+	case "RANGE":
+		s.rangeKeys(w, args)
+	case "SAVE":
+		if err := s.Save(); err != nil {
+			w.error(err.Error())
+		} else {
+			w.simple("OK")
+		}
+	default:
+		w.error(fmt.Sprintf("unknown command '%s'", strings.ToLower(name)))
+	}
+	return false
+}
+
+// arity writes an error and returns false unless min <= len(args) <= max,
+// where a negative max is unbounded
+func arity(w respWriter, name string, args [][]byte, min, max int) bool {
+	if len(args) < min || (max >= 0 && len(args) > max) {
+		w.error(fmt.Sprintf("wrong number of arguments for '%s' command", strings.ToLower(name)))
+		return false
+	}
+	return true
+}
+
+// get returns the live record for key, deleting it if it has expired
+func (s *Server) get(key string) (*Record, Meta, bool) {
+	entry, ok := s.list.Get(key)
+	if !ok {
+		return nil, entry.Context, false
+	}
+	if s.expired(entry.Context) {
+		s.deleteExpired(key)
+		return nil, entry.Context, false
+	}
+	return entry.Item, entry.Context, true
+}
+
+// expired returns true if meta carries an expiry that has passed
+func (s *Server) expired(meta Meta) bool {
+	return meta.ExpiresAt != 0 && meta.ExpiresAt <= s.now().UnixNano()
+}
+
+// deleteExpired deletes key if it is still expired, so a concurrent SET that
+// replaced it is not lost, and returns true if it was deleted. Pinned keys
+// are left in place until they are unpinned.
+func (s *Server) deleteExpired(key string) bool {
+	if entry, _ := s.list.Get(key); s.expired(entry.Context) && !s.list.Pinned(key) {
+		return s.list.Delete(key)
+	}
+	return false
+}
+
+// set handles SET key value [EX seconds | PX milliseconds]
+func (s *Server) set(w respWriter, args [][]byte) {
+	if !arity(w, "SET", args, 2, 4) {
+		return
+	}
+
+	var meta Meta
+	if len(args) > 2 {
+		if len(args) != 4 {
+			w.error("syntax error")
+			return
+		}
+		n, err := strconv.ParseInt(string(args[3]), 10, 64)
+		if err != nil || n <= 0 {
+			w.error("invalid expire time in 'set' command")
+			return
+		}
+		var ttl time.Duration
+		switch strings.ToUpper(string(args[2])) {
+		case "EX":
+			ttl = time.Duration(n) * time.Second
+		case "PX":
+			ttl = time.Duration(n) * time.Millisecond
+		default:
+			w.error("syntax error")
+			return
+		}
+		meta.ExpiresAt = s.now().Add(ttl).UnixNano()
+	}
+
+	s.list.Insert(&Record{Key: string(args[0]), Value: args[1]}, meta)
+	s.indexExpiry(string(args[0]), meta)
+	w.simple("OK")
+}
+
+// scan handles SCAN cursor [COUNT n]. The cursor is "0" to start and
+// otherwise the last key returned prefixed with ':'; a returned cursor of "0"
+// means the scan is complete. Keys are returned in order.
+func (s *Server) scan(w respWriter, args [][]byte) {
+	if !arity(w, "SCAN", args, 1, 3) {
+		return
+	}
+	count := scanDefaultCount
+	if len(args) > 1 {
+		if len(args) != 3 || !strings.EqualFold(string(args[1]), "COUNT") {
+			w.error("syntax error")
+			return
+		}
+		n, err := strconv.Atoi(string(args[2]))
+		if err != nil || n <= 0 {
+			w.error("syntax error")
+			return
+		}
+		count = n
+	}
+
+	var cursor *zerocopyskiplist.Cursor[Record, string, Meta]
+	switch start := string(args[0]); {
+	case start == "0":
+		cursor = s.list.SeekGE("")
+	case strings.HasPrefix(start, ":"):
+		cursor = s.list.SeekGE(start[1:])
+		if cursor.Valid() && cursor.Key() == start[1:] {
+			cursor.Next()
+		}
+	default:
+		w.error("invalid cursor")
+		return
+	}
+
+	var keys []string
+	for ; cursor.Valid() && len(keys) < count; cursor.Next() {
+		if !s.expired(cursor.Context()) {
+			keys = append(keys, cursor.Key())
+		}
+	}
+
+	next := "0"
+	if cursor.Valid() && len(keys) > 0 {
+		next = ":" + keys[len(keys)-1]
+	}
+	w.array(2)
+	w.bulk([]byte(next))
+	w.array(len(keys))
+	for _, key := range keys {
+		w.bulk([]byte(key))
+	}
+}
+
+// rangeKeys handles RANGE min max [LIMIT n], returning the keys and values
+// with min <= key <= max as a flat array of pairs in key order
+func (s *Server) rangeKeys(w respWriter, args [][]byte) {
+	if !arity(w, "RANGE", args, 2, 4) {
+		return
+	}
+	limit := -1
+	if len(args) > 2 {
+		if len(args) != 4 || !strings.EqualFold(string(args[2]), "LIMIT") {
+			w.error("syntax error")
+			return
+		}
+		n, err := strconv.Atoi(string(args[3]))
+		if err != nil || n < 0 {
+			w.error("syntax error")
+			return
+		}
+		limit = n
+	}
+
+	max := string(args[1])
+	var pairs []*Record
+	for cursor := s.list.SeekGE(string(args[0])); cursor.Valid() && cursor.Key() <= max && len(pairs) != limit; cursor.Next() {
+		if !s.expired(cursor.Context()) {
+			pairs = append(pairs, cursor.Item())
+		}
+	}
+
+	w.array(2 * len(pairs))
+	for _, record := range pairs {
+		w.bulk([]byte(record.Key))
+		w.bulk(record.Value)
+	}
+}
+
+// Save writes a snapshot to the snapshot path, replacing the previous one
+// only once the new snapshot is complete
+func (s *Server) Save() error {
+	if s.snapshotPath == "" {
+		return errors.New("no snapshot path configured")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.snapshotPath), filepath.Base(s.snapshotPath)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	out := bufio.NewWriter(tmp)
+	if err := s.list.SaveSnapshot(out, s.codec); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := out.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.snapshotPath)
+}
@@ -0,0 +1,270 @@
+package zcslserver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// testClient sends commands and parses replies into strings, int64s, nil and []any
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func startServer(t *testing.T, s *Server) *testClient {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	go s.Serve(l)
+	t.Cleanup(func() { s.Close() })
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	return &testClient{t: t, conn: conn, r: bufio.NewReader(conn)}
+}
+
+func (c *testClient) do(args ...string) any {
+	c.t.Helper()
+	cmd := fmt.Sprintf("*%d\r\n", len(args))
+	for _, arg := range args {
+		cmd += fmt.Sprintf("$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := c.conn.Write([]byte(cmd)); err != nil {
+		c.t.Fatalf("Write: %v", err)
+	}
+	return c.reply()
+}
+
+func (c *testClient) reply() any {
+	c.t.Helper()
+	line, err := readLine(c.r)
+	if err != nil {
+		c.t.Fatalf("Reading reply: %v", err)
+	}
+	switch line[0] {
+	case '+', '-':
+		return string(line)
+	case ':':
+		n, _ := strconv.ParseInt(string(line[1:]), 10, 64)
+		return n
+	case '$':
+		size, _ := strconv.Atoi(string(line[1:]))
+		if size < 0 {
+			return nil
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			c.t.Fatalf("Reading bulk: %v", err)
+		}
+		return string(buf[:size])
+	case '*':
+		n, _ := strconv.Atoi(string(line[1:]))
+		items := make([]any, n)
+		for i := range items {
+			items[i] = c.reply()
+		}
+		return items
+	}
+	c.t.Fatalf("Unexpected reply %q", line)
+	return nil
+}
+
+func (c *testClient) expect(want any, args ...string) {
+	c.t.Helper()
+	if got := c.do(args...); !reflect.DeepEqual(got, want) {
+		c.t.Errorf("%v: expected %#v, got %#v", args, want, got)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	s, err := New("")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	c := startServer(t, s)
+
+	c.expect("+PONG", "PING")
+	c.expect(nil, "GET", "a")
+	c.expect("+OK", "SET", "a", "1")
+	c.expect("+OK", "SET", "b", "2")
+	c.expect("+OK", "SET", "c", "3")
+	c.expect("1", "GET", "a")
+	c.expect(int64(2), "EXISTS", "a", "b", "z")
+	c.expect(int64(3), "DBSIZE")
+	c.expect([]any{"a", "1", "b", "2"}, "RANGE", "a", "b")
+	c.expect([]any{"b", "2"}, "RANGE", "b", "z", "LIMIT", "1")
+	c.expect(int64(1), "DEL", "b", "z")
+	c.expect([]any{"0", []any{"a", "c"}}, "SCAN", "0")
+	c.expect("-ERR wrong number of arguments for 'get' command", "GET")
+	c.expect("-ERR unknown command 'nope'", "NOPE")
+	c.expect("-ERR syntax error", "SET", "a", "1", "EX")
+
+	// Inline commands work too
+	c.conn.Write([]byte("GET c\r\n"))
+	if got := c.reply(); got != "3" {
+		t.Errorf("Inline GET returned %#v", got)
+	}
+}
+
+func TestScanPages(t *testing.T) {
+	s, err := New("")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	c := startServer(t, s)
+	for i := 0; i < 25; i++ {
+		c.do("SET", fmt.Sprintf("key%02d", i), "v")
+	}
+
+	var keys []any
+	cursor := "0"
+	for pages := 0; ; pages++ {
+		reply := c.do("SCAN", cursor, "COUNT", "10").([]any)
+		keys = append(keys, reply[1].([]any)...)
+		cursor = reply[0].(string)
+		if cursor == "0" {
+			break
+		}
+		if pages > 5 {
+			t.Fatal("SCAN did not terminate")
+		}
+	}
+	if len(keys) != 25 || keys[0] != "key00" || keys[24] != "key24" {
+		t.Errorf("SCAN returned %v", keys)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	s, err := New("")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	now := time.Unix(1000, 0)
+	s.now = func() time.Time { return now }
+	c := startServer(t, s)
+
+	c.expect("+OK", "SET", "short", "x", "EX", "10")
+	c.expect("+OK", "SET", "shorter", "x", "PX", "1500")
+	c.expect("+OK", "SET", "forever", "x")
+	c.expect(int64(10), "TTL", "short")
+	c.expect(int64(-1), "TTL", "forever")
+	c.expect(int64(-2), "TTL", "missing")
+
+	now = now.Add(5 * time.Second)
+	c.expect(nil, "GET", "shorter")
+	c.expect(int64(5), "TTL", "short")
+	c.expect(int64(2), "DBSIZE")
+
+	now = now.Add(5 * time.Second)
+	if swept := s.SweepExpired(); swept != 1 {
+		t.Errorf("Expected 1 swept key, got %d", swept)
+	}
+	c.expect(int64(1), "DBSIZE")
+}
+
+func TestExpiryIndex(t *testing.T) {
+	s, err := New("")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	now := time.Unix(1000, 0)
+	s.now = func() time.Time { return now }
+	c := startServer(t, s)
+
+	if _, ok := s.NextExpiry(); ok {
+		t.Error("A server without TTLs should have no next expiry")
+	}
+	c.expect("+OK", "SET", "a", "x", "EX", "30")
+	c.expect("+OK", "SET", "b", "x", "EX", "10")
+	c.expect("+OK", "SET", "c", "x", "EX", "20")
+	if next, ok := s.NextExpiry(); !ok || !next.Equal(now.Add(10*time.Second)) {
+		t.Errorf("NextExpiry = %v, want %v", next, now.Add(10*time.Second))
+	}
+
+	// Overwriting b without a TTL leaves a stale index entry the sweep skips
+	c.expect("+OK", "SET", "b", "y")
+	now = now.Add(20 * time.Second)
+	if swept := s.SweepExpired(); swept != 1 {
+		t.Errorf("Expected 1 swept key, got %d", swept)
+	}
+	c.expect("y", "GET", "b")
+	if next, ok := s.NextExpiry(); !ok || !next.Equal(now.Add(10*time.Second)) {
+		t.Errorf("NextExpiry after the sweep = %v, want %v", next, now.Add(10*time.Second))
+	}
+
+	// A pinned key stays indexed until a sweep can delete it
+	now = now.Add(10 * time.Second)
+	s.List().Pin("a")
+	if swept := s.SweepExpired(); swept != 0 {
+		t.Errorf("Expected the pinned key to survive, swept %d", swept)
+	}
+	s.List().Unpin("a")
+	if swept := s.SweepExpired(); swept != 1 {
+		t.Errorf("Expected the unpinned key to be swept, swept %d", swept)
+	}
+	if _, ok := s.NextExpiry(); ok {
+		t.Error("The index should be empty once every TTL has been swept")
+	}
+}
+
+func TestSaveAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.zcsl")
+	s, err := New(path)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	c := startServer(t, s)
+	c.expect("+OK", "SET", "k1", "v1")
+	c.expect("+OK", "SET", "k2", "v2", "EX", "100")
+	c.expect("+OK", "SAVE")
+
+	reloaded, err := New(path)
+	if err != nil {
+		t.Fatalf("New from snapshot: %v", err)
+	}
+	c = startServer(t, reloaded)
+	c.expect("v1", "GET", "k1")
+	c.expect(int64(100), "TTL", "k2")
+	if err := reloaded.List().Validate(); err != nil {
+		t.Errorf("Validate after reload: %v", err)
+	}
+}
+
+func BenchmarkPipelinedSetGet(b *testing.B) {
+	s, err := New("")
+	if err != nil {
+		b.Fatalf("New: %v", err)
+	}
+	client, server := net.Pipe()
+	go s.ServeConn(server)
+	defer client.Close()
+
+	r := bufio.NewReader(client)
+	go func() {
+		w := bufio.NewWriter(client)
+		for i := 0; i < b.N; i++ {
+			key := strconv.Itoa(i % 10000)
+			fmt.Fprintf(w, "*3\r\n$3\r\nSET\r\n$%d\r\n%s\r\n$5\r\nvalue\r\n", len(key), key)
+			fmt.Fprintf(w, "*2\r\n$3\r\nGET\r\n$%d\r\n%s\r\n", len(key), key)
+		}
+		w.Flush()
+	}()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		readLine(r) // +OK
+		readLine(r) // $5
+		readLine(r) // value
+	}
+}
@@ -4,12 +4,18 @@ package zerocopyskiplist
 
 import (
 	"fmt"
-	"math/rand"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"unsafe"
 )
 
+// unlinkedLevel marks a node that has been removed from the list
+const unlinkedLevel = -1
+
+// callbackBatchSize is the number of nodes CallbackToIovecSlice snapshots per read lock acquisition
+const callbackBatchSize = 256
+
 // MergeStrategy defines how to handle conflicts during merge operations
 type MergeStrategy int
 
@@ -19,25 +25,93 @@ const (
 	MergeError
 )
 
-// ItemPtr represents a node in the skiplist with context support
+// ItemPtr represents a node in the skiplist with context support.
+//
+// Nodes are doubly linked on level 0: Prev() of the first node and Next() of
+// the last node are nil, and for every other node n, n.Next().Prev() == n.
+// When the list is built WithLevelBacklinks the same guarantee holds on every
+// level the node participates in.
+//
+// First, Last, Find and FindItem return the live node, which the list keeps
+// changing after the lock is released: following its links or reading its
+// context while other goroutines write to the list is a data race. Lists that
+// are written concurrently should be built WithDetachedNodes, and walked with
+// a Cursor or ForEach, which lock around every step.
 type ItemPtr[T any, K comparable, C comparable] struct {
-	item     *T
-	key      K
-	context  C // Changed from *C to C (value semantics)
-	forward  []*ItemPtr[T, K, C]
-	backward *ItemPtr[T, K, C]
-	level    int
+	item      *T
+	key       K
+	context   C // Changed from *C to C (value semantics)
+	forward   []*ItemPtr[T, K, C]
+	backward  *ItemPtr[T, K, C]
+	backlinks []*ItemPtr[T, K, C] // backlinks[i-1] is the predecessor on level i, only WithLevelBacklinks
+	fkeys     []K                 // fkeys[i] caches forward[i].key, only WithInlineKeys
+	level     int
 }
 
-// ZeroCopySkiplist is the main skiplist structure with context support
+// ZeroCopySkiplist is the main skiplist structure with context support.
+//
+// Keys are unique: inserting an item whose key is present replaces the
+// existing item. Scans and cursors resume from the last key they returned,
+// which relies on this. To keep several items under one logical key, make the
+// key composite, with the logical key followed by a field that breaks ties in
+// the order wanted (an insert sequence number, a timestamp or a context
+// field, for instance encoded with the zcslkey package), and read them back
+// in that order with ScanPrefix.
 type ZeroCopySkiplist[T any, K comparable, C comparable] struct {
 	header         *ItemPtr[T, K, C]
+	tails          []*ItemPtr[T, K, C] // tails[i] is the last node on level i
 	maxLevel       int
 	level          int
 	length         int
+	totalBytes     int64 // sum of getItemSize over all items
+	pressure       pressureHook
 	getKeyFromItem func(*T) K
 	getItemSize    func(*T) int
 	cmpKey         func(K, K) int
+	opts           options
+	nodePool       *sync.Pool // recycled nodes, only WithNodePool
+	inlineKeys     bool       // nodes cache successor keys in fkeys
+	keyKind        keyKind    // specialised descent for natural-order keys
+	replayed       int        // levels consumed from opts.levelSequence
+	recordedLevels []int      // levels generated so far, only WithLevelRecording
+	nodesAllocated uint64
+	nodesReleased  uint64
+	nodesRecycled  uint64
+	leaks          *leakCounters                    // only WithLeakTracking
+	lockOrder      uint64                           // position in the canonical order for locking several lists
+	frozen         bool                             // mutations are rejected, see Freeze
+	life           lifecycle                        // background tasks and teardown steps, see Close
+	quotas         map[C]*contextQuota              // per-context limits, see SetContextQuota
+	levelCounts    []int                            // levelCounts[i] is the number of nodes of level i
+	generation     uint64                           // bumped by every link change
+	version        uint64                           // bumped by every link change, replacement and context update, see Generation
+	paths          *pathCache[T, K, C]              // recent search paths, only WithPathCache
+	contention     *contentionCounters              // write lock waits, only WithContentionTracking
+	latency        *[numOperations]latencyHistogram // only WithLatencyHistograms
+	watchdog       *lockWatchdog                    // slow write lock reports, only WithSlowLockWatchdog
+	holders        lockHolders                      // lock owners, only in zcslparanoid builds
+	pins           map[K]int                        // pin counts of entries protected from eviction, see Pin
+	leases         map[K]int                        // lease counts of entries referenced by iovecs, see Lease
+	deferred       map[K]deferredWrite[T, C]        // writes to leased entries awaiting Release
+	contextStats   map[C]*ContextStats              // per-context counters, only WithContextStats
+	lengths        *contextLengths[C]               // per-context entry counts, only WithContextLengths
+	graveyard      map[K]tombstone[T, C]            // soft-deleted entries awaiting Restore, see SoftDelete
+	hot            *hotspotTracker[K]               // access counts per key range, only WithHotspotTracking
+	flat           bool                             // entries are in small, with no nodes, see WithSmallListThreshold
+	small          []smallEntry[T, K, C]            // sorted entries while flat
+	normalizeKey   func(K) K                        // applied to keys on insert and lookup, only WithKeyNormalizer
+	weigher        func(*T, C) int64                // entry weights, see SetWeigher
+	totalWeight    int64                            // sum of weigher over all entries
+	weightLimit    int64                            // evict above this total weight, see SetWeightLimit
+	admitFilter    admissionFilter[T, K, C]         // consulted for new keys at the weight limit
+	sketch         *FrequencySketch[K]              // access frequencies, only SetFrequencyAdmission
+	flushLog       []FlushVersion[T, K, C]          // versions awaiting FlushExactlyOnce
+	flushMu        sync.Mutex                       // serialises FlushExactlyOnce cycles
+	ranges         rangeLocks[K]                    // advisory key ranges, see LockRange
+	amp            writeAmp                         // bytes changed and flushed, see Stats.WriteAmplification
+	misuses        atomic.Uint64                    // misuse detected, see WithMisusePolicy
+	refusals       uint64                           // inserts refused by quotas or the admission filter
+	itemBase       func(*T) unsafe.Pointer          // item memory for iovecs, nil for the item itself
 	rw             sync.RWMutex
 }
 
@@ -47,6 +121,33 @@ func MakeZeroCopySkiplist[T any, K comparable, C comparable](
 	getKeyFromItem func(*T) K,
 	getItemSize func(*T) int,
 	cmpKey func(K, K) int,
+	opts ...Option,
+) *ZeroCopySkiplist[T, K, C] {
+	o := buildOptions(opts)
+	if o.descending {
+		cmpKey = reverseCompare(cmpKey)
+	}
+	return newSkiplist[T, K, C](maxLevel, getKeyFromItem, getItemSize, cmpKey, o)
+}
+
+// makeZeroCopySkiplist creates a skiplist - always requires explicit context type parameter
+func makeZeroCopySkiplist[T any, K comparable, C comparable](
+	maxLevel int,
+	getKeyFromItem func(*T) K,
+	getItemSize func(*T) int,
+	cmpKey func(K, K) int,
+	opts ...Option,
+) *ZeroCopySkiplist[T, K, C] {
+	return MakeZeroCopySkiplist[T, K, C](maxLevel, getKeyFromItem, getItemSize, cmpKey, opts...)
+}
+
+// newSkiplist creates an empty skiplist from already resolved options
+func newSkiplist[T any, K comparable, C comparable](
+	maxLevel int,
+	getKeyFromItem func(*T) K,
+	getItemSize func(*T) int,
+	cmpKey func(K, K) int,
+	opts options,
 ) *ZeroCopySkiplist[T, K, C] {
 
 	header := &ItemPtr[T, K, C]{
@@ -54,56 +155,195 @@ func MakeZeroCopySkiplist[T any, K comparable, C comparable](
 		level:   maxLevel,
 	}
 
-	return &ZeroCopySkiplist[T, K, C]{
+	inlineKeys := opts.inlineKeys && inlineableKey[K]()
+	if inlineKeys {
+		header.fkeys = make([]K, maxLevel+1)
+	}
+
+	kind := keyKindCustom
+	if opts.naturalOrder {
+		kind = orderedKeyKind[K]()
+	}
+
+	var nodePool *sync.Pool
+	if opts.nodePool {
+		nodePool = &sync.Pool{}
+	}
+
+	var leaks *leakCounters
+	if opts.leakTracking {
+		leaks = &leakCounters{}
+	}
+
+	var contention *contentionCounters
+	if opts.contentionTracking {
+		contention = &contentionCounters{}
+	}
+
+	var latency *[numOperations]latencyHistogram
+	if opts.latencyHistograms {
+		latency = new([numOperations]latencyHistogram)
+	}
+
+	normalizeKey := normalizer[K](opts)
+	if normalizeKey != nil {
+		getKey := getKeyFromItem
+		getKeyFromItem = func(item *T) K {
+			return normalizeKey(getKey(item))
+		}
+	}
+
+	var contextStats map[C]*ContextStats
+	if opts.contextStats {
+		contextStats = make(map[C]*ContextStats)
+	}
+
+	var lengths *contextLengths[C]
+	if opts.contextLengths > 0 {
+		lengths = &contextLengths[C]{counts: make(map[C]int), limit: opts.contextLengths}
+	}
+
+	sl := &ZeroCopySkiplist[T, K, C]{
 		header:         header,
+		tails:          make([]*ItemPtr[T, K, C], maxLevel+1),
+		levelCounts:    make([]int, maxLevel+1),
 		maxLevel:       maxLevel,
 		level:          0,
 		length:         0,
 		getKeyFromItem: getKeyFromItem,
 		getItemSize:    getItemSize,
 		cmpKey:         cmpKey,
+		opts:           opts,
+		nodePool:       nodePool,
+		inlineKeys:     inlineKeys,
+		paths:          newPathCache[T, K, C](opts.pathCache),
+		contention:     contention,
+		latency:        latency,
+		contextStats:   contextStats,
+		lengths:        lengths,
+		watchdog:       newLockWatchdog(opts),
+		hot:            newHotspotTracker[K](opts, maxLevel),
+		normalizeKey:   normalizeKey,
+		keyKind:        kind,
+		leaks:          leaks,
+		lockOrder:      nextLockOrder.Add(1),
 	}
-}
-
-// makeZeroCopySkiplist creates a skiplist - always requires explicit context type parameter
-func makeZeroCopySkiplist[T any, K comparable, C comparable](
-	maxLevel int,
-	getKeyFromItem func(*T) K,
-	getItemSize func(*T) int,
-	cmpKey func(K, K) int,
-) *ZeroCopySkiplist[T, K, C] {
-	return MakeZeroCopySkiplist[T, K, C](maxLevel, getKeyFromItem, getItemSize, cmpKey)
+	sl.flat = sl.startsSmall()
+	if sl.watchdog != nil {
+		sl.Go(sl.watchdog.run)
+	}
+	return sl
 }
 
 // Insert adds an item to the skiplist with optional context
 func (sl *ZeroCopySkiplist[T, K, C]) Insert(item *T, context C) bool {
-	sl.rw.Lock()
-	defer sl.rw.Unlock()
+	inserted, _ := sl.InsertChecked(item, context)
+	return inserted
+}
+
+// InsertChecked is Insert returning why an item was refused: ErrFrozen, or a
+// *MisuseError under the misuse policy set by WithMisusePolicy
+func (sl *ZeroCopySkiplist[T, K, C]) InsertChecked(item *T, context C) (bool, error) {
+	if err := sl.checkItem("Insert", item); err != nil {
+		return false, err
+	}
+
+	defer sl.endOp(OperationInsert, sl.startOp())
+	var frozen, inserted bool
+	var violation string
+	hook, totalBytes, entries := func() (func(int64, int), int64, int) {
+		sl.lockFlat()
+		defer sl.unlock()
+		key := sl.getKeyFromItem(item)
+		if sl.sketch != nil {
+			sl.sketch.Record(key)
+		}
+		frozen = sl.frozen
+		inserted = !frozen && sl.insertAny(item, context)
+		if !frozen && !sl.flat && (inserted || sl.opts.comparatorRate > 0) && sl.sampleComparator() {
+			violation = sl.comparatorViolation(key, inserted)
+		}
+		if sl.hot != nil {
+			sl.touch(key)
+		}
+		return sl.checkPressure()
+	}()
+
+	if hook != nil {
+		hook(totalBytes, entries)
+	}
+	switch {
+	case frozen:
+		return false, ErrFrozen
+	case violation != "":
+		return inserted, sl.misuse("Insert", MisuseInconsistentComparator, violation)
+	}
+	return inserted, nil
+}
+
+// insertAny performs insert on a list that may be in small list storage,
+// leaving it once the slice is full; the caller must hold the write lock
+func (sl *ZeroCopySkiplist[T, K, C]) insertAny(item *T, context C) bool {
+	if sl.flat {
+		if inserted, full := sl.insertSmall(item, context); !full {
+			return inserted
+		}
+		sl.materialize()
+	}
+	return sl.insert(item, context)
+}
 
+// insert performs Insert; the caller must hold the write lock
+func (sl *ZeroCopySkiplist[T, K, C]) insert(item *T, context C) bool {
+	if len(sl.quotas) > 0 && !sl.admitItem(item, context) {
+		sl.refusals++
+		return false
+	}
 	key := sl.getKeyFromItem(item)
 
 	// Find position for insertion
 	update := make([]*ItemPtr[T, K, C], sl.maxLevel+1)
-	current := sl.header
-
-	for i := sl.level; i >= 0; i-- {
-		for current.forward[i] != nil && sl.cmpKey(current.forward[i].key, key) < 0 {
-			current = current.forward[i]
-		}
-		update[i] = current
+	current := sl.descend(key, -1, update).forward[0]
+	if sl.refused(item, key, context, current) {
+		sl.refusals++
+		return false
 	}
+	return sl.insertAt(item, key, context, update, current)
+}
 
-	current = current.forward[0]
+// insertAt performs insert once the search path to key has been found:
+// update holds the last node before key on every level and current is the
+// node after update[0]; the caller must hold the write lock
+func (sl *ZeroCopySkiplist[T, K, C]) insertAt(item *T, key K, context C, update []*ItemPtr[T, K, C], current *ItemPtr[T, K, C]) bool {
+	if len(sl.graveyard) > 0 {
+		delete(sl.graveyard, key)
+	}
 
 	// If key already exists, update item and context, return false
 	if current != nil && sl.cmpKey(current.key, key) == 0 {
+		if sl.leased(key) {
+			sl.deferWrite(key, item, context)
+			return false
+		}
+		if len(sl.quotas) > 0 {
+			sl.chargeQuota(current.context, int64(sl.getItemSize(current.item)), -1)
+			sl.chargeQuota(context, int64(sl.getItemSize(item)), 1)
+		}
+		sl.chargeContext(current.context, int64(sl.getItemSize(current.item)), -1, false)
+		sl.chargeContext(context, int64(sl.getItemSize(item)), 1, true)
+		sl.chargeWeight(current.item, current.context, -1)
+		sl.chargeWeight(item, context, 1)
+		sl.totalBytes += int64(sl.getItemSize(item)) - int64(sl.getItemSize(current.item))
+		sl.version++
 		current.item = item
 		current.context = context // Always update context (no nil check needed for value types)
+		sl.recordVersion(current, false)
+		sl.countChange(sl.getItemSize(item))
 		return false
 	}
 
 	// Generate random level for new node
-	newLevel := sl.randomLevel()
+	newLevel := sl.randomLevel(current == nil)
 	if newLevel > sl.level {
 		for i := sl.level + 1; i <= newLevel; i++ {
 			update[i] = sl.header
@@ -112,63 +352,141 @@ func (sl *ZeroCopySkiplist[T, K, C]) Insert(item *T, context C) bool {
 	}
 
 	// Create new node
-	newNode := &ItemPtr[T, K, C]{
-		item:    item,
-		key:     key,
-		context: context,
-		forward: make([]*ItemPtr[T, K, C], newLevel+1),
-		level:   newLevel,
-	}
+	newNode := sl.allocNode(newLevel)
+	newNode.item = item
+	newNode.key = key
+	newNode.context = context
 
-	// Update forward pointers
+	// Update forward pointers, backward links and level tails
 	for i := 0; i <= newLevel; i++ {
-		newNode.forward[i] = update[i].forward[i]
-		update[i].forward[i] = newNode
-	}
+		sl.link(newNode, i, update[i].forward[i])
+		sl.link(update[i], i, newNode)
 
-	// Update backward pointer
-	if newNode.forward[0] != nil {
-		newNode.forward[0].backward = newNode
-	}
-	if update[0] != sl.header {
-		newNode.backward = update[0]
+		newNode.setPrevAt(i, sl.nodeOrNil(update[i]))
+		if next := newNode.forward[i]; next != nil {
+			next.setPrevAt(i, newNode)
+		} else {
+			sl.tails[i] = newNode
+		}
 	}
 
 	sl.length++
+	sl.totalBytes += int64(sl.getItemSize(item))
+	if len(sl.quotas) > 0 {
+		sl.chargeQuota(context, int64(sl.getItemSize(item)), 1)
+	}
+	sl.chargeContext(context, int64(sl.getItemSize(item)), 1, true)
+	sl.chargeWeight(item, context, 1)
+	sl.recordVersion(newNode, false)
+	sl.countChange(sl.getItemSize(item))
+
+	if sl.sampleParanoid() {
+		sl.checkAround("insert", newNode)
+	}
 	return true
 }
 
 // Delete removes an item with the given key
 func (sl *ZeroCopySkiplist[T, K, C]) Delete(key K) bool {
-	sl.rw.Lock()
-	defer sl.rw.Unlock()
+	key = sl.normalize(key)
+	defer sl.endOp(OperationDelete, sl.startOp())
+	sl.lockFlat()
+	defer sl.unlock()
+	var deleted bool
+	if !sl.frozen {
+		if sl.flat {
+			deleted = sl.deleteSmall(key)
+		} else {
+			deleted = sl.delete(key)
+		}
+	}
+	if sl.hot != nil {
+		sl.touch(key)
+	}
+	sl.checkPressure()
+	return deleted
+}
 
-	update := make([]*ItemPtr[T, K, C], sl.maxLevel+1)
-	current := sl.header
+// Clear removes every item from the list
+func (sl *ZeroCopySkiplist[T, K, C]) Clear() {
+	sl.lock()
+	defer sl.unlock()
+	if sl.frozen {
+		return
+	}
 
-	// Find the node to delete
-	for i := sl.level; i >= 0; i-- {
-		for current.forward[i] != nil && sl.cmpKey(current.forward[i].key, key) < 0 {
-			current = current.forward[i]
+	current := sl.header.forward[0]
+	for current != nil {
+		next := current.forward[0]
+		if sl.opts.flushExactlyOnce {
+			sl.version++
+			sl.recordVersion(current, true)
 		}
-		update[i] = current
+		// Mark the node unlinked so cursors parked on it know to re-seek
+		current.level = unlinkedLevel
+		sl.nodesReleased++
+		sl.releaseNode(current)
+		current = next
 	}
 
-	current = current.forward[0]
+	for i := range sl.header.forward {
+		sl.link(sl.header, i, nil)
+		sl.tails[i] = nil
+	}
+	sl.level = 0
+	sl.length = 0
+	sl.totalBytes = 0
+	sl.totalWeight = 0
+	clear(sl.levelCounts)
+	clear(sl.pins)
+	clear(sl.deferred)
+	clear(sl.graveyard)
+	sl.flat = sl.startsSmall()
+	for _, q := range sl.quotas {
+		q.entries, q.bytes = 0, 0
+	}
+	for _, cs := range sl.contextStats {
+		cs.Entries, cs.Bytes = 0, 0
+	}
+	if sl.lengths != nil {
+		sl.lengths.reset()
+	}
+	sl.pressure.over = false
+}
+
+// delete performs Delete; the caller must hold the write lock
+func (sl *ZeroCopySkiplist[T, K, C]) delete(key K) bool {
+	// Find the node to delete
+	update := make([]*ItemPtr[T, K, C], sl.maxLevel+1)
+	current := sl.descend(key, -1, update).forward[0]
 
 	// If key doesn't exist, return false
 	if current == nil || sl.cmpKey(current.key, key) != 0 {
 		return false
 	}
+	if sl.leased(key) {
+		var zeroContext C
+		sl.deferWrite(key, nil, zeroContext)
+		return true
+	}
 
-	// Update forward pointers
+	sl.unlink(current, update)
+	return true
+}
+
+// unlink removes node from the list given its predecessor on every level it
+// occupies; the caller must hold the write lock
+func (sl *ZeroCopySkiplist[T, K, C]) unlink(current *ItemPtr[T, K, C], update []*ItemPtr[T, K, C]) {
+	// Update forward pointers, backward links and level tails
 	for i := 0; i <= current.level; i++ {
-		update[i].forward[i] = current.forward[i]
-	}
+		sl.link(update[i], i, current.forward[i])
 
-	// Update backward pointer
-	if current.forward[0] != nil {
-		current.forward[0].backward = current.backward
+		prev := sl.nodeOrNil(update[i])
+		if next := current.forward[i]; next != nil {
+			next.setPrevAt(i, prev)
+		} else {
+			sl.tails[i] = prev
+		}
 	}
 
 	// Update level if necessary
@@ -176,46 +494,97 @@ func (sl *ZeroCopySkiplist[T, K, C]) Delete(key K) bool {
 		sl.level--
 	}
 
+	if sl.sampleParanoid() {
+		for i := 0; i <= current.level; i++ {
+			if update[i] != sl.header {
+				sl.checkAround("delete", update[i])
+			}
+		}
+		if next := current.forward[0]; next != nil {
+			sl.checkAround("delete", next)
+		}
+	}
+
+	if len(sl.pins) > 0 {
+		delete(sl.pins, current.key)
+	}
+	if len(sl.deferred) > 0 {
+		delete(sl.deferred, current.key)
+	}
+
+	// Mark the node unlinked so cursors parked on it know to re-seek
+	sl.levelCounts[current.level]--
+	current.level = unlinkedLevel
+
 	sl.length--
-	return true
+	sl.totalBytes -= int64(sl.getItemSize(current.item))
+	if len(sl.quotas) > 0 {
+		sl.chargeQuota(current.context, int64(sl.getItemSize(current.item)), -1)
+	}
+	sl.chargeContext(current.context, int64(sl.getItemSize(current.item)), -1, false)
+	sl.chargeWeight(current.item, current.context, -1)
+	sl.recordVersion(current, true)
+	sl.nodesReleased++
+	sl.releaseNode(current)
 }
 
 // First returns the first item in the skiplist
 func (sl *ZeroCopySkiplist[T, K, C]) First() *ItemPtr[T, K, C] {
-	sl.rw.RLock()
-	defer sl.rw.RUnlock()
-	return sl.header.forward[0]
+	sl.rlockFlat()
+	defer sl.runlock()
+	if sl.flat {
+		if len(sl.small) == 0 {
+			return nil
+		}
+		return sl.smallResult(0)
+	}
+	return sl.result(sl.header.forward[0])
 }
 
 // Last returns the last item in the skiplist
 func (sl *ZeroCopySkiplist[T, K, C]) Last() *ItemPtr[T, K, C] {
-	sl.rw.RLock()
-	defer sl.rw.RUnlock()
-
-	current := sl.header
-	for i := sl.level; i >= 0; i-- {
-		for current.forward[i] != nil {
-			current = current.forward[i]
+	sl.rlockFlat()
+	defer sl.runlock()
+	if sl.flat {
+		if len(sl.small) == 0 {
+			return nil
 		}
+		return sl.smallResult(len(sl.small) - 1)
 	}
+	return sl.result(sl.tails[0])
+}
 
-	if current == sl.header {
-		return nil
+// Bounds returns the smallest and largest keys in the list in O(1), false if
+// the list is empty. The bounds follow list order: they are the keys of First
+// and Last, so a WithDescending list returns its largest key first.
+func (sl *ZeroCopySkiplist[T, K, C]) Bounds() (min K, max K, ok bool) {
+	sl.rlockFlat()
+	defer sl.runlock()
+
+	if sl.flat {
+		if len(sl.small) == 0 {
+			return min, max, false
+		}
+		return sl.small[0].key, sl.small[len(sl.small)-1].key, true
 	}
-	return current
+	first, last := sl.header.forward[0], sl.tails[0]
+	if first == nil {
+		return min, max, false
+	}
+	return first.key, last.key, true
 }
 
 // Length returns the number of items in the skiplist
 func (sl *ZeroCopySkiplist[T, K, C]) Length() int {
-	sl.rw.RLock()
-	defer sl.rw.RUnlock()
+	sl.rlockFlat()
+	defer sl.runlock()
 	return sl.length
 }
 
 // IsEmpty returns true if the skiplist is empty
 func (sl *ZeroCopySkiplist[T, K, C]) IsEmpty() bool {
-	sl.rw.RLock()
-	defer sl.rw.RUnlock()
+	sl.rlockFlat()
+	defer sl.runlock()
 	return sl.length == 0
 }
 
@@ -226,91 +595,310 @@ func (sl *ZeroCopySkiplist[T, K, C]) Find(key K) (*ItemPtr[T, K, C], C) {
 
 // Copy creates a deep copy of the skiplist structure (zero-copy for items)
 func (sl *ZeroCopySkiplist[T, K, C]) Copy() *ZeroCopySkiplist[T, K, C] {
-	sl.rw.RLock()
-	defer sl.rw.RUnlock()
+	sl.rlockFlat()
+	defer sl.runlock()
+	return sl.copy()
+}
 
-	newSL := MakeZeroCopySkiplist[T, K, C](sl.maxLevel, sl.getKeyFromItem, sl.getItemSize, sl.cmpKey)
+// copyWithGeneration returns a Copy of the list and the Generation it reflects
+func (sl *ZeroCopySkiplist[T, K, C]) copyWithGeneration() (*ZeroCopySkiplist[T, K, C], uint64) {
+	sl.rlockFlat()
+	defer sl.runlock()
+	return sl.copy(), sl.version
+}
 
-	current := sl.First()
-	for current != nil {
-		newSL.Insert(current.item, current.context)
-		current = current.Next()
+// copy performs Copy, in small list storage if the list is and the copy's
+// threshold allows; the caller must hold the lock
+func (sl *ZeroCopySkiplist[T, K, C]) copy() *ZeroCopySkiplist[T, K, C] {
+	opts := sl.opts
+	opts.slowLockThreshold = 0
+	newSL := newSkiplist[T, K, C](sl.maxLevel, sl.getKeyFromItem, sl.getItemSize, sl.cmpKey, opts)
+	newSL.getKeyFromItem = sl.getKeyFromItem // already normalizing
+	newSL.itemBase = sl.itemBase
+
+	for _, e := range sl.small {
+		newSL.appendTail(e.item, e.key, e.context)
+	}
+	// Walk the nodes directly, First and Next would take the read lock again
+	// and deadlock against a waiting writer; keys are already in order so
+	// each one is appended at the tails
+	for current := sl.header.forward[0]; current != nil; current = current.forward[0] {
+		newSL.appendTail(current.item, current.key, current.context)
 	}
 
 	return newSL
 }
 
-// CallbackToIovecSlice generates Iovec slices for items that match the callback filter
+// CallbackToIovecSlice generates Iovec slices for items that match the callback filter.
+//
+// The read lock is only held while a batch of up to callbackBatchSize nodes is
+// snapshotted; the callback itself runs with no lock held. This keeps a slow
+// callback from stalling writers and allows the callback to call any method on
+// the skiplist, including Delete and UpdateContext, without deadlocking.
+//
+// The ItemPtr passed to the callback is a detached point-in-time copy of the
+// node: its Item, Key and Context reflect the list when the batch was taken,
+// Next and Prev return nil since it holds no links, and SetContext on it does
+// not modify the list (use UpdateContext instead).
+// Mutations made while the scan is in progress are visible to later batches
+// but not to the batch currently being processed.
 func (sl *ZeroCopySkiplist[T, K, C]) CallbackToIovecSlice(callback func(*ItemPtr[T, K, C]) bool) []syscall.Iovec {
-	sl.rw.RLock()
-	defer sl.rw.RUnlock()
+	sl.rlockFlat()
+	expected := sl.length / 2
+	sl.runlock()
+	return sl.CallbackToIovecSliceN(expected, callback)
+}
 
-	iovecs := make([]syscall.Iovec, 0, sl.length/2)
+// CallbackToIovecSliceN is CallbackToIovecSlice with the result allocated for
+// expected iovecs, for callers who know roughly how many items the callback
+// accepts (from EstimateRangeSize, ContextUsage or the previous flush). With
+// a good estimate a multi-million entry flush allocates its slice once
+// instead of growing it repeatedly.
+func (sl *ZeroCopySkiplist[T, K, C]) CallbackToIovecSliceN(expected int, callback func(*ItemPtr[T, K, C]) bool) []syscall.Iovec {
+	defer sl.endOp(OperationScan, sl.startOp())
+	iovecs := sl.appendIovecs(make([]syscall.Iovec, 0, max(expected, 0)), Ascending, callback)
+	if sl.leaks != nil {
+		sl.leaks.trackIovecs(iovecs)
+	}
+	return iovecs
+}
 
-	current := sl.First()
-	for current != nil {
-		// Save current.Next() in case the user wants to do something crazy like delete current
-		tmp := current.Next()
-		if callback(current) { // Fixed: removed negation and pass current directly (not &current)
-			iovec := syscall.Iovec{
-				Base: (*byte)(unsafe.Pointer(current.item)),
-				Len:  uint64(sl.getItemSize(current.item)),
+// AppendIovecs appends an iovec for every item the filter accepts to dst and
+// returns the extended slice, like append. Passing the previous result
+// truncated to dst[:0] reuses its array across flush cycles, so a steady
+// state flush allocates nothing. The filter runs as for CallbackToIovecSlice,
+// with no lock held. With WithLeakTracking only new arrays are tracked.
+func (sl *ZeroCopySkiplist[T, K, C]) AppendIovecs(dst []syscall.Iovec, filter func(*ItemPtr[T, K, C]) bool) []syscall.Iovec {
+	return sl.AppendIovecsIn(dst, Ascending, filter)
+}
+
+// appendIovecs appends an iovec to dst for every item the callback accepts,
+// walking the list in dir and snapshotting in batches as described for
+// CallbackToIovecSlice
+func (sl *ZeroCopySkiplist[T, K, C]) appendIovecs(iovecs []syscall.Iovec, dir Direction, callback func(*ItemPtr[T, K, C]) bool) []syscall.Iovec {
+	batch := make([]ItemPtr[T, K, C], 0, callbackBatchSize)
+	var lastKey K
+	resume := false
+	for {
+		batch = sl.snapshotIn(dir, batch[:0], lastKey, resume)
+		for i := range batch {
+			if callback(&batch[i]) {
+				iovecs = append(iovecs, sl.iovec(batch[i].item))
 			}
-			iovecs = append(iovecs, iovec)
 		}
-		current = tmp
+		if len(batch) < callbackBatchSize {
+			return iovecs
+		}
+		lastKey = batch[len(batch)-1].key
+		resume = true
 	}
-	return iovecs
+}
+
+// iovec returns the iovec covering item's memory: the item itself, or the
+// external memory it describes for lists of Extents
+func (sl *ZeroCopySkiplist[T, K, C]) iovec(item *T) syscall.Iovec {
+	base := unsafe.Pointer(item)
+	if sl.itemBase != nil {
+		base = sl.itemBase(item)
+	}
+	return syscall.Iovec{Base: (*byte)(base), Len: uint64(sl.getItemSize(item))}
+}
+
+// snapshotBatch appends copies of up to cap(dst) nodes to dst, starting at the
+// first node or, when resume is true, at the first node with a key greater than after
+func (sl *ZeroCopySkiplist[T, K, C]) snapshotBatch(dst []ItemPtr[T, K, C], after K, resume bool) []ItemPtr[T, K, C] {
+	sl.rlockFlat()
+	defer sl.runlock()
+
+	if sl.flat {
+		i := 0
+		if resume {
+			var found bool
+			if i, found = sl.smallIndex(after); found {
+				i++
+			}
+		}
+		for ; i < len(sl.small) && len(dst) < cap(dst); i++ {
+			dst = append(dst, *sl.smallResult(i))
+		}
+		return dst
+	}
+
+	current := sl.header.forward[0]
+	if resume {
+		current = sl.seek(after, false)
+	}
+
+	touched := 0
+	for current != nil && len(dst) < cap(dst) {
+		touched += prefetch(current, 1)
+		dst = append(dst, current.detach())
+		current = current.forward[0]
+	}
+	prefetchSink.Add(int64(touched))
+	return dst
+}
+
+// prefetchScans enables prefetch; BenchmarkScanScattered turns it off for a
+// baseline
+var prefetchScans = true
+
+// prefetchSink receives the count of nodes each scan prefetched, giving
+// prefetch's loads a use so the compiler cannot remove them
+var prefetchSink atomic.Int64
+
+// prefetch touches a node a few positions ahead of node in a level 0 scan,
+// reached through node's level 1 link in direction (1 forwards, -1 backwards
+// when the list has level backlinks). The address comes from a link the scan
+// does not otherwise follow, so the cache misses for that node and its link
+// slice overlap with the scan instead of being paid one after another when the
+// scan gets there. Nodes without a level 1 link touch nothing. It returns 1
+// if it reached the link slice, else 0; scans add the results up and store
+// the total in prefetchSink once per batch.
+func prefetch[T any, K comparable, C comparable](node *ItemPtr[T, K, C], direction int) int {
+	if !prefetchScans || node.level < 1 {
+		return 0
+	}
+
+	far := node.forward[1]
+	if direction < 0 {
+		far = node.prevAt(1)
+	}
+	if far == nil {
+		return 0
+	}
+
+	near := far.forward[0]
+	if direction < 0 {
+		near = far.backward
+	}
+	if near == nil || len(near.forward) == 0 || near.forward[0] == nil {
+		return 0
+	}
+	return 1
 }
 
 // ToIovecSlice generates Iovec slices for all items (ignoring context parameter for backward compatibility)
 func (sl *ZeroCopySkiplist[T, K, C]) ToIovecSlice(context C) []syscall.Iovec {
 	// Note: context parameter is ignored to maintain backward compatibility with existing ToIovecSlice() calls
-	return sl.CallbackToIovecSlice(func(item *ItemPtr[T, K, C]) bool {
+	return sl.CallbackToIovecSliceN(sl.Length(), func(item *ItemPtr[T, K, C]) bool {
 		return true // Include all items
 	})
 }
 
-// ToContextIovecSlice generates Iovec slices for items that match the context
+// ToContextIovecSlice generates Iovec slices for items that match the context.
+// When the context has a quota its entry count sizes the result exactly.
 func (sl *ZeroCopySkiplist[T, K, C]) ToContextIovecSlice(context C) []syscall.Iovec {
-	return sl.CallbackToIovecSlice(func(item *ItemPtr[T, K, C]) bool {
+	matching, _ := sl.contextCounts(context)
+	return sl.CallbackToIovecSliceN(matching, func(item *ItemPtr[T, K, C]) bool {
 		return item.context == context // Direct value comparison (no pointer dereferencing)
 	})
 }
 
 // ToNotContextIovecSlice generates Iovec slices for items that don't match the context
 func (sl *ZeroCopySkiplist[T, K, C]) ToNotContextIovecSlice(context C) []syscall.Iovec {
-	return sl.CallbackToIovecSlice(func(item *ItemPtr[T, K, C]) bool {
+	_, notMatching := sl.contextCounts(context)
+	return sl.CallbackToIovecSliceN(notMatching, func(item *ItemPtr[T, K, C]) bool {
 		return item.context != context // Direct value comparison (no pointer dereferencing)
 	})
 }
 
-// Merge merges another skiplist into this one with conflict resolution
+// contextCounts returns the expected numbers of items with and without
+// context: exact when the context has a quota, otherwise half the list each
+func (sl *ZeroCopySkiplist[T, K, C]) contextCounts(context C) (matching, notMatching int) {
+	sl.rlock()
+	defer sl.runlock()
+
+	if q := sl.quotas[context]; q != nil {
+		return q.entries, sl.length - q.entries
+	}
+	return sl.length / 2, sl.length / 2
+}
+
+// Merge merges another skiplist into this one with conflict resolution.
+//
+// other is walked in key order while sl's search path is carried forward from
+// one key to the next instead of descending from the head each time, so each
+// insert only searches the gap since the previous one: a small other merges
+// in O(m log n) and two large lists in a single linear pass over both, like a
+// two-pointer merge, with the same bookkeeping as Insert for every entry.
+// With context quotas every key is searched from the head, as quota
+// eviction can restructure the list. Both
+// lists are locked for the whole merge, sl for writing and other for reading,
+// and a pressure hook fires at most once, at the end. MergeError stops at the
+// first conflict, keeping the entries merged before it.
 func (sl *ZeroCopySkiplist[T, K, C]) Merge(other *ZeroCopySkiplist[T, K, C], strategy MergeStrategy) error {
-	other.rw.RLock()
-	defer other.rw.RUnlock()
+	if other == sl {
+		// Every key conflicts with itself; nothing changes
+		sl.rlock()
+		defer sl.runlock()
+		if sl.frozen {
+			return ErrFrozen
+		}
+		if first := sl.header.forward[0]; strategy == MergeError && first != nil {
+			return fmt.Errorf("key conflict during merge: %v", first.key)
+		}
+		return nil
+	}
+	var err error
+	hook, totalBytes, entries := func() (func(int64, int), int64, int) {
+		for _, l := range lockOrdered([]*ZeroCopySkiplist[T, K, C]{sl, other}) {
+			if l == sl {
+				l.lock()
+			} else {
+				l.rlock()
+			}
+		}
+		defer other.runlock()
+		defer sl.unlock()
+		if sl.frozen {
+			err = ErrFrozen
+			return nil, 0, 0
+		}
 
-	current := other.First()
-	for current != nil {
-		existing, _ := sl.search(current.key)
-
-		if existing != nil {
-			// Handle conflict based on strategy
-			switch strategy {
-			case MergeTheirs:
-				sl.Insert(current.item, current.context)
-			case MergeOurs:
-				// Keep existing, do nothing
-			case MergeError:
-				return fmt.Errorf("key conflict during merge: %v", current.key)
+		update := make([]*ItemPtr[T, K, C], sl.maxLevel+1)
+		restart := true
+		var prev K
+		for node := other.header.forward[0]; node != nil; node = node.forward[0] {
+			key := sl.getKeyFromItem(node.item)
+			// Restart from the head unless the path is known to precede key
+			if restart || sl.cmpKey(prev, key) >= 0 {
+				for i := range update {
+					update[i] = sl.header
+				}
 			}
-		} else {
-			sl.Insert(current.item, current.context)
+			prev = key
+
+			current := sl.descendFrom(key, update)
+			if current != nil && sl.cmpKey(current.key, key) == 0 {
+				if strategy == MergeOurs {
+					continue
+				}
+				if strategy == MergeError {
+					err = fmt.Errorf("key conflict during merge: %v", node.key)
+					break
+				}
+			}
+			// Quota eviction restructures the list
+			restart = len(sl.quotas) > 0
+			if len(sl.quotas) > 0 {
+				if !sl.admitItem(node.item, node.context) {
+					continue
+				}
+				current = sl.descend(key, -1, update).forward[0]
+			}
+			if sl.refused(node.item, key, node.context, current) {
+				continue
+			}
+			sl.insertAt(node.item, key, node.context, update, current)
 		}
-		current = current.Next()
-	}
 
-	return nil
+		return sl.checkPressure()
+	}()
+	if hook != nil {
+		hook(totalBytes, entries)
+	}
+	return err
 }
 
 // Item returns the pointer to the original data structure
@@ -331,14 +919,50 @@ func (ip *ItemPtr[T, K, C]) Context() C {
 
 // UpdateContext updates the context for an existing key (changed parameter from *C to C)
 func (sl *ZeroCopySkiplist[T, K, C]) UpdateContext(key K, context C) bool {
-	item, _ := sl.search(key)
-	if item != nil {
-		sl.rw.Lock()
-		item.context = context
-		sl.rw.Unlock()
-		return true
+	key = sl.normalize(key)
+	sl.lockFlat()
+	defer sl.unlock()
+	if sl.frozen {
+		return false
+	}
+	if sl.flat {
+		return sl.updateSmall(key, context)
+	}
+	if !sl.updateContext(key, context) {
+		return false
 	}
-	return false
+	sl.enforceWeightLimit()
+	return true
+}
+
+// updateContext performs UpdateContext; the caller must hold the write lock
+func (sl *ZeroCopySkiplist[T, K, C]) updateContext(key K, context C) bool {
+	// Look the node up under the write lock so it cannot be deleted (or
+	// recycled for another key) between the lookup and the update
+	item := sl.seek(key, true)
+	return item != nil && sl.cmpKey(item.key, key) == 0 && sl.setContext(item, context)
+}
+
+// setContext gives node context, subject to the quota of context, and
+// returns false if the quota refused it; the caller must hold the write lock
+func (sl *ZeroCopySkiplist[T, K, C]) setContext(item *ItemPtr[T, K, C], context C) bool {
+	size := int64(sl.getItemSize(item.item))
+	if len(sl.quotas) > 0 {
+		if !sl.admit(context, size, item) {
+			return false
+		}
+		sl.chargeQuota(item.context, size, -1)
+		sl.chargeQuota(context, size, 1)
+	}
+	sl.chargeContext(item.context, size, -1, false)
+	sl.chargeContext(context, size, 1, false)
+	sl.chargeWeight(item.item, item.context, -1)
+	sl.chargeWeight(item.item, context, 1)
+	sl.version++
+	item.context = context
+	sl.recordVersion(item, false)
+	sl.countChange(int(size))
+	return true
 }
 
 // SetContext updates the context value (changed parameter from *C to C)
@@ -353,45 +977,256 @@ func (ip *ItemPtr[T, K, C]) Key() K {
 
 // search function updated to return context value (changed from *C to C)
 func (sl *ZeroCopySkiplist[T, K, C]) search(key K) (*ItemPtr[T, K, C], C) {
-	sl.rw.RLock()
-	defer sl.rw.RUnlock()
+	key = sl.normalize(key)
+	defer sl.endOp(OperationFind, sl.startOp())
+	sl.rlockFlat()
+	defer sl.runlock()
+	if sl.hot != nil {
+		sl.touch(key)
+	}
+	if sl.sketch != nil {
+		sl.sketch.Record(key)
+	}
 
-	current := sl.header
+	if sl.flat {
+		if i, found := sl.smallIndex(key); found {
+			return sl.smallResult(i), sl.small[i].context
+		}
+		var zeroContext C
+		return nil, zeroContext
+	}
+	current := sl.seek(key, true)
+	if current != nil && sl.cmpKey(current.key, key) == 0 {
+		return sl.result(current), current.context
+	}
 
-	// Search from top level down
+	// Return zero value for context when not found (instead of nil)
+	var zeroContext C
+	return nil, zeroContext
+}
+
+// seek returns the first node with a key >= key (inclusive) or > key (exclusive);
+// the caller must hold the lock
+func (sl *ZeroCopySkiplist[T, K, C]) seek(key K, inclusive bool) *ItemPtr[T, K, C] {
+	bound := 0
+	if inclusive {
+		bound = -1
+	}
+
+	// Move to next node which is the first candidate
+	return sl.descend(key, bound, nil).forward[0]
+}
+
+// descend searches from the top level down for the last node on each level
+// whose key compares <= bound against key (bound -1 finds the nodes before key,
+// bound 0 the nodes at or before it), recording the node found on each level
+// in update when it is not nil, and returns the level 0 node (possibly the
+// header); the caller must hold the lock
+func (sl *ZeroCopySkiplist[T, K, C]) descend(key K, bound int, update []*ItemPtr[T, K, C]) *ItemPtr[T, K, C] {
+	if sl.paths != nil && bound < 0 {
+		return sl.descendCached(key, update)
+	}
+	return sl.descendFromHeader(key, bound, update)
+}
+
+// descendFrom advances the search path update, whose nodes on every level
+// up to the list's level all come before key, to the last node before key on
+// each level, and returns the node after update[0]. It starts each level from
+// the later of update's node and the node found on the level above, so the
+// cost depends on the distance moved rather than the list's length; the
+// caller must hold the lock
+func (sl *ZeroCopySkiplist[T, K, C]) descendFrom(key K, update []*ItemPtr[T, K, C]) *ItemPtr[T, K, C] {
+	above := sl.header
 	for i := sl.level; i >= 0; i-- {
+		current := update[i]
+		if above != sl.header && (current == sl.header || sl.cmpKey(above.key, current.key) > 0) {
+			current = above
+		}
 		for current.forward[i] != nil && sl.cmpKey(current.forward[i].key, key) < 0 {
 			current = current.forward[i]
 		}
+		update[i] = current
+		above = current
 	}
+	return update[0].forward[0]
+}
 
-	// Move to next node which should contain the key if it exists
-	current = current.forward[0]
+// descendFromHeader performs descend without consulting the path cache; the
+// caller must hold the lock
+func (sl *ZeroCopySkiplist[T, K, C]) descendFromHeader(key K, bound int, update []*ItemPtr[T, K, C]) *ItemPtr[T, K, C] {
+	switch sl.keyKind {
+	case keyKindInt64:
+		return descendOrdered[int64](sl, key, bound, update)
+	case keyKindUint64:
+		return descendOrdered[uint64](sl, key, bound, update)
+	case keyKindString:
+		return descendOrdered[string](sl, key, bound, update)
+	}
 
-	if current != nil && sl.cmpKey(current.key, key) == 0 {
-		return current, current.context
+	current := sl.header
+
+	if sl.inlineKeys {
+		// Compare against the successor keys cached in the current node, so
+		// the successor itself is only touched when the search moves onto it
+		for i := sl.level; i >= 0; i-- {
+			for current.forward[i] != nil && sl.cmpKey(current.fkeys[i], key) <= bound {
+				current = current.forward[i]
+			}
+			if update != nil {
+				update[i] = current
+			}
+		}
+		return current
 	}
 
-	// Return zero value for context when not found (instead of nil)
-	var zeroContext C
-	return nil, zeroContext
+	for i := sl.level; i >= 0; i-- {
+		for current.forward[i] != nil && sl.cmpKey(current.forward[i].key, key) <= bound {
+			current = current.forward[i]
+		}
+		if update != nil {
+			update[i] = current
+		}
+	}
+	return current
+}
+
+// link points node's level i forward link at next, keeping the inline key cache in step
+func (sl *ZeroCopySkiplist[T, K, C]) link(node *ItemPtr[T, K, C], i int, next *ItemPtr[T, K, C]) {
+	sl.generation++
+	sl.version++
+	node.forward[i] = next
+	if sl.inlineKeys && next != nil {
+		node.fkeys[i] = next.key
+	}
 }
 
-// Next returns the next item in sorted order
+// seekLast returns the last node with a key <= key (inclusive) or < key (exclusive);
+// the caller must hold the lock. With level backlinks the search descends from
+// the level tails, which is cheaper when the target lies near the end of the list.
+func (sl *ZeroCopySkiplist[T, K, C]) seekLast(key K, inclusive bool) *ItemPtr[T, K, C] {
+	bound := 0
+	if !inclusive {
+		bound = -1
+	}
+
+	if !sl.opts.levelBacklinks {
+		if next := sl.seek(key, !inclusive); next != nil {
+			return next.backward
+		}
+		return sl.tails[0]
+	}
+
+	// A nil current stands for the position past the end of every level
+	var current *ItemPtr[T, K, C]
+	for i := sl.level; i >= 0; i-- {
+		prev := sl.tails[i]
+		if current != nil {
+			prev = current.prevAt(i)
+		}
+		for prev != nil && sl.cmpKey(prev.key, key) > bound {
+			current = prev
+			prev = current.prevAt(i)
+		}
+		if i == 0 {
+			return prev
+		}
+	}
+	return nil
+}
+
+// nodeOrNil maps the header sentinel to nil so it never leaks into backward links
+func (sl *ZeroCopySkiplist[T, K, C]) nodeOrNil(node *ItemPtr[T, K, C]) *ItemPtr[T, K, C] {
+	if node == sl.header {
+		return nil
+	}
+	return node
+}
+
+// prevAt returns the predecessor on the given level, nil if there is none or
+// the list does not maintain backlinks for that level
+func (ip *ItemPtr[T, K, C]) prevAt(level int) *ItemPtr[T, K, C] {
+	if level == 0 {
+		return ip.backward
+	}
+	if ip.backlinks == nil {
+		return nil
+	}
+	return ip.backlinks[level-1]
+}
+
+// setPrevAt sets the predecessor on the given level, ignoring levels without backlinks
+func (ip *ItemPtr[T, K, C]) setPrevAt(level int, prev *ItemPtr[T, K, C]) {
+	if level == 0 {
+		ip.backward = prev
+	} else if ip.backlinks != nil {
+		ip.backlinks[level-1] = prev
+	}
+}
+
+// result returns node as handed to callers: a detached copy WithDetachedNodes,
+// otherwise the node itself; the caller must hold the lock
+func (sl *ZeroCopySkiplist[T, K, C]) result(node *ItemPtr[T, K, C]) *ItemPtr[T, K, C] {
+	if node == nil || !sl.opts.detachedNodes {
+		return node
+	}
+	detached := node.detach()
+	return &detached
+}
+
+// detach returns a copy of node holding its entry but none of its links, so
+// it can be handed out while the list changes; the caller must hold the lock
+func (ip *ItemPtr[T, K, C]) detach() ItemPtr[T, K, C] {
+	return ItemPtr[T, K, C]{item: ip.item, key: ip.key, context: ip.context, level: unlinkedLevel}
+}
+
+// Detached returns true if ip is a copy returned WithDetachedNodes rather than
+// a node linked into a list
+func (ip *ItemPtr[T, K, C]) Detached() bool {
+	return ip.forward == nil
+}
+
+// Next returns the next item in sorted order, nil for a detached node
 func (ip *ItemPtr[T, K, C]) Next() *ItemPtr[T, K, C] {
+	if ip.forward == nil {
+		return nil
+	}
 	return ip.forward[0]
 }
 
-// Prev returns the previous item in sorted order
+// Prev returns the previous item in sorted order, nil for the first item
 func (ip *ItemPtr[T, K, C]) Prev() *ItemPtr[T, K, C] {
 	return ip.backward
 }
 
-// randomLevel generates a random level for new nodes
-func (sl *ZeroCopySkiplist[T, K, C]) randomLevel() int {
-	level := 0
-	for rand.Float32() < 0.5 && level < sl.maxLevel {
-		level++
+// randomLevel generates a level for a new node, appended after the last node
+// if appending, from the list's LevelPolicy, or replays the next level of the
+// WithLevelSequence sequence while there is one
+func (sl *ZeroCopySkiplist[T, K, C]) randomLevel(appending bool) int {
+	var level int
+	if sl.replayed < len(sl.opts.levelSequence) {
+		level = sl.opts.levelSequence[sl.replayed]
+		sl.replayed++
+	} else {
+		policy := sl.opts.levelPolicy
+		if policy == nil {
+			policy = defaultLevels
+		}
+		level = policy(sl.nodesAllocated, appending, sl.maxLevel)
+	}
+	level = min(max(level, 0), sl.maxLevel)
+
+	if sl.opts.recordLevels {
+		sl.recordedLevels = append(sl.recordedLevels, level)
 	}
 	return level
 }
+
+// LevelSequence returns the levels assigned to new nodes so far, in insertion
+// order, when the list was built WithLevelRecording. Building a list with
+// WithLevelSequence(levels) and repeating the same inserts and deletes
+// reproduces the exact topology of this one.
+func (sl *ZeroCopySkiplist[T, K, C]) LevelSequence() []int {
+	sl.rlock()
+	defer sl.runlock()
+	return append([]int(nil), sl.recordedLevels...)
+}
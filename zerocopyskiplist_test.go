@@ -2,6 +2,7 @@ package zerocopyskiplist
 
 import (
 	"fmt"
+	"math/rand"
 	"os"
 	"sync"
 	"syscall"
@@ -480,6 +481,41 @@ func TestCopy(t *testing.T) {
 	}
 }
 
+func TestClear(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		16,
+		getKeyFromTestItem,
+		getTestItemSize,
+		compareInt,
+		WithLevelBacklinks(),
+	)
+
+	items := createTestItems(200)
+	for _, item := range items {
+		skiplist.Insert(item, TestContext{})
+	}
+	cursor := skiplist.SeekGE(100)
+
+	skiplist.Clear()
+	if !skiplist.IsEmpty() || skiplist.TotalItemBytes() != 0 || skiplist.First() != nil || skiplist.Last() != nil {
+		t.Error("Clear should leave an empty list")
+	}
+	if err := skiplist.Validate(); err != nil {
+		t.Errorf("Validate after Clear: %v", err)
+	}
+	if cursor.Next() {
+		t.Error("A cursor parked before Clear should find no further items")
+	}
+
+	// The list is usable again afterwards
+	for _, item := range items[:50] {
+		skiplist.Insert(item, TestContext{})
+	}
+	if skiplist.Length() != 50 || skiplist.Validate() != nil {
+		t.Error("List should be usable after Clear")
+	}
+}
+
 // NEW TESTS FOR CALLBACK FUNCTIONALITY
 
 func TestCallbackToIovecSlice(t *testing.T) {
@@ -687,6 +723,75 @@ func TestCallbackToIovecSliceWithDifferentTypes(t *testing.T) {
 	}
 }
 
+func TestCallbackToIovecSliceMutatingCallback(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		16,
+		getKeyFromTestItem,
+		getTestItemSize,
+		compareInt,
+	)
+
+	// Use more items than a single batch so the scan has to resume
+	const numItems = callbackBatchSize*3 + 7
+	items := createTestItems(numItems)
+	for _, item := range items {
+		skiplist.Insert(item, TestContext{})
+	}
+
+	// Callback deletes odd keys and marks even keys - this used to deadlock
+	flushed := TestContext{MetadataKey: "flushed"}
+	iovecs := skiplist.CallbackToIovecSlice(func(item *ItemPtr[TestItem, int, TestContext]) bool {
+		if item.Key()%2 == 1 {
+			return !skiplist.Delete(item.Key())
+		}
+		return skiplist.UpdateContext(item.Key(), flushed)
+	})
+
+	expectedCount := numItems / 2
+	if len(iovecs) != expectedCount {
+		t.Errorf("Expected %d iovecs, got %d", expectedCount, len(iovecs))
+	}
+
+	if skiplist.Length() != expectedCount {
+		t.Errorf("Expected length %d after callback deletes, got %d", expectedCount, skiplist.Length())
+	}
+
+	for current := skiplist.First(); current != nil; current = current.Next() {
+		if current.Key()%2 == 1 {
+			t.Errorf("Odd key %d should have been deleted", current.Key())
+		}
+		if current.Context() != flushed {
+			t.Errorf("Key %d should have been marked flushed", current.Key())
+		}
+	}
+}
+
+func TestCallbackToIovecSliceSnapshot(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		16,
+		getKeyFromTestItem,
+		getTestItemSize,
+		compareInt,
+	)
+
+	items := createTestItems(3)
+	for _, item := range items {
+		skiplist.Insert(item, TestContext{AccessCount: 1})
+	}
+
+	// SetContext on the callback's ItemPtr only changes the snapshot copy
+	skiplist.CallbackToIovecSlice(func(item *ItemPtr[TestItem, int, TestContext]) bool {
+		item.SetContext(TestContext{AccessCount: 2})
+		return true
+	})
+
+	for current := skiplist.First(); current != nil; current = current.Next() {
+		if current.Context().AccessCount != 1 {
+			t.Errorf("Key %d context should be unchanged by snapshot SetContext", current.Key())
+		}
+	}
+}
+
 // UPDATED TESTS FOR IOVEC FUNCTIONALITY
 
 func TestToIovecSlice(t *testing.T) {
@@ -840,6 +945,84 @@ func TestToNotContextIovecSlice(t *testing.T) {
 	}
 }
 
+func TestCallbackToIovecSliceN(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		16,
+		getKeyFromTestItem,
+		getTestItemSize,
+		compareInt,
+	)
+
+	target := TestContext{AccessCount: 1}
+	skiplist.SetContextQuota(target, 0, 0, QuotaReject)
+	for i, item := range createTestItems(1000) {
+		skiplist.Insert(item, TestContext{AccessCount: i % 4})
+	}
+
+	// The expected count sizes the result, which never has to grow
+	iovecs := skiplist.CallbackToIovecSliceN(500, func(item *ItemPtr[TestItem, int, TestContext]) bool {
+		return item.Key()%2 == 0
+	})
+	if len(iovecs) != 500 || cap(iovecs) != 500 {
+		t.Errorf("Expected 500 iovecs with capacity 500, got %d with capacity %d", len(iovecs), cap(iovecs))
+	}
+	if iovecs := skiplist.CallbackToIovecSliceN(-1, func(*ItemPtr[TestItem, int, TestContext]) bool { return false }); len(iovecs) != 0 {
+		t.Errorf("Expected no iovecs, got %d", len(iovecs))
+	}
+
+	// Whole list and quota tracked context flushes are sized exactly
+	if iovecs := skiplist.ToIovecSlice(target); cap(iovecs) != 1000 {
+		t.Errorf("ToIovecSlice capacity = %d, want 1000", cap(iovecs))
+	}
+	if iovecs := skiplist.ToContextIovecSlice(target); len(iovecs) != 250 || cap(iovecs) != 250 {
+		t.Errorf("ToContextIovecSlice len %d cap %d, want 250", len(iovecs), cap(iovecs))
+	}
+	if iovecs := skiplist.ToNotContextIovecSlice(target); len(iovecs) != 750 || cap(iovecs) != 750 {
+		t.Errorf("ToNotContextIovecSlice len %d cap %d, want 750", len(iovecs), cap(iovecs))
+	}
+}
+
+func TestAppendIovecs(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
+		16,
+		getKeyFromTestItem,
+		getTestItemSize,
+		compareInt,
+		WithLeakTracking(),
+	)
+	items := createTestItems(600)
+	for _, item := range items {
+		skiplist.Insert(item, TestContext{})
+	}
+	all := func(*ItemPtr[TestItem, int, TestContext]) bool { return true }
+
+	// Appending keeps what is already in dst
+	head := []syscall.Iovec{{Len: 7}}
+	iovecs := skiplist.AppendIovecs(head, all)
+	if len(iovecs) != 601 || iovecs[0].Len != 7 {
+		t.Fatalf("Expected the existing iovec followed by 600 more, got %d", len(iovecs))
+	}
+	if iovecs[1].Base != (*byte)(unsafe.Pointer(items[0])) {
+		t.Error("First appended iovec should point at the first item")
+	}
+
+	// A buffer large enough is reused across flush cycles
+	buf := make([]syscall.Iovec, 0, 1000)
+	for range 3 {
+		buf = skiplist.AppendIovecs(buf[:0], func(item *ItemPtr[TestItem, int, TestContext]) bool {
+			return item.Key() > 100
+		})
+		if len(buf) != 500 || cap(buf) != 1000 {
+			t.Fatalf("Expected 500 iovecs in the reused buffer, got %d with capacity %d", len(buf), cap(buf))
+		}
+	}
+
+	// Only the arrays allocated by the list are tracked
+	if batches := skiplist.Stats().IovecBatches; batches != 1 {
+		t.Errorf("Expected 1 tracked iovec batch, got %d", batches)
+	}
+}
+
 func TestMerge(t *testing.T) {
 	skiplist1 := MakeZeroCopySkiplist[TestItem, int, TestContext](
 		16,
@@ -932,6 +1115,118 @@ func TestMerge(t *testing.T) {
 	}
 }
 
+func TestMergeCarriedPath(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	for _, opts := range [][]Option{
+		nil,
+		{WithInlineKeys()},
+		{WithLevelBacklinks(), WithPathCache(64)},
+		{WithSmallListThreshold(40)},
+	} {
+		for _, sizes := range [][2]int{{2000, 20}, {20, 2000}, {2000, 2000}, {0, 50}} {
+			build := func(n int) (*ZeroCopySkiplist[TestItem, int, TestContext], map[int]*TestItem) {
+				sl := MakeZeroCopySkiplist[TestItem, int, TestContext](16, getKeyFromTestItem, getTestItemSize, compareInt, opts...)
+				want := make(map[int]*TestItem)
+				for i := 0; i < n; i++ {
+					item := &TestItem{ID: rng.Intn(4000)}
+					sl.Insert(item, TestContext{})
+					want[item.ID] = item
+				}
+				return sl, want
+			}
+			ours, want := build(sizes[0])
+			theirs, other := build(sizes[1])
+			for key, item := range other {
+				want[key] = item
+			}
+
+			if err := ours.Merge(theirs, MergeTheirs); err != nil {
+				t.Fatalf("Merge: %v", err)
+			}
+			if err := ours.Validate(); err != nil {
+				t.Fatalf("Merged list of %v is invalid: %v", sizes, err)
+			}
+			if ours.Length() != len(want) {
+				t.Errorf("Merged %v into %d entries, want %d", sizes, ours.Length(), len(want))
+			}
+			for key, item := range want {
+				if found, _ := ours.Find(key); found == nil || found.Item() != item {
+					t.Errorf("Key %d missing or not theirs after merging %v", key, sizes)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestMergeSelfConcurrent(t *testing.T) {
+	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	items := createTestItems(100)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			item := items[i%len(items)]
+			skiplist.Insert(item, TestContext{})
+			skiplist.Delete(item.ID)
+		}
+	}()
+	// The self merge reads the first key while the writer changes it, which
+	// the race detector reports unless it holds the lock
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+			skiplist.Merge(skiplist, MergeError)
+		}
+	}
+	if err := skiplist.Merge(skiplist, MergeError); err != nil {
+		t.Errorf("Self merge of an empty list = %v", err)
+	}
+}
+
+func TestMergeQuotasAndHooks(t *testing.T) {
+	ours := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	theirs := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	for _, item := range createTestItems(20) {
+		if item.ID%2 == 0 {
+			ours.Insert(item, TestContext{})
+		} else {
+			theirs.Insert(item, TestContext{AccessCount: 1})
+		}
+	}
+	ours.SetContextQuota(TestContext{AccessCount: 1}, 4, 0, QuotaEvictOldest)
+	hooks := 0
+	ours.SetPressureHook(0, 12, func(int64, int) { hooks++ })
+
+	if err := ours.Merge(theirs, MergeOurs); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _, _ := ours.ContextUsage(TestContext{AccessCount: 1}); entries != 4 || ours.Length() != 14 {
+		t.Errorf("Merge under a quota left %d quota entries of %d", entries, ours.Length())
+	}
+	for key := 17; key <= 19; key += 2 {
+		if found, _ := ours.Find(key); found == nil {
+			t.Errorf("Quota eviction should keep the newest key %d", key)
+		}
+	}
+	if err := ours.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if hooks != 1 {
+		t.Errorf("Pressure hook fired %d times, want once", hooks)
+	}
+
+	if err := ours.Merge(ours, MergeTheirs); err != nil {
+		t.Errorf("Merging a list into itself = %v", err)
+	}
+	ours.Freeze()
+	if err := ours.Merge(theirs, MergeTheirs); err != ErrFrozen {
+		t.Errorf("Merge into a frozen list = %v, want ErrFrozen", err)
+	}
+}
+
 func TestLargeDataset(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping large dataset test in short mode")
@@ -1422,6 +1717,24 @@ func BenchmarkInsert(b *testing.B) {
 	}
 }
 
+func BenchmarkMerge(b *testing.B) {
+	for _, n := range []int{1000, 100000} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			ours := MakeZeroCopySkiplist[TestItem, int, TestContext](20, getKeyFromTestItem, getTestItemSize, compareInt)
+			theirs := MakeZeroCopySkiplist[TestItem, int, TestContext](20, getKeyFromTestItem, getTestItemSize, compareInt)
+			for i := 0; i < n; i++ {
+				ours.Insert(&TestItem{ID: 2 * i}, TestContext{})
+				theirs.Insert(&TestItem{ID: 2*i + 1}, TestContext{})
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				merged := ours.Copy()
+				merged.Merge(theirs, MergeTheirs)
+			}
+		})
+	}
+}
+
 func BenchmarkFind(b *testing.B) {
 	skiplist := MakeZeroCopySkiplist[TestItem, int, TestContext](
 		16,
@@ -1540,3 +1853,64 @@ func BenchmarkCallbackToIovecSlice(b *testing.B) {
 		_ = skiplist.CallbackToIovecSlice(callback)
 	}
 }
+
+func TestDetachedNodes(t *testing.T) {
+	sl := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt, WithDetachedNodes())
+	items := createTestItems(10)
+	for _, item := range items {
+		sl.Insert(item, TestContext{AccessCount: item.ID})
+	}
+
+	first, last := sl.First(), sl.Last()
+	found, ctx := sl.Find(5)
+	if first.Key() != 1 || last.Key() != 10 || found.Item() != items[4] || ctx.AccessCount != 5 {
+		t.Fatal("Detached nodes should carry the key, item and context")
+	}
+	if !found.Detached() || found.Next() != nil || found.Prev() != nil {
+		t.Error("Detached nodes should have no links")
+	}
+
+	// Later writes do not reach the copy, and writes to the copy do not reach the list
+	sl.UpdateContext(5, TestContext{AccessCount: 50})
+	found.SetContext(TestContext{AccessCount: 500})
+	if found.Context().AccessCount != 500 {
+		t.Error("SetContext should change the detached copy")
+	}
+	if _, ctx := sl.Find(5); ctx.AccessCount != 50 {
+		t.Errorf("List context = %d, want 50", ctx.AccessCount)
+	}
+	if sl.FindItem(11) != nil {
+		t.Error("FindItem of a missing key should return nil")
+	}
+
+	live := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	live.Insert(items[0], TestContext{})
+	if live.First().Detached() {
+		t.Error("Nodes should be live without WithDetachedNodes")
+	}
+}
+
+func TestBounds(t *testing.T) {
+	sl := MakeZeroCopySkiplist[TestItem, int, TestContext](8, getKeyFromTestItem, getTestItemSize, compareInt)
+	if _, _, ok := sl.Bounds(); ok {
+		t.Error("Bounds of an empty list should report false")
+	}
+
+	items := createTestItems(50)
+	for _, i := range []int{20, 4, 49, 0, 33} {
+		sl.Insert(items[i], TestContext{})
+	}
+	if min, max, ok := sl.Bounds(); !ok || min != 1 || max != 50 {
+		t.Errorf("Bounds = %d, %d, %v; want 1, 50, true", min, max, ok)
+	}
+
+	sl.Delete(1)
+	sl.Delete(50)
+	if min, max, _ := sl.Bounds(); min != 5 || max != 34 {
+		t.Errorf("Bounds after deleting the ends = %d, %d; want 5, 34", min, max)
+	}
+	sl.Clear()
+	if _, _, ok := sl.Bounds(); ok {
+		t.Error("Bounds of a cleared list should report false")
+	}
+}